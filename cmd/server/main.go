@@ -63,16 +63,12 @@ func run() error {
 	slog.Info("database migrations applied")
 
 	// 4. Create Redis cache
-	redisCache, err := cache.NewRedisCache(cfg.Redis.URL)
+	appCache, err := connectCache(ctx, cfg.Redis)
 	if err != nil {
-		return fmt.Errorf("create redis cache: %w", err)
+		return err
 	}
-	defer redisCache.Close()
-
-	if err := redisCache.Ping(ctx); err != nil {
-		return fmt.Errorf("ping redis: %w", err)
-	}
-	slog.Info("redis connected")
+	defer appCache.Close()
+	_, cacheDegraded := appCache.(cache.NopCache)
 
 	// 5. Create AI provider
 	aiProvider, err := ai.NewProvider(cfg.AI)
@@ -80,6 +76,10 @@ func run() error {
 		return fmt.Errorf("create AI provider: %w", err)
 	}
 	slog.Info("AI provider initialized", "provider", aiProvider.Name())
+	var healthAI handler.AIProviderNamer = aiProvider
+	if cfg.AI.DeepHealth {
+		healthAI = handler.NewDeepAIHealthCheck(aiProvider, cfg.AI.DeepHealthInterval)
+	}
 
 	// 6. Create Loki client
 	lokiClient := loki.NewHTTPClient(
@@ -88,35 +88,141 @@ func run() error {
 		cfg.Loki.Password,
 		cfg.Loki.OrgID,
 		cfg.Loki.Timeout,
+		loki.WithMaxLines(cfg.Loki.MaxLines),
+		loki.WithQueryTimeout(cfg.Loki.QueryTimeout),
+		loki.WithPathPrefix(cfg.Loki.PathPrefix),
+		loki.WithRetry(cfg.Loki.MaxRetries, cfg.Loki.RetryBackoff),
+		loki.WithTransportConfig(loki.TransportConfig{
+			DialTimeout:           cfg.Loki.DialTimeout,
+			TLSHandshakeTimeout:   cfg.Loki.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: cfg.Loki.ResponseHeaderTimeout,
+			KeepAlive:             cfg.Loki.KeepAlive,
+			IdleConnTimeout:       cfg.Loki.IdleConnTimeout,
+		}),
 	)
 	slog.Info("loki client initialized", "url", cfg.Loki.BaseURL)
 
 	// 7. Create store
 	pgStore := store.NewPostgresStore(pool)
+	store.ConfigurePagination(cfg.Server.DefaultPageLimit, cfg.Server.MaxPageLimit)
+	severities := analysis.NewLevelSeverities(cfg.Analysis.LevelSeverities)
+	if cfg.Analysis.AutoAnalyze {
+		if err := severities.CheckAutoAnalyzeLevel(cfg.Analysis.AutoAnalyzeMinLevel); err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+	}
+
+	// Cluster writes from the API go through the same cache as listings so
+	// they invalidate them.
+	var clusterStore store.Store = pgStore
+	if cfg.Server.ClusterListCacheTTL > 0 && !cacheDegraded {
+		clusterStore = analysis.NewClusterListCache(pgStore, appCache, cfg.Server.ClusterListCacheTTL)
+	}
 
 	// 8. Create services
-	analysisSvc := ai.NewAnalysisService(aiProvider, lokiClient, pgStore, redisCache, cfg.AI.InferenceTimeout)
-	searchSvc := analysis.NewSearchService(lokiClient, pgStore, redisCache)
+	analysisSvc := ai.NewAnalysisService(aiProvider, lokiClient, pgStore, appCache, cfg.AI.InferenceTimeout,
+		ai.WithAnalyzeTimeout(cfg.AI.AnalyzeTimeout),
+		ai.WithSummarizeTimeout(cfg.AI.SummarizeTimeout),
+		ai.WithSummarizeRetries(cfg.AI.SummarizeRetries),
+		ai.WithSummarizeMaxInflight(cfg.AI.SummarizeMaxInflight),
+		ai.WithAnalyzeMaxConcurrency(cfg.AI.AnalyzeMaxConcurrency),
+		ai.WithAnalyzeQueueTimeout(cfg.AI.AnalyzeQueueTimeout),
+		ai.WithLokiQueryCacheTTL(cfg.Loki.QueryCacheTTL),
+		ai.WithAllowedLabels(cfg.Loki.AllowedLabels),
+		ai.WithContextDirection(cfg.Analysis.ContextDirection),
+		ai.WithContextSampling(cfg.AI.ContextStrategy, cfg.AI.ContextLimit, severities.Severity),
+		ai.WithAllowedModels(cfg.AI.AllowedModels),
+		ai.WithPromptPricing(cfg.AI.PromptCostPer1KTokens),
+		ai.WithFingerprinter(analysis.Fingerprint),
+	)
+	clusterOpts := []analysis.ClusterOption{
+		analysis.WithLevelFromMessage(cfg.Analysis.LevelFromMessage),
+		analysis.WithLevelSeverities(severities),
+	}
+	searchSvc := analysis.NewSearchService(lokiClient, pgStore, appCache, cfg.Loki.AllowedLabels, clusterOpts...)
+	previewSvc := analysis.NewPreviewService(lokiClient, pgStore, cfg.Loki.AllowedLabels, clusterOpts...)
+	autoAnalyzeLevel := ""
+	if cfg.Analysis.AutoAnalyze {
+		autoAnalyzeLevel = cfg.Analysis.AutoAnalyzeMinLevel
+		slog.Info("cluster auto-analyze enabled", "min_level", autoAnalyzeLevel)
+	}
+	ingester := analysis.NewIngester(clusterStore, analysisSvc, autoAnalyzeLevel, severities)
+	detectSvc := analysis.NewDetectService(lokiClient, ingester, cfg.Loki.AllowedLabels, clusterOpts...)
 	summarizeAdapter := &summarizeAdapterSvc{svc: analysisSvc}
 
+	if cfg.Analysis.AutoResolveAfter > 0 {
+		resolver := analysis.NewAutoResolver(clusterStore, cfg.Analysis.AutoResolveAfter, cfg.Analysis.AutoResolveInterval)
+		go resolver.Run(ctx)
+		slog.Info("cluster auto-resolve enabled", "after", cfg.Analysis.AutoResolveAfter)
+	}
+
 	// 9. Build router with dependencies
 	auth := mw.NewAuth(pgStore)
-	rateLimit := mw.NewRateLimit(redisCache, 60)
+	rateLimit := mw.NewRateLimit(appCache, 60)
+	for route := range cfg.Server.RouteScopes {
+		if _, ok := api.DefaultRouteScopes[route]; !ok {
+			slog.Warn("ROUTE_SCOPES names an unknown route", "route", route)
+		}
+	}
+
+	proxies, err := mw.NewTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("configure trusted proxies: %w", err)
+	}
+	hopts := []handler.Option{
+		handler.WithCrossTenantStatus(cfg.Server.CrossTenantResponse),
+		handler.WithStrictRequestBodies(cfg.Server.StrictRequestBodies),
+		handler.WithMaxSummarizeWindow(cfg.AI.SummarizeMaxWindow),
+		handler.WithClusterLevels(severities.KnownLevels()),
+	}
 
 	deps := api.Dependencies{
-		Auth:      auth,
-		RateLimit: rateLimit,
-
-		HealthHandler:    handler.NewHealthHandler(pgStore, redisCache, lokiClient, aiProvider),
-		AnalyzeHandler:   handler.NewAnalyzeHandler(pgStore, analysisSvc),
-		PollJobHandler:   handler.NewPollJobHandler(pgStore, redisCache),
-		ListClusters:     handler.NewListClustersHandler(pgStore),
-		GetCluster:       handler.NewGetClusterHandler(pgStore),
-		SummarizeHandler: handler.NewSummarizeHandler(summarizeAdapter),
-		SearchHandler:    handler.NewSearchHandler(searchSvc),
-		CreateKeyHandler: handler.NewCreateKeyHandler(pgStore),
+		Auth:           auth,
+		RateLimit:      rateLimit,
+		TrustedProxies: proxies,
+		RouteScopes:    cfg.Server.RouteScopes,
+
+		HealthHandler:    handler.NewHealthHandler(pgStore, appCache, lokiClient, healthAI),
+		MetricsHandler:   handler.NewMetricsHandler(appCache),
+		AnalyzeHandler:   handler.NewAnalyzeHandler(pgStore, analysisSvc, hopts...),
+		PollJobHandler:   handler.NewPollJobHandler(pgStore, appCache, hopts...),
+		JobLogsHandler:   handler.NewJobLogsHandler(pgStore, hopts...),
+		ReplayHandler:    handler.NewReplayAnalysisHandler(pgStore, analysisSvc, hopts...),
+		CancelJobHandler: handler.NewCancelJobHandler(analysisSvc, pgStore, hopts...),
+		GetAnalysisHandler: handler.NewGetAnalysisHandler(pgStore),
+		BulkPollHandler:  handler.NewBulkPollJobsHandler(pgStore, appCache, hopts...),
+		EstimateAnalysisHandler: handler.NewEstimateAnalysisHandler(pgStore, analysisSvc, hopts...),
+		ListClusters:     handler.NewListClustersHandler(clusterStore, hopts...),
+		GetCluster:       handler.NewGetClusterHandler(pgStore, hopts...),
+		PatchCluster:     handler.NewPatchClusterHandler(clusterStore, hopts...),
+		DeleteCluster:    handler.NewDeleteClusterHandler(clusterStore, hopts...),
+		ClusterSummarizeHandler: handler.NewClusterSummarizeHandler(pgStore, summarizeAdapter, hopts...),
+		SummarizeHandler: handler.NewSummarizeHandler(summarizeAdapter, hopts...),
+		BatchSummarizeHandler: handler.NewBatchSummarizeHandler(summarizeAdapter, hopts...),
+		SearchHandler:    handler.NewSearchHandler(searchSvc, hopts...),
+		ValidateQueryHandler: handler.NewValidateQueryHandler(lokiClient, cfg.Loki.AllowedLabels, hopts...),
+		DetectHandler:    handler.NewDetectHandler(detectSvc, hopts...),
+		DetectPreviewHandler: handler.NewDetectPreviewHandler(previewSvc, hopts...),
+		CreateKeyHandler: handler.NewCreateKeyHandler(pgStore, hopts...),
 		ListKeysHandler:  handler.NewListKeysHandler(pgStore),
 		RevokeKeyHandler: handler.NewRevokeKeyHandler(pgStore),
+		RevokeAllKeysHandler: handler.NewRevokeAllKeysHandler(pgStore),
+		MigrationsHandler:    handler.NewMigrationStatusHandler(pgStore),
+		PruneOrphanedResultsHandler: handler.NewPruneOrphanedResultsHandler(pgStore),
+		JobStatsHandler:  handler.NewJobStatsHandler(pgStore),
+		ListJobsHandler:  handler.NewListJobsHandler(pgStore),
+		CreateSuppressionHandler: handler.NewCreateSuppressionHandler(pgStore, hopts...),
+		ListSuppressionsHandler:  handler.NewListSuppressionsHandler(pgStore),
+		DeleteSuppressionHandler: handler.NewDeleteSuppressionHandler(pgStore),
+	}
+
+	if len(cfg.Server.CORSAllowedOrigins) > 0 {
+		cors, err := mw.NewCORS(cfg.Server.CORSAllowedOrigins)
+		if err != nil {
+			return fmt.Errorf("create CORS middleware: %w", err)
+		}
+		deps.CORS = cors
+		slog.Info("CORS enabled", "origins", cfg.Server.CORSAllowedOrigins)
 	}
 
 	router := api.NewRouter(deps)
@@ -161,20 +267,58 @@ func run() error {
 	return nil
 }
 
+// serverCache is the cache the server runs with: a *cache.RedisCache, possibly
+// wrapped in a *cache.TieredCache, or a cache.NopCache when Redis is optional
+// and unavailable.
+type serverCache interface {
+	cache.Cache
+	Stats() cache.CacheStats
+	Close() error
+}
+
+// connectCache connects to Redis. If Redis is unreachable and cfg.Required is
+// false, it logs a warning and returns a cache.NopCache so the server can run
+// degraded, without caching or rate limiting. A reachable Redis is wrapped in
+// an in-memory fallback of cfg.FallbackSize entries, unless that is 0.
+func connectCache(ctx context.Context, cfg config.RedisConfig) (serverCache, error) {
+	redisCache, err := cache.NewRedisCache(cfg.URL)
+	if err != nil {
+		err = fmt.Errorf("create redis cache: %w", err)
+	} else if err = redisCache.Ping(ctx); err != nil {
+		redisCache.Close()
+		err = fmt.Errorf("ping redis: %w", err)
+	} else {
+		slog.Info("redis connected")
+		if cfg.FallbackSize > 0 {
+			return cache.NewTieredCache(redisCache, cfg.FallbackSize), nil
+		}
+		return redisCache, nil
+	}
+
+	if cfg.Required {
+		return nil, err
+	}
+	slog.Warn("redis unavailable, running without caching or rate limiting", "error", err)
+	return cache.NopCache{}, nil
+}
+
 // summarizeAdapterSvc adapts ai.AnalysisService to the handler.Summarizer interface.
-// The handler interface doesn't pass context, so the adapter uses context.Background().
 type summarizeAdapterSvc struct {
 	svc *ai.AnalysisService
 }
 
-func (a *summarizeAdapterSvc) Summarize(params handler.SummarizeParams) (*handler.SummarizeResult, error) {
-	result, err := a.svc.Summarize(context.Background(), ai.SummarizeParams{
-		TenantID:  params.TenantID,
-		Service:   params.Service,
-		Namespace: params.Namespace,
-		Start:     params.Start,
-		End:       params.End,
-		MaxLines:  params.MaxLines,
+func (a *summarizeAdapterSvc) Summarize(ctx context.Context, params handler.SummarizeParams) (*handler.SummarizeResult, error) {
+	result, err := a.svc.Summarize(ctx, ai.SummarizeParams{
+		TenantID:    params.TenantID,
+		Service:     params.Service,
+		Namespace:   params.Namespace,
+		Start:       params.Start,
+		End:         params.End,
+		MaxLines:    params.MaxLines,
+		Model:       params.Model,
+		Levels:      params.Levels,
+		Fingerprint: params.Fingerprint,
+		NoCache:     params.NoCache,
 	})
 	if err != nil {
 		return nil, err
@@ -186,5 +330,8 @@ func (a *summarizeAdapterSvc) Summarize(params handler.SummarizeParams) (*handle
 		To:            result.To,
 		Provider:      result.Provider,
 		Model:         result.Model,
+		CacheHit:      result.CacheHit,
+		LogsCacheHit:  result.LogsCacheHit,
+		Truncated:     result.Truncated,
 	}, nil
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kiranshivaraju/loghunter/internal/api/handler"
 	"github.com/kiranshivaraju/loghunter/internal/cache"
+	"github.com/kiranshivaraju/loghunter/internal/config"
 	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -32,7 +33,7 @@ func (s *testStore) GetAPIKeyByPrefix(_ context.Context, _ string) ([]*models.AP
 }
 func (s *testStore) UpdateAPIKeyLastUsed(_ context.Context, _ uuid.UUID) error   { return nil }
 func (s *testStore) CreateAPIKey(_ context.Context, _ *models.APIKey) error      { return nil }
-func (s *testStore) ListAPIKeys(_ context.Context, _ uuid.UUID) ([]*models.APIKey, error) {
+func (s *testStore) ListAPIKeys(_ context.Context, _ uuid.UUID, _ ...store.APIKeyListOption) ([]*models.APIKey, error) {
 	return nil, nil
 }
 func (s *testStore) RevokeAPIKey(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
@@ -51,19 +52,64 @@ func (s *testStore) GetClustersByFingerprints(_ context.Context, _ uuid.UUID, _
 func (s *testStore) CreateAnalysisResult(_ context.Context, _ *models.AnalysisResult) error {
 	return nil
 }
-func (s *testStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (s *testStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, store.ErrNotFound
 }
-func (s *testStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (s *testStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, store.ErrNotFound
 }
 func (s *testStore) CreateJob(_ context.Context, _ *models.Job) error { return nil }
 func (s *testStore) GetJob(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.Job, error) {
 	return nil, store.ErrNotFound
 }
+
+func (s *testStore) JobExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
 func (s *testStore) UpdateJobStatus(_ context.Context, _ uuid.UUID, _ string, _ ...store.JobUpdateOption) error {
 	return nil
 }
+func (s *testStore) JobStats(_ context.Context, _ uuid.UUID, _ time.Time) (store.JobStats, error) {
+	return store.JobStats{}, nil
+}
+func (s *testStore) GetTenantByAPIKeyPrefix(_ context.Context, _ string) (*models.Tenant, error) {
+	return nil, store.ErrNotFound
+}
+func (s *testStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
+func (s *testStore) SaveAnalysisContext(_ context.Context, _ *models.AnalysisContext) error { return nil }
+func (s *testStore) GetAnalysisContext(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisContext, error) {
+	return nil, store.ErrNotFound
+}
+func (s *testStore) ListAPIKeysPaged(_ context.Context, _ uuid.UUID, _, _ int, _ ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
+	return nil, 0, nil
+}
+func (s *testStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, _ string) (*models.ErrorCluster, error) {
+	return nil, store.ErrNotFound
+}
+func (s *testStore) GetJobsByIDs(_ context.Context, _ uuid.UUID, _ []uuid.UUID) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+func (s *testStore) CountErrorClusters(_ context.Context, _ store.ClusterFilter) (int, error) { return 0, nil }
+func (s *testStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error { return nil }
+func (s *testStore) SetClusterPinned(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ bool) error { return nil }
+func (s *testStore) RevokeAllAPIKeys(_ context.Context, _ uuid.UUID, _ ...uuid.UUID) (int, error) { return 0, nil }
+func (s *testStore) ErrorClusterExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+func (s *testStore) GetJobByID(_ context.Context, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (s *testStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) { return &store.MigrationStatus{}, nil }
+func (s *testStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return 0, nil
+}
+func (s *testStore) GetAnalysisResultByID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
+	return nil, store.ErrNotFound
+}
+func (s *testStore) ListJobs(_ context.Context, _ uuid.UUID, _ store.JobFilter) ([]*models.Job, int, error) { return nil, 0, nil }
+func (s *testStore) CreateClusterSuppression(_ context.Context, _ *models.ClusterSuppression) error { return nil }
+func (s *testStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) { return nil, nil }
+func (s *testStore) DeleteClusterSuppression(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *testStore) DeleteErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *testStore) GetTenant(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id}, nil
+}
 
 var _ store.Store = (*testStore)(nil)
 
@@ -173,6 +219,44 @@ func TestHealthHandler_BothDegraded(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 }
 
+func TestHealthHandler_CacheDisabled(t *testing.T) {
+	h := handler.NewHealthHandler(&testStore{}, cache.NopCache{}, &testLoki{}, &testAI{name: "ollama"})
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	w := httptest.NewRecorder()
+	h(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	data := body["data"].(map[string]any)
+	assert.Equal(t, "degraded", data["status"])
+	assert.Equal(t, "degraded", data["checks"].(map[string]any)["redis"])
+}
+
+// ─── connectCache tests ─────────────────────────────────────────────────────
+
+func TestConnectCache_OptionalRedisDegrades(t *testing.T) {
+	for _, url := range []string{"redis://127.0.0.1:1", "not-a-redis-url"} {
+		t.Run(url, func(t *testing.T) {
+			c, err := connectCache(context.Background(), config.RedisConfig{URL: url, Required: false})
+			require.NoError(t, err)
+			assert.Equal(t, cache.NopCache{}, c)
+		})
+	}
+}
+
+func TestConnectCache_RequiredRedisFails(t *testing.T) {
+	_, err := connectCache(context.Background(), config.RedisConfig{URL: "redis://127.0.0.1:1", Required: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ping redis")
+
+	_, err = connectCache(context.Background(), config.RedisConfig{URL: "not-a-redis-url", Required: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create redis cache")
+}
+
 // ─── run() config validation tests ──────────────────────────────────────────
 
 func TestRun_FailsOnMissingConfig(t *testing.T) {
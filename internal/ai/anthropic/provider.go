@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -50,9 +51,15 @@ type Provider struct {
 // NewProvider creates a new Anthropic AI provider.
 // API key is sourced from config (environment variable) — never hardcoded.
 func NewProvider(cfg config.AnthropicConfig) *Provider {
+	return NewProviderWithClient(cfg, shared.NewHTTPClient(0, shared.DefaultHTTPMaxIdleConns))
+}
+
+// NewProviderWithClient creates a new Anthropic AI provider that sends requests
+// through client, so the connection pool can be shared.
+func NewProviderWithClient(cfg config.AnthropicConfig, client *http.Client) *Provider {
 	return &Provider{
 		cfg:     cfg,
-		client:  &http.Client{},
+		client:  client,
 		baseURL: defaultBaseURL,
 	}
 }
@@ -67,7 +74,8 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 		return models.AnalysisResult{}, fmt.Errorf("building prompt: %w", err)
 	}
 
-	content, err := p.chat(ctx, prompt)
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := p.chat(ctx, model, prompt)
 	if err != nil {
 		return models.AnalysisResult{}, err
 	}
@@ -77,28 +85,29 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 		return models.AnalysisResult{}, fmt.Errorf("%w: %v", shared.ErrInvalidResponse, err)
 	}
 
-	return parsed.ToResult("anthropic", p.cfg.Model), nil
+	return parsed.ToResult("anthropic", model), nil
 }
 
 // Summarize condenses log lines into a plain-language summary via Anthropic.
-func (p *Provider) Summarize(ctx context.Context, logs []models.LogLine) (string, error) {
-	prompt, err := shared.BuildSummarizePrompt(logs)
+func (p *Provider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
+	prompt, err := shared.BuildSummarizePrompt(req.Logs)
 	if err != nil {
-		return "", fmt.Errorf("building prompt: %w", err)
+		return models.Summary{}, fmt.Errorf("building prompt: %w", err)
 	}
 
-	content, err := p.chat(ctx, prompt)
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := p.chat(ctx, model, prompt)
 	if err != nil {
-		return "", err
+		return models.Summary{}, err
 	}
 
-	return strings.TrimSpace(content), nil
+	return models.Summary{Text: strings.TrimSpace(content), Model: model}, nil
 }
 
 // chat sends a message to the Anthropic Messages API and returns the response text.
-func (p *Provider) chat(ctx context.Context, prompt string) (string, error) {
+func (p *Provider) chat(ctx context.Context, model, prompt string) (string, error) {
 	body := anthropicRequest{
-		Model:     p.cfg.Model,
+		Model:     model,
 		MaxTokens: 1024,
 		Messages: []anthropicMessage{
 			{Role: "user", Content: prompt},
@@ -126,7 +135,7 @@ func (p *Provider) chat(ctx context.Context, prompt string) (string, error) {
 		}
 		return "", fmt.Errorf("%w: %v", shared.ErrProviderUnavailable, err)
 	}
-	defer resp.Body.Close()
+	defer shared.CloseBody(resp.Body)
 
 	if resp.StatusCode >= 500 {
 		return "", fmt.Errorf("%w: HTTP %d", shared.ErrProviderUnavailable, resp.StatusCode)
@@ -119,12 +119,15 @@ func TestSummarize_Success(t *testing.T) {
 	defer ts.Close()
 
 	p := newTestProvider(ts.URL)
-	summary, err := p.Summarize(context.Background(), sampleLogs())
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if summary != "The service experienced intermittent connection failures." {
-		t.Errorf("unexpected summary: %s", summary)
+	if summary.Text != "The service experienced intermittent connection failures." {
+		t.Errorf("unexpected summary: %s", summary.Text)
+	}
+	if summary.Model != "claude-sonnet-4-5-20250929" {
+		t.Errorf("expected the configured model claude-sonnet-4-5-20250929, got %s", summary.Model)
 	}
 }
 
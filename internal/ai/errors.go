@@ -1,6 +1,13 @@
 package ai
 
-import "github.com/kiranshivaraju/loghunter/internal/ai/shared"
+import (
+	"context"
+	"errors"
+
+	"github.com/kiranshivaraju/loghunter/internal/ai/shared"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
 
 // Re-export error sentinels from shared package for backwards compatibility.
 var (
@@ -9,3 +16,48 @@ var (
 	ErrInvalidResponse     = shared.ErrInvalidResponse
 	ErrNoLogsFound         = shared.ErrNoLogsFound
 )
+
+// ErrModelNotAllowed is returned when a request overrides the model with one
+// that is not in the configured allowlist.
+var ErrModelNotAllowed = errors.New("model not allowed")
+
+// ErrSummarizeBusy is returned when WithSummarizeMaxInflight summaries are
+// already running.
+var ErrSummarizeBusy = errors.New("too many summaries in flight")
+
+// ErrJobFinished is returned by CancelJob for a job that already completed
+// or failed.
+var ErrJobFinished = errors.New("job has already finished")
+
+// ErrAnalysisInProgress is returned by the analysis entry points when another
+// analysis holds the cluster's analysis lock but its job cannot be returned,
+// e.g. because it was not recorded in time. AnalyzeSync returns it whenever
+// another analysis is in flight.
+var ErrAnalysisInProgress = errors.New("analysis already in progress")
+
+// ErrAnalyzeQueueTimeout is returned when no WithAnalyzeMaxConcurrency slot
+// frees up within the queue timeout.
+var ErrAnalyzeQueueTimeout = errors.New("timed out waiting for an analysis slot")
+
+// JobErrorCode classifies an analysis failure into a machine-readable job error code.
+// Uses errors.Is so wrapped errors are classified by their sentinel.
+func JobErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrAnalyzeQueueTimeout):
+		return models.JobErrorQueueTimeout
+	case errors.Is(err, loki.ErrLokiUnreachable), errors.Is(err, loki.ErrLokiTimeout):
+		return models.JobErrorLokiUnavailable
+	case errors.Is(err, loki.ErrLokiQueryError):
+		return models.JobErrorLokiQuery
+	case errors.Is(err, ErrInferenceTimeout), errors.Is(err, context.DeadlineExceeded):
+		return models.JobErrorAITimeout
+	case errors.Is(err, ErrInvalidResponse):
+		return models.JobErrorAIInvalidResponse
+	case errors.Is(err, ErrProviderUnavailable):
+		return models.JobErrorAIUnavailable
+	case errors.Is(err, ErrNoLogsFound):
+		return models.JobErrorNoLogs
+	default:
+		return models.JobErrorInternal
+	}
+}
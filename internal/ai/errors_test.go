@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+func TestJobErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"loki unreachable", loki.ErrLokiUnreachable, models.JobErrorLokiUnavailable},
+		{"loki timeout", loki.ErrLokiTimeout, models.JobErrorLokiUnavailable},
+		{"loki query error", fmt.Errorf("%w: status 400", loki.ErrLokiQueryError), models.JobErrorLokiQuery},
+		{"inference timeout", ErrInferenceTimeout, models.JobErrorAITimeout},
+		{"context deadline", context.DeadlineExceeded, models.JobErrorAITimeout},
+		{"invalid response", fmt.Errorf("%w: bad json", ErrInvalidResponse), models.JobErrorAIInvalidResponse},
+		{"provider unavailable", ErrProviderUnavailable, models.JobErrorAIUnavailable},
+		{"no logs", fmt.Errorf("fetching logs: %w", ErrNoLogsFound), models.JobErrorNoLogs},
+		{"queue timeout", ErrAnalyzeQueueTimeout, models.JobErrorQueueTimeout},
+		{"unknown", errors.New("boom"), models.JobErrorInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JobErrorCode(tt.err); got != tt.want {
+				t.Errorf("JobErrorCode(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
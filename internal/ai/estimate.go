@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kiranshivaraju/loghunter/internal/ai/shared"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// charsPerToken approximates how many bytes of prompt text make up one token.
+// It is the usual rule of thumb for English text and log lines; real
+// tokenizers vary by model, so estimates are only a guide.
+const charsPerToken = 4
+
+// TokenEstimate is the expected size of an analysis prompt.
+type TokenEstimate struct {
+	ContextLines int    `json:"context_lines"`
+	PromptTokens int    `json:"prompt_tokens"`
+	Provider     string `json:"provider"`
+	// EstimatedCost is PromptTokens priced with WithPromptPricing, in the
+	// configured currency. Nil when no pricing is configured.
+	EstimatedCost *float64 `json:"estimated_cost,omitempty"`
+}
+
+// WithPromptPricing sets the price of 1,000 prompt tokens used by
+// EstimateAnalysis. Without it, estimates carry no cost.
+func WithPromptPricing(per1K float64) ServiceOption {
+	return func(s *AnalysisService) {
+		if per1K > 0 {
+			s.promptCostPer1K = per1K
+		}
+	}
+}
+
+// EstimateAnalysis fetches the context logs an analysis of cluster would use,
+// applies the same context sampling, and estimates the size of the resulting
+// prompt. The provider is not called and nothing is stored.
+func (s *AnalysisService) EstimateAnalysis(ctx context.Context, cluster *models.ErrorCluster) (TokenEstimate, error) {
+	if err := s.checkModel(shared.ModelFromContext(ctx, "")); err != nil {
+		return TokenEstimate{}, err
+	}
+	if err := s.qb.CheckLabels(clusterQueryParams(cluster).Labels()...); err != nil {
+		return TokenEstimate{}, err
+	}
+
+	logs, err := s.fetchContextLogs(ctx, s.logger.With("cluster_id", cluster.ID, "tenant_id", cluster.TenantID), cluster)
+	if err != nil {
+		return TokenEstimate{}, err
+	}
+	// An analysis without context fails, so there is nothing to estimate.
+	if len(logs) == 0 {
+		return TokenEstimate{}, ErrNoLogsFound
+	}
+	logs = selectContextLogs(logs, s.contextLimit, s.contextStrategy, s.severity)
+
+	prompt, err := shared.BuildAnalyzePrompt(models.AnalysisRequest{
+		Cluster:     *cluster,
+		ContextLogs: logs,
+	})
+	if err != nil {
+		return TokenEstimate{}, fmt.Errorf("building prompt: %w", err)
+	}
+
+	est := TokenEstimate{
+		ContextLines: len(logs),
+		PromptTokens: (len(prompt) + charsPerToken - 1) / charsPerToken,
+		Provider:     s.provider.Name(),
+	}
+	if s.promptCostPer1K > 0 {
+		cost := float64(est.PromptTokens) / 1000 * s.promptCostPer1K
+		est.EstimatedCost = &cost
+	}
+	return est, nil
+}
@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+func estimateLines(n int) []models.LogLine {
+	lines := make([]models.LogLine, n)
+	base := time.Now().Add(-time.Minute)
+	for i := range lines {
+		lines[i] = models.LogLine{
+			Timestamp: base.Add(time.Duration(i) * time.Millisecond),
+			Level:     "error",
+			Message:   fmt.Sprintf("payment declined for order %d: upstream timeout", i),
+		}
+	}
+	return lines
+}
+
+func TestEstimateAnalysis_ScalesWithFetchedLines(t *testing.T) {
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(context.Context, models.AnalysisRequest) (models.AnalysisResult, error) {
+			t.Error("estimate must not call the provider")
+			return models.AnalysisResult{}, nil
+		},
+	}
+	cluster := testCluster()
+
+	estimate := func(n int) TokenEstimate {
+		t.Helper()
+		lokiClient := &mockLoki{lines: estimateLines(n)}
+		svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+		est, err := svc.EstimateAnalysis(context.Background(), cluster)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if lokiClient.lastReq.Limit != contextLogLimit {
+			t.Errorf("expected the context fetch limit %d, got %d", contextLogLimit, lokiClient.lastReq.Limit)
+		}
+		return est
+	}
+
+	small, large := estimate(10), estimate(100)
+	if small.ContextLines != 10 || large.ContextLines != 100 {
+		t.Fatalf("expected 10 and 100 context lines, got %d and %d", small.ContextLines, large.ContextLines)
+	}
+	if small.PromptTokens <= 0 || large.PromptTokens <= 5*small.PromptTokens {
+		t.Errorf("expected tokens to scale with lines, got %d for 10 and %d for 100", small.PromptTokens, large.PromptTokens)
+	}
+	if small.Provider != "mock" {
+		t.Errorf("expected provider mock, got %q", small.Provider)
+	}
+	if small.EstimatedCost != nil {
+		t.Errorf("expected no cost without pricing, got %v", *small.EstimatedCost)
+	}
+}
+
+func TestEstimateAnalysis_RespectsContextLimit(t *testing.T) {
+	lokiClient := &mockLoki{lines: estimateLines(100)}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithContextSampling(ContextStrategyRecent, 20, nil))
+
+	est, err := svc.EstimateAnalysis(context.Background(), testCluster())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if est.ContextLines != 20 {
+		t.Errorf("expected the sampled 20 lines, got %d", est.ContextLines)
+	}
+}
+
+func TestEstimateAnalysis_Pricing(t *testing.T) {
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{lines: estimateLines(10)}, newMockStore(), newMockCache(), 30*time.Second,
+		WithPromptPricing(0.5))
+
+	est, err := svc.EstimateAnalysis(context.Background(), testCluster())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if est.EstimatedCost == nil {
+		t.Fatal("expected a cost with pricing configured")
+	}
+	if want := float64(est.PromptTokens) / 1000 * 0.5; *est.EstimatedCost != want {
+		t.Errorf("expected cost %v, got %v", want, *est.EstimatedCost)
+	}
+}
+
+func TestEstimateAnalysis_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		loki *mockLoki
+		want error
+	}{
+		{"no logs", &mockLoki{}, ErrNoLogsFound},
+		{"loki down", &mockLoki{err: errors.New("connection refused")}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAnalysisService(&mockProvider{name: "mock"}, tt.loki, newMockStore(), newMockCache(), 30*time.Second)
+			_, err := svc.EstimateAnalysis(context.Background(), testCluster())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEstimateAnalysis_RejectsDisallowedModel(t *testing.T) {
+	lokiClient := &mockLoki{lines: estimateLines(1)}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	_, err := svc.EstimateAnalysis(WithModel(context.Background(), "gpt-4"), testCluster())
+	if !errors.Is(err, ErrModelNotAllowed) {
+		t.Fatalf("expected ErrModelNotAllowed, got %v", err)
+	}
+	if lokiClient.calls != 0 {
+		t.Error("expected no loki query for a rejected model")
+	}
+}
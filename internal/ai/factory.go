@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/kiranshivaraju/loghunter/internal/ai/anthropic"
+	"github.com/kiranshivaraju/loghunter/internal/ai/mock"
 	"github.com/kiranshivaraju/loghunter/internal/ai/ollama"
 	"github.com/kiranshivaraju/loghunter/internal/ai/openai"
 	"github.com/kiranshivaraju/loghunter/internal/ai/vllm"
@@ -14,16 +15,21 @@ import (
 // NewProvider constructs the appropriate AI provider based on config.
 // Called once at server startup.
 func NewProvider(cfg config.AIConfig) (models.AIProvider, error) {
+	client := NewHTTPClient(providerTimeout(cfg), cfg.HTTPMaxIdleConns)
+
 	switch cfg.Provider {
 	case "ollama":
-		return ollama.NewProvider(cfg.Ollama), nil
+		return ollama.NewProviderWithClient(cfg.Ollama, client), nil
 	case "vllm":
-		return vllm.NewProvider(cfg.VLLM), nil
+		return vllm.NewProviderWithClient(cfg.VLLM, client), nil
 	case "openai":
-		return openai.NewProvider(cfg.OpenAI), nil
+		return openai.NewProviderWithClient(cfg.OpenAI, client), nil
 	case "anthropic":
-		return anthropic.NewProvider(cfg.Anthropic), nil
+		return anthropic.NewProviderWithClient(cfg.Anthropic, client), nil
+	case "mock":
+		// Canned responses for local development without an AI backend.
+		return mock.NewMockProvider(), nil
 	default:
-		return nil, fmt.Errorf("unknown AI provider %q: must be one of ollama, vllm, openai, anthropic", cfg.Provider)
+		return nil, fmt.Errorf("unknown AI provider %q: must be one of ollama, vllm, openai, anthropic, mock", cfg.Provider)
 	}
 }
@@ -1,10 +1,12 @@
 package ai_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/kiranshivaraju/loghunter/internal/ai"
 	"github.com/kiranshivaraju/loghunter/internal/config"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -49,6 +51,16 @@ func TestNewProvider_Anthropic(t *testing.T) {
 	assert.Equal(t, "anthropic", p.Name())
 }
 
+func TestNewProvider_Mock(t *testing.T) {
+	p, err := ai.NewProvider(config.AIConfig{Provider: "mock"})
+	require.NoError(t, err)
+	assert.Equal(t, "mock", p.Name())
+
+	result, err := p.Analyze(context.Background(), models.AnalysisRequest{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.RootCause)
+}
+
 func TestNewProvider_Unknown(t *testing.T) {
 	cfg := config.AIConfig{Provider: "unknown-provider"}
 	_, err := ai.NewProvider(cfg)
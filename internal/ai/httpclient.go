@@ -0,0 +1,28 @@
+package ai
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/internal/ai/shared"
+	"github.com/kiranshivaraju/loghunter/internal/config"
+)
+
+// NewHTTPClient returns the connection-reusing HTTP client shared by the AI
+// providers. See shared.NewHTTPClient for the transport settings.
+func NewHTTPClient(timeout time.Duration, maxIdleConns int) *http.Client {
+	return shared.NewHTTPClient(timeout, maxIdleConns)
+}
+
+// providerTimeout is the per-request backstop for provider HTTP calls: the
+// longest configured operation timeout, so it never cuts a call short of its
+// own context deadline.
+func providerTimeout(cfg config.AIConfig) time.Duration {
+	timeout := cfg.InferenceTimeout
+	for _, t := range []time.Duration{cfg.AnalyzeTimeout, cfg.SummarizeTimeout} {
+		if t > timeout {
+			timeout = t
+		}
+	}
+	return timeout
+}
@@ -0,0 +1,62 @@
+package ai_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/internal/ai"
+	"github.com/kiranshivaraju/loghunter/internal/ai/ollama"
+	"github.com/kiranshivaraju/loghunter/internal/config"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient_TransportSettings(t *testing.T) {
+	client := ai.NewHTTPClient(45*time.Second, 16)
+
+	assert.Equal(t, 45*time.Second, client.Timeout)
+	tr, ok := client.Transport.(*http.Transport)
+	require.True(t, ok, "expected *http.Transport, got %T", client.Transport)
+	assert.Equal(t, 16, tr.MaxIdleConns)
+	assert.Equal(t, 16, tr.MaxIdleConnsPerHost, "all idle slots should be usable by the single AI backend")
+	assert.Equal(t, 90*time.Second, tr.IdleConnTimeout)
+	assert.NotNil(t, tr.DialContext)
+	assert.True(t, tr.ForceAttemptHTTP2)
+}
+
+func TestNewHTTPClient_DefaultIdleConns(t *testing.T) {
+	tr := ai.NewHTTPClient(0, 0).Transport.(*http.Transport)
+	assert.Equal(t, 32, tr.MaxIdleConnsPerHost)
+}
+
+func TestNewHTTPClient_ReusesConnectionsAcrossCalls(t *testing.T) {
+	var conns atomic.Int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Trailing newline as written by json.Encoder: the provider's decoder
+		// stops before it, so the body must be drained for reuse.
+		w.Write([]byte(`{"message":{"role":"assistant","content":"all good"}}` + "\n"))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	p := ollama.NewProviderWithClient(config.OllamaConfig{BaseURL: ts.URL, Model: "llama3"}, ai.NewHTTPClient(0, 4))
+	logs := []models.LogLine{{Timestamp: time.Now(), Message: "boom", Level: "ERROR"}}
+	for i := 0; i < 3; i++ {
+		_, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: logs})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), conns.Load(), "sequential calls should share one connection")
+}
@@ -0,0 +1,25 @@
+package ai
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// analysisJobsTotal counts analysis jobs that reached a terminal status.
+var analysisJobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "loghunter_analysis_jobs_total",
+	Help: "Analysis jobs finished, by terminal status.",
+}, []string{"status"})
+
+// analysisJobsActive and analysisJobsQueued track background and synchronous
+// analyses running and waiting for a WithAnalyzeMaxConcurrency slot.
+var (
+	analysisJobsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "loghunter_analysis_jobs_active",
+		Help: "Analyses currently running.",
+	})
+	analysisJobsQueued = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "loghunter_analysis_jobs_queued",
+		Help: "Analyses waiting for a concurrency slot.",
+	})
+)
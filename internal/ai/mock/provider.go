@@ -5,7 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/kiranshivaraju/loghunter/internal/ai"
+	"github.com/kiranshivaraju/loghunter/internal/ai/shared"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
@@ -13,7 +13,7 @@ import (
 type MockProvider struct {
 	Name_         string
 	AnalyzeFunc   func(ctx context.Context, req models.AnalysisRequest) (models.AnalysisResult, error)
-	SummarizeFunc func(ctx context.Context, logs []models.LogLine) (string, error)
+	SummarizeFunc func(ctx context.Context, req models.SummarizeRequest) (models.Summary, error)
 }
 
 func (m *MockProvider) Name() string { return m.Name_ }
@@ -25,11 +25,11 @@ func (m *MockProvider) Analyze(ctx context.Context, req models.AnalysisRequest)
 	return models.AnalysisResult{}, nil
 }
 
-func (m *MockProvider) Summarize(ctx context.Context, logs []models.LogLine) (string, error) {
+func (m *MockProvider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
 	if m.SummarizeFunc != nil {
-		return m.SummarizeFunc(ctx, logs)
+		return m.SummarizeFunc(ctx, req)
 	}
-	return "", nil
+	return models.Summary{}, nil
 }
 
 // NewMockProvider returns a MockProvider with sensible default responses.
@@ -51,8 +51,8 @@ func NewMockProvider() *MockProvider {
 				CreatedAt:       time.Now().UTC(),
 			}, nil
 		},
-		SummarizeFunc: func(_ context.Context, logs []models.LogLine) (string, error) {
-			return "Mock summary: processed log entries for testing", nil
+		SummarizeFunc: func(_ context.Context, _ models.SummarizeRequest) (models.Summary, error) {
+			return models.Summary{Text: "Mock summary: processed log entries for testing", Model: "mock-v1"}, nil
 		},
 	}
 }
@@ -64,8 +64,8 @@ func NewFailingProvider(err error) *MockProvider {
 		AnalyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
 			return models.AnalysisResult{}, err
 		},
-		SummarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
-			return "", err
+		SummarizeFunc: func(_ context.Context, _ models.SummarizeRequest) (models.Summary, error) {
+			return models.Summary{}, err
 		},
 	}
 }
@@ -76,11 +76,11 @@ func NewTimeoutProvider() *MockProvider {
 		Name_: "mock-timeout",
 		AnalyzeFunc: func(ctx context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
 			<-ctx.Done()
-			return models.AnalysisResult{}, ai.ErrInferenceTimeout
+			return models.AnalysisResult{}, shared.ErrInferenceTimeout
 		},
-		SummarizeFunc: func(ctx context.Context, _ []models.LogLine) (string, error) {
+		SummarizeFunc: func(ctx context.Context, _ models.SummarizeRequest) (models.Summary, error) {
 			<-ctx.Done()
-			return "", ai.ErrInferenceTimeout
+			return models.Summary{}, shared.ErrInferenceTimeout
 		},
 	}
 }
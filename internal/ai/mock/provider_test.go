@@ -64,11 +64,11 @@ func TestNewMockProvider_Analyze(t *testing.T) {
 
 func TestNewMockProvider_Summarize(t *testing.T) {
 	p := mock.NewMockProvider()
-	summary, err := p.Summarize(context.Background(), sampleLogs())
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 
 	require.NoError(t, err)
-	assert.NotEmpty(t, summary)
-	assert.Contains(t, summary, "Mock summary")
+	assert.Contains(t, summary.Text, "Mock summary")
+	assert.Equal(t, "mock-v1", summary.Model)
 }
 
 // --- NewFailingProvider ---
@@ -87,7 +87,7 @@ func TestNewFailingProvider_Analyze(t *testing.T) {
 
 func TestNewFailingProvider_Summarize(t *testing.T) {
 	p := mock.NewFailingProvider(ai.ErrInvalidResponse)
-	_, err := p.Summarize(context.Background(), sampleLogs())
+	_, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 
 	assert.ErrorIs(t, err, ai.ErrInvalidResponse)
 }
@@ -99,7 +99,7 @@ func TestNewFailingProvider_CustomError(t *testing.T) {
 	_, err := p.Analyze(context.Background(), sampleRequest())
 	assert.ErrorIs(t, err, customErr)
 
-	_, err = p.Summarize(context.Background(), sampleLogs())
+	_, err = p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 	assert.ErrorIs(t, err, customErr)
 }
 
@@ -124,7 +124,7 @@ func TestNewTimeoutProvider_Summarize(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
 	defer cancel()
 
-	_, err := p.Summarize(ctx, sampleLogs())
+	_, err := p.Summarize(ctx, models.SummarizeRequest{Logs: sampleLogs()})
 	assert.ErrorIs(t, err, ai.ErrInferenceTimeout)
 }
 
@@ -149,9 +149,9 @@ func TestMockProvider_NilFuncs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, models.AnalysisResult{}, result)
 
-	summary, err := p.Summarize(context.Background(), sampleLogs())
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 	assert.NoError(t, err)
-	assert.Equal(t, "", summary)
+	assert.Equal(t, models.Summary{}, summary)
 }
 
 // --- Interface compliance ---
@@ -2,6 +2,7 @@ package ollama
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -40,9 +41,15 @@ type Provider struct {
 
 // NewProvider creates a new Ollama AI provider.
 func NewProvider(cfg config.OllamaConfig) *Provider {
+	return NewProviderWithClient(cfg, shared.NewHTTPClient(0, shared.DefaultHTTPMaxIdleConns))
+}
+
+// NewProviderWithClient creates a new Ollama AI provider that sends requests
+// through client, so the connection pool can be shared.
+func NewProviderWithClient(cfg config.OllamaConfig, client *http.Client) *Provider {
 	return &Provider{
 		cfg:    cfg,
-		client: &http.Client{},
+		client: client,
 	}
 }
 
@@ -56,7 +63,8 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 		return models.AnalysisResult{}, fmt.Errorf("building prompt: %w", err)
 	}
 
-	content, err := p.chat(ctx, prompt)
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := p.chat(ctx, model, prompt)
 	if err != nil {
 		return models.AnalysisResult{}, err
 	}
@@ -66,28 +74,29 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 		return models.AnalysisResult{}, fmt.Errorf("%w: %v", shared.ErrInvalidResponse, err)
 	}
 
-	return parsed.ToResult("ollama", p.cfg.Model), nil
+	return parsed.ToResult("ollama", model), nil
 }
 
 // Summarize condenses log lines into a plain-language summary via Ollama.
-func (p *Provider) Summarize(ctx context.Context, logs []models.LogLine) (string, error) {
-	prompt, err := shared.BuildSummarizePrompt(logs)
+func (p *Provider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
+	prompt, err := shared.BuildSummarizePrompt(req.Logs)
 	if err != nil {
-		return "", fmt.Errorf("building prompt: %w", err)
+		return models.Summary{}, fmt.Errorf("building prompt: %w", err)
 	}
 
-	content, err := p.chat(ctx, prompt)
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := p.chat(ctx, model, prompt)
 	if err != nil {
-		return "", err
+		return models.Summary{}, err
 	}
 
-	return strings.TrimSpace(content), nil
+	return models.Summary{Text: strings.TrimSpace(content), Model: model}, nil
 }
 
 // chat sends a chat request to Ollama and returns the assistant's response content.
-func (p *Provider) chat(ctx context.Context, prompt string) (string, error) {
+func (p *Provider) chat(ctx context.Context, model, prompt string) (string, error) {
 	body := ollamaChatRequest{
-		Model: p.cfg.Model,
+		Model: model,
 		Messages: []ollamaMessage{
 			{Role: "user", Content: prompt},
 		},
@@ -113,7 +122,7 @@ func (p *Provider) chat(ctx context.Context, prompt string) (string, error) {
 		}
 		return "", fmt.Errorf("%w: %v", shared.ErrProviderUnavailable, err)
 	}
-	defer resp.Body.Close()
+	defer shared.CloseBody(resp.Body)
 
 	if resp.StatusCode >= 500 {
 		return "", fmt.Errorf("%w: HTTP %d", shared.ErrProviderUnavailable, resp.StatusCode)
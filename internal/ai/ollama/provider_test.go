@@ -103,6 +103,54 @@ func TestAnalyze_Success(t *testing.T) {
 	}
 }
 
+func TestAnalyze_ModelOverride(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "mistral" {
+			t.Errorf("expected model mistral, got %s", req.Model)
+		}
+		json.NewEncoder(w).Encode(ollamaChatResponse{
+			Message: ollamaMessage{Role: "assistant", Content: `{"root_cause": "rc", "confidence": 0.5, "summary": "s"}`},
+		})
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	req := sampleRequest()
+	req.Model = "mistral"
+	result, err := p.Analyze(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Model != "mistral" {
+		t.Errorf("expected result model mistral, got %s", result.Model)
+	}
+}
+
+func TestSummarize_ModelOverride(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "mistral" {
+			t.Errorf("expected model mistral, got %s", req.Model)
+		}
+		json.NewEncoder(w).Encode(ollamaChatResponse{
+			Message: ollamaMessage{Role: "assistant", Content: "summary"},
+		})
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs(), Model: "mistral"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Model != "mistral" {
+		t.Errorf("expected summary model mistral, got %s", summary.Model)
+	}
+}
+
 func TestSummarize_Success(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := ollamaChatResponse{
@@ -117,12 +165,15 @@ func TestSummarize_Success(t *testing.T) {
 	defer ts.Close()
 
 	p := newTestProvider(ts.URL)
-	summary, err := p.Summarize(context.Background(), sampleLogs())
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if summary != "The service experienced intermittent connection failures." {
-		t.Errorf("unexpected summary: %s", summary)
+	if summary.Text != "The service experienced intermittent connection failures." {
+		t.Errorf("unexpected summary: %s", summary.Text)
+	}
+	if summary.Model != "llama3" {
+		t.Errorf("expected the configured model llama3, got %s", summary.Model)
 	}
 }
 
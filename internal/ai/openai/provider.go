@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -23,9 +24,15 @@ type Provider struct {
 // NewProvider creates a new OpenAI AI provider.
 // API key is sourced from config (environment variable) — never hardcoded.
 func NewProvider(cfg config.OpenAIConfig) *Provider {
+	return NewProviderWithClient(cfg, shared.NewHTTPClient(0, shared.DefaultHTTPMaxIdleConns))
+}
+
+// NewProviderWithClient creates a new OpenAI AI provider that sends requests
+// through client, so the connection pool can be shared.
+func NewProviderWithClient(cfg config.OpenAIConfig, client *http.Client) *Provider {
 	return &Provider{
 		cfg:     cfg,
-		client:  &http.Client{},
+		client:  client,
 		baseURL: defaultBaseURL,
 	}
 }
@@ -41,7 +48,8 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 	}
 
 	url := p.baseURL + "/v1/chat/completions"
-	content, err := shared.OpenAIChat(ctx, p.client, url, p.cfg.Model, prompt, p.authHeaders())
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := shared.OpenAIChat(ctx, p.client, url, model, prompt, p.authHeaders())
 	if err != nil {
 		return models.AnalysisResult{}, err
 	}
@@ -51,23 +59,24 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 		return models.AnalysisResult{}, fmt.Errorf("%w: %v", shared.ErrInvalidResponse, err)
 	}
 
-	return parsed.ToResult("openai", p.cfg.Model), nil
+	return parsed.ToResult("openai", model), nil
 }
 
 // Summarize condenses log lines into a plain-language summary via OpenAI.
-func (p *Provider) Summarize(ctx context.Context, logs []models.LogLine) (string, error) {
-	prompt, err := shared.BuildSummarizePrompt(logs)
+func (p *Provider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
+	prompt, err := shared.BuildSummarizePrompt(req.Logs)
 	if err != nil {
-		return "", fmt.Errorf("building prompt: %w", err)
+		return models.Summary{}, fmt.Errorf("building prompt: %w", err)
 	}
 
 	url := p.baseURL + "/v1/chat/completions"
-	content, err := shared.OpenAIChat(ctx, p.client, url, p.cfg.Model, prompt, p.authHeaders())
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := shared.OpenAIChat(ctx, p.client, url, model, prompt, p.authHeaders())
 	if err != nil {
-		return "", err
+		return models.Summary{}, err
 	}
 
-	return content, nil
+	return models.Summary{Text: content, Model: model}, nil
 }
 
 func (p *Provider) authHeaders() map[string]string {
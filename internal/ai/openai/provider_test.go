@@ -107,12 +107,15 @@ func TestSummarize_Success(t *testing.T) {
 	defer ts.Close()
 
 	p := newTestProvider(ts.URL)
-	summary, err := p.Summarize(context.Background(), sampleLogs())
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if summary != "The service experienced intermittent connection failures." {
-		t.Errorf("unexpected summary: %s", summary)
+	if summary.Text != "The service experienced intermittent connection failures." {
+		t.Errorf("unexpected summary: %s", summary.Text)
+	}
+	if summary.Model != "gpt-4" {
+		t.Errorf("expected the configured model gpt-4, got %s", summary.Model)
 	}
 }
 
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"slices"
+
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// Context sampling strategies, used when more context lines are fetched than
+// the configured limit.
+const (
+	// ContextStrategyRecent keeps the most recent lines.
+	ContextStrategyRecent = "recent"
+	// ContextStrategySpread keeps lines evenly spaced across the window.
+	ContextStrategySpread = "spread"
+	// ContextStrategyErrorsFirst keeps the most severe lines, the most recent
+	// first within a severity.
+	ContextStrategyErrorsFirst = "errors_first"
+)
+
+// selectContextLogs returns at most limit of logs chosen by strategy, in
+// chronological order. severity ranks levels for ContextStrategyErrorsFirst.
+// An unknown strategy, or errors_first without severity, behaves like
+// ContextStrategyRecent.
+func selectContextLogs(logs []models.LogLine, limit int, strategy string, severity func(level string) int) []models.LogLine {
+	if limit <= 0 || len(logs) <= limit {
+		return logs
+	}
+	if strategy == ContextStrategyErrorsFirst && severity == nil {
+		strategy = ContextStrategyRecent
+	}
+
+	sorted := slices.Clone(logs)
+	slices.SortStableFunc(sorted, func(a, b models.LogLine) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	switch strategy {
+	case ContextStrategySpread:
+		selected := make([]models.LogLine, limit)
+		for i := range selected {
+			selected[i] = sorted[i*len(sorted)/limit]
+		}
+		return selected
+	case ContextStrategyErrorsFirst:
+		idx := make([]int, len(sorted))
+		for i := range idx {
+			idx[i] = i
+		}
+		slices.SortStableFunc(idx, func(a, b int) int {
+			if d := severity(sorted[b].Level) - severity(sorted[a].Level); d != 0 {
+				return d
+			}
+			return b - a
+		})
+		idx = idx[:limit]
+		slices.Sort(idx)
+		selected := make([]models.LogLine, limit)
+		for i, j := range idx {
+			selected[i] = sorted[j]
+		}
+		return selected
+	default:
+		return sorted[len(sorted)-limit:]
+	}
+}
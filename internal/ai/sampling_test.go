@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// testSeverity ranks levels the way clustering does.
+func testSeverity(level string) int {
+	switch strings.ToUpper(level) {
+	case "FATAL":
+		return 4
+	case "ERROR":
+		return 2
+	case "WARN":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// contextLines returns one line per level, a second apart, in reverse
+// chronological order as a backward Loki query would return them.
+func contextLines(levels ...string) []models.LogLine {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	lines := make([]models.LogLine, len(levels))
+	for i, level := range levels {
+		lines[len(levels)-1-i] = models.LogLine{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Message:   fmt.Sprintf("line %d", i),
+			Level:     level,
+		}
+	}
+	return lines
+}
+
+func messages(lines []models.LogLine) string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.Message
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSelectContextLogs_UnderLimit(t *testing.T) {
+	logs := contextLines("info", "error")
+	got := selectContextLogs(logs, 5, ContextStrategySpread, testSeverity)
+	if len(got) != 2 {
+		t.Fatalf("expected all lines under the limit, got %d", len(got))
+	}
+}
+
+func TestSelectContextLogs_Recent(t *testing.T) {
+	logs := contextLines("info", "info", "info", "info", "info", "info")
+	got := selectContextLogs(logs, 3, ContextStrategyRecent, testSeverity)
+	if want := "line 3,line 4,line 5"; messages(got) != want {
+		t.Errorf("expected %q, got %q", want, messages(got))
+	}
+}
+
+func TestSelectContextLogs_Spread(t *testing.T) {
+	logs := contextLines("info", "info", "info", "info", "info", "info", "info", "info", "info")
+	got := selectContextLogs(logs, 3, ContextStrategySpread, testSeverity)
+	if want := "line 0,line 3,line 6"; messages(got) != want {
+		t.Errorf("expected %q, got %q", want, messages(got))
+	}
+}
+
+func TestSelectContextLogs_ErrorsFirst(t *testing.T) {
+	logs := contextLines("error", "info", "fatal", "warn", "error", "info")
+	got := selectContextLogs(logs, 3, ContextStrategyErrorsFirst, testSeverity)
+	// fatal, then the two errors; the result stays chronological.
+	if want := "line 0,line 2,line 4"; messages(got) != want {
+		t.Errorf("expected %q, got %q", want, messages(got))
+	}
+
+	// Ties within a severity favour the most recent lines.
+	got = selectContextLogs(logs, 2, ContextStrategyErrorsFirst, testSeverity)
+	if want := "line 2,line 4"; messages(got) != want {
+		t.Errorf("expected %q, got %q", want, messages(got))
+	}
+}
+
+func TestSelectContextLogs_ErrorsFirstWithoutSeverity(t *testing.T) {
+	logs := contextLines("fatal", "info", "info")
+	got := selectContextLogs(logs, 1, ContextStrategyErrorsFirst, nil)
+	if want := "line 2"; messages(got) != want {
+		t.Errorf("expected the recent fallback %q, got %q", want, messages(got))
+	}
+}
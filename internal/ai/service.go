@@ -2,17 +2,25 @@ package ai
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/ai/shared"
 	"github.com/kiranshivaraju/loghunter/internal/cache"
 	"github.com/kiranshivaraju/loghunter/internal/loki"
 	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/logql"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
+	"golang.org/x/sync/semaphore"
 )
 
 // SummarizeParams holds validated parameters for a summarization request.
@@ -23,6 +31,18 @@ type SummarizeParams struct {
 	Start     time.Time
 	End       time.Time
 	MaxLines  int
+	// Model overrides the provider's configured model; empty uses the default.
+	Model string
+	// Levels restricts the query to these log levels; empty matches all.
+	Levels []string
+	// Fingerprint keeps only lines with this message fingerprint, so a
+	// cluster's own lines are summarized. It needs WithFingerprinter. The
+	// filter is applied before the MaxLines limit: the window is paged through
+	// until MaxLines matching lines are found, up to fingerprintMaxPages pages.
+	Fingerprint string
+	// NoCache skips the cached summary and Loki lines and fetches the window
+	// again. The fresh results are still cached.
+	NoCache bool
 }
 
 // SummarizeResult is the output of a summarization operation.
@@ -32,44 +52,382 @@ type SummarizeResult struct {
 	From          time.Time
 	To            time.Time
 	Provider      string
-	Model         string
+	// Model is the model that wrote the summary: the override, or the
+	// provider's configured model.
+	Model    string
+	CacheHit bool
+	// LogsCacheHit reports whether the lines were served from the Loki query
+	// cache instead of being fetched from Loki.
+	LogsCacheHit bool
+	// Truncated reports whether Loki returned more lines than the client's
+	// max-lines cap, so the summary covers only part of the window.
+	Truncated bool
 }
 
+const (
+	// summarizeCacheTTL bounds how long a summary of a fixed window is reused.
+	summarizeCacheTTL = 15 * time.Minute
+	// summarizeLiveWindow is how close to the present End may be before the
+	// window is treated as "now" and the summary is not cached.
+	summarizeLiveWindow = time.Minute
+	// contextSampleLines is how many lines from each end of the context logs
+	// are kept for GET /api/v1/analyze/{jobID}/logs.
+	contextSampleLines = 50
+	// contextLogLimit caps the context logs fetched for an analysis.
+	contextLogLimit = 1000
+	// summarizeRetryBackoff is the wait before the first Summarize retry; it
+	// doubles with each further retry.
+	summarizeRetryBackoff = 500 * time.Millisecond
+	// analysisLockSlack is added to the analyze timeout to cover the Loki
+	// fetch and job bookkeeping while a cluster's analysis lock is held.
+	analysisLockSlack = time.Minute
+	// inFlightLookupAttempts and inFlightLookupBackoff bound how long a
+	// trigger that lost the analysis lock waits for the winner to record its
+	// job before giving up with ErrAnalysisInProgress.
+	inFlightLookupAttempts = 5
+	inFlightLookupBackoff  = 50 * time.Millisecond
+)
+
+// DefaultLokiQueryCacheTTL is how long the lines fetched for a summary are
+// reused when no TTL is configured.
+const DefaultLokiQueryCacheTTL = 60 * time.Second
+
+// DefaultAnalyzeQueueTimeout is how long an analysis waits for a
+// WithAnalyzeMaxConcurrency slot when no timeout is configured.
+const DefaultAnalyzeQueueTimeout = 5 * time.Minute
+
+// DefaultContextDirection is the Loki query direction used for analysis context.
+//
+// The context window spans five minutes either side of the cluster, but only
+// contextLogLimit lines are fetched. "forward" fills the limit from the start of
+// the window, so the model sees the lead-up to the first occurrence, which is
+// where the cause usually is; on a long-running cluster the latest occurrences
+// may be cut off. "backward" fills it from the end, favouring the most recent
+// lines at the risk of losing everything that preceded the error.
+const DefaultContextDirection = "forward"
+
 // AnalysisService orchestrates AI analysis and summarization.
 type AnalysisService struct {
-	provider models.AIProvider
-	loki     loki.Client
-	store    store.Store
-	cache    cache.Cache
-	timeout  time.Duration
+	provider         models.AIProvider
+	loki             loki.Client
+	store            store.Store
+	cache            cache.Cache
+	analyzeTimeout   time.Duration
+	summarizeTimeout time.Duration
+	qb               logql.QueryBuilder
+	contextDirection string
+	logger           *slog.Logger
+	allowedModels    map[string]bool
+	summarizeRetries int
+	retryBackoff     time.Duration
+	contextLimit     int
+	contextStrategy  string
+	severity         func(level string) int
+	fingerprint      func(message string) string
+	promptCostPer1K  float64
+	summarizeSlots   *semaphore.Weighted
+	lokiQueryTTL     time.Duration
+	analyzeSlots     *semaphore.Weighted
+	analyzeQueueWait time.Duration
+
+	// cancels holds the cancel func of each job runAnalysis is running, for CancelJob.
+	cancelsMu sync.Mutex
+	cancels   map[uuid.UUID]context.CancelCauseFunc
+}
+
+// ServiceOption configures optional AnalysisService behavior.
+type ServiceOption func(*AnalysisService)
+
+// WithAnalyzeTimeout overrides the provider timeout applied to Analyze calls.
+func WithAnalyzeTimeout(d time.Duration) ServiceOption {
+	return func(s *AnalysisService) {
+		if d > 0 {
+			s.analyzeTimeout = d
+		}
+	}
+}
+
+// WithSummarizeTimeout overrides the provider timeout applied to Summarize calls.
+func WithSummarizeTimeout(d time.Duration) ServiceOption {
+	return func(s *AnalysisService) {
+		if d > 0 {
+			s.summarizeTimeout = d
+		}
+	}
+}
+
+// WithSummarizeRetries lets Summarize retry a Loki query or provider call up to
+// n times when it fails with ErrLokiUnreachable or ErrProviderUnavailable.
+// Defaults to 0, which fails on the first error.
+func WithSummarizeRetries(n int) ServiceOption {
+	return func(s *AnalysisService) {
+		if n > 0 {
+			s.summarizeRetries = n
+		}
+	}
+}
+
+// WithSummarizeMaxInflight caps the summaries that may query Loki and the
+// provider at once at n; further calls fail fast with ErrSummarizeBusy. Cached
+// summaries are served regardless. Defaults to 0, which sets no cap.
+func WithSummarizeMaxInflight(n int) ServiceOption {
+	return func(s *AnalysisService) {
+		if n > 0 {
+			s.summarizeSlots = semaphore.NewWeighted(int64(n))
+		}
+	}
+}
+
+// WithAnalyzeMaxConcurrency caps the analyses that may run at once at n, so a
+// burst of triggers cannot overwhelm the provider. Further jobs are still
+// created pending and wait for a slot for up to the queue timeout (see
+// WithAnalyzeQueueTimeout), failing with JobErrorQueueTimeout after that.
+// AnalyzeSync waits the same way. Defaults to 0, which sets no cap.
+func WithAnalyzeMaxConcurrency(n int) ServiceOption {
+	return func(s *AnalysisService) {
+		if n > 0 {
+			s.analyzeSlots = semaphore.NewWeighted(int64(n))
+		}
+	}
+}
+
+// WithAnalyzeQueueTimeout sets how long an analysis waits for a
+// WithAnalyzeMaxConcurrency slot. Defaults to DefaultAnalyzeQueueTimeout;
+// d <= 0 keeps the default.
+func WithAnalyzeQueueTimeout(d time.Duration) ServiceOption {
+	return func(s *AnalysisService) {
+		if d > 0 {
+			s.analyzeQueueWait = d
+		}
+	}
+}
+
+// WithLokiQueryCacheTTL sets how long the lines fetched for a summary are
+// cached under their query and window. Unlike the summary cache it also covers
+// windows ending now, so keep it short. 0 disables it; defaults to
+// DefaultLokiQueryCacheTTL.
+func WithLokiQueryCacheTTL(d time.Duration) ServiceOption {
+	return func(s *AnalysisService) {
+		if d >= 0 {
+			s.lokiQueryTTL = d
+		}
+	}
+}
+
+// WithAllowedLabels restricts the Loki labels the service's queries may reference.
+func WithAllowedLabels(labels []string) ServiceOption {
+	return func(s *AnalysisService) {
+		s.qb.AllowedLabels = labels
+	}
+}
+
+// WithContextDirection sets the Loki direction ("forward" or "backward") used to
+// fetch analysis context logs. See DefaultContextDirection for the tradeoff.
+func WithContextDirection(dir string) ServiceOption {
+	return func(s *AnalysisService) {
+		if dir != "" {
+			s.contextDirection = dir
+		}
+	}
+}
+
+// WithContextSampling caps the context lines sent to the provider at limit.
+// When more are fetched, strategy (one of the ContextStrategy constants)
+// picks which to keep; severity ranks levels for ContextStrategyErrorsFirst.
+// Without it every fetched line is sent.
+func WithContextSampling(strategy string, limit int, severity func(level string) int) ServiceOption {
+	return func(s *AnalysisService) {
+		if limit > 0 {
+			s.contextLimit = limit
+			s.contextStrategy = strategy
+			s.severity = severity
+		}
+	}
+}
+
+// WithFingerprinter sets how log messages are fingerprinted when a summary is
+// restricted to one cluster's lines. Without it, SummarizeParams.Fingerprint
+// is ignored.
+func WithFingerprinter(fingerprint func(message string) string) ServiceOption {
+	return func(s *AnalysisService) {
+		s.fingerprint = fingerprint
+	}
+}
+
+// WithLogger sets the logger for analysis lifecycle events. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) ServiceOption {
+	return func(s *AnalysisService) {
+		if l != nil {
+			s.logger = l
+		}
+	}
+}
+
+// WithAllowedModels sets the models a request may select instead of the
+// provider's configured one. With none, every override is rejected.
+func WithAllowedModels(names []string) ServiceOption {
+	return func(s *AnalysisService) {
+		s.allowedModels = make(map[string]bool, len(names))
+		for _, n := range names {
+			s.allowedModels[n] = true
+		}
+	}
+}
+
+// WithModel returns a context that overrides the provider's model for the
+// analysis or summary started with it. The service rejects models not allowed
+// by WithAllowedModels with ErrModelNotAllowed.
+func WithModel(ctx context.Context, model string) context.Context {
+	return shared.WithModel(ctx, model)
 }
 
 // NewAnalysisService creates a new AnalysisService.
-func NewAnalysisService(provider models.AIProvider, lokiClient loki.Client, st store.Store, ca cache.Cache, timeout time.Duration) *AnalysisService {
-	return &AnalysisService{
-		provider: provider,
-		loki:     lokiClient,
-		store:    st,
-		cache:    ca,
-		timeout:  timeout,
+// timeout is the default provider timeout for both analyze and summarize.
+func NewAnalysisService(provider models.AIProvider, lokiClient loki.Client, st store.Store, ca cache.Cache, timeout time.Duration, opts ...ServiceOption) *AnalysisService {
+	s := &AnalysisService{
+		provider:         provider,
+		loki:             lokiClient,
+		store:            st,
+		cache:            ca,
+		analyzeTimeout:   timeout,
+		summarizeTimeout: timeout,
+		contextDirection: DefaultContextDirection,
+		logger:           slog.Default(),
+		retryBackoff:     summarizeRetryBackoff,
+		lokiQueryTTL:     DefaultLokiQueryCacheTTL,
+		analyzeQueueWait: DefaultAnalyzeQueueTimeout,
+		cancels:          make(map[uuid.UUID]context.CancelCauseFunc),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // TriggerAnalysis creates a pending job and dispatches analysis in a background goroutine.
 // Returns the job immediately without waiting for analysis to complete.
+//
+// While a job for the cluster is in flight on any replica sharing the cache,
+// that job is returned instead of starting another; see lockCluster.
 func (s *AnalysisService) TriggerAnalysis(ctx context.Context, cluster *models.ErrorCluster) (*models.Job, error) {
+	return s.startJob(ctx, cluster, nil, nil)
+}
+
+// AnalyzeWithLogs is TriggerAnalysis for callers that already hold the
+// relevant logs (e.g. from a CI failure): Loki is not queried and logs are
+// used as the analysis context, subject to the same context sampling. An
+// in-flight job for the cluster is returned as for TriggerAnalysis, and logs
+// are then ignored.
+func (s *AnalysisService) AnalyzeWithLogs(ctx context.Context, cluster *models.ErrorCluster, logs []models.LogLine) (*models.Job, error) {
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("invalid request: logs are required")
+	}
+	return s.startJob(ctx, cluster, nil, logs)
+}
+
+// ReplayAnalysis is TriggerAnalysis for re-running a past job: the new job
+// records originalJobID as replayed_from so the two results can be compared.
+// An in-flight job for the cluster is returned as for TriggerAnalysis.
+func (s *AnalysisService) ReplayAnalysis(ctx context.Context, cluster *models.ErrorCluster, originalJobID uuid.UUID) (*models.Job, error) {
+	return s.startJob(ctx, cluster, &originalJobID, nil)
+}
+
+// AnalyzeSync runs analysis inline and returns the result, for callers that
+// would rather block than poll. A job and result are still persisted. The
+// provider call is bounded by both ctx and the analyze timeout, and provider
+// errors are returned unwrapped so callers can match the sentinel errors.
+// While another analysis of the cluster is in flight it returns
+// ErrAnalysisInProgress, as there is no result to return yet.
+func (s *AnalysisService) AnalyzeSync(ctx context.Context, cluster *models.ErrorCluster) (*models.AnalysisResult, error) {
+	unlock, inFlight, err := s.lockCluster(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if inFlight != nil {
+		return nil, ErrAnalysisInProgress
+	}
+	defer unlock()
+
+	job, err := s.createLockedJob(ctx, cluster, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireAnalyzeSlot(ctx)
+	if err != nil {
+		s.failJob(context.WithoutCancel(ctx), job.ID, JobErrorCode(err), err.Error())
+		return nil, err
+	}
+	defer release()
+
+	// Job bookkeeping must land even if ctx is cancelled mid-analysis.
+	return s.execute(ctx, context.WithoutCancel(ctx), s.jobLogger(job.ID, cluster), cluster, job.ID, shared.ModelFromContext(ctx, ""), nil)
+}
+
+// startJob takes the cluster's analysis lock, creates a job and dispatches
+// it, holding the lock until the job finishes. If another analysis of the
+// cluster holds the lock, its job is returned instead. replayedFrom and
+// provided are passed on to createJob and dispatch.
+func (s *AnalysisService) startJob(ctx context.Context, cluster *models.ErrorCluster, replayedFrom *uuid.UUID, provided []models.LogLine) (*models.Job, error) {
+	unlock, inFlight, err := s.lockCluster(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if inFlight != nil {
+		return inFlight, nil
+	}
+
+	job, err := s.createLockedJob(ctx, cluster, replayedFrom)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	s.dispatch(cluster, job.ID, shared.ModelFromContext(ctx, ""), provided, unlock)
+
+	return job, nil
+}
+
+// createLockedJob is createJob for a caller holding the cluster's analysis
+// lock: the job is also recorded as the cluster's in-flight job, so triggers
+// that lose the lock can return it.
+func (s *AnalysisService) createLockedJob(ctx context.Context, cluster *models.ErrorCluster, replayedFrom *uuid.UUID) (*models.Job, error) {
+	job, err := s.createJob(ctx, cluster, replayedFrom)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.cache.Set(ctx, cache.AnalysisInFlightKey(cluster.TenantID, cluster.ID), []byte(job.ID.String()), s.analysisLockTTL())
+	return job, nil
+}
+
+// createJob validates the cluster and persists a pending analysis job for it.
+// replayedFrom, if set, links the job to the earlier job it re-runs. The job's
+// metadata records the cluster fingerprint and any requested model.
+func (s *AnalysisService) createJob(ctx context.Context, cluster *models.ErrorCluster, replayedFrom *uuid.UUID) (*models.Job, error) {
 	if cluster.ID == uuid.Nil {
 		return nil, fmt.Errorf("invalid cluster: ID is required")
 	}
+	model := shared.ModelFromContext(ctx, "")
+	if err := s.checkModel(model); err != nil {
+		return nil, err
+	}
+	if err := s.qb.CheckLabels(clusterQueryParams(cluster).Labels()...); err != nil {
+		return nil, err
+	}
 
 	job := &models.Job{
-		ID:        uuid.New(),
-		TenantID:  cluster.TenantID,
-		Type:      "analysis",
-		Status:    models.JobStatusPending,
-		ClusterID: &cluster.ID,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		ID:           uuid.New(),
+		TenantID:     cluster.TenantID,
+		Type:         models.JobTypeAnalysis,
+		Status:       models.JobStatusPending,
+		ClusterID:    &cluster.ID,
+		ReplayedFrom: replayedFrom,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		Metadata:     map[string]string{models.JobMetaFingerprint: cluster.Fingerprint},
+	}
+	if model != "" {
+		job.Metadata[models.JobMetaModel] = model
 	}
 
 	if err := s.store.CreateJob(ctx, job); err != nil {
@@ -78,70 +436,298 @@ func (s *AnalysisService) TriggerAnalysis(ctx context.Context, cluster *models.E
 
 	_ = s.cache.SetJobStatus(ctx, job.ID, models.JobStatusPending, 30*time.Minute)
 
-	go s.runAnalysis(cluster, job.ID, cluster.TenantID)
-
 	return job, nil
 }
 
-// runAnalysis performs the actual AI analysis in a goroutine.
+// lockCluster takes the cluster's analysis lock for an analysis entry point.
+// If another analysis holds it, the job that analysis started is returned
+// instead. The winner records its job only after taking the lock, so the
+// lookup is retried for up to inFlightLookupAttempts before giving up with
+// ErrAnalysisInProgress; the same error is returned if the recorded job has
+// already finished. Caches that cannot lock, and lock errors, let the
+// analysis go ahead unlocked, as a duplicate job is better than none. The
+// returned unlock is never nil.
+func (s *AnalysisService) lockCluster(ctx context.Context, cluster *models.ErrorCluster) (unlock func(), inFlight *models.Job, err error) {
+	noop := func() {}
+	locker, ok := s.cache.(cache.Locker)
+	if !ok {
+		return noop, nil, nil
+	}
+	acquired, unlock, err := locker.TryLock(ctx, cache.AnalysisLockKey(cluster.TenantID, cluster.ID), s.analysisLockTTL())
+	if err != nil {
+		if !errors.Is(err, cache.ErrDisabled) {
+			s.logger.Warn("failed to take analysis lock", "cluster_id", cluster.ID, "tenant_id", cluster.TenantID, "error", err)
+		}
+		return noop, nil, nil
+	}
+	if acquired {
+		return unlock, nil, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		if job := s.inFlightJob(ctx, cluster); job != nil {
+			return nil, job, nil
+		}
+		if attempt == inFlightLookupAttempts {
+			return nil, nil, ErrAnalysisInProgress
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ErrAnalysisInProgress
+		case <-time.After(inFlightLookupBackoff):
+		}
+	}
+}
+
+// inFlightJob returns the cluster's recorded in-flight job if it is still
+// pending or running, and nil otherwise.
+func (s *AnalysisService) inFlightJob(ctx context.Context, cluster *models.ErrorCluster) *models.Job {
+	data, found, err := s.cache.Get(ctx, cache.AnalysisInFlightKey(cluster.TenantID, cluster.ID))
+	if err != nil || !found {
+		return nil
+	}
+	jobID, err := uuid.Parse(string(data))
+	if err != nil {
+		return nil
+	}
+	job, err := s.store.GetJob(ctx, jobID, cluster.TenantID)
+	if err != nil || (job.Status != models.JobStatusPending && job.Status != models.JobStatusRunning) {
+		return nil
+	}
+	return job
+}
+
+// analysisLockTTL is how long a cluster's analysis lock is held at most: the
+// provider timeout plus analysisLockSlack, and the queue timeout when
+// analyses are capped. A job that runs longer may be duplicated by a later
+// trigger.
+func (s *AnalysisService) analysisLockTTL() time.Duration {
+	ttl := s.analyzeTimeout + analysisLockSlack
+	if s.analyzeSlots != nil {
+		ttl += s.analyzeQueueWait
+	}
+	return ttl
+}
+
+// acquireAnalyzeSlot waits for a WithAnalyzeMaxConcurrency slot, returning
+// ErrAnalyzeQueueTimeout if none frees up within the queue timeout, or ctx's
+// error if ctx ends first. Without a cap it returns at once. The returned
+// release must be called when the analysis finishes.
+func (s *AnalysisService) acquireAnalyzeSlot(ctx context.Context) (release func(), err error) {
+	if s.analyzeSlots != nil {
+		analysisJobsQueued.Inc()
+		waitCtx, cancel := context.WithTimeout(ctx, s.analyzeQueueWait)
+		err := s.analyzeSlots.Acquire(waitCtx, 1)
+		cancel()
+		analysisJobsQueued.Dec()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, ErrAnalyzeQueueTimeout
+		}
+	}
+
+	analysisJobsActive.Inc()
+	return func() {
+		analysisJobsActive.Dec()
+		if s.analyzeSlots != nil {
+			s.analyzeSlots.Release(1)
+		}
+	}, nil
+}
+
+// errJobCancelled is the cancellation cause CancelJob gives a running job.
+var errJobCancelled = errors.New("cancelled")
+
+// dispatch starts runAnalysis for a created job in a background goroutine.
+//
+// The job intentionally runs on context.Background() rather than the
+// triggering request's context: the client only waits for the job ID, so a
+// disconnect must not cancel the analysis. Only CancelJob does; the job is
+// registered for it before dispatch returns. The goroutine first waits for an
+// analysis slot; a job that times out waiting is failed without running.
+// model carries over the request's model override, if any. unlock, if non-nil,
+// is called once the job has finished.
+func (s *AnalysisService) dispatch(cluster *models.ErrorCluster, jobID uuid.UUID, model string, provided []models.LogLine, unlock func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s.cancelsMu.Lock()
+	s.cancels[jobID] = cancel
+	s.cancelsMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.cancelsMu.Lock()
+			delete(s.cancels, jobID)
+			s.cancelsMu.Unlock()
+			cancel(nil)
+			if unlock != nil {
+				unlock()
+			}
+		}()
+
+		release, err := s.acquireAnalyzeSlot(ctx)
+		if err != nil {
+			// A cancelled wait was already marked failed by CancelJob.
+			if !errors.Is(context.Cause(ctx), errJobCancelled) {
+				code := JobErrorCode(err)
+				s.failJob(context.WithoutCancel(ctx), jobID, code, err.Error())
+				s.jobLogger(jobID, cluster).Warn("analysis failed", "status", models.JobStatusFailed,
+					"error_code", code, "error", err)
+			}
+			return
+		}
+		defer release()
+
+		s.runAnalysis(ctx, cluster, jobID, model, provided)
+	}()
+}
+
+// runAnalysis performs the actual AI analysis for a dispatched job.
 // It recovers from panics and always marks the job as completed or failed.
-func (s *AnalysisService) runAnalysis(cluster *models.ErrorCluster, jobID uuid.UUID, tenantID uuid.UUID) {
-	ctx := context.Background()
+// The analysis runs on ctx, so cancelling it aborts the Loki fetch and
+// provider call, which is still bounded by analyzeTimeout; job bookkeeping
+// outlives the cancellation. model, if set, overrides the provider's model, and
+// provided, if non-nil, replaces the Loki context fetch.
+func (s *AnalysisService) runAnalysis(ctx context.Context, cluster *models.ErrorCluster, jobID uuid.UUID, model string, provided []models.LogLine) {
+	bookCtx := context.WithoutCancel(ctx)
+	log := s.jobLogger(jobID, cluster)
 
 	defer func() {
 		if r := recover(); r != nil {
-			slog.Error("panic in runAnalysis", "error", r, "job_id", jobID)
-			_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusFailed,
-				store.WithErrorMessage(fmt.Sprintf("panic: %v", r)))
-			_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusFailed, 30*time.Minute)
+			log.Error("panic in runAnalysis", "error", r, "status", models.JobStatusFailed)
+			s.failJob(bookCtx, jobID, models.JobErrorInternal, fmt.Sprintf("panic: %v", r))
 		}
 	}()
 
-	// Mark as running
-	_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusRunning)
-	_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusRunning, 30*time.Minute)
+	_, _ = s.execute(ctx, bookCtx, log, cluster, jobID, model, provided)
+}
 
-	// Fetch context logs from Loki (±5 min around cluster window)
-	qb := logql.QueryBuilder{}
-	query := qb.BuildDetectionQuery(logql.DetectionParams{
-		Service:   cluster.Service,
-		Namespace: cluster.Namespace,
-	})
+// CancelJob stops one of the tenant's analysis jobs and marks it cancelled with
+// the JobErrorCancelled code. A job running in this process has its context
+// cancelled, aborting the Loki fetch or provider call in flight; one that
+// finishes before the signal lands keeps its result. A pending or running job
+// this process is not running, e.g. one orphaned by a restart, is only marked.
+// It returns store.ErrNotFound for an unknown job and ErrJobFinished for one
+// that already completed, failed or was cancelled.
+func (s *AnalysisService) CancelJob(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) error {
+	job, err := s.store.GetJob(ctx, jobID, tenantID)
+	if err != nil {
+		return err
+	}
+	if job.Status != models.JobStatusPending && job.Status != models.JobStatusRunning {
+		return ErrJobFinished
+	}
 
-	logs, err := s.loki.QueryRange(ctx, loki.QueryRangeRequest{
-		Query: query,
-		Start: cluster.FirstSeenAt.Add(-5 * time.Minute),
-		End:   cluster.LastSeenAt.Add(5 * time.Minute),
-		Limit: 1000,
-	})
+	if err := s.markCancelled(context.WithoutCancel(ctx), jobID); err != nil {
+		return err
+	}
+
+	s.cancelsMu.Lock()
+	cancel, ok := s.cancels[jobID]
+	s.cancelsMu.Unlock()
+	if ok {
+		cancel(errJobCancelled)
+	}
+	s.logger.Info("analysis cancelled", "job_id", jobID, "tenant_id", tenantID, "running", ok)
+	return nil
+}
+
+// jobLogger returns a logger carrying the identifiers of one analysis job.
+func (s *AnalysisService) jobLogger(jobID uuid.UUID, cluster *models.ErrorCluster) *slog.Logger {
+	return s.logger.With(
+		"job_id", jobID,
+		"cluster_id", cluster.ID,
+		"tenant_id", cluster.TenantID,
+		"provider", s.provider.Name(),
+	)
+}
+
+// execute runs a created job to completion: it marks the job running, analyzes
+// the cluster on ctx, and records the outcome on bookCtx.
+func (s *AnalysisService) execute(ctx, bookCtx context.Context, log *slog.Logger, cluster *models.ErrorCluster, jobID uuid.UUID, model string, provided []models.LogLine) (*models.AnalysisResult, error) {
+	start := time.Now()
+	log.Info("analysis started", "status", models.JobStatusRunning)
+
+	s.markRunning(bookCtx, jobID)
+	result, code, err := s.analyze(ctx, log, cluster, jobID, cluster.TenantID, model, provided)
+	if errors.Is(context.Cause(ctx), errJobCancelled) {
+		// CancelJob already marked the job; keep its status over whatever the
+		// aborted analysis reports.
+		if err == nil {
+			err = errJobCancelled
+		}
+		log.Info("analysis aborted", "status", models.JobStatusCancelled,
+			"error_code", models.JobErrorCancelled, "duration_ms", time.Since(start).Milliseconds())
+		return nil, err
+	}
 	if err != nil {
-		_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusFailed,
-			store.WithErrorMessage(fmt.Sprintf("fetching logs: %v", err)))
-		_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusFailed, 30*time.Minute)
-		return
+		s.failJob(bookCtx, jobID, code, err.Error())
+		log.Warn("analysis failed", "status", models.JobStatusFailed,
+			"error_code", code, "error", err, "duration_ms", time.Since(start).Milliseconds())
+		return nil, err
+	}
+	s.completeJob(bookCtx, jobID, cluster.ID)
+	log.Info("analysis completed", "status", models.JobStatusCompleted,
+		"confidence", result.Confidence, "duration_ms", time.Since(start).Milliseconds())
+
+	return result, nil
+}
+
+// analyze fetches context logs, calls the provider, and stores the result.
+// Non-nil provided logs are used as-is instead of querying Loki, and a
+// non-empty model overrides the provider's.
+// On failure it returns the job error code alongside the error.
+func (s *AnalysisService) analyze(ctx context.Context, log *slog.Logger, cluster *models.ErrorCluster, jobID uuid.UUID, tenantID uuid.UUID, model string, provided []models.LogLine) (*models.AnalysisResult, string, error) {
+	logs := provided
+	if logs == nil {
+		var err error
+		if logs, err = s.fetchContextLogs(ctx, log, cluster); err != nil {
+			return nil, JobErrorCode(err), err
+		}
+	} else {
+		log.Info("analysis context provided", "lines_provided", len(logs))
+	}
+	// Without context the provider can only guess; fail so the client can
+	// retry with a wider window instead of storing a low-quality result.
+	if len(logs) == 0 {
+		return nil, models.JobErrorNoLogs, ErrNoLogsFound
+	}
+	logs = selectContextLogs(logs, s.contextLimit, s.contextStrategy, s.severity)
+
+	// Keep a sample of what the provider sees; losing it must not fail the job.
+	if err := s.store.SaveAnalysisContext(ctx, &models.AnalysisContext{
+		JobID:      jobID,
+		TenantID:   tenantID,
+		Lines:      sampleContextLogs(logs, contextSampleLines),
+		TotalLines: len(logs),
+		CreatedAt:  time.Now().UTC(),
+	}); err != nil {
+		log.Warn("failed to save analysis context", "error", err)
 	}
 
 	// Call AI provider with timeout
-	analysisCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	analysisCtx, cancel := context.WithTimeout(ctx, s.analyzeTimeout)
 	defer cancel()
 
+	inferStart := time.Now()
 	result, err := s.provider.Analyze(analysisCtx, models.AnalysisRequest{
 		Cluster:     *cluster,
 		ContextLogs: logs,
+		Model:       model,
 	})
 	if err != nil {
-		_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusFailed,
-			store.WithErrorMessage(err.Error()))
-		_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusFailed, 30*time.Minute)
-		return
+		return nil, JobErrorCode(err), err
 	}
+	log.Info("analysis inference finished", "model", result.Model,
+		"duration_ms", time.Since(inferStart).Milliseconds())
 
-	// Clamp confidence to [0, 1]
-	if result.Confidence < 0 {
-		result.Confidence = 0
-	}
-	if result.Confidence > 1.0 {
-		result.Confidence = 1.0
+	// Clamp confidence to [0, 1], recording that the provider went out of range.
+	if result.Confidence < 0 || result.Confidence > 1.0 {
+		log.Warn("analysis confidence out of range, clamping", "model", result.Model,
+			"confidence", result.Confidence)
+		result.Confidence = min(max(result.Confidence, 0), 1.0)
+		result.ConfidenceClamped = true
 	}
 
 	// Truncate fields
@@ -157,35 +743,134 @@ func (s *AnalysisService) runAnalysis(cluster *models.ErrorCluster, jobID uuid.U
 	result.CreatedAt = time.Now().UTC()
 
 	if err := s.store.CreateAnalysisResult(ctx, &result); err != nil {
-		_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusFailed,
-			store.WithErrorMessage(fmt.Sprintf("storing result: %v", err)))
-		_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusFailed, 30*time.Minute)
-		return
+		return nil, models.JobErrorStore, fmt.Errorf("storing result: %w", err)
 	}
 
-	// Mark completed
+	return &result, "", nil
+}
+
+// fetchContextLogs queries Loki for the logs around the cluster's window
+// (±5 min).
+func (s *AnalysisService) fetchContextLogs(ctx context.Context, log *slog.Logger, cluster *models.ErrorCluster) ([]models.LogLine, error) {
+	query := s.qb.BuildDetectionQuery(clusterQueryParams(cluster))
+
+	fetchStart := time.Now()
+	logs, err := s.loki.QueryRange(ctx, loki.QueryRangeRequest{
+		Query:     query,
+		Start:     cluster.FirstSeenAt.Add(-5 * time.Minute),
+		End:       cluster.LastSeenAt.Add(5 * time.Minute),
+		Limit:     contextLogLimit,
+		Direction: s.contextDirection,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching logs: %w", err)
+	}
+	log.Info("analysis context fetched", "lines_fetched", len(logs),
+		"duration_ms", time.Since(fetchStart).Milliseconds())
+	return logs, nil
+}
+
+// markRunning moves a job to running in the store and cache.
+func (s *AnalysisService) markRunning(ctx context.Context, jobID uuid.UUID) {
+	_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusRunning)
+	_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusRunning, 30*time.Minute)
+}
+
+// completeJob marks a job as completed and links it to its cluster.
+func (s *AnalysisService) completeJob(ctx context.Context, jobID, clusterID uuid.UUID) {
 	_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusCompleted,
-		store.WithClusterID(cluster.ID))
+		store.WithClusterID(clusterID))
 	_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusCompleted, 30*time.Minute)
+	analysisJobsTotal.WithLabelValues(models.JobStatusCompleted).Inc()
+}
+
+// sampleContextLogs returns the first and last n lines of logs, or all of
+// them when there are no more than 2n.
+func sampleContextLogs(logs []models.LogLine, n int) []models.LogLine {
+	if len(logs) <= 2*n {
+		return logs
+	}
+	sample := make([]models.LogLine, 0, 2*n)
+	sample = append(sample, logs[:n]...)
+	return append(sample, logs[len(logs)-n:]...)
+}
+
+// clusterQueryParams returns the detection query parameters used to fetch a cluster's context logs.
+func clusterQueryParams(cluster *models.ErrorCluster) logql.DetectionParams {
+	return logql.DetectionParams{
+		Service:   cluster.Service,
+		Namespace: cluster.Namespace,
+	}
+}
+
+// failJob marks a job as failed with a machine-readable code and a human message.
+func (s *AnalysisService) failJob(ctx context.Context, jobID uuid.UUID, code, msg string) {
+	_ = s.store.UpdateJobStatus(ctx, jobID, models.JobStatusFailed,
+		store.WithErrorMessage(msg), store.WithErrorCode(code))
+	_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusFailed, 30*time.Minute)
+	analysisJobsTotal.WithLabelValues(models.JobStatusFailed).Inc()
+}
+
+// markCancelled records a job stopped by CancelJob in the store and cache. It
+// returns ErrJobFinished if the job completed or failed since CancelJob read
+// it, leaving the cache and metrics alone.
+func (s *AnalysisService) markCancelled(ctx context.Context, jobID uuid.UUID) error {
+	err := s.store.UpdateJobStatus(ctx, jobID, models.JobStatusCancelled,
+		store.WithErrorMessage(errJobCancelled.Error()), store.WithErrorCode(models.JobErrorCancelled))
+	if errors.Is(err, store.ErrConcurrentUpdate) || errors.Is(err, store.ErrInvalidTransition) {
+		return ErrJobFinished
+	}
+	if err != nil {
+		return fmt.Errorf("cancel job: %w", err)
+	}
+	_ = s.cache.SetJobStatus(ctx, jobID, models.JobStatusCancelled, 30*time.Minute)
+	analysisJobsTotal.WithLabelValues(models.JobStatusCancelled).Inc()
+	return nil
 }
 
 // Summarize fetches logs from Loki and sends them to the AI provider for summarization.
+// Both calls run under ctx, so cancelling it (e.g. on client disconnect) aborts them.
+// Results for windows that have fully elapsed are cached; windows ending at
+// "now" keep receiving new logs and are always recomputed.
+// The fetched lines are cached separately for a short TTL (see
+// WithLokiQueryCacheTTL), so repeats of the same query skip Loki either way.
 func (s *AnalysisService) Summarize(ctx context.Context, params SummarizeParams) (*SummarizeResult, error) {
-	qb := logql.QueryBuilder{}
-	query := qb.BuildSearchQuery(logql.SearchParams{
+	if err := s.checkModel(params.Model); err != nil {
+		return nil, err
+	}
+	qp := logql.SearchParams{
 		Service:   params.Service,
 		Namespace: params.Namespace,
-	})
+		Levels:    params.Levels,
+	}
+	if err := s.qb.CheckLabels(qp.Labels()...); err != nil {
+		return nil, err
+	}
 
-	logs, err := s.loki.QueryRange(ctx, loki.QueryRangeRequest{
-		Query: query,
-		Start: params.Start,
-		End:   params.End,
-		Limit: params.MaxLines,
-	})
+	cacheable := params.End.Before(time.Now().Add(-summarizeLiveWindow))
+	cacheKey := cache.SummarizeResultKey(params.TenantID, summarizeParamsHash(params))
+
+	if cacheable && !params.NoCache {
+		var result SummarizeResult
+		if found, err := cache.GetJSON(ctx, s.cache, cacheKey, &result); err == nil && found {
+			result.CacheHit = true
+			return &result, nil
+		}
+	}
+
+	if s.summarizeSlots != nil {
+		if !s.summarizeSlots.TryAcquire(1) {
+			return nil, ErrSummarizeBusy
+		}
+		defer s.summarizeSlots.Release(1)
+	}
+
+	query := s.qb.BuildSearchQuery(qp)
+	fetched, logsCacheHit, err := s.summarizeLogs(ctx, params, query)
 	if err != nil {
-		return nil, fmt.Errorf("querying logs: %w", err)
+		return nil, err
 	}
+	logs := fetched.Lines
 
 	if len(logs) == 0 {
 		return nil, ErrNoLogsFound
@@ -196,21 +881,219 @@ func (s *AnalysisService) Summarize(ctx context.Context, params SummarizeParams)
 		logs[i].Message = truncateString(logs[i].Message, 500)
 	}
 
-	summarizeCtx, cancel := context.WithTimeout(ctx, s.timeout)
-	defer cancel()
-
-	summary, err := s.provider.Summarize(summarizeCtx, logs)
+	var summary models.Summary
+	err = s.retryTransient(ctx, "summarize", func() error {
+		summarizeCtx, cancel := context.WithTimeout(ctx, s.summarizeTimeout)
+		defer cancel()
+		var err error
+		summary, err = s.provider.Summarize(summarizeCtx, models.SummarizeRequest{Logs: logs, Model: params.Model})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &SummarizeResult{
-		Summary:       summary,
+	result := &SummarizeResult{
+		Summary:       summary.Text,
 		LinesAnalyzed: len(logs),
 		From:          params.Start,
 		To:            params.End,
 		Provider:      s.provider.Name(),
-	}, nil
+		Model:         summary.Model,
+		LogsCacheHit:  logsCacheHit,
+		Truncated:     fetched.Truncated,
+	}
+
+	if cacheable {
+		_ = cache.SetJSON(ctx, s.cache, cacheKey, result, summarizeCacheTTL)
+	}
+
+	return result, nil
+}
+
+// summarizeLogs fetches the lines for a summary, serving them from the Loki
+// query cache when the same query and window were fetched within
+// s.lokiQueryTTL. It reports whether the cache was hit.
+func (s *AnalysisService) summarizeLogs(ctx context.Context, params SummarizeParams, query string) (*loki.QueryRangeResponse, bool, error) {
+	// Without a fingerprinter the filter is ignored, so it is left out of the key.
+	fingerprint := params.Fingerprint
+	if s.fingerprint == nil {
+		fingerprint = ""
+	}
+	cacheKey := cache.LokiQueryKey(params.TenantID, lokiQueryHash(query, fingerprint, params.Start, params.End, params.MaxLines))
+	if s.lokiQueryTTL > 0 && !params.NoCache {
+		var cached loki.QueryRangeResponse
+		if found, err := cache.GetJSON(ctx, s.cache, cacheKey, &cached); err == nil && found {
+			return &cached, true, nil
+		}
+	}
+
+	var resp *loki.QueryRangeResponse
+	var err error
+	if fingerprint != "" {
+		resp, err = s.fetchFingerprintLines(ctx, params, query)
+	} else {
+		resp, err = s.fetchLines(ctx, loki.QueryRangeRequest{
+			Query: query,
+			Start: params.Start,
+			End:   params.End,
+			Limit: params.MaxLines,
+		})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if s.lokiQueryTTL > 0 {
+		_ = cache.SetJSON(ctx, s.cache, cacheKey, resp, s.lokiQueryTTL)
+	}
+	return resp, false, nil
+}
+
+// fingerprintMaxPages bounds the Loki queries made for one fingerprint-filtered
+// summary, so a cluster drowned out by the rest of its service's lines cannot
+// turn a summary into a scan of the whole window.
+const fingerprintMaxPages = 10
+
+// fetchFingerprintLines pages backward through the window MaxLines lines at a
+// time, keeping those with params.Fingerprint, until MaxLines of them are found
+// or the window is exhausted. The result is Truncated if a page was, or if
+// fingerprintMaxPages ran out first.
+func (s *AnalysisService) fetchFingerprintLines(ctx context.Context, params SummarizeParams, query string) (*loki.QueryRangeResponse, error) {
+	result := &loki.QueryRangeResponse{}
+	end := params.End
+	// boundary holds the previous page's lines at its oldest timestamp. The
+	// next page ends just after that timestamp so lines sharing it are not
+	// lost, and these are skipped as already seen.
+	var boundary []models.LogLine
+	for page := 0; ; page++ {
+		if page == fingerprintMaxPages {
+			result.Truncated = true
+			return result, nil
+		}
+		resp, err := s.fetchLines(ctx, loki.QueryRangeRequest{
+			Query: query,
+			Start: params.Start,
+			End:   end,
+			Limit: params.MaxLines,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var oldest time.Time
+		for _, l := range resp.Lines {
+			if oldest.IsZero() || l.Timestamp.Before(oldest) {
+				oldest = l.Timestamp
+			}
+			if len(result.Lines) < params.MaxLines && s.fingerprint(l.Message) == params.Fingerprint &&
+				!slices.ContainsFunc(boundary, func(b models.LogLine) bool { return sameLogLine(b, l) }) {
+				result.Lines = append(result.Lines, l)
+			}
+		}
+		if resp.Truncated {
+			result.Truncated = true
+			return result, nil
+		}
+		next := oldest.Add(time.Nanosecond)
+		// Stop once enough lines match, Loki has no more, or a whole page
+		// shares one timestamp and paging would not move.
+		if len(result.Lines) >= params.MaxLines || len(resp.Lines) < params.MaxLines || !next.Before(end) {
+			return result, nil
+		}
+		boundary = boundary[:0]
+		for _, l := range resp.Lines {
+			if l.Timestamp.Equal(oldest) {
+				boundary = append(boundary, l)
+			}
+		}
+		end = next
+	}
+}
+
+// sameLogLine reports whether a and b are the same Loki entry.
+func sameLogLine(a, b models.LogLine) bool {
+	return a.Timestamp.Equal(b.Timestamp) && a.Message == b.Message && maps.Equal(a.Labels, b.Labels)
+}
+
+// fetchLines runs one Loki range query, retrying transient failures.
+func (s *AnalysisService) fetchLines(ctx context.Context, req loki.QueryRangeRequest) (*loki.QueryRangeResponse, error) {
+	var resp *loki.QueryRangeResponse
+	err := s.retryTransient(ctx, "loki query", func() error {
+		var err error
+		resp, err = s.loki.QueryRangeDetailed(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying logs: %w", err)
+	}
+	return resp, nil
+}
+
+// retryTransient runs op, retrying it up to s.summarizeRetries times while it
+// fails with ErrLokiUnreachable or ErrProviderUnavailable. Retries stop early
+// when ctx is done or its deadline would pass during the backoff; the last
+// error is returned.
+func (s *AnalysisService) retryTransient(ctx context.Context, name string, op func() error) error {
+	backoff := s.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt > s.summarizeRetries || !isTransient(err) {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		s.logger.Warn("transient error, retrying",
+			"op", name, "attempt", attempt, "max_retries", s.summarizeRetries, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransient reports whether err is worth retrying.
+func isTransient(err error) bool {
+	return errors.Is(err, loki.ErrLokiUnreachable) || errors.Is(err, ErrProviderUnavailable)
+}
+
+// checkModel returns ErrModelNotAllowed if model is a non-empty override that
+// is not in the allowlist.
+func (s *AnalysisService) checkModel(model string) error {
+	if model != "" && !s.allowedModels[model] {
+		return fmt.Errorf("%w: %q", ErrModelNotAllowed, model)
+	}
+	return nil
+}
+
+// summarizeParamsHash returns a short stable hash of the summarize query and window.
+func summarizeParamsHash(params SummarizeParams) string {
+	raw := fmt.Sprintf("%s:%s:%s:%s:%s:%d:%s:%s:%s",
+		params.TenantID,
+		params.Service,
+		params.Namespace,
+		params.Start.UTC().Format(time.RFC3339),
+		params.End.UTC().Format(time.RFC3339),
+		params.MaxLines,
+		params.Model,
+		strings.Join(params.Levels, ","),
+		params.Fingerprint,
+	)
+	h := sha256.Sum256([]byte(raw))
+	return fmt.Sprintf("%x", h[:8])
+}
+
+// lokiQueryHash returns a short stable hash of a Loki query, the fingerprint
+// its lines are filtered on, and its window. The window is truncated to the
+// second, so lookback requests made moments apart share an entry.
+func lokiQueryHash(query, fingerprint string, start, end time.Time, limit int) string {
+	raw := fmt.Sprintf("%s:%s:%d:%d:%d", query, fingerprint, start.Unix(), end.Unix(), limit)
+	h := sha256.Sum256([]byte(raw))
+	return fmt.Sprintf("%x", h[:8])
 }
 
 // truncateString truncates s to maxBytes without splitting UTF-8 runes.
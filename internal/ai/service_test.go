@@ -1,16 +1,27 @@
 package ai
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/cache"
 	"github.com/kiranshivaraju/loghunter/internal/loki"
 	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // --- mocks ---
@@ -19,6 +30,7 @@ type mockStore struct {
 	mu             sync.Mutex
 	jobs           map[uuid.UUID]*models.Job
 	results        []*models.AnalysisResult
+	contexts       []*models.AnalysisContext
 	statusUpdates  []statusUpdate
 	createJobErr   error
 	updateStatusErr error
@@ -40,15 +52,32 @@ func (s *mockStore) GetDefaultTenant(_ context.Context) (*models.Tenant, error)
 func (s *mockStore) GetAPIKeyByPrefix(_ context.Context, _ string) ([]*models.APIKey, error) { return nil, nil }
 func (s *mockStore) UpdateAPIKeyLastUsed(_ context.Context, _ uuid.UUID) error { return nil }
 func (s *mockStore) CreateAPIKey(_ context.Context, _ *models.APIKey) error { return nil }
-func (s *mockStore) ListAPIKeys(_ context.Context, _ uuid.UUID) ([]*models.APIKey, error) { return nil, nil }
+func (s *mockStore) ListAPIKeys(_ context.Context, _ uuid.UUID, _ ...store.APIKeyListOption) ([]*models.APIKey, error) { return nil, nil }
 func (s *mockStore) RevokeAPIKey(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
 func (s *mockStore) UpsertErrorCluster(_ context.Context, _ *models.ErrorCluster) (*models.ErrorCluster, error) { return nil, nil }
 func (s *mockStore) ListErrorClusters(_ context.Context, _ store.ClusterFilter) ([]*models.ErrorCluster, int, error) { return nil, 0, nil }
 func (s *mockStore) GetErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.ErrorCluster, error) { return nil, nil }
 func (s *mockStore) GetClustersByFingerprints(_ context.Context, _ uuid.UUID, _ []string) ([]*models.ErrorCluster, error) { return nil, nil }
-func (s *mockStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) { return nil, nil }
-func (s *mockStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) { return nil, nil }
-func (s *mockStore) GetJob(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (s *mockStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) { return nil, nil }
+func (s *mockStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) { return nil, nil }
+// GetJob returns a copy of a created job carrying its latest status update.
+func (s *mockStore) GetJob(_ context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || job.TenantID != tenantID {
+		return nil, store.ErrNotFound
+	}
+	cp := *job
+	for _, u := range s.statusUpdates {
+		if u.ID == id {
+			cp.Status = u.Status
+		}
+	}
+	return &cp, nil
+}
+
+func (s *mockStore) JobExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
 
 func (s *mockStore) CreateJob(_ context.Context, job *models.Job) error {
 	if s.createJobErr != nil {
@@ -89,18 +118,79 @@ func (s *mockStore) CreateAnalysisResult(_ context.Context, result *models.Analy
 	s.results = append(s.results, result)
 	return nil
 }
+func (s *mockStore) JobStats(_ context.Context, _ uuid.UUID, _ time.Time) (store.JobStats, error) {
+	return store.JobStats{}, nil
+}
+func (s *mockStore) GetTenantByAPIKeyPrefix(_ context.Context, _ string) (*models.Tenant, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
+func (s *mockStore) SaveAnalysisContext(_ context.Context, ac *models.AnalysisContext) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contexts = append(s.contexts, ac)
+	return nil
+}
+func (s *mockStore) GetAnalysisContext(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisContext, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) ListAPIKeysPaged(_ context.Context, _ uuid.UUID, _, _ int, _ ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
+	return nil, 0, nil
+}
+func (s *mockStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, _ string) (*models.ErrorCluster, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) GetJobsByIDs(_ context.Context, _ uuid.UUID, _ []uuid.UUID) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+func (s *mockStore) CountErrorClusters(_ context.Context, _ store.ClusterFilter) (int, error) { return 0, nil }
+func (s *mockStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error { return nil }
+func (s *mockStore) SetClusterPinned(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ bool) error { return nil }
+func (s *mockStore) RevokeAllAPIKeys(_ context.Context, _ uuid.UUID, _ ...uuid.UUID) (int, error) { return 0, nil }
+func (s *mockStore) ErrorClusterExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+func (s *mockStore) GetJobByID(_ context.Context, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (s *mockStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) { return &store.MigrationStatus{}, nil }
+func (s *mockStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return 0, nil
+}
+func (s *mockStore) GetAnalysisResultByID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) ListJobs(_ context.Context, _ uuid.UUID, _ store.JobFilter) ([]*models.Job, int, error) { return nil, 0, nil }
+func (s *mockStore) CreateClusterSuppression(_ context.Context, _ *models.ClusterSuppression) error { return nil }
+func (s *mockStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) { return nil, nil }
+func (s *mockStore) DeleteClusterSuppression(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *mockStore) DeleteErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *mockStore) GetTenant(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id}, nil
+}
 
 type mockCache struct {
 	mu       sync.Mutex
 	statuses map[string]string
+	data     map[string][]byte
 }
 
 func newMockCache() *mockCache {
-	return &mockCache{statuses: make(map[string]string)}
+	return &mockCache{statuses: make(map[string]string), data: make(map[string][]byte)}
+}
+
+func (c *mockCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *mockCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.data[key]
+	return v, ok, nil
 }
 
-func (c *mockCache) Set(_ context.Context, _ string, _ []byte, _ time.Duration) error { return nil }
-func (c *mockCache) Get(_ context.Context, _ string) ([]byte, bool, error) { return nil, false, nil }
 func (c *mockCache) Delete(_ context.Context, _ string) error { return nil }
 func (c *mockCache) Ping(_ context.Context) error { return nil }
 func (c *mockCache) IncrWithExpiry(_ context.Context, _ string, _ time.Duration) (int64, error) { return 0, nil }
@@ -119,14 +209,63 @@ func (c *mockCache) GetJobStatus(_ context.Context, jobID uuid.UUID) (string, bo
 	return s, ok, nil
 }
 
+// lockingCache is a mockCache that can also take locks, like a shared Redis.
+type lockingCache struct {
+	*mockCache
+	held    map[string]bool
+	lockErr error
+}
+
+func newLockingCache() *lockingCache {
+	return &lockingCache{mockCache: newMockCache(), held: make(map[string]bool)}
+}
+
+func (c *lockingCache) TryLock(_ context.Context, key string, _ time.Duration) (bool, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lockErr != nil {
+		return false, nil, c.lockErr
+	}
+	if c.held[key] {
+		return false, nil, nil
+	}
+	c.held[key] = true
+	return true, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.held, key)
+	}, nil
+}
+
+func (c *lockingCache) isHeld(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.held[key]
+}
+
 type mockLoki struct {
-	lines []models.LogLine
-	err   error
+	lines     []models.LogLine
+	err       error
+	truncated bool
+	lastReq   loki.QueryRangeRequest
+	calls     int
 }
 
-func (l *mockLoki) QueryRange(_ context.Context, _ loki.QueryRangeRequest) ([]models.LogLine, error) {
+func (l *mockLoki) QueryRange(_ context.Context, req loki.QueryRangeRequest) ([]models.LogLine, error) {
+	l.calls++
+	l.lastReq = req
 	return l.lines, l.err
 }
+func (l *mockLoki) QueryRangeDetailed(ctx context.Context, req loki.QueryRangeRequest) (*loki.QueryRangeResponse, error) {
+	lines, err := l.QueryRange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &loki.QueryRangeResponse{Lines: lines, Truncated: l.truncated}, nil
+}
+func (l *mockLoki) Query(_ context.Context, _ loki.QueryRequest) (loki.QueryResult, error) {
+	return loki.QueryResult{}, nil
+}
 func (l *mockLoki) Labels(_ context.Context) ([]string, error)                { return nil, nil }
 func (l *mockLoki) LabelValues(_ context.Context, _ string) ([]string, error) { return nil, nil }
 func (l *mockLoki) Ready(_ context.Context) error                             { return nil }
@@ -144,13 +283,23 @@ func (p *mockProvider) Analyze(ctx context.Context, req models.AnalysisRequest)
 	}
 	return models.AnalysisResult{}, nil
 }
-func (p *mockProvider) Summarize(ctx context.Context, logs []models.LogLine) (string, error) {
+// Summarize reports req.Model, or mockDefaultModel without an override, as the
+// model used.
+func (p *mockProvider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
+	summary := models.Summary{Model: cmp.Or(req.Model, mockDefaultModel)}
 	if p.summarizeFunc != nil {
-		return p.summarizeFunc(ctx, logs)
+		text, err := p.summarizeFunc(ctx, req.Logs)
+		if err != nil {
+			return models.Summary{}, err
+		}
+		summary.Text = text
 	}
-	return "", nil
+	return summary, nil
 }
 
+// mockDefaultModel is the model mockProvider reports when none is requested.
+const mockDefaultModel = "mock-default"
+
 // --- helpers ---
 
 func testCluster() *models.ErrorCluster {
@@ -185,6 +334,19 @@ func waitForGoroutine(t *testing.T, s *mockStore, expectedUpdates int) {
 	}
 }
 
+// statusesFor returns the statuses recorded for a job, in order.
+func (s *mockStore) statusesFor(id uuid.UUID) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []string
+	for _, u := range s.statusUpdates {
+		if u.ID == id {
+			statuses = append(statuses, u.Status)
+		}
+	}
+	return statuses
+}
+
 // --- TriggerAnalysis tests ---
 
 func TestTriggerAnalysis_ReturnsJobImmediately(t *testing.T) {
@@ -238,6 +400,170 @@ func TestTriggerAnalysis_ReturnsJobImmediately(t *testing.T) {
 	}
 }
 
+func TestReplayAnalysis_LinksOriginalJob(t *testing.T) {
+	st := newMockStore()
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5, Summary: "s"}, nil
+		},
+	}
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}},
+	}
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 30*time.Second)
+
+	originalID := uuid.New()
+	job, err := svc.ReplayAnalysis(context.Background(), testCluster(), originalID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForGoroutine(t, st, 2)
+
+	st.mu.Lock()
+	stored := st.jobs[job.ID]
+	st.mu.Unlock()
+	if stored == nil || stored.ReplayedFrom == nil || *stored.ReplayedFrom != originalID {
+		t.Fatalf("expected stored job replayed from %s, got %+v", originalID, stored)
+	}
+	if job.ID == originalID {
+		t.Error("expected a new job ID")
+	}
+}
+
+func TestAnalyzeWithLogs_SkipsLoki(t *testing.T) {
+	st := newMockStore()
+	seen := make(chan []models.LogLine, 1)
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, req models.AnalysisRequest) (models.AnalysisResult, error) {
+			seen <- req.ContextLogs
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5, Summary: "s"}, nil
+		},
+	}
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "from loki", Level: "error"}},
+	}
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 30*time.Second)
+
+	provided := []models.LogLine{
+		{Timestamp: time.Now().Add(-time.Second), Message: "ci step failed", Level: "error"},
+		{Timestamp: time.Now(), Message: "exit code 1", Level: "error"},
+	}
+	job, err := svc.AnalyzeWithLogs(context.Background(), testCluster(), provided)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Status != models.JobStatusPending {
+		t.Errorf("expected status pending, got %s", job.Status)
+	}
+
+	select {
+	case got := <-seen:
+		if len(got) != 2 || got[0].Message != "ci step failed" || got[1].Message != "exit code 1" {
+			t.Errorf("expected provided logs as context, got %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for analysis")
+	}
+	if lokiClient.calls != 0 {
+		t.Errorf("expected Loki not to be queried, got %d calls", lokiClient.calls)
+	}
+}
+
+func TestAnalyzeWithLogs_RequiresLogs(t *testing.T) {
+	st := newMockStore()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, newMockCache(), 30*time.Second)
+
+	if _, err := svc.AnalyzeWithLogs(context.Background(), testCluster(), nil); err == nil {
+		t.Fatal("expected error for missing logs")
+	}
+	if len(st.jobs) != 0 {
+		t.Errorf("expected no job to be created, got %d", len(st.jobs))
+	}
+}
+
+func TestTriggerAnalysis_ModelOverride(t *testing.T) {
+	st := newMockStore()
+	seen := make(chan string, 1)
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, req models.AnalysisRequest) (models.AnalysisResult, error) {
+			seen <- req.Model
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5, Summary: "s"}, nil
+		},
+	}
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}},
+	}
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 30*time.Second,
+		WithAllowedModels([]string{"strong-model"}))
+
+	if _, err := svc.TriggerAnalysis(WithModel(context.Background(), "strong-model"), testCluster()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case got := <-seen:
+		if got != "strong-model" {
+			t.Errorf("expected provider to see model strong-model, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for analysis")
+	}
+}
+
+func TestTriggerAnalysis_RecordsJobMetadata(t *testing.T) {
+	st := newMockStore()
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}},
+	}
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5, Summary: "s"}, nil
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 30*time.Second,
+		WithAllowedModels([]string{"strong-model"}))
+
+	cluster := testCluster()
+	job, err := svc.TriggerAnalysis(WithModel(context.Background(), "strong-model"), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Type != models.JobTypeAnalysis {
+		t.Errorf("expected job type %q, got %q", models.JobTypeAnalysis, job.Type)
+	}
+	if got := job.Metadata[models.JobMetaFingerprint]; got != cluster.Fingerprint {
+		t.Errorf("expected fingerprint %q in metadata, got %q", cluster.Fingerprint, got)
+	}
+	if got := job.Metadata[models.JobMetaModel]; got != "strong-model" {
+		t.Errorf("expected model strong-model in metadata, got %q", got)
+	}
+
+	job, err = svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := job.Metadata[models.JobMetaModel]; ok {
+		t.Errorf("expected no model in metadata without an override, got %v", job.Metadata)
+	}
+}
+
+func TestTriggerAnalysis_ModelNotAllowed(t *testing.T) {
+	st := newMockStore()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, newMockCache(), 30*time.Second,
+		WithAllowedModels([]string{"strong-model"}))
+
+	_, err := svc.TriggerAnalysis(WithModel(context.Background(), "other-model"), testCluster())
+	if !errors.Is(err, ErrModelNotAllowed) {
+		t.Fatalf("expected ErrModelNotAllowed, got %v", err)
+	}
+	if len(st.jobs) != 0 {
+		t.Errorf("expected no job to be created, got %d", len(st.jobs))
+	}
+}
+
 func TestTriggerAnalysis_InvalidCluster(t *testing.T) {
 	svc := NewAnalysisService(
 		&mockProvider{name: "mock"},
@@ -255,6 +581,186 @@ func TestTriggerAnalysis_InvalidCluster(t *testing.T) {
 	}
 }
 
+func TestTriggerAnalysis_ReturnsInFlightJob(t *testing.T) {
+	st := newMockStore()
+	ca := newLockingCache()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			calls.Add(1)
+			<-release
+			return models.AnalysisResult{RootCause: "cause", Confidence: 0.9}, nil
+		},
+	}
+	lokiClient := &mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}}}
+	svc := NewAnalysisService(provider, lokiClient, st, ca, 30*time.Second)
+
+	cluster := testCluster()
+	first, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("expected the in-flight job %s, got a new job %s", first.ID, second.ID)
+	}
+	st.mu.Lock()
+	jobs := len(st.jobs)
+	st.mu.Unlock()
+	if jobs != 1 {
+		t.Errorf("expected 1 job created, got %d", jobs)
+	}
+
+	close(release)
+	waitForGoroutine(t, st, 2)
+	lockKey := cache.AnalysisLockKey(cluster.TenantID, cluster.ID)
+	deadline := time.Now().Add(5 * time.Second)
+	for ca.isHeld(lockKey) {
+		if time.Now().After(deadline) {
+			t.Fatal("analysis lock was not released after the job finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 provider call, got %d", n)
+	}
+
+	// Once the job has finished, a new trigger starts a new job.
+	third, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if third.ID == first.ID {
+		t.Error("expected a new job after the first one finished")
+	}
+	waitForGoroutine(t, st, 4)
+}
+
+func TestTriggerAnalysis_LockHeldWithoutJob(t *testing.T) {
+	st := newMockStore()
+	ca := newLockingCache()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, ca, 30*time.Second)
+
+	cluster := testCluster()
+	// Another replica holds the lock but has not recorded its job yet.
+	ca.held[cache.AnalysisLockKey(cluster.TenantID, cluster.ID)] = true
+
+	_, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if !errors.Is(err, ErrAnalysisInProgress) {
+		t.Fatalf("expected ErrAnalysisInProgress, got %v", err)
+	}
+	if len(st.jobs) != 0 {
+		t.Errorf("expected no job created, got %d", len(st.jobs))
+	}
+}
+
+func TestTriggerAnalysis_LockLoserWaitsForJob(t *testing.T) {
+	st := newMockStore()
+	ca := newLockingCache()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, ca, 30*time.Second)
+
+	cluster := testCluster()
+	// Another replica has taken the lock and records its job moments later.
+	ca.held[cache.AnalysisLockKey(cluster.TenantID, cluster.ID)] = true
+	pending := &models.Job{ID: uuid.New(), TenantID: cluster.TenantID, Status: models.JobStatusPending}
+	st.jobs[pending.ID] = pending
+	go func() {
+		time.Sleep(inFlightLookupBackoff / 2)
+		_ = ca.Set(context.Background(), cache.AnalysisInFlightKey(cluster.TenantID, cluster.ID),
+			[]byte(pending.ID.String()), time.Minute)
+	}()
+
+	job, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("expected the in-flight job once recorded, got %v", err)
+	}
+	if job.ID != pending.ID {
+		t.Errorf("expected job %s, got %s", pending.ID, job.ID)
+	}
+}
+
+func TestAnalysisEntryPoints_ReturnInFlightJob(t *testing.T) {
+	st := newMockStore()
+	ca := newLockingCache()
+	release := make(chan struct{})
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			<-release
+			return models.AnalysisResult{RootCause: "cause", Confidence: 0.9}, nil
+		},
+	}
+	lokiClient := &mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}}}
+	svc := NewAnalysisService(provider, lokiClient, st, ca, 30*time.Second)
+
+	cluster := testCluster()
+	first, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	withLogs, err := svc.AnalyzeWithLogs(context.Background(), cluster, lokiClient.lines)
+	if err != nil {
+		t.Fatalf("AnalyzeWithLogs: unexpected error: %v", err)
+	}
+	replay, err := svc.ReplayAnalysis(context.Background(), cluster, first.ID)
+	if err != nil {
+		t.Fatalf("ReplayAnalysis: unexpected error: %v", err)
+	}
+	if withLogs.ID != first.ID || replay.ID != first.ID {
+		t.Errorf("expected the in-flight job %s, got %s and %s", first.ID, withLogs.ID, replay.ID)
+	}
+	if _, err := svc.AnalyzeSync(context.Background(), cluster); !errors.Is(err, ErrAnalysisInProgress) {
+		t.Errorf("AnalyzeSync: expected ErrAnalysisInProgress, got %v", err)
+	}
+	st.mu.Lock()
+	jobs := len(st.jobs)
+	st.mu.Unlock()
+	if jobs != 1 {
+		t.Errorf("expected 1 job created, got %d", jobs)
+	}
+
+	close(release)
+	waitForGoroutine(t, st, 2)
+}
+
+func TestTriggerAnalysis_LockErrorDoesNotBlock(t *testing.T) {
+	st := newMockStore()
+	ca := newLockingCache()
+	ca.lockErr = errors.New("redis down")
+	lokiClient := &mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}}}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, st, ca, 30*time.Second)
+
+	job, err := svc.TriggerAnalysis(context.Background(), testCluster())
+	if err != nil {
+		t.Fatalf("expected the analysis to go ahead unlocked, got %v", err)
+	}
+	if job == nil {
+		t.Fatal("expected job, got nil")
+	}
+	waitForGoroutine(t, st, 2)
+}
+
+func TestTriggerAnalysis_CreateFailureReleasesLock(t *testing.T) {
+	st := newMockStore()
+	ca := newLockingCache()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, ca, 30*time.Second,
+		WithAllowedModels([]string{"allowed"}))
+
+	cluster := testCluster()
+	if _, err := svc.TriggerAnalysis(WithModel(context.Background(), "other"), cluster); !errors.Is(err, ErrModelNotAllowed) {
+		t.Fatalf("expected ErrModelNotAllowed, got %v", err)
+	}
+	if ca.isHeld(cache.AnalysisLockKey(cluster.TenantID, cluster.ID)) {
+		t.Error("expected the lock to be released when the job could not be created")
+	}
+}
+
 func TestRunAnalysis_StoresResultOnSuccess(t *testing.T) {
 	st := newMockStore()
 	ca := newMockCache()
@@ -367,23 +873,360 @@ func TestRunAnalysis_MarksJobFailedOnProviderError(t *testing.T) {
 	}
 }
 
-func TestRunAnalysis_ClampsConfidence(t *testing.T) {
+// --- AnalyzeSync tests ---
+
+func TestAnalyzeSync_Success(t *testing.T) {
 	st := newMockStore()
+	ca := newMockCache()
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}},
+	}
 	provider := &mockProvider{
 		name: "mock",
 		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
-			return models.AnalysisResult{
-				Confidence: 1.5, // > 1.0, should be clamped
-				RootCause:  "cause",
-				Summary:    "summary",
-			}, nil
+			return models.AnalysisResult{RootCause: "db down", Confidence: 1.7, Summary: "s", Model: "m"}, nil
 		},
 	}
-
-	svc := NewAnalysisService(provider,
-		&mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "err", Level: "error", Labels: map[string]string{}}}},
-		st, newMockCache(), 30*time.Second)
-
+	svc := NewAnalysisService(provider, lokiClient, st, ca, 30*time.Second)
+	cluster := testCluster()
+
+	result, err := svc.AnalyzeSync(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RootCause != "db down" || result.Confidence != 1.0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.ClusterID != cluster.ID || result.Provider != "mock" {
+		t.Errorf("expected result linked to cluster and provider, got %+v", result)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if len(st.jobs) != 1 || len(st.results) != 1 {
+		t.Fatalf("expected 1 job and 1 result persisted, got %d and %d", len(st.jobs), len(st.results))
+	}
+	if st.results[0].JobID != result.JobID {
+		t.Error("expected persisted result to match returned result")
+	}
+	if n := len(st.statusUpdates); n != 2 || st.statusUpdates[n-1].Status != models.JobStatusCompleted {
+		t.Errorf("expected running then completed, got %+v", st.statusUpdates)
+	}
+	if status, _, _ := ca.GetJobStatus(context.Background(), result.JobID); status != models.JobStatusCompleted {
+		t.Errorf("expected cached status completed, got %s", status)
+	}
+}
+
+func TestAnalyzeSync_ProviderError(t *testing.T) {
+	st := newMockStore()
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}},
+	}
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{}, ErrInferenceTimeout
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 30*time.Second)
+
+	result, err := svc.AnalyzeSync(context.Background(), testCluster())
+	if !errors.Is(err, ErrInferenceTimeout) {
+		t.Fatalf("expected ErrInferenceTimeout, got %v", err)
+	}
+	if result != nil {
+		t.Error("expected nil result on error")
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if len(st.results) != 0 {
+		t.Errorf("expected no results stored, got %d", len(st.results))
+	}
+	if last := st.statusUpdates[len(st.statusUpdates)-1]; last.Status != models.JobStatusFailed {
+		t.Errorf("expected job marked failed, got %s", last.Status)
+	}
+}
+
+func TestAnalyzeSync_NoContextLogs(t *testing.T) {
+	// With no surrounding logs the job fails as NO_LOGS rather than asking the
+	// provider to guess from the sample message alone.
+	called := false
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			called = true
+			return models.AnalysisResult{RootCause: "from sample", Confidence: 0.4}, nil
+		},
+	}
+	st := newMockStore()
+	svc := NewAnalysisService(provider, &mockLoki{lines: []models.LogLine{}}, st, newMockCache(), 30*time.Second)
+
+	_, err := svc.AnalyzeSync(context.Background(), testCluster())
+	if !errors.Is(err, ErrNoLogsFound) {
+		t.Fatalf("expected ErrNoLogsFound, got %v", err)
+	}
+	if called {
+		t.Error("provider must not be called without context logs")
+	}
+	if len(st.results) != 0 {
+		t.Errorf("expected no stored result, got %d", len(st.results))
+	}
+}
+
+func TestAnalyzeSync_NoContextLogsFailsJob(t *testing.T) {
+	logger, records := captureLogs(t)
+	st := newMockStore()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{lines: []models.LogLine{}}, st, newMockCache(),
+		30*time.Second, WithLogger(logger))
+
+	if _, err := svc.AnalyzeSync(context.Background(), testCluster()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if n := len(st.statusUpdates); n == 0 || st.statusUpdates[n-1].Status != models.JobStatusFailed {
+		t.Fatalf("expected job to end failed, got %+v", st.statusUpdates)
+	}
+	failed := findLog(records(), "analysis failed")
+	if failed == nil || failed["error_code"] != models.JobErrorNoLogs {
+		t.Errorf("expected error_code %s, got %v", models.JobErrorNoLogs, failed)
+	}
+}
+
+func TestAnalyzeSync_SamplesContextLogs(t *testing.T) {
+	var gotLogs []models.LogLine
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, req models.AnalysisRequest) (models.AnalysisResult, error) {
+			gotLogs = req.ContextLogs
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5}, nil
+		},
+	}
+	svc := NewAnalysisService(provider, &mockLoki{lines: contextLines("error", "info", "fatal", "info")},
+		newMockStore(), newMockCache(), 30*time.Second,
+		WithContextSampling(ContextStrategyErrorsFirst, 2, testSeverity))
+
+	if _, err := svc.AnalyzeSync(context.Background(), testCluster()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "line 0,line 2"; messages(gotLogs) != want {
+		t.Errorf("expected provider to see %q, got %q", want, messages(gotLogs))
+	}
+}
+
+func TestAnalyzeSync_SavesContextSample(t *testing.T) {
+	base := time.Now()
+	lines := make([]models.LogLine, 130)
+	for i := range lines {
+		lines[i] = models.LogLine{Timestamp: base.Add(time.Duration(i) * time.Second), Message: fmt.Sprintf("line %d", i)}
+	}
+	st := newMockStore()
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5}, nil
+		},
+	}
+	svc := NewAnalysisService(provider, &mockLoki{lines: lines}, st, newMockCache(), 30*time.Second)
+	cluster := testCluster()
+
+	result, err := svc.AnalyzeSync(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if len(st.contexts) != 1 {
+		t.Fatalf("expected 1 saved context, got %d", len(st.contexts))
+	}
+	ac := st.contexts[0]
+	if ac.JobID != result.JobID || ac.TenantID != cluster.TenantID {
+		t.Errorf("expected context scoped to job %s and tenant %s, got %+v", result.JobID, cluster.TenantID, ac)
+	}
+	if ac.TotalLines != 130 {
+		t.Errorf("expected total_lines 130, got %d", ac.TotalLines)
+	}
+	if len(ac.Lines) != 2*contextSampleLines {
+		t.Fatalf("expected %d sampled lines, got %d", 2*contextSampleLines, len(ac.Lines))
+	}
+	if ac.Lines[0].Message != "line 0" || ac.Lines[len(ac.Lines)-1].Message != "line 129" {
+		t.Errorf("expected sample to keep the first and last lines, got %q ... %q",
+			ac.Lines[0].Message, ac.Lines[len(ac.Lines)-1].Message)
+	}
+	if ac.Lines[contextSampleLines].Message != "line 80" {
+		t.Errorf("expected tail to start at line 80, got %q", ac.Lines[contextSampleLines].Message)
+	}
+}
+
+func TestAnalyzeSync_ContextWindowAndDirection(t *testing.T) {
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5}, nil
+		},
+	}
+	cluster := testCluster()
+
+	tests := []struct {
+		name string
+		opts []ServiceOption
+		want string
+	}{
+		{"default leads up to the error", nil, "forward"},
+		{"configured backward", []ServiceOption{WithContextDirection("backward")}, "backward"},
+		{"empty keeps default", []ServiceOption{WithContextDirection("")}, "forward"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lokiClient := &mockLoki{lines: contextLines("error")}
+			svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second, tt.opts...)
+
+			if _, err := svc.AnalyzeSync(context.Background(), cluster); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req := lokiClient.lastReq
+			if req.Direction != tt.want {
+				t.Errorf("expected direction %q, got %q", tt.want, req.Direction)
+			}
+			if !req.Start.Equal(cluster.FirstSeenAt.Add(-5*time.Minute)) || !req.End.Equal(cluster.LastSeenAt.Add(5*time.Minute)) {
+				t.Errorf("unexpected window %s - %s", req.Start, req.End)
+			}
+			if req.Limit != contextLogLimit {
+				t.Errorf("expected limit %d, got %d", contextLogLimit, req.Limit)
+			}
+		})
+	}
+}
+
+// captureLogs returns a JSON logger writing to a buffer and a function that
+// decodes the records written so far.
+func captureLogs(t *testing.T) (*slog.Logger, func() []map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	return logger, func() []map[string]any {
+		var records []map[string]any
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var rec map[string]any
+			if err := dec.Decode(&rec); err != nil {
+				t.Fatalf("decode log record: %v", err)
+			}
+			records = append(records, rec)
+		}
+		return records
+	}
+}
+
+func findLog(records []map[string]any, msg string) map[string]any {
+	for _, rec := range records {
+		if rec["msg"] == msg {
+			return rec
+		}
+	}
+	return nil
+}
+
+func TestAnalyzeSync_LogsLifecycleOnSuccess(t *testing.T) {
+	logger, records := captureLogs(t)
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{RootCause: "rc", Confidence: 0.5, Model: "m1"}, nil
+		},
+	}
+	lokiClient := &mockLoki{lines: []models.LogLine{{Message: "a"}, {Message: "b"}, {Message: "c"}}}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second, WithLogger(logger))
+	cluster := testCluster()
+
+	result, err := svc.AnalyzeSync(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recs := records()
+	for _, msg := range []string{"analysis started", "analysis context fetched", "analysis inference finished", "analysis completed"} {
+		rec := findLog(recs, msg)
+		if rec == nil {
+			t.Fatalf("missing %q log record in %v", msg, recs)
+		}
+		if rec["job_id"] != result.JobID.String() || rec["cluster_id"] != cluster.ID.String() ||
+			rec["tenant_id"] != cluster.TenantID.String() || rec["provider"] != "mock" {
+			t.Errorf("%q: missing job context fields: %v", msg, rec)
+		}
+	}
+	if rec := findLog(recs, "analysis context fetched"); rec["lines_fetched"] != float64(3) {
+		t.Errorf("expected lines_fetched 3, got %v", rec["lines_fetched"])
+	}
+	done := findLog(recs, "analysis completed")
+	if done["status"] != models.JobStatusCompleted {
+		t.Errorf("expected status completed, got %v", done["status"])
+	}
+	if _, ok := done["duration_ms"].(float64); !ok {
+		t.Errorf("expected numeric duration_ms, got %v", done["duration_ms"])
+	}
+}
+
+func TestAnalyzeSync_LogsLifecycleOnFailure(t *testing.T) {
+	logger, records := captureLogs(t)
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{}, ErrInferenceTimeout
+		},
+	}
+	svc := NewAnalysisService(provider, &mockLoki{lines: contextLines("error")}, newMockStore(), newMockCache(),
+		30*time.Second, WithLogger(logger))
+	cluster := testCluster()
+
+	if _, err := svc.AnalyzeSync(context.Background(), cluster); err == nil {
+		t.Fatal("expected error")
+	}
+
+	recs := records()
+	failed := findLog(recs, "analysis failed")
+	if failed == nil {
+		t.Fatalf("missing failure log record in %v", recs)
+	}
+	if failed["status"] != models.JobStatusFailed || failed["error_code"] != models.JobErrorAITimeout {
+		t.Errorf("unexpected failure fields: %v", failed)
+	}
+	if failed["cluster_id"] != cluster.ID.String() || failed["job_id"] == nil {
+		t.Errorf("expected job context on failure record: %v", failed)
+	}
+	if findLog(recs, "analysis completed") != nil {
+		t.Error("did not expect a completed record on failure")
+	}
+}
+
+func TestAnalyzeSync_LokiError(t *testing.T) {
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{err: loki.ErrLokiUnreachable},
+		newMockStore(), newMockCache(), 30*time.Second)
+
+	_, err := svc.AnalyzeSync(context.Background(), testCluster())
+	if !errors.Is(err, loki.ErrLokiUnreachable) {
+		t.Fatalf("expected ErrLokiUnreachable, got %v", err)
+	}
+}
+
+func TestRunAnalysis_ClampsConfidence(t *testing.T) {
+	st := newMockStore()
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			return models.AnalysisResult{
+				Confidence: 1.5, // > 1.0, should be clamped
+				RootCause:  "cause",
+				Summary:    "summary",
+			}, nil
+		},
+	}
+
+	svc := NewAnalysisService(provider,
+		&mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "err", Level: "error", Labels: map[string]string{}}}},
+		st, newMockCache(), 30*time.Second)
+
 	cluster := testCluster()
 	svc.TriggerAnalysis(context.Background(), cluster)
 	waitForGoroutine(t, st, 2)
@@ -396,6 +1239,53 @@ func TestRunAnalysis_ClampsConfidence(t *testing.T) {
 	if st.results[0].Confidence != 1.0 {
 		t.Errorf("expected confidence clamped to 1.0, got %f", st.results[0].Confidence)
 	}
+	if !st.results[0].ConfidenceClamped {
+		t.Error("expected ConfidenceClamped to be set")
+	}
+}
+
+func TestRunAnalysis_ConfidenceClampedFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		confidence  float64
+		want        float64
+		wantClamped bool
+	}{
+		{"negative", -0.2, 0, true},
+		{"lower bound", 0, 0, false},
+		{"in range", 0.7, 0.7, false},
+		{"upper bound", 1.0, 1.0, false},
+		{"above one", 1.5, 1.0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newMockStore()
+			provider := &mockProvider{
+				name: "mock",
+				analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+					return models.AnalysisResult{Confidence: tt.confidence, RootCause: "cause", Summary: "summary"}, nil
+				},
+			}
+			svc := NewAnalysisService(provider,
+				&mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "err", Level: "error", Labels: map[string]string{}}}},
+				st, newMockCache(), 30*time.Second)
+
+			svc.TriggerAnalysis(context.Background(), testCluster())
+			waitForGoroutine(t, st, 2)
+
+			st.mu.Lock()
+			defer st.mu.Unlock()
+			if len(st.results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(st.results))
+			}
+			if st.results[0].Confidence != tt.want {
+				t.Errorf("expected confidence %f, got %f", tt.want, st.results[0].Confidence)
+			}
+			if st.results[0].ConfidenceClamped != tt.wantClamped {
+				t.Errorf("expected ConfidenceClamped %v, got %v", tt.wantClamped, st.results[0].ConfidenceClamped)
+			}
+		})
+	}
 }
 
 func TestRunAnalysis_DoesNotPanic(t *testing.T) {
@@ -472,79 +1362,655 @@ func TestSummarize_Success(t *testing.T) {
 	}
 }
 
-func TestSummarize_NoLogsFound(t *testing.T) {
+func TestSummarize_CachesElapsedWindow(t *testing.T) {
 	lokiClient := &mockLoki{
-		lines: []models.LogLine{}, // empty
+		lines: []models.LogLine{{Timestamp: time.Now().Add(-2 * time.Hour), Message: "log line", Level: "error"}},
+	}
+	calls := 0
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			calls++
+			return "cached summary", nil
+		},
 	}
-	provider := &mockProvider{name: "mock"}
-
 	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
 
-	_, err := svc.Summarize(context.Background(), SummarizeParams{
-		TenantID:  uuid.New(),
-		Service:   "api",
-		Namespace: "prod",
-		Start:     time.Now().Add(-1 * time.Hour),
-		End:       time.Now(),
-		MaxLines:  500,
-	})
-	if err == nil {
-		t.Fatal("expected error for empty logs")
+	end := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	params := SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: end.Add(-1 * time.Hour), End: end, MaxLines: 500,
 	}
-	if !errors.Is(err, ErrNoLogsFound) {
-		t.Errorf("expected ErrNoLogsFound, got: %v", err)
+
+	first, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.CacheHit {
+		t.Error("expected first call to miss the cache")
+	}
+
+	second, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.CacheHit {
+		t.Error("expected second call to hit the cache")
+	}
+	if second.Summary != "cached summary" || second.LinesAnalyzed != 1 {
+		t.Errorf("unexpected cached result: %+v", second)
+	}
+	if calls != 1 {
+		t.Errorf("expected provider to be called once, got %d", calls)
+	}
+
+	// A different window is a different key.
+	params.MaxLines = 100
+	third, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if third.CacheHit || calls != 2 {
+		t.Errorf("expected a miss for different params, hit=%v calls=%d", third.CacheHit, calls)
 	}
 }
 
-func TestSummarize_ProviderError(t *testing.T) {
+func TestSummarize_SkipsCacheForLiveWindow(t *testing.T) {
 	lokiClient := &mockLoki{
-		lines: []models.LogLine{
-			{Timestamp: time.Now(), Message: "log", Level: "error"},
-		},
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
 	}
+	calls := 0
 	provider := &mockProvider{
 		name: "mock",
 		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
-			return "", ErrProviderUnavailable
+			calls++
+			return "live summary", nil
 		},
 	}
+	ca := newMockCache()
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), ca, 30*time.Second)
 
-	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
-
-	_, err := svc.Summarize(context.Background(), SummarizeParams{
-		TenantID: uuid.New(),
-		Service:  "api",
-		Start:    time.Now().Add(-1 * time.Hour),
-		End:      time.Now(),
-		MaxLines: 500,
-	})
-	if !errors.Is(err, ErrProviderUnavailable) {
-		t.Errorf("expected ErrProviderUnavailable, got: %v", err)
+	now := time.Now()
+	params := SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500,
+	}
+	for i := 0; i < 2; i++ {
+		result, err := svc.Summarize(context.Background(), params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.CacheHit {
+			t.Error("expected live window never to hit the cache")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected provider to be called twice, got %d", calls)
+	}
+	// Only the fetched lines are cached; the summary itself is not.
+	for key := range ca.data {
+		if strings.HasPrefix(key, "ai:summarize:") {
+			t.Errorf("expected no summary cached for a live window, got %s", key)
+		}
 	}
 }
 
-func TestSummarize_TruncatesLongLines(t *testing.T) {
-	longMsg := ""
-	for i := 0; i < 1000; i++ {
-		longMsg += "x"
-	}
-	var capturedLogs []models.LogLine
+func TestSummarize_CachesLokiQuery(t *testing.T) {
 	lokiClient := &mockLoki{
-		lines: []models.LogLine{
-			{Timestamp: time.Now(), Message: longMsg, Level: "error"},
-		},
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
 	}
-	provider := &mockProvider{
-		name: "mock",
-		summarizeFunc: func(_ context.Context, logs []models.LogLine) (string, error) {
-			capturedLogs = logs
-			return "summary", nil
-		},
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	// A live window is never served from the summary cache, so every call
+	// reaches the Loki query cache.
+	now := time.Now()
+	params := SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500,
 	}
 
-	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+	first, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.LogsCacheHit {
+		t.Error("expected first call to fetch from Loki")
+	}
 
-	svc.Summarize(context.Background(), SummarizeParams{
+	second, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.LogsCacheHit || second.LinesAnalyzed != 1 {
+		t.Errorf("expected second call to be served from the Loki query cache, got %+v", second)
+	}
+	if lokiClient.calls != 1 {
+		t.Errorf("expected Loki to be queried once, got %d", lokiClient.calls)
+	}
+
+	// A different limit is a different query.
+	params.MaxLines = 100
+	if third, err := svc.Summarize(context.Background(), params); err != nil || third.LogsCacheHit {
+		t.Errorf("expected a miss for a different limit, got %+v, %v", third, err)
+	}
+	if lokiClient.calls != 2 {
+		t.Errorf("expected Loki to be queried twice, got %d", lokiClient.calls)
+	}
+}
+
+func TestSummarize_LookbackCallsShareLokiQuery(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
+	}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	// Two "lookback 1h" requests a few milliseconds apart within one second.
+	tenantID := uuid.New()
+	base := time.Now().Truncate(time.Second)
+	for i, end := range []time.Time{base.Add(2 * time.Millisecond), base.Add(7 * time.Millisecond)} {
+		params := SummarizeParams{
+			TenantID: tenantID, Service: "api", Namespace: "prod",
+			Start: end.Add(-1 * time.Hour), End: end, MaxLines: 500,
+		}
+		result, err := svc.Summarize(context.Background(), params)
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		if result.LogsCacheHit != (i == 1) {
+			t.Errorf("call %d: expected LogsCacheHit %v, got %v", i, i == 1, result.LogsCacheHit)
+		}
+	}
+	if lokiClient.calls != 1 {
+		t.Errorf("expected Loki to be queried once, got %d", lokiClient.calls)
+	}
+}
+
+func TestSummarize_ReportsTruncation(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines:     []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
+		truncated: true,
+	}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	now := time.Now()
+	params := SummarizeParams{TenantID: uuid.New(), Service: "api", Start: now.Add(-time.Hour), End: now, MaxLines: 500}
+	for i := 0; i < 2; i++ {
+		result, err := svc.Summarize(context.Background(), params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The second call is served from the Loki query cache, which keeps the flag.
+		if !result.Truncated {
+			t.Errorf("call %d: expected the summary to be marked truncated", i)
+		}
+	}
+	if lokiClient.calls != 1 {
+		t.Errorf("expected Loki to be queried once, got %d", lokiClient.calls)
+	}
+}
+
+func TestSummarize_NoCacheFetchesFresh(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now().Add(-2 * time.Hour), Message: "log line", Level: "error"}},
+	}
+	calls := 0
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			calls++
+			return "summary", nil
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	end := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	params := SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: end.Add(-1 * time.Hour), End: end, MaxLines: 500,
+	}
+	if _, err := svc.Summarize(context.Background(), params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params.NoCache = true
+	result, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CacheHit || result.LogsCacheHit {
+		t.Errorf("expected NoCache to skip both caches, got %+v", result)
+	}
+	if lokiClient.calls != 2 || calls != 2 {
+		t.Errorf("expected a fresh fetch and summary, got %d Loki and %d provider calls", lokiClient.calls, calls)
+	}
+
+	// The fresh results were cached again.
+	params.NoCache = false
+	if result, err := svc.Summarize(context.Background(), params); err != nil || !result.CacheHit {
+		t.Errorf("expected a cache hit after a NoCache call, got %+v, %v", result, err)
+	}
+}
+
+func TestSummarize_LokiQueryCacheDisabled(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
+	}
+	ca := newMockCache()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), ca, 30*time.Second,
+		WithLokiQueryCacheTTL(0))
+
+	now := time.Now()
+	params := SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500,
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Summarize(context.Background(), params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if lokiClient.calls != 2 {
+		t.Errorf("expected Loki to be queried on every call, got %d", lokiClient.calls)
+	}
+	if len(ca.data) != 0 {
+		t.Errorf("expected nothing cached, got %d entries", len(ca.data))
+	}
+}
+
+func TestSummarize_DisallowedLabel(t *testing.T) {
+	lokiClient := &mockLoki{err: errors.New("loki should not be called")}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithAllowedLabels([]string{"service"}))
+
+	now := time.Now()
+	_, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500,
+	})
+	if !errors.Is(err, logql.ErrInvalidLabel) {
+		t.Fatalf("expected ErrInvalidLabel, got %v", err)
+	}
+}
+
+func TestSummarize_ModelOverride(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
+	}
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			return "summary", nil
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithAllowedModels([]string{"small-model"}))
+
+	now := time.Now()
+	params := SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500, Model: "small-model",
+	}
+	result, err := svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Model != "small-model" {
+		t.Errorf("expected result model small-model, got %q", result.Model)
+	}
+
+	// Without an override the provider's own model is reported.
+	params.Model = ""
+	result, err = svc.Summarize(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Model != mockDefaultModel {
+		t.Errorf("expected result model %s, got %q", mockDefaultModel, result.Model)
+	}
+}
+
+func TestSummarize_ModelNotAllowed(t *testing.T) {
+	lokiClient := &mockLoki{err: errors.New("loki should not be called")}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithAllowedModels([]string{"small-model"}))
+
+	now := time.Now()
+	_, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID: uuid.New(), Service: "api", Namespace: "prod",
+		Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500, Model: "gpt-huge",
+	})
+	if !errors.Is(err, ErrModelNotAllowed) {
+		t.Fatalf("expected ErrModelNotAllowed, got %v", err)
+	}
+}
+
+func TestSummarize_CancelledContextAbortsProvider(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{{Timestamp: time.Now(), Message: "log line", Level: "error"}},
+	}
+	started := make(chan struct{})
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(ctx context.Context, _ []models.LogLine) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		now := time.Now()
+		_, err := svc.Summarize(ctx, SummarizeParams{
+			TenantID: uuid.New(), Service: "api",
+			Start: now.Add(-1 * time.Hour), End: now, MaxLines: 500,
+		})
+		errCh <- err
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("provider call was not aborted by context cancellation")
+	}
+}
+
+func TestSummarize_FiltersByFingerprint(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{
+			{Timestamp: time.Now().Add(-2 * time.Minute), Message: "db timeout", Level: "error"},
+			{Timestamp: time.Now().Add(-1 * time.Minute), Message: "cache miss", Level: "error"},
+			{Timestamp: time.Now(), Message: "db timeout", Level: "error"},
+		},
+	}
+	var sent []models.LogLine
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, logs []models.LogLine) (string, error) {
+			sent = logs
+			return "summary", nil
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithFingerprinter(func(msg string) string { return "fp:" + msg }))
+
+	result, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID:    uuid.New(),
+		Service:     "api",
+		Namespace:   "prod",
+		Start:       time.Now().Add(-1 * time.Hour),
+		End:         time.Now(),
+		MaxLines:    500,
+		Levels:      []string{"error"},
+		Fingerprint: "fp:db timeout",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.LinesAnalyzed != 2 || len(sent) != 2 {
+		t.Fatalf("expected 2 matching lines, got %d analyzed, %d sent", result.LinesAnalyzed, len(sent))
+	}
+	for _, l := range sent {
+		if l.Message != "db timeout" {
+			t.Errorf("unexpected line sent to provider: %q", l.Message)
+		}
+	}
+	if !strings.Contains(lokiClient.lastReq.Query, "error") {
+		t.Errorf("expected query to filter on level, got %s", lokiClient.lastReq.Query)
+	}
+}
+
+// pagedLoki serves lines like Loki's backward range queries: newest first,
+// within [Start, End), at most Limit of them.
+type pagedLoki struct {
+	mockLoki
+}
+
+func (l *pagedLoki) QueryRangeDetailed(_ context.Context, req loki.QueryRangeRequest) (*loki.QueryRangeResponse, error) {
+	l.calls++
+	l.lastReq = req
+	var page []models.LogLine
+	for i := len(l.lines) - 1; i >= 0 && len(page) < req.Limit; i-- {
+		ts := l.lines[i].Timestamp
+		if !ts.Before(req.Start) && ts.Before(req.End) {
+			page = append(page, l.lines[i])
+		}
+	}
+	return &loki.QueryRangeResponse{Lines: page}, nil
+}
+
+func TestSummarize_FingerprintFilterFillsMaxLines(t *testing.T) {
+	end := time.Now().Add(-time.Hour).Truncate(time.Second)
+	lokiClient := &pagedLoki{}
+	for i := 0; i < 30; i++ {
+		msg := "cache miss"
+		if i%3 == 0 {
+			msg = "db timeout"
+		}
+		lokiClient.lines = append(lokiClient.lines, models.LogLine{
+			Timestamp: end.Add(time.Duration(i-30) * time.Second), Message: msg, Level: "error",
+		})
+	}
+	var sent []models.LogLine
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, logs []models.LogLine) (string, error) {
+			sent = logs
+			return "summary", nil
+		},
+	}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithFingerprinter(func(msg string) string { return "fp:" + msg }))
+
+	result, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID:    uuid.New(),
+		Service:     "api",
+		Start:       end.Add(-time.Hour),
+		End:         end,
+		MaxLines:    5,
+		Fingerprint: "fp:db timeout",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.LinesAnalyzed != 5 || len(sent) != 5 {
+		t.Fatalf("expected MaxLines matching lines, got %d analyzed, %d sent", result.LinesAnalyzed, len(sent))
+	}
+	for _, l := range sent {
+		if l.Message != "db timeout" {
+			t.Errorf("unexpected line sent to provider: %q", l.Message)
+		}
+	}
+	if lokiClient.calls < 2 {
+		t.Errorf("expected the window to be paged through, got %d Loki calls", lokiClient.calls)
+	}
+	if result.Truncated {
+		t.Error("expected an untruncated result")
+	}
+}
+
+func TestSummarize_FingerprintFilterStopsAtPageLimit(t *testing.T) {
+	end := time.Now().Add(-time.Hour).Truncate(time.Second)
+	lokiClient := &pagedLoki{}
+	for i := 0; i < 100; i++ {
+		lokiClient.lines = append(lokiClient.lines, models.LogLine{
+			Timestamp: end.Add(time.Duration(i-100) * time.Second), Message: "cache miss", Level: "error",
+		})
+	}
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithFingerprinter(func(msg string) string { return "fp:" + msg }))
+
+	_, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID:    uuid.New(),
+		Service:     "api",
+		Start:       end.Add(-time.Hour),
+		End:         end,
+		MaxLines:    2,
+		Fingerprint: "fp:db timeout",
+	})
+	if !errors.Is(err, ErrNoLogsFound) {
+		t.Fatalf("expected ErrNoLogsFound, got %v", err)
+	}
+	if lokiClient.calls != fingerprintMaxPages {
+		t.Errorf("expected %d Loki calls, got %d", fingerprintMaxPages, lokiClient.calls)
+	}
+}
+
+func TestSummarize_NoLogsFound(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{}, // empty
+	}
+	provider := &mockProvider{name: "mock"}
+
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	_, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID:  uuid.New(),
+		Service:   "api",
+		Namespace: "prod",
+		Start:     time.Now().Add(-1 * time.Hour),
+		End:       time.Now(),
+		MaxLines:  500,
+	})
+	if err == nil {
+		t.Fatal("expected error for empty logs")
+	}
+	if !errors.Is(err, ErrNoLogsFound) {
+		t.Errorf("expected ErrNoLogsFound, got: %v", err)
+	}
+}
+
+func TestSummarize_ProviderError(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{
+			{Timestamp: time.Now(), Message: "log", Level: "error"},
+		},
+	}
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			return "", ErrProviderUnavailable
+		},
+	}
+
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	_, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID: uuid.New(),
+		Service:  "api",
+		Start:    time.Now().Add(-1 * time.Hour),
+		End:      time.Now(),
+		MaxLines: 500,
+	})
+	if !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("expected ErrProviderUnavailable, got: %v", err)
+	}
+}
+
+func TestSummarize_RetriesTransientError(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{
+			{Timestamp: time.Now(), Message: "log", Level: "error"},
+		},
+	}
+	calls := 0
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			calls++
+			if calls < 3 {
+				return "", fmt.Errorf("ollama: %w", ErrProviderUnavailable)
+			}
+			return "recovered", nil
+		},
+	}
+
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithSummarizeRetries(2))
+	svc.retryBackoff = time.Millisecond
+
+	result, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID: uuid.New(),
+		Service:  "api",
+		Start:    time.Now().Add(-1 * time.Hour),
+		End:      time.Now(),
+		MaxLines: 500,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Summary != "recovered" {
+		t.Errorf("expected summary from the successful attempt, got %q", result.Summary)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 provider calls, got %d", calls)
+	}
+}
+
+func TestSummarize_DoesNotRetryNonTransientError(t *testing.T) {
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{
+			{Timestamp: time.Now(), Message: "log", Level: "error"},
+		},
+	}
+	calls := 0
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			calls++
+			return "", ErrInferenceTimeout
+		},
+	}
+
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithSummarizeRetries(3))
+	svc.retryBackoff = time.Millisecond
+
+	_, err := svc.Summarize(context.Background(), SummarizeParams{
+		TenantID: uuid.New(),
+		Service:  "api",
+		Start:    time.Now().Add(-1 * time.Hour),
+		End:      time.Now(),
+		MaxLines: 500,
+	})
+	if !errors.Is(err, ErrInferenceTimeout) {
+		t.Errorf("expected ErrInferenceTimeout, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 provider call, got %d", calls)
+	}
+}
+
+func TestSummarize_TruncatesLongLines(t *testing.T) {
+	longMsg := ""
+	for i := 0; i < 1000; i++ {
+		longMsg += "x"
+	}
+	var capturedLogs []models.LogLine
+	lokiClient := &mockLoki{
+		lines: []models.LogLine{
+			{Timestamp: time.Now(), Message: longMsg, Level: "error"},
+		},
+	}
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, logs []models.LogLine) (string, error) {
+			capturedLogs = logs
+			return "summary", nil
+		},
+	}
+
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second)
+
+	svc.Summarize(context.Background(), SummarizeParams{
 		TenantID: uuid.New(),
 		Service:  "api",
 		Start:    time.Now().Add(-1 * time.Hour),
@@ -559,3 +2025,471 @@ func TestSummarize_TruncatesLongLines(t *testing.T) {
 		t.Errorf("expected message truncated to 500 chars, got %d", len(capturedLogs[0].Message))
 	}
 }
+
+// --- Timeout tests ---
+
+func TestAnalysisService_PerOperationTimeouts(t *testing.T) {
+	var analyzeDeadline, summarizeDeadline time.Duration
+	var mu sync.Mutex
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(ctx context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			dl, _ := ctx.Deadline()
+			mu.Lock()
+			analyzeDeadline = time.Until(dl)
+			mu.Unlock()
+			return models.AnalysisResult{RootCause: "cause", Summary: "summary"}, nil
+		},
+		summarizeFunc: func(ctx context.Context, _ []models.LogLine) (string, error) {
+			dl, _ := ctx.Deadline()
+			summarizeDeadline = time.Until(dl)
+			return "summary", nil
+		},
+	}
+	lokiClient := &mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "err", Level: "error"}}}
+	st := newMockStore()
+
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 60*time.Second,
+		WithAnalyzeTimeout(10*time.Second),
+		WithSummarizeTimeout(120*time.Second),
+	)
+
+	if _, err := svc.TriggerAnalysis(context.Background(), testCluster()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForGoroutine(t, st, 2)
+
+	now := time.Now()
+	if _, err := svc.Summarize(context.Background(), SummarizeParams{
+		Service: "api", Start: now.Add(-time.Hour), End: now, MaxLines: 100,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if analyzeDeadline <= 9*time.Second || analyzeDeadline > 10*time.Second {
+		t.Errorf("expected analyze deadline ~10s, got %v", analyzeDeadline)
+	}
+	if summarizeDeadline <= 119*time.Second || summarizeDeadline > 120*time.Second {
+		t.Errorf("expected summarize deadline ~120s, got %v", summarizeDeadline)
+	}
+}
+
+func TestAnalysisService_TimeoutsDefaultToSharedValue(t *testing.T) {
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, newMockStore(), newMockCache(), 45*time.Second,
+		WithAnalyzeTimeout(0),
+	)
+
+	if svc.analyzeTimeout != 45*time.Second {
+		t.Errorf("expected analyze timeout 45s, got %v", svc.analyzeTimeout)
+	}
+	if svc.summarizeTimeout != 45*time.Second {
+		t.Errorf("expected summarize timeout 45s, got %v", svc.summarizeTimeout)
+	}
+}
+
+// staticLoki returns the same lines to every query and is safe for
+// concurrent use.
+type staticLoki struct{ lines []models.LogLine }
+
+func (l staticLoki) QueryRange(_ context.Context, _ loki.QueryRangeRequest) ([]models.LogLine, error) {
+	return slices.Clone(l.lines), nil
+}
+func (l staticLoki) QueryRangeDetailed(_ context.Context, _ loki.QueryRangeRequest) (*loki.QueryRangeResponse, error) {
+	return &loki.QueryRangeResponse{Lines: slices.Clone(l.lines)}, nil
+}
+func (staticLoki) Query(_ context.Context, _ loki.QueryRequest) (loki.QueryResult, error) {
+	return loki.QueryResult{}, nil
+}
+func (staticLoki) Labels(_ context.Context) ([]string, error)                { return nil, nil }
+func (staticLoki) LabelValues(_ context.Context, _ string) ([]string, error) { return nil, nil }
+func (staticLoki) Ready(_ context.Context) error                             { return nil }
+
+func liveSummarizeParams() SummarizeParams {
+	now := time.Now()
+	return SummarizeParams{TenantID: uuid.New(), Service: "api", Start: now.Add(-time.Hour), End: now, MaxLines: 10}
+}
+
+func TestSummarize_MaxInflightFailsFast(t *testing.T) {
+	release := make(chan struct{})
+	var inflight atomic.Int32
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			inflight.Add(1)
+			defer inflight.Add(-1)
+			<-release
+			return "summary", nil
+		},
+	}
+	lokiClient := staticLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "boom", Level: "error"}}}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithSummarizeMaxInflight(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.Summarize(context.Background(), liveSummarizeParams()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for inflight.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for two summaries in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	_, err := svc.Summarize(context.Background(), liveSummarizeParams())
+	if !errors.Is(err, ErrSummarizeBusy) {
+		t.Fatalf("expected ErrSummarizeBusy, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected an over-limit call to fail fast, took %v", elapsed)
+	}
+
+	close(release)
+	wg.Wait()
+
+	// Finished summaries free their slots.
+	if _, err := svc.Summarize(context.Background(), liveSummarizeParams()); err != nil {
+		t.Errorf("expected a slot after the summaries finished, got %v", err)
+	}
+}
+
+func TestSummarize_MaxInflightBoundsConcurrency(t *testing.T) {
+	const limit, callers = 3, 20
+	var inflight, peak atomic.Int32
+	provider := &mockProvider{
+		name: "mock",
+		summarizeFunc: func(_ context.Context, _ []models.LogLine) (string, error) {
+			n := inflight.Add(1)
+			defer inflight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return "summary", nil
+		},
+	}
+	lokiClient := staticLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "boom", Level: "error"}}}
+	svc := NewAnalysisService(provider, lokiClient, newMockStore(), newMockCache(), 30*time.Second,
+		WithSummarizeMaxInflight(limit))
+
+	var wg sync.WaitGroup
+	var ok, busy atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Summarize(context.Background(), liveSummarizeParams())
+			switch {
+			case err == nil:
+				ok.Add(1)
+			case errors.Is(err, ErrSummarizeBusy):
+				busy.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("expected at most %d summaries in flight, saw %d", limit, p)
+	}
+	if ok.Load() == 0 || ok.Load()+busy.Load() != callers {
+		t.Errorf("expected every call to succeed or report busy, got %d ok and %d busy", ok.Load(), busy.Load())
+	}
+}
+
+// --- CancelJob tests ---
+
+func TestTriggerAnalysis_MaxConcurrencyQueuesJobs(t *testing.T) {
+	st := newMockStore()
+	release := make(chan struct{})
+	var running, peak atomic.Int32
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			return models.AnalysisResult{RootCause: "cause", Confidence: 0.9}, nil
+		},
+	}
+	lokiClient := &mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}}}
+	svc := NewAnalysisService(provider, lokiClient, st, newMockCache(), 30*time.Second,
+		WithAnalyzeMaxConcurrency(2))
+
+	activeBefore := testutil.ToFloat64(analysisJobsActive)
+	queuedBefore := testutil.ToFloat64(analysisJobsQueued)
+	for i := 0; i < 5; i++ {
+		cluster := testCluster()
+		job, err := svc.TriggerAnalysis(context.Background(), cluster)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Status != models.JobStatusPending {
+			t.Errorf("expected a pending job while queued, got %s", job.Status)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(analysisJobsActive)-activeBefore != 2 || testutil.ToFloat64(analysisJobsQueued)-queuedBefore != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 active and 3 queued, got %v and %v",
+				testutil.ToFloat64(analysisJobsActive)-activeBefore, testutil.ToFloat64(analysisJobsQueued)-queuedBefore)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+	// Each job records running then completed.
+	waitForGoroutine(t, st, 10)
+	if p := peak.Load(); p != 2 {
+		t.Errorf("expected at most 2 analyses at once, peak was %d", p)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(analysisJobsActive) != activeBefore {
+		if time.Now().After(deadline) {
+			t.Fatal("active gauge did not return to its starting value")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if q := testutil.ToFloat64(analysisJobsQueued); q != queuedBefore {
+		t.Errorf("expected queued gauge back at %v, got %v", queuedBefore, q)
+	}
+}
+
+func TestTriggerAnalysis_QueueTimeoutFailsJob(t *testing.T) {
+	logger, records := captureLogs(t)
+	st := newMockStore()
+	called := false
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			called = true
+			return models.AnalysisResult{}, nil
+		},
+	}
+	svc := NewAnalysisService(provider, &mockLoki{}, st, newMockCache(), 30*time.Second,
+		WithAnalyzeMaxConcurrency(1), WithAnalyzeQueueTimeout(50*time.Millisecond), WithLogger(logger))
+	// Occupy the only slot.
+	release, err := svc.acquireAnalyzeSlot(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	job, err := svc.TriggerAnalysis(context.Background(), testCluster())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Status != models.JobStatusPending {
+		t.Errorf("expected a pending job, got %s", job.Status)
+	}
+
+	// Wait for the dispatched goroutine to give up and deregister the job.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		svc.cancelsMu.Lock()
+		n := len(svc.cancels)
+		svc.cancelsMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the queued job to give up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	statuses := st.statusesFor(job.ID)
+	if len(statuses) != 1 || statuses[0] != models.JobStatusFailed {
+		t.Fatalf("expected the queued job to fail without running, got %v", statuses)
+	}
+	if called {
+		t.Error("provider must not be called for a job that timed out queued")
+	}
+	failed := findLog(records(), "analysis failed")
+	if failed == nil || failed["error_code"] != models.JobErrorQueueTimeout {
+		t.Errorf("expected error_code %s, got %v", models.JobErrorQueueTimeout, failed)
+	}
+}
+
+func TestAnalyzeSync_QueueTimeout(t *testing.T) {
+	st := newMockStore()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, newMockCache(), 30*time.Second,
+		WithAnalyzeMaxConcurrency(1), WithAnalyzeQueueTimeout(20*time.Millisecond))
+	// Occupy the only slot.
+	release, err := svc.acquireAnalyzeSlot(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	_, err = svc.AnalyzeSync(context.Background(), testCluster())
+	if !errors.Is(err, ErrAnalyzeQueueTimeout) {
+		t.Fatalf("expected ErrAnalyzeQueueTimeout, got %v", err)
+	}
+	if len(st.statusUpdates) != 1 || st.statusUpdates[0].Status != models.JobStatusFailed {
+		t.Errorf("expected the job to be marked failed, got %v", st.statusUpdates)
+	}
+}
+
+func TestCancelJob_AbortsRunningAnalysis(t *testing.T) {
+	st := newMockStore()
+	ca := newMockCache()
+	started := make(chan struct{})
+	causes := make(chan error, 1)
+	provider := &mockProvider{
+		name: "mock",
+		analyzeFunc: func(ctx context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+			close(started)
+			<-ctx.Done()
+			causes <- context.Cause(ctx)
+			return models.AnalysisResult{}, ctx.Err()
+		},
+	}
+	lokiClient := &mockLoki{lines: []models.LogLine{{Timestamp: time.Now(), Message: "error msg", Level: "error"}}}
+	svc := NewAnalysisService(provider, lokiClient, st, ca, 30*time.Second)
+
+	cluster := testCluster()
+	job, err := svc.TriggerAnalysis(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+
+	if err := svc.CancelJob(context.Background(), job.ID, cluster.TenantID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case cause := <-causes:
+		if !errors.Is(cause, errJobCancelled) {
+			t.Errorf("expected the provider context to be cancelled by CancelJob, got %v", cause)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("provider call was not cancelled")
+	}
+
+	// Wait for runAnalysis to return and deregister the job.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		svc.cancelsMu.Lock()
+		n := len(svc.cancels)
+		svc.cancelsMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for runAnalysis to return")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	statuses := st.statusesFor(job.ID)
+	if len(statuses) != 2 || statuses[0] != models.JobStatusRunning || statuses[1] != models.JobStatusCancelled {
+		t.Errorf("expected running then a single cancelled update, got %v", statuses)
+	}
+	if status, _, _ := ca.GetJobStatus(context.Background(), job.ID); status != models.JobStatusCancelled {
+		t.Errorf("expected cached status cancelled, got %s", status)
+	}
+	if len(st.results) != 0 {
+		t.Errorf("expected no result stored for a cancelled job, got %d", len(st.results))
+	}
+}
+
+func TestCancelJob_NotRunningHere(t *testing.T) {
+	st := newMockStore()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, newMockCache(), 30*time.Second)
+
+	// A pending job no goroutine is running, e.g. one left behind by a restart.
+	job := &models.Job{ID: uuid.New(), TenantID: uuid.New(), Status: models.JobStatusPending}
+	if err := st.CreateJob(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancelledBefore := testutil.ToFloat64(analysisJobsTotal.WithLabelValues(models.JobStatusCancelled))
+	if err := svc.CancelJob(context.Background(), job.ID, job.TenantID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if statuses := st.statusesFor(job.ID); len(statuses) != 1 || statuses[0] != models.JobStatusCancelled {
+		t.Errorf("expected the job to be marked cancelled, got %v", statuses)
+	}
+	if n := testutil.ToFloat64(analysisJobsTotal.WithLabelValues(models.JobStatusCancelled)) - cancelledBefore; n != 1 {
+		t.Errorf("expected the cancelled job counter to grow by 1, got %v", n)
+	}
+
+	// Once cancelled it cannot be cancelled again.
+	if err := svc.CancelJob(context.Background(), job.ID, job.TenantID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("expected ErrJobFinished, got %v", err)
+	}
+}
+
+func TestCancelJob_FinishedMeanwhile(t *testing.T) {
+	st := newMockStore()
+	ca := newMockCache()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, ca, 30*time.Second)
+
+	// The job reads as running but completes before the cancel is recorded, so
+	// the store rejects the transition.
+	job := &models.Job{ID: uuid.New(), TenantID: uuid.New(), Status: models.JobStatusRunning}
+	if err := st.CreateJob(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st.updateStatusErr = store.ErrConcurrentUpdate
+
+	cancelledBefore := testutil.ToFloat64(analysisJobsTotal.WithLabelValues(models.JobStatusCancelled))
+	if err := svc.CancelJob(context.Background(), job.ID, job.TenantID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("expected ErrJobFinished, got %v", err)
+	}
+	if status, found, _ := ca.GetJobStatus(context.Background(), job.ID); found {
+		t.Errorf("expected no cached status, got %s", status)
+	}
+	if n := testutil.ToFloat64(analysisJobsTotal.WithLabelValues(models.JobStatusCancelled)) - cancelledBefore; n != 0 {
+		t.Errorf("expected the cancelled job counter to be unchanged, got +%v", n)
+	}
+
+	st.updateStatusErr = errors.New("connection reset")
+	if err := svc.CancelJob(context.Background(), job.ID, job.TenantID); err == nil || errors.Is(err, ErrJobFinished) {
+		t.Errorf("expected the store error to be returned, got %v", err)
+	}
+}
+
+func TestCancelJob_NotFound(t *testing.T) {
+	st := newMockStore()
+	svc := NewAnalysisService(&mockProvider{name: "mock"}, &mockLoki{}, st, newMockCache(), 30*time.Second)
+
+	job := &models.Job{ID: uuid.New(), TenantID: uuid.New(), Status: models.JobStatusPending}
+	if err := st.CreateJob(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := svc.CancelJob(context.Background(), uuid.New(), job.TenantID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown job, got %v", err)
+	}
+	if err := svc.CancelJob(context.Background(), job.ID, uuid.New()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another tenant's job, got %v", err)
+	}
+	if statuses := st.statusesFor(job.ID); len(statuses) != 0 {
+		t.Errorf("expected the job to be untouched, got %v", statuses)
+	}
+}
@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPMaxIdleConns is the idle connection pool size used when none is configured.
+const DefaultHTTPMaxIdleConns = 32
+
+// NewHTTPClient returns an HTTP client tuned for many requests to a single AI
+// backend. Go's default transport keeps only two idle connections per host, so
+// concurrent inference calls would otherwise keep dialing new connections.
+// Every idle slot is available to one host, since a provider talks to exactly
+// one. timeout caps each request; 0 leaves deadlines to the caller's context.
+func NewHTTPClient(timeout time.Duration, maxIdleConns int) *http.Client {
+	if maxIdleConns <= 0 {
+		maxIdleConns = DefaultHTTPMaxIdleConns
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          maxIdleConns,
+			MaxIdleConnsPerHost:   maxIdleConns,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
+
+// maxDrainBytes bounds how much of an unread response body CloseBody discards.
+const maxDrainBytes = 64 << 10
+
+// CloseBody drains what is left of a response body before closing it. A body
+// closed before EOF (e.g. after json.Decoder stops at the closing brace) takes
+// its connection down with it instead of returning it to the idle pool.
+func CloseBody(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
+}
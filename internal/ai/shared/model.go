@@ -0,0 +1,23 @@
+package shared
+
+import "context"
+
+type modelKey struct{}
+
+// WithModel returns a context carrying a model override for the analysis
+// service, which passes it to providers as AnalysisRequest.Model. An empty
+// model leaves ctx unchanged.
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+// ModelFromContext returns the model set by WithModel, or fallback if none was set.
+func ModelFromContext(ctx context.Context, fallback string) string {
+	if m, ok := ctx.Value(modelKey{}).(string); ok {
+		return m
+	}
+	return fallback
+}
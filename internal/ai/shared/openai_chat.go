@@ -63,7 +63,7 @@ func OpenAIChat(ctx context.Context, client *http.Client, url, model, prompt str
 		}
 		return "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
 	}
-	defer resp.Body.Close()
+	defer CloseBody(resp.Body)
 
 	if resp.StatusCode == http.StatusTooManyRequests {
 		return "", fmt.Errorf("%w: rate limited (HTTP 429)", ErrProviderUnavailable)
@@ -1,6 +1,7 @@
 package vllm
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -20,9 +21,15 @@ type Provider struct {
 
 // NewProvider creates a new vLLM AI provider.
 func NewProvider(cfg config.VLLMConfig) *Provider {
+	return NewProviderWithClient(cfg, shared.NewHTTPClient(0, shared.DefaultHTTPMaxIdleConns))
+}
+
+// NewProviderWithClient creates a new vLLM AI provider that sends requests
+// through client, so the connection pool can be shared.
+func NewProviderWithClient(cfg config.VLLMConfig, client *http.Client) *Provider {
 	return &Provider{
 		cfg:    cfg,
-		client: &http.Client{},
+		client: client,
 	}
 }
 
@@ -37,7 +44,8 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 	}
 
 	url := strings.TrimRight(p.cfg.BaseURL, "/") + "/v1/chat/completions"
-	content, err := shared.OpenAIChat(ctx, p.client, url, p.cfg.Model, prompt, nil)
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := shared.OpenAIChat(ctx, p.client, url, model, prompt, nil)
 	if err != nil {
 		return models.AnalysisResult{}, err
 	}
@@ -47,23 +55,24 @@ func (p *Provider) Analyze(ctx context.Context, req models.AnalysisRequest) (mod
 		return models.AnalysisResult{}, fmt.Errorf("%w: %v", shared.ErrInvalidResponse, err)
 	}
 
-	return parsed.ToResult("vllm", p.cfg.Model), nil
+	return parsed.ToResult("vllm", model), nil
 }
 
 // Summarize condenses log lines into a plain-language summary via vLLM.
-func (p *Provider) Summarize(ctx context.Context, logs []models.LogLine) (string, error) {
-	prompt, err := shared.BuildSummarizePrompt(logs)
+func (p *Provider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
+	prompt, err := shared.BuildSummarizePrompt(req.Logs)
 	if err != nil {
-		return "", fmt.Errorf("building prompt: %w", err)
+		return models.Summary{}, fmt.Errorf("building prompt: %w", err)
 	}
 
 	url := strings.TrimRight(p.cfg.BaseURL, "/") + "/v1/chat/completions"
-	content, err := shared.OpenAIChat(ctx, p.client, url, p.cfg.Model, prompt, nil)
+	model := cmp.Or(req.Model, p.cfg.Model)
+	content, err := shared.OpenAIChat(ctx, p.client, url, model, prompt, nil)
 	if err != nil {
-		return "", err
+		return models.Summary{}, err
 	}
 
-	return content, nil
+	return models.Summary{Text: content, Model: model}, nil
 }
 
 var _ models.AIProvider = (*Provider)(nil)
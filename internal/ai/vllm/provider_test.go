@@ -109,12 +109,15 @@ func TestSummarize_Success(t *testing.T) {
 	defer ts.Close()
 
 	p := newTestProvider(ts.URL)
-	summary, err := p.Summarize(context.Background(), sampleLogs())
+	summary, err := p.Summarize(context.Background(), models.SummarizeRequest{Logs: sampleLogs()})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if summary != "The service experienced intermittent connection failures." {
-		t.Errorf("unexpected summary: %s", summary)
+	if summary.Text != "The service experienced intermittent connection failures." {
+		t.Errorf("unexpected summary: %s", summary.Text)
+	}
+	if summary.Model != "mistral-7b" {
+		t.Errorf("expected the configured model mistral-7b, got %s", summary.Model)
 	}
 }
 
@@ -23,14 +23,83 @@ var (
 	reWhitespace = regexp.MustCompile(`\s+`)
 )
 
+// Level detection regexes. Structured fields (level=error, "severity":"warn")
+// are matched case-insensitively; bare keywords only when upper-case, so
+// prose such as "no error occurred" is not mistaken for a severity.
+var (
+	reLevelField   = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)"?\s*[=:]\s*"?([a-z]+)`)
+	reLevelKeyword = regexp.MustCompile(`\b(FATAL|PANIC|CRITICAL|CRIT|ERROR|ERR|WARNING|WARN)\b`)
+)
+
+// messageLevels maps recognised level spellings to the canonical level names.
+var messageLevels = map[string]string{
+	"fatal":    "fatal",
+	"panic":    "fatal",
+	"critical": "critical",
+	"crit":     "critical",
+	"error":    "error",
+	"err":      "error",
+	"warning":  "warn",
+	"warn":     "warn",
+	"info":     "info",
+	"debug":    "debug",
+}
+
+// ClusterOption configures Cluster.
+type ClusterOption func(*clusterConfig)
+
+type clusterConfig struct {
+	levelFromMessage bool
+	severities       LevelSeverities
+}
+
+func newClusterConfig(opts []ClusterOption) clusterConfig {
+	var cfg clusterConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithLevelSeverities ranks levels with s instead of the default mapping,
+// both when picking a cluster's level and when ordering clusters, and for the
+// default detection levels.
+func WithLevelSeverities(s LevelSeverities) ClusterOption {
+	return func(c *clusterConfig) { c.severities = s }
+}
+
+// WithLevelFromMessage makes Cluster fall back to DetectLevelFromMessage for
+// lines whose Level is empty. Disabled by default.
+func WithLevelFromMessage(enabled bool) ClusterOption {
+	return func(c *clusterConfig) { c.levelFromMessage = enabled }
+}
+
+// DetectLevelFromMessage extracts a log level embedded in msg, such as
+// "FATAL: out of memory", "[ERROR] ..." or "level=warn ...". It returns the
+// canonical lower-case level, or "" when none is found.
+func DetectLevelFromMessage(msg string) string {
+	if m := reLevelField.FindStringSubmatch(msg); m != nil {
+		if level, ok := messageLevels[strings.ToLower(m[1])]; ok {
+			return level
+		}
+	}
+	if m := reLevelKeyword.FindStringSubmatch(msg); m != nil {
+		return messageLevels[strings.ToLower(m[1])]
+	}
+	return ""
+}
+
 // Cluster groups log lines into deduplicated ErrorClusters by fingerprint.
 // Returns clusters sorted by (Count DESC, severity DESC).
 // Returns empty slice for empty input (never nil).
-func Cluster(lines []models.LogLine, service, namespace string) []models.ErrorCluster {
+func Cluster(lines []models.LogLine, service, namespace string, opts ...ClusterOption) []models.ErrorCluster {
 	if len(lines) == 0 {
 		return []models.ErrorCluster{}
 	}
 
+	cfg := newClusterConfig(opts)
+	severity := cfg.severities.Severity
+
 	type clusterState struct {
 		fingerprint   string
 		level         string
@@ -43,6 +112,9 @@ func Cluster(lines []models.LogLine, service, namespace string) []models.ErrorCl
 	groups := make(map[string]*clusterState)
 
 	for _, line := range lines {
+		if line.Level == "" && cfg.levelFromMessage {
+			line.Level = DetectLevelFromMessage(line.Message)
+		}
 		fp := Fingerprint(line.Message)
 		cs, exists := groups[fp]
 		if !exists {
@@ -63,7 +135,7 @@ func Cluster(lines []models.LogLine, service, namespace string) []models.ErrorCl
 		if line.Timestamp.UnixNano() > cs.lastSeen {
 			cs.lastSeen = line.Timestamp.UnixNano()
 		}
-		if LevelSeverity(line.Level) > LevelSeverity(cs.level) {
+		if severity(line.Level) > severity(cs.level) {
 			cs.level = line.Level
 		}
 	}
@@ -87,7 +159,7 @@ func Cluster(lines []models.LogLine, service, namespace string) []models.ErrorCl
 		if clusters[i].Count != clusters[j].Count {
 			return clusters[i].Count > clusters[j].Count
 		}
-		return LevelSeverity(clusters[i].Level) > LevelSeverity(clusters[j].Level)
+		return severity(clusters[i].Level) > severity(clusters[j].Level)
 	})
 
 	return clusters
@@ -114,22 +186,6 @@ func NormalizeMessage(msg string) string {
 	return msg
 }
 
-// LevelSeverity maps a log level string to a numeric severity.
-func LevelSeverity(level string) int {
-	switch strings.ToUpper(level) {
-	case "FATAL":
-		return 4
-	case "CRITICAL":
-		return 3
-	case "ERROR":
-		return 2
-	case "WARN", "WARNING":
-		return 1
-	default:
-		return 0
-	}
-}
-
 // truncateString truncates s to maxBytes without splitting UTF-8 runes.
 func truncateString(s string, maxBytes int) string {
 	if len(s) <= maxBytes {
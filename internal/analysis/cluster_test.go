@@ -1,6 +1,7 @@
 package analysis
 
 import (
+	"slices"
 	"testing"
 	"time"
 
@@ -332,6 +333,13 @@ func TestLevelSeverity(t *testing.T) {
 		{"info", 0},
 		{"debug", 0},
 		{"unknown", 0},
+		{"emergency", 4},
+		{"emerg", 4},
+		{"alert", 4},
+		{"crit", 3},
+		{"err", 2},
+		{"notice", 0},
+		{"trace", 0},
 	}
 
 	for _, tt := range tests {
@@ -343,3 +351,166 @@ func TestLevelSeverity(t *testing.T) {
 		})
 	}
 }
+
+func TestNewLevelSeverities_Override(t *testing.T) {
+	sev := NewLevelSeverities(map[string]int{"notice": 1, "Trace": -1, "audit": 3})
+
+	tests := []struct {
+		level    string
+		expected int
+	}{
+		{"notice", 1},
+		{"NOTICE", 1},
+		{"trace", -1},
+		{"audit", 3},
+		{"error", 2}, // defaults not overridden are kept
+		{"emergency", 4},
+	}
+	for _, tt := range tests {
+		if got := sev.Severity(tt.level); got != tt.expected {
+			t.Errorf("Severity(%q) = %d, want %d", tt.level, got, tt.expected)
+		}
+	}
+
+	if got := LevelSeverity("audit"); got != 0 {
+		t.Errorf("expected the defaults to be left alone, got audit=%d", got)
+	}
+}
+
+func TestCluster_UsesConfiguredSeverity(t *testing.T) {
+	sev := NewLevelSeverities(map[string]int{"notice": 1, "trace": -1})
+
+	now := time.Now().UnixNano()
+	lines := []models.LogLine{
+		{Timestamp: time.Unix(0, now), Message: "disk almost full", Level: "trace"},
+		{Timestamp: time.Unix(0, now+1), Message: "disk almost full", Level: "notice"},
+		{Timestamp: time.Unix(0, now+2), Message: "disk almost full", Level: "info"},
+	}
+	clusters := Cluster(lines, "svc", "default", WithLevelSeverities(sev))
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(clusters))
+	}
+	if clusters[0].Level != "notice" {
+		t.Errorf("expected the configured most severe level notice, got %q", clusters[0].Level)
+	}
+}
+
+func TestDetectionLevels(t *testing.T) {
+	got := LevelSeverities(nil).DetectionLevels(1)
+	want := []string{"alert", "emerg", "emergency", "fatal", "panic", "crit", "critical", "err", "error", "warn", "warning"}
+	if !slices.Equal(got, want) {
+		t.Errorf("DetectionLevels(1) = %v, want %v", got, want)
+	}
+
+	sev := NewLevelSeverities(map[string]int{"notice": 1})
+	if !slices.Contains(sev.DetectionLevels(1), "notice") {
+		t.Error("expected a level raised to 1 to be detected")
+	}
+	if slices.Contains(sev.DetectionLevels(1), "info") {
+		t.Error("expected info not to be detected")
+	}
+}
+
+func TestKnownLevels(t *testing.T) {
+	got := NewLevelSeverities(map[string]int{"audit": -1}).KnownLevels()
+	for _, level := range []string{"fatal", "warning", "info", "debug", "audit"} {
+		if !slices.Contains(got, level) {
+			t.Errorf("expected %s in KnownLevels, got %v", level, got)
+		}
+	}
+	if got[len(got)-1] != "audit" {
+		t.Errorf("expected the least severe level last, got %v", got)
+	}
+}
+
+func TestCheckAutoAnalyzeLevel(t *testing.T) {
+	var sev LevelSeverities
+	for _, level := range []string{"fatal", "critical", "error", "warn", "emergency"} {
+		if err := sev.CheckAutoAnalyzeLevel(level); err != nil {
+			t.Errorf("CheckAutoAnalyzeLevel(%q) = %v, want nil", level, err)
+		}
+	}
+	for _, level := range []string{"info", "debug", "loud", ""} {
+		if err := sev.CheckAutoAnalyzeLevel(level); err == nil {
+			t.Errorf("CheckAutoAnalyzeLevel(%q) = nil, want an error", level)
+		}
+	}
+
+	sev = NewLevelSeverities(map[string]int{"notice": 1})
+	if err := sev.CheckAutoAnalyzeLevel("notice"); err != nil {
+		t.Errorf("expected a configured level to be accepted, got %v", err)
+	}
+}
+
+// --- DetectLevelFromMessage tests ---
+
+func TestDetectLevelFromMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{"leading keyword with colon", "FATAL: out of memory", "fatal"},
+		{"bracketed keyword", "[ERROR] connection refused", "error"},
+		{"embedded after timestamp", "2024-01-15T10:30:00Z WARN disk almost full", "warn"},
+		{"warning spelling", "WARNING: deprecated flag", "warn"},
+		{"short err keyword", "ERR failed to bind port", "error"},
+		{"panic maps to fatal", "PANIC: nil map write", "fatal"},
+		{"logfmt level field", `ts=2024-01-15 level=error msg="db down"`, "error"},
+		{"json severity field", `{"severity":"Critical","msg":"quota exceeded"}`, "critical"},
+		{"field wins over keyword", "level=info msg=\"ERROR counter reset\"", "info"},
+		{"lower-case prose is ignored", "no error occurred while syncing", ""},
+		{"no level at all", "connection refused", ""},
+		{"empty message", "", ""},
+		{"keyword inside word is ignored", "ERRORS_TOTAL metric reset", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLevelFromMessage(tt.msg); got != tt.want {
+				t.Errorf("DetectLevelFromMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCluster_LevelFromMessageFillsEmptyLevel(t *testing.T) {
+	now := time.Now()
+	lines := []models.LogLine{
+		{Timestamp: now, Message: "WARN slow query", Level: "", Labels: map[string]string{}},
+		{Timestamp: now, Message: "FATAL: out of memory", Level: "", Labels: map[string]string{}},
+	}
+
+	clusters := Cluster(lines, "svc", "ns", WithLevelFromMessage(true))
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+	if clusters[0].Level != "fatal" {
+		t.Errorf("expected first cluster level 'fatal', got %q", clusters[0].Level)
+	}
+	if clusters[1].Level != "warn" {
+		t.Errorf("expected second cluster level 'warn', got %q", clusters[1].Level)
+	}
+}
+
+func TestCluster_LevelFromMessageKeepsLabelLevel(t *testing.T) {
+	lines := []models.LogLine{
+		{Timestamp: time.Now(), Message: "FATAL: out of memory", Level: "warn", Labels: map[string]string{}},
+	}
+
+	clusters := Cluster(lines, "svc", "ns", WithLevelFromMessage(true))
+	if clusters[0].Level != "warn" {
+		t.Errorf("expected label level 'warn' to be kept, got %q", clusters[0].Level)
+	}
+}
+
+func TestCluster_LevelFromMessageDisabledByDefault(t *testing.T) {
+	lines := []models.LogLine{
+		{Timestamp: time.Now(), Message: "FATAL: out of memory", Level: "", Labels: map[string]string{}},
+	}
+
+	clusters := Cluster(lines, "svc", "ns")
+	if clusters[0].Level != "" {
+		t.Errorf("expected empty level without the option, got %q", clusters[0].Level)
+	}
+}
@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/cache"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// clusterListVersionTTL keeps a tenant's version counter well beyond any list
+// entry built from it; a counter that expires restarts at 0 long after those
+// entries are gone.
+const clusterListVersionTTL = 24 * time.Hour
+
+// ClusterListCache wraps a store.Store and caches ListErrorClusters results
+// per tenant and filter for a short TTL. Each tenant has a version counter that
+// is part of every list key; writes that change a tenant's clusters bump it, so
+// a list cached before the write is never served after it. Cache errors fall
+// through to the store.
+type ClusterListCache struct {
+	store.Store
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewClusterListCache wraps st so cluster lists are cached in ca for ttl.
+func NewClusterListCache(st store.Store, ca cache.Cache, ttl time.Duration) *ClusterListCache {
+	return &ClusterListCache{Store: st, cache: ca, ttl: ttl}
+}
+
+type cachedClusterList struct {
+	Clusters []*models.ErrorCluster `json:"clusters"`
+	Total    int                    `json:"total"`
+}
+
+// ListErrorClusters serves the list from the cache when the tenant's clusters
+// have not changed since it was stored.
+func (c *ClusterListCache) ListErrorClusters(ctx context.Context, filter store.ClusterFilter) ([]*models.ErrorCluster, int, error) {
+	version, err := c.version(ctx, filter.TenantID)
+	if err != nil {
+		return c.Store.ListErrorClusters(ctx, filter)
+	}
+	key := cache.ClusterListKey(filter.TenantID, version, c.filterHash(filter))
+
+	var cached cachedClusterList
+	if found, err := cache.GetJSON(ctx, c.cache, key, &cached); err == nil && found {
+		return cached.Clusters, cached.Total, nil
+	}
+
+	clusters, total, err := c.Store.ListErrorClusters(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	_ = cache.SetJSON(ctx, c.cache, key, cachedClusterList{Clusters: clusters, Total: total}, c.ttl)
+	return clusters, total, nil
+}
+
+// UpsertErrorCluster writes through and invalidates the tenant's cached lists.
+func (c *ClusterListCache) UpsertErrorCluster(ctx context.Context, cluster *models.ErrorCluster) (*models.ErrorCluster, error) {
+	saved, err := c.Store.UpsertErrorCluster(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, cluster.TenantID)
+	return saved, nil
+}
+
+// TouchErrorCluster writes through and invalidates the tenant's cached lists.
+func (c *ClusterListCache) TouchErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, seenAt time.Time) error {
+	if err := c.Store.TouchErrorCluster(ctx, id, tenantID, seenAt); err != nil {
+		return err
+	}
+	c.invalidate(ctx, tenantID)
+	return nil
+}
+
+// SetClusterPinned writes through and invalidates the tenant's cached lists.
+func (c *ClusterListCache) SetClusterPinned(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, pinned bool) error {
+	if err := c.Store.SetClusterPinned(ctx, id, tenantID, pinned); err != nil {
+		return err
+	}
+	c.invalidate(ctx, tenantID)
+	return nil
+}
+
+// DeleteErrorCluster writes through and invalidates the tenant's cached lists.
+func (c *ClusterListCache) DeleteErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error {
+	if err := c.Store.DeleteErrorCluster(ctx, id, tenantID); err != nil {
+		return err
+	}
+	c.invalidate(ctx, tenantID)
+	return nil
+}
+
+// AutoResolveClusters writes through and invalidates the cached lists of every
+// tenant that had clusters resolved.
+func (c *ClusterListCache) AutoResolveClusters(ctx context.Context, staleBefore time.Time) (map[uuid.UUID]int, error) {
+	resolved, err := c.Store.AutoResolveClusters(ctx, staleBefore)
+	if err != nil {
+		return nil, err
+	}
+	for tenantID := range resolved {
+		c.invalidate(ctx, tenantID)
+	}
+	return resolved, nil
+}
+
+// version returns the tenant's current list version; 0 if none was bumped yet.
+func (c *ClusterListCache) version(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	data, found, err := c.cache.Get(ctx, cache.ClusterListVersionKey(tenantID))
+	if err != nil || !found {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+// invalidate bumps the tenant's list version. If that fails, cached lists may
+// be served for up to the TTL, so the failure is logged rather than returned
+// for a write that already succeeded.
+func (c *ClusterListCache) invalidate(ctx context.Context, tenantID uuid.UUID) {
+	if _, err := c.cache.IncrWithExpiry(ctx, cache.ClusterListVersionKey(tenantID), clusterListVersionTTL); err != nil {
+		slog.Warn("failed to invalidate cluster list cache", "tenant_id", tenantID, "error", err)
+	}
+}
+
+// filterHash returns a short stable hash of filter. Relative windows (since,
+// active_within) are truncated to the TTL so repeated requests share an entry;
+// the list is at most one TTL stale either way.
+func (c *ClusterListCache) filterHash(filter store.ClusterFilter) string {
+	filter.Since = filter.Since.Truncate(c.ttl)
+	filter.SeenBefore = filter.SeenBefore.Truncate(c.ttl)
+	raw, _ := json.Marshal(filter)
+	h := sha256.Sum256(raw)
+	return fmt.Sprintf("%x", h[:8])
+}
+
+// Compile-time check that ClusterListCache implements store.Store.
+var _ store.Store = (*ClusterListCache)(nil)
@@ -0,0 +1,180 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// countingClusterStore counts ListErrorClusters calls so tests can tell a
+// cached list from a fresh one.
+type countingClusterStore struct {
+	mockSearchStore
+	listCalls int
+	resolved  map[uuid.UUID]int
+}
+
+func (m *countingClusterStore) ListErrorClusters(_ context.Context, _ store.ClusterFilter) ([]*models.ErrorCluster, int, error) {
+	m.listCalls++
+	return m.clusters, len(m.clusters), nil
+}
+
+func (m *countingClusterStore) UpsertErrorCluster(_ context.Context, c *models.ErrorCluster) (*models.ErrorCluster, error) {
+	m.clusters = append(m.clusters, c)
+	return c, nil
+}
+
+func (m *countingClusterStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return m.resolved, nil
+}
+
+// failingCache fails every operation.
+type failingCache struct{ *mockSearchCache }
+
+func (*failingCache) Get(_ context.Context, _ string) ([]byte, bool, error) {
+	return nil, false, errors.New("redis down")
+}
+func (*failingCache) Set(_ context.Context, _ string, _ []byte, _ time.Duration) error {
+	return errors.New("redis down")
+}
+func (*failingCache) IncrWithExpiry(_ context.Context, _ string, _ time.Duration) (int64, error) {
+	return 0, errors.New("redis down")
+}
+
+func TestClusterListCache_ServesCachedList(t *testing.T) {
+	tenantID := uuid.New()
+	st := &countingClusterStore{mockSearchStore: mockSearchStore{
+		clusters: []*models.ErrorCluster{{ID: uuid.New(), TenantID: tenantID, Service: "api"}},
+	}}
+	c := NewClusterListCache(st, newMockCache(), time.Minute)
+	filter := store.ClusterFilter{TenantID: tenantID, Page: 1, Limit: 20}
+
+	for i := 0; i < 2; i++ {
+		clusters, total, err := c.ListErrorClusters(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if total != 1 || len(clusters) != 1 || clusters[0].ID != st.clusters[0].ID {
+			t.Fatalf("call %d: unexpected list %v (total %d)", i, clusters, total)
+		}
+	}
+	if st.listCalls != 1 {
+		t.Errorf("expected the second list to be served from cache, store called %d times", st.listCalls)
+	}
+
+	// A different filter is a different entry.
+	filter.Service = "worker"
+	if _, _, err := c.ListErrorClusters(context.Background(), filter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.listCalls != 2 {
+		t.Errorf("expected a new filter to miss the cache, store called %d times", st.listCalls)
+	}
+}
+
+func TestClusterListCache_UpsertInvalidates(t *testing.T) {
+	tenantID := uuid.New()
+	otherTenantID := uuid.New()
+	st := &countingClusterStore{}
+	c := NewClusterListCache(st, newMockCache(), time.Minute)
+	ctx := context.Background()
+
+	if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: otherTenantID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := c.UpsertErrorCluster(ctx, &models.ErrorCluster{ID: uuid.New(), TenantID: tenantID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clusters, total, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 1 || len(clusters) != 1 {
+		t.Errorf("expected the upserted cluster after invalidation, got %d (total %d)", len(clusters), total)
+	}
+	if st.listCalls != 3 {
+		t.Errorf("expected the upsert to invalidate the list, store called %d times", st.listCalls)
+	}
+
+	// The other tenant's list is untouched.
+	if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: otherTenantID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.listCalls != 3 {
+		t.Errorf("expected another tenant's list to stay cached, store called %d times", st.listCalls)
+	}
+}
+
+func TestClusterListCache_DeleteInvalidates(t *testing.T) {
+	tenantID := uuid.New()
+	st := &countingClusterStore{}
+	c := NewClusterListCache(st, newMockCache(), time.Minute)
+	ctx := context.Background()
+
+	if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.DeleteErrorCluster(ctx, uuid.New(), tenantID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.listCalls != 2 {
+		t.Errorf("expected the delete to invalidate the list, store called %d times", st.listCalls)
+	}
+}
+
+func TestClusterListCache_AutoResolveInvalidatesResolvedTenants(t *testing.T) {
+	tenantID := uuid.New()
+	otherTenantID := uuid.New()
+	st := &countingClusterStore{resolved: map[uuid.UUID]int{tenantID: 2}}
+	c := NewClusterListCache(st, newMockCache(), time.Minute)
+	ctx := context.Background()
+
+	for _, id := range []uuid.UUID{tenantID, otherTenantID} {
+		if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := c.AutoResolveClusters(ctx, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []uuid.UUID{tenantID, otherTenantID} {
+		if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if st.listCalls != 3 {
+		t.Errorf("expected only the resolved tenant's list to be invalidated, store called %d times", st.listCalls)
+	}
+}
+
+func TestClusterListCache_FailsOpen(t *testing.T) {
+	tenantID := uuid.New()
+	st := &countingClusterStore{}
+	c := NewClusterListCache(st, &failingCache{newMockCache()}, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.ListErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID}); err != nil {
+			t.Fatalf("expected cache errors to fall through, got %v", err)
+		}
+	}
+	if st.listCalls != 2 {
+		t.Errorf("expected every list to hit the store, got %d calls", st.listCalls)
+	}
+	if _, err := c.UpsertErrorCluster(ctx, &models.ErrorCluster{ID: uuid.New(), TenantID: tenantID}); err != nil {
+		t.Errorf("expected upsert to succeed despite cache errors, got %v", err)
+	}
+}
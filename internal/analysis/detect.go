@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"context"
+
+	"github.com/kiranshivaraju/loghunter/internal/api/handler"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
+)
+
+// DetectService implements handler.ClusterDetector: it runs the same
+// detection as PreviewService and hands the clusters to an Ingester, which
+// stores them and starts automatic analysis of new ones.
+type DetectService struct {
+	loki     loki.Client
+	ingester *Ingester
+	qb       logql.QueryBuilder
+	opts     []ClusterOption
+}
+
+// NewDetectService creates a new DetectService.
+// allowedLabels restricts the labels queries may reference; nil uses logql.DefaultAllowedLabels.
+func NewDetectService(lokiClient loki.Client, ingester *Ingester, allowedLabels []string, opts ...ClusterOption) *DetectService {
+	return &DetectService{
+		loki:     lokiClient,
+		ingester: ingester,
+		qb:       logql.QueryBuilder{AllowedLabels: allowedLabels},
+		opts:     opts,
+	}
+}
+
+// Detect clusters the window's detection results and ingests them for the
+// tenant.
+func (s *DetectService) Detect(ctx context.Context, params handler.PreviewParams) (*handler.DetectResult, error) {
+	clusters, query, lines, err := runDetection(ctx, s.loki, s.qb, params, s.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.ingester.Ingest(ctx, params.TenantID, clusters)
+	if err != nil {
+		return nil, err
+	}
+	return &handler.DetectResult{
+		Clusters:     stored,
+		Query:        query,
+		LinesScanned: lines,
+	}, nil
+}
+
+// Compile-time check that DetectService implements ClusterDetector.
+var _ handler.ClusterDetector = (*DetectService)(nil)
@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+func TestDetect_IngestsClustersAndAutoAnalyzes(t *testing.T) {
+	now := time.Now()
+	lines := []models.LogLine{
+		{Timestamp: now, Message: "connection refused at 0x1f", Level: "ERROR"},
+		{Timestamp: now, Message: "disk almost full", Level: "WARN"},
+	}
+	st := &mockIngestStore{}
+	trigger := &mockAnalysisStarter{}
+	params := previewParams()
+
+	svc := NewDetectService(&mockLokiClient{lines: lines}, NewIngester(st, trigger, "error", nil), nil)
+	result, err := svc.Detect(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.LinesScanned != 2 || result.Query == "" {
+		t.Errorf("expected 2 lines scanned and a query, got %d %q", result.LinesScanned, result.Query)
+	}
+	if len(result.Clusters) != 2 || len(st.upserted) != 2 {
+		t.Fatalf("expected 2 clusters stored, got %d", len(st.upserted))
+	}
+	if st.upserted[0].TenantID != params.TenantID {
+		t.Errorf("expected tenant %s on stored cluster, got %s", params.TenantID, st.upserted[0].TenantID)
+	}
+	if len(trigger.triggered) != 1 || LevelSeverity(trigger.triggered[0].Level) != LevelSeverity("error") {
+		t.Errorf("expected only the error cluster auto-analyzed, got %d", len(trigger.triggered))
+	}
+}
+
+func TestDetect_LokiErrorStoresNothing(t *testing.T) {
+	st := &mockIngestStore{}
+	svc := NewDetectService(&mockLokiClient{err: loki.ErrLokiUnreachable}, NewIngester(st, &mockAnalysisStarter{}, "", nil), nil)
+	_, err := svc.Detect(context.Background(), previewParams())
+	if !errors.Is(err, loki.ErrLokiUnreachable) {
+		t.Errorf("expected ErrLokiUnreachable, got %v", err)
+	}
+	if len(st.upserted) != 0 {
+		t.Errorf("expected no clusters stored, got %d", len(st.upserted))
+	}
+}
+
+func TestDetect_SuppressedClusterNotStoredOrAnalyzed(t *testing.T) {
+	lines := []models.LogLine{{Timestamp: time.Now(), Message: "GET /healthz 404", Level: "ERROR"}}
+	st := &mockIngestStore{suppressions: []*models.ClusterSuppression{{Pattern: "/healthz"}}}
+	trigger := &mockAnalysisStarter{}
+
+	svc := NewDetectService(&mockLokiClient{lines: lines}, NewIngester(st, trigger, "error", nil), nil)
+	result, err := svc.Detect(context.Background(), previewParams())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Clusters) != 0 || len(st.upserted) != 0 {
+		t.Errorf("expected the suppressed cluster not to be stored, got %d", len(st.upserted))
+	}
+	if len(trigger.triggered) != 0 {
+		t.Errorf("expected no analysis for a suppressed cluster, got %d", len(trigger.triggered))
+	}
+}
@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// IngestStore is the store interface needed by Ingester.
+type IngestStore interface {
+	GetErrorClusterByFingerprint(ctx context.Context, tenantID uuid.UUID, service, namespace, fingerprint string) (*models.ErrorCluster, error)
+	UpsertErrorCluster(ctx context.Context, cluster *models.ErrorCluster) (*models.ErrorCluster, error)
+	ListClusterSuppressions(ctx context.Context, tenantID uuid.UUID) ([]*models.ClusterSuppression, error)
+}
+
+// AnalysisStarter starts an async analysis job for a cluster.
+type AnalysisStarter interface {
+	TriggerAnalysis(ctx context.Context, cluster *models.ErrorCluster) (*models.Job, error)
+}
+
+// Ingester persists detected clusters and, when enabled, starts analysis for
+// clusters seen for the first time at or above a minimum severity.
+type Ingester struct {
+	store       IngestStore
+	trigger     AnalysisStarter
+	severities  LevelSeverities
+	minSeverity int
+}
+
+// NewIngester creates an Ingester. minLevel is the lowest level (e.g. "error")
+// that triggers automatic analysis of a new cluster; "" disables it. Levels
+// are ranked with severities (nil for the defaults).
+func NewIngester(st IngestStore, trigger AnalysisStarter, minLevel string, severities LevelSeverities) *Ingester {
+	ing := &Ingester{store: st, trigger: trigger, severities: severities}
+	if minLevel != "" {
+		ing.minSeverity = max(severities.Severity(minLevel), 1)
+	}
+	return ing
+}
+
+// Ingest upserts clusters for tenantID and returns the stored rows. Recurring
+// clusters are never re-analyzed, so only the first sighting of a fingerprint
+// can start a job. A failed trigger is logged and does not fail ingestion.
+// Clusters matching one of the tenant's suppressions are dropped: they are
+// neither stored nor analyzed, and are left out of the result.
+func (ing *Ingester) Ingest(ctx context.Context, tenantID uuid.UUID, clusters []models.ErrorCluster) ([]*models.ErrorCluster, error) {
+	sups, err := ing.store.ListClusterSuppressions(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("ingest: loading suppressions: %w", err)
+	}
+	suppressed := newSuppressor(sups)
+
+	stored := make([]*models.ErrorCluster, 0, len(clusters))
+	for i := range clusters {
+		c := &clusters[i]
+		c.TenantID = tenantID
+		if suppressed.matches(c) {
+			continue
+		}
+
+		_, err := ing.store.GetErrorClusterByFingerprint(ctx, tenantID, c.Service, c.Namespace, c.Fingerprint)
+		isNew := errors.Is(err, store.ErrNotFound)
+		if err != nil && !isNew {
+			return stored, fmt.Errorf("ingest cluster %s: %w", c.Fingerprint, err)
+		}
+
+		saved, err := ing.store.UpsertErrorCluster(ctx, c)
+		if err != nil {
+			return stored, fmt.Errorf("ingest cluster %s: %w", c.Fingerprint, err)
+		}
+		stored = append(stored, saved)
+
+		if isNew && ing.shouldAnalyze(saved) {
+			if _, err := ing.trigger.TriggerAnalysis(ctx, saved); err != nil {
+				slog.Warn("auto-analyze failed", "cluster_id", saved.ID, "error", err)
+			}
+		}
+	}
+	return stored, nil
+}
+
+func (ing *Ingester) shouldAnalyze(c *models.ErrorCluster) bool {
+	return ing.minSeverity > 0 && ing.severities.Severity(c.Level) >= ing.minSeverity
+}
+
+// suppressor matches clusters against a tenant's suppression list.
+type suppressor struct {
+	fingerprints map[string]bool
+	patterns     []*regexp.Regexp
+}
+
+// newSuppressor compiles sups. Patterns are validated when a suppression is
+// created, so one that no longer compiles is logged and ignored.
+func newSuppressor(sups []*models.ClusterSuppression) *suppressor {
+	s := &suppressor{fingerprints: make(map[string]bool)}
+	for _, sup := range sups {
+		if sup.Fingerprint != "" {
+			s.fingerprints[sup.Fingerprint] = true
+		}
+		if sup.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(sup.Pattern)
+		if err != nil {
+			slog.Warn("ignoring invalid suppression pattern", "suppression_id", sup.ID, "error", err)
+			continue
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s
+}
+
+func (s *suppressor) matches(c *models.ErrorCluster) bool {
+	if s.fingerprints[c.Fingerprint] {
+		return true
+	}
+	for _, re := range s.patterns {
+		if re.MatchString(c.SampleMessage) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+type mockIngestStore struct {
+	existing     map[string]*models.ErrorCluster
+	upserted     []*models.ErrorCluster
+	lookupErr    error
+	suppressions []*models.ClusterSuppression
+	suppressErr  error
+}
+
+func (m *mockIngestStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, fingerprint string) (*models.ErrorCluster, error) {
+	if m.lookupErr != nil {
+		return nil, m.lookupErr
+	}
+	if c, ok := m.existing[fingerprint]; ok {
+		return c, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+func (m *mockIngestStore) UpsertErrorCluster(_ context.Context, c *models.ErrorCluster) (*models.ErrorCluster, error) {
+	saved := *c
+	m.upserted = append(m.upserted, &saved)
+	return &saved, nil
+}
+
+func (m *mockIngestStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) {
+	return m.suppressions, m.suppressErr
+}
+
+type mockAnalysisStarter struct {
+	triggered []*models.ErrorCluster
+	err       error
+}
+
+func (m *mockAnalysisStarter) TriggerAnalysis(_ context.Context, c *models.ErrorCluster) (*models.Job, error) {
+	m.triggered = append(m.triggered, c)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Job{ID: uuid.New(), ClusterID: &c.ID}, nil
+}
+
+func ingestCluster(fingerprint, level string) models.ErrorCluster {
+	now := time.Now()
+	return models.ErrorCluster{
+		ID: uuid.New(), Service: "svc", Namespace: "ns", Fingerprint: fingerprint,
+		Level: level, FirstSeenAt: now, LastSeenAt: now, Count: 1,
+	}
+}
+
+func TestIngester_NewFatalClusterTriggersAnalysis(t *testing.T) {
+	st := &mockIngestStore{}
+	trigger := &mockAnalysisStarter{}
+	tenantID := uuid.New()
+
+	stored, err := NewIngester(st, trigger, "error", nil).Ingest(context.Background(), tenantID,
+		[]models.ErrorCluster{ingestCluster("fp-fatal", "fatal"), ingestCluster("fp-warn", "warn")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stored) != 2 || len(st.upserted) != 2 {
+		t.Fatalf("expected both clusters stored, got %d", len(st.upserted))
+	}
+	if st.upserted[0].TenantID != tenantID {
+		t.Errorf("expected tenant %s on upserted cluster, got %s", tenantID, st.upserted[0].TenantID)
+	}
+	if len(trigger.triggered) != 1 {
+		t.Fatalf("expected 1 analysis triggered, got %d", len(trigger.triggered))
+	}
+	if trigger.triggered[0].Fingerprint != "fp-fatal" {
+		t.Errorf("expected fatal cluster analyzed, got %s", trigger.triggered[0].Fingerprint)
+	}
+}
+
+func TestIngester_RecurringClusterNotReanalyzed(t *testing.T) {
+	existing := ingestCluster("fp-fatal", "fatal")
+	st := &mockIngestStore{existing: map[string]*models.ErrorCluster{"fp-fatal": &existing}}
+	trigger := &mockAnalysisStarter{}
+
+	_, err := NewIngester(st, trigger, "error", nil).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{ingestCluster("fp-fatal", "fatal")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(st.upserted) != 1 {
+		t.Errorf("expected recurring cluster still upserted, got %d", len(st.upserted))
+	}
+	if len(trigger.triggered) != 0 {
+		t.Errorf("expected no analysis for a recurring cluster, got %d", len(trigger.triggered))
+	}
+}
+
+func TestIngester_DisabledNeverTriggers(t *testing.T) {
+	trigger := &mockAnalysisStarter{}
+
+	_, err := NewIngester(&mockIngestStore{}, trigger, "", nil).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{ingestCluster("fp-fatal", "fatal")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trigger.triggered) != 0 {
+		t.Errorf("expected no analysis when disabled, got %d", len(trigger.triggered))
+	}
+}
+
+func TestIngester_TriggerErrorDoesNotFailIngest(t *testing.T) {
+	st := &mockIngestStore{}
+	trigger := &mockAnalysisStarter{err: errors.New("provider down")}
+
+	stored, err := NewIngester(st, trigger, "warn", nil).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{ingestCluster("fp-a", "error"), ingestCluster("fp-b", "warn")})
+	if err != nil {
+		t.Fatalf("expected trigger failure to be swallowed, got %v", err)
+	}
+	if len(stored) != 2 || len(trigger.triggered) != 2 {
+		t.Errorf("expected 2 stored and 2 trigger attempts, got %d and %d", len(stored), len(trigger.triggered))
+	}
+}
+
+func TestIngester_RanksWithConfiguredSeverities(t *testing.T) {
+	st := &mockIngestStore{}
+	trigger := &mockAnalysisStarter{}
+	sev := NewLevelSeverities(map[string]int{"notice": 1})
+
+	_, err := NewIngester(st, trigger, "notice", sev).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{ingestCluster("fp-a", "notice"), ingestCluster("fp-b", "info")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trigger.triggered) != 1 || trigger.triggered[0].Fingerprint != "fp-a" {
+		t.Errorf("expected only the notice cluster to be analyzed, got %v", trigger.triggered)
+	}
+}
+
+func TestIngester_LookupErrorStopsIngest(t *testing.T) {
+	st := &mockIngestStore{lookupErr: errors.New("db down")}
+	trigger := &mockAnalysisStarter{}
+
+	_, err := NewIngester(st, trigger, "error", nil).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{ingestCluster("fp-a", "fatal")})
+	if err == nil {
+		t.Fatal("expected lookup error")
+	}
+	if len(st.upserted) != 0 || len(trigger.triggered) != 0 {
+		t.Error("expected nothing stored or triggered after a lookup error")
+	}
+}
+
+func TestIngester_SuppressedClustersSkipped(t *testing.T) {
+	noisy := ingestCluster("fp-noisy", "fatal")
+	disconnect := ingestCluster("fp-disconnect", "error")
+	disconnect.SampleMessage = "client disconnected: broken pipe"
+	kept := ingestCluster("fp-kept", "fatal")
+
+	st := &mockIngestStore{suppressions: []*models.ClusterSuppression{
+		{ID: uuid.New(), Fingerprint: "fp-noisy"},
+		{ID: uuid.New(), Pattern: `client disconnected`},
+		{ID: uuid.New(), Pattern: `(unclosed`},
+	}}
+	trigger := &mockAnalysisStarter{}
+
+	stored, err := NewIngester(st, trigger, "error", nil).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{noisy, disconnect, kept})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stored) != 1 || len(st.upserted) != 1 || st.upserted[0].Fingerprint != "fp-kept" {
+		t.Fatalf("expected only fp-kept stored, got %d upserts", len(st.upserted))
+	}
+	if len(trigger.triggered) != 1 || trigger.triggered[0].Fingerprint != "fp-kept" {
+		t.Errorf("expected only fp-kept analyzed, got %d triggers", len(trigger.triggered))
+	}
+}
+
+func TestIngester_SuppressionLookupErrorStopsIngest(t *testing.T) {
+	st := &mockIngestStore{suppressErr: errors.New("db down")}
+
+	_, err := NewIngester(st, &mockAnalysisStarter{}, "error", nil).Ingest(context.Background(), uuid.New(),
+		[]models.ErrorCluster{ingestCluster("fp-a", "fatal")})
+	if err == nil {
+		t.Fatal("expected suppression lookup error")
+	}
+	if len(st.upserted) != 0 {
+		t.Error("expected nothing stored after a suppression lookup error")
+	}
+}
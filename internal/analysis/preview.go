@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/api/handler"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// PreviewStore is the read-only store interface needed by PreviewService.
+type PreviewStore interface {
+	GetClustersByFingerprints(ctx context.Context, tenantID uuid.UUID, fingerprints []string) ([]*models.ErrorCluster, error)
+	ListClusterSuppressions(ctx context.Context, tenantID uuid.UUID) ([]*models.ClusterSuppression, error)
+}
+
+// PreviewService implements handler.ClusterPreviewer: it runs the detection
+// query and Cluster over a window and reports what would be stored, without
+// writing anything.
+type PreviewService struct {
+	loki  loki.Client
+	store PreviewStore
+	qb    logql.QueryBuilder
+	opts  []ClusterOption
+}
+
+// NewPreviewService creates a new PreviewService.
+// allowedLabels restricts the labels queries may reference; nil uses logql.DefaultAllowedLabels.
+func NewPreviewService(lokiClient loki.Client, st PreviewStore, allowedLabels []string, opts ...ClusterOption) *PreviewService {
+	return &PreviewService{
+		loki:  lokiClient,
+		store: st,
+		qb:    logql.QueryBuilder{AllowedLabels: allowedLabels},
+		opts:  opts,
+	}
+}
+
+// Preview clusters the window's detection results and marks each cluster that
+// already exists for the tenant, or that ingestion would drop as suppressed.
+func (s *PreviewService) Preview(ctx context.Context, params handler.PreviewParams) (*handler.PreviewResult, error) {
+	clusters, query, lines, err := runDetection(ctx, s.loki, s.qb, params, s.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprints := make([]string, len(clusters))
+	for i, c := range clusters {
+		fingerprints[i] = c.Fingerprint
+	}
+
+	// Existing clusters are matched on the same natural key an upsert uses.
+	existing := make(map[string]uuid.UUID)
+	if len(fingerprints) > 0 {
+		stored, err := s.store.GetClustersByFingerprints(ctx, params.TenantID, fingerprints)
+		if err != nil {
+			return nil, fmt.Errorf("looking up existing clusters: %w", err)
+		}
+		for _, c := range stored {
+			if c.Service == params.Service && c.Namespace == params.Namespace {
+				existing[c.Fingerprint] = c.ID
+			}
+		}
+	}
+
+	sups, err := s.store.ListClusterSuppressions(ctx, params.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("loading suppressions: %w", err)
+	}
+	suppressed := newSuppressor(sups)
+
+	result := &handler.PreviewResult{
+		Clusters:     make([]handler.PreviewCluster, len(clusters)),
+		Query:        query,
+		LinesScanned: lines,
+	}
+	for i, c := range clusters {
+		// Cluster assigns a fresh ID; a preview has none to report.
+		c.ID = uuid.Nil
+		result.Clusters[i] = handler.PreviewCluster{ErrorCluster: c, Suppressed: suppressed.matches(&c)}
+		if id, ok := existing[c.Fingerprint]; ok {
+			result.Clusters[i].ExistingClusterID = &id
+		}
+	}
+	return result, nil
+}
+
+// runDetection runs the detection query for params and clusters the lines it
+// returns. It reports the clusters, the query sent and the number of lines
+// scanned.
+func runDetection(ctx context.Context, lokiClient loki.Client, qb logql.QueryBuilder, params handler.PreviewParams, opts []ClusterOption) ([]models.ErrorCluster, string, int, error) {
+	levels := params.Levels
+	if len(levels) == 0 {
+		// Everything from warn up, including configured aliases.
+		sev := newClusterConfig(opts).severities
+		levels = sev.DetectionLevels(max(sev.Severity("warn"), 1))
+	}
+	qp := logql.DetectionParams{
+		Service:   params.Service,
+		Namespace: params.Namespace,
+		Start:     params.Start,
+		End:       params.End,
+		Levels:    levels,
+	}
+	if err := qb.CheckLabels(qp.Labels()...); err != nil {
+		return nil, "", 0, err
+	}
+
+	query := qb.BuildDetectionQuery(qp)
+	lines, err := lokiClient.QueryRange(ctx, loki.QueryRangeRequest{
+		Query:     query,
+		Start:     params.Start,
+		End:       params.End,
+		Limit:     params.Limit,
+		Direction: "backward",
+	})
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("querying loki: %w", err)
+	}
+
+	return Cluster(lines, params.Service, params.Namespace, opts...), query, len(lines), nil
+}
+
+// Compile-time check that PreviewService implements ClusterPreviewer.
+var _ handler.ClusterPreviewer = (*PreviewService)(nil)
@@ -0,0 +1,169 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/api/handler"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// writeTrackingStore fails the test on any store write.
+type writeTrackingStore struct {
+	mockSearchStore
+	writes       []string
+	suppressions []*models.ClusterSuppression
+}
+
+func (s *writeTrackingStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) {
+	return s.suppressions, nil
+}
+
+func (s *writeTrackingStore) UpsertErrorCluster(_ context.Context, _ *models.ErrorCluster) (*models.ErrorCluster, error) {
+	s.writes = append(s.writes, "UpsertErrorCluster")
+	return nil, nil
+}
+func (s *writeTrackingStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error {
+	s.writes = append(s.writes, "TouchErrorCluster")
+	return nil
+}
+func (s *writeTrackingStore) CreateJob(_ context.Context, _ *models.Job) error {
+	s.writes = append(s.writes, "CreateJob")
+	return nil
+}
+func (s *writeTrackingStore) CreateAnalysisResult(_ context.Context, _ *models.AnalysisResult) error {
+	s.writes = append(s.writes, "CreateAnalysisResult")
+	return nil
+}
+
+func previewParams() handler.PreviewParams {
+	return handler.PreviewParams{
+		TenantID:  uuid.New(),
+		Service:   "payments-api",
+		Namespace: "default",
+		Start:     time.Now().Add(-1 * time.Hour),
+		End:       time.Now(),
+		Limit:     1000,
+	}
+}
+
+func TestPreview_ReturnsClustersWithoutWriting(t *testing.T) {
+	now := time.Now()
+	lines := []models.LogLine{
+		{Timestamp: now, Message: "connection refused at 0x1f", Level: "ERROR"},
+		{Timestamp: now, Message: "connection refused at 0x2e", Level: "ERROR"},
+		{Timestamp: now, Message: "disk almost full", Level: "WARN"},
+	}
+	existingID := uuid.New()
+	st := &writeTrackingStore{mockSearchStore: mockSearchStore{clusters: []*models.ErrorCluster{
+		{ID: existingID, Service: "payments-api", Namespace: "default", Fingerprint: Fingerprint("disk almost full")},
+		// Same fingerprint in another service must not be reported as existing.
+		{ID: uuid.New(), Service: "other", Namespace: "default", Fingerprint: Fingerprint(lines[0].Message)},
+	}}}
+
+	svc := NewPreviewService(&mockLokiClient{lines: lines}, st, nil)
+	result, err := svc.Preview(context.Background(), previewParams())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(st.writes) != 0 {
+		t.Errorf("expected no store writes, got %v", st.writes)
+	}
+	if result.LinesScanned != 3 {
+		t.Errorf("expected 3 lines scanned, got %d", result.LinesScanned)
+	}
+	if len(result.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(result.Clusters))
+	}
+
+	top := result.Clusters[0]
+	if top.Count != 2 || top.Service != "payments-api" {
+		t.Errorf("expected the 2-line payments-api cluster first, got %+v", top.ErrorCluster)
+	}
+	if top.ID != uuid.Nil {
+		t.Errorf("expected no ID on a preview cluster, got %s", top.ID)
+	}
+	if top.ExistingClusterID != nil {
+		t.Errorf("expected new cluster, got existing %s", *top.ExistingClusterID)
+	}
+	if got := result.Clusters[1].ExistingClusterID; got == nil || *got != existingID {
+		t.Errorf("expected existing cluster %s, got %v", existingID, got)
+	}
+}
+
+func TestPreview_DefaultLevelsInQuery(t *testing.T) {
+	svc := NewPreviewService(&mockLokiClient{}, &writeTrackingStore{}, nil)
+	result, err := svc.Preview(context.Background(), previewParams())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, level := range []string{"fatal", "critical", "error", "warn", "emergency", "alert"} {
+		if !strings.Contains(result.Query, level) {
+			t.Errorf("expected default detection level %s in query, got %s", level, result.Query)
+		}
+	}
+	if strings.Contains(result.Query, "info") {
+		t.Errorf("expected info not to be detected by default, got %s", result.Query)
+	}
+	if len(result.Clusters) != 0 {
+		t.Errorf("expected no clusters for an empty window, got %d", len(result.Clusters))
+	}
+}
+
+func TestPreview_LokiError(t *testing.T) {
+	svc := NewPreviewService(&mockLokiClient{err: loki.ErrLokiUnreachable}, &writeTrackingStore{}, nil)
+	_, err := svc.Preview(context.Background(), previewParams())
+	if !errors.Is(err, loki.ErrLokiUnreachable) {
+		t.Errorf("expected ErrLokiUnreachable, got %v", err)
+	}
+}
+
+func TestPreview_DisallowedLabel(t *testing.T) {
+	svc := NewPreviewService(&mockLokiClient{}, &writeTrackingStore{}, []string{"service"})
+	_, err := svc.Preview(context.Background(), previewParams())
+	if !errors.Is(err, logql.ErrInvalidLabel) {
+		t.Errorf("expected ErrInvalidLabel, got %v", err)
+	}
+}
+
+func TestPreview_MarksSuppressedClusters(t *testing.T) {
+	now := time.Now()
+	lines := []models.LogLine{
+		{Timestamp: now, Message: "client disconnected", Level: "ERROR"},
+		{Timestamp: now, Message: "connection refused", Level: "ERROR"},
+	}
+	st := &writeTrackingStore{suppressions: []*models.ClusterSuppression{{ID: uuid.New(), Pattern: "^client disconnected"}}}
+
+	result, err := NewPreviewService(&mockLokiClient{lines: lines}, st, nil).Preview(context.Background(), previewParams())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Clusters) != 2 {
+		t.Fatalf("expected both clusters reported, got %d", len(result.Clusters))
+	}
+	for _, c := range result.Clusters {
+		want := c.SampleMessage == "client disconnected"
+		if c.Suppressed != want {
+			t.Errorf("cluster %q: expected suppressed=%v, got %v", c.SampleMessage, want, c.Suppressed)
+		}
+	}
+}
+
+func TestPreview_LevelFromMessage(t *testing.T) {
+	lines := []models.LogLine{{Timestamp: time.Now(), Message: "FATAL: out of memory"}}
+	svc := NewPreviewService(&mockLokiClient{lines: lines}, &writeTrackingStore{}, nil, WithLevelFromMessage(true))
+	result, err := svc.Preview(context.Background(), previewParams())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Clusters) != 1 || result.Clusters[0].Level != "fatal" {
+		t.Errorf("expected one fatal cluster, got %+v", result.Clusters)
+	}
+}
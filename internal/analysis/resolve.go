@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClusterResolver is the store interface needed by AutoResolver.
+type ClusterResolver interface {
+	AutoResolveClusters(ctx context.Context, staleBefore time.Time) (map[uuid.UUID]int, error)
+}
+
+// AutoResolver periodically resolves open clusters that have stopped firing.
+type AutoResolver struct {
+	store    ClusterResolver
+	after    time.Duration
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewAutoResolver creates an AutoResolver that resolves clusters not seen for
+// longer than after, checking every interval.
+func NewAutoResolver(st ClusterResolver, after, interval time.Duration) *AutoResolver {
+	return &AutoResolver{
+		store:    st,
+		after:    after,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Sweep resolves every open cluster last seen before now minus the configured
+// threshold and returns how many were resolved.
+func (r *AutoResolver) Sweep(ctx context.Context) (int, error) {
+	resolved, err := r.store.AutoResolveClusters(ctx, r.now().Add(-r.after))
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, n := range resolved {
+		total += n
+	}
+	return total, nil
+}
+
+// Run sweeps immediately and then on every interval until ctx is cancelled.
+func (r *AutoResolver) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.Sweep(ctx)
+		if err != nil {
+			slog.Error("auto-resolve sweep failed", "error", err)
+		} else if n > 0 {
+			slog.Info("auto-resolved stale clusters", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
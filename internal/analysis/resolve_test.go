@@ -0,0 +1,86 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type mockClusterResolver struct {
+	mu       sync.Mutex
+	cutoffs  []time.Time
+	resolved map[uuid.UUID]int
+	err      error
+}
+
+func (m *mockClusterResolver) AutoResolveClusters(_ context.Context, staleBefore time.Time) (map[uuid.UUID]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cutoffs = append(m.cutoffs, staleBefore)
+	return m.resolved, m.err
+}
+
+func (m *mockClusterResolver) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.cutoffs)
+}
+
+func TestAutoResolver_SweepUsesThreshold(t *testing.T) {
+	st := &mockClusterResolver{resolved: map[uuid.UUID]int{uuid.New(): 2, uuid.New(): 1}}
+	r := NewAutoResolver(st, 72*time.Hour, time.Minute)
+	now := time.Date(2024, 2, 17, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	n, err := r.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 resolved, got %d", n)
+	}
+	if want := now.Add(-72 * time.Hour); !st.cutoffs[0].Equal(want) {
+		t.Errorf("expected cutoff %v, got %v", want, st.cutoffs[0])
+	}
+}
+
+func TestAutoResolver_SweepError(t *testing.T) {
+	st := &mockClusterResolver{err: errors.New("db down")}
+	r := NewAutoResolver(st, time.Hour, time.Minute)
+
+	if _, err := r.Sweep(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestAutoResolver_RunSweepsUntilCancelled(t *testing.T) {
+	st := &mockClusterResolver{}
+	r := NewAutoResolver(st, time.Hour, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for st.calls() < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected repeated sweeps, got %d", st.calls())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
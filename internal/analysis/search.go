@@ -3,7 +3,6 @@ package analysis
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -23,45 +22,61 @@ type SearchService struct {
 	store store.Store
 	cache cache.Cache
 	qb    logql.QueryBuilder
+	opts  []ClusterOption
 }
 
 // NewSearchService creates a new SearchService.
-func NewSearchService(lokiClient loki.Client, st store.Store, ca cache.Cache) *SearchService {
+// allowedLabels restricts the labels queries may reference; nil uses logql.DefaultAllowedLabels.
+// opts configure the clustering of lines for requests that ask for it.
+func NewSearchService(lokiClient loki.Client, st store.Store, ca cache.Cache, allowedLabels []string, opts ...ClusterOption) *SearchService {
 	return &SearchService{
 		loki:  lokiClient,
 		store: st,
 		cache: ca,
+		qb:    logql.QueryBuilder{AllowedLabels: allowedLabels},
+		opts:  opts,
 	}
 }
 
 // Search queries Loki for log lines matching the given parameters, with Redis caching.
+// The query runs against the tenant's Loki org, and returns
+// handler.ErrNoLogsFound when nothing matches. With params.Cluster set, the
+// returned lines are also grouped into clusters.
 func (s *SearchService) Search(ctx context.Context, params handler.SearchParams) (*handler.SearchResult, error) {
+	// Reject disallowed labels before touching the cache or Loki
+	qp := logql.SearchParams{
+		Service:   params.Service,
+		Namespace: params.Namespace,
+		Start:     params.Start,
+		End:       params.End,
+		Levels:    params.Levels,
+		Keyword:   params.Keyword,
+	}
+	if err := s.qb.CheckLabels(qp.Labels()...); err != nil {
+		return nil, err
+	}
+
 	// Build cache key from tenant + filter hash
 	filterHash := s.buildFilterHash(params)
 	cacheKey := cache.SearchResultKey(params.TenantID, filterHash)
 
 	// Check cache
-	cached, found, err := s.cache.Get(ctx, cacheKey)
-	if err == nil && found {
-		var result handler.SearchResult
-		if json.Unmarshal(cached, &result) == nil {
-			result.CacheHit = true
-			return &result, nil
-		}
+	var cached handler.SearchResult
+	if found, err := cache.GetJSON(ctx, s.cache, cacheKey, &cached); err == nil && found {
+		cached.CacheHit = true
+		return &cached, nil
+	}
+
+	tenant, err := s.store.GetTenant(ctx, params.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("looking up tenant: %w", err)
 	}
 
 	// Build LogQL query
-	query := s.qb.BuildSearchQuery(logql.SearchParams{
-		Service:   params.Service,
-		Namespace: params.Namespace,
-		Start:     params.Start,
-		End:       params.End,
-		Levels:    params.Levels,
-		Keyword:   params.Keyword,
-	})
+	query := s.qb.BuildSearchQuery(qp)
 
 	// Query Loki with limit+1 to detect has_next
-	lines, err := s.loki.QueryRange(ctx, loki.QueryRangeRequest{
+	resp, err := s.loki.QueryRangeDetailed(loki.WithOrgID(ctx, tenant.LokiOrgID), loki.QueryRangeRequest{
 		Query:     query,
 		Start:     params.Start,
 		End:       params.End,
@@ -71,12 +86,16 @@ func (s *SearchService) Search(ctx context.Context, params handler.SearchParams)
 	if err != nil {
 		return nil, fmt.Errorf("querying loki: %w", err)
 	}
+	lines := resp.Lines
 
 	// Determine if there are more results
 	hasMore := len(lines) > params.Limit
 	if hasMore {
 		lines = lines[:params.Limit]
 	}
+	if len(lines) == 0 {
+		return nil, handler.ErrNoLogsFound
+	}
 
 	// Build fingerprints for cluster lookup
 	fingerprintMap := make(map[string]bool)
@@ -109,6 +128,7 @@ func (s *SearchService) Search(ctx context.Context, params handler.SearchParams)
 			Message:   line.Message,
 			Level:     line.Level,
 			Labels:    line.Labels,
+			Fields:    line.Fields,
 		}
 		fp := Fingerprint(line.Message)
 		if id, ok := clustersByFP[fp]; ok {
@@ -118,28 +138,42 @@ func (s *SearchService) Search(ctx context.Context, params handler.SearchParams)
 	}
 
 	result := &handler.SearchResult{
-		Results:  results,
-		Query:    query,
-		CacheHit: false,
+		Results:   results,
+		Query:     query,
+		CacheHit:  false,
+		Truncated: resp.Truncated,
 	}
 
-	// Cache the result
-	if data, err := json.Marshal(result); err == nil {
-		_ = s.cache.Set(ctx, cacheKey, data, searchCacheTTL)
+	if params.Cluster {
+		clusters := Cluster(lines, params.Service, params.Namespace, s.opts...)
+		result.Clusters = make([]handler.PreviewCluster, len(clusters))
+		for i, c := range clusters {
+			// Nothing is stored, so only an existing cluster has an ID to report.
+			c.ID = uuid.Nil
+			result.Clusters[i] = handler.PreviewCluster{ErrorCluster: c}
+			if id, ok := clustersByFP[c.Fingerprint]; ok {
+				result.Clusters[i].ExistingClusterID = &id
+			}
+		}
 	}
 
+	// Cache the result
+	_ = cache.SetJSON(ctx, s.cache, cacheKey, result, searchCacheTTL)
+
 	return result, nil
 }
 
 func (s *SearchService) buildFilterHash(params handler.SearchParams) string {
-	raw := fmt.Sprintf("%s:%s:%s:%s:%v:%s:%d",
+	raw := fmt.Sprintf("%s:%s:%s:%s:%s:%v:%s:%d:%t",
 		params.TenantID,
 		params.Service,
 		params.Namespace,
 		params.Start.Format(time.RFC3339),
+		params.End.Format(time.RFC3339),
 		params.Levels,
 		params.Keyword,
 		params.Limit,
+		params.Cluster,
 	)
 	h := sha256.Sum256([]byte(raw))
 	return fmt.Sprintf("%x", h[:8])
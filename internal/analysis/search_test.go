@@ -3,6 +3,8 @@ package analysis
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -10,19 +12,33 @@ import (
 	"github.com/kiranshivaraju/loghunter/internal/api/handler"
 	"github.com/kiranshivaraju/loghunter/internal/loki"
 	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
 // --- mock loki client ---
 
 type mockLokiClient struct {
-	lines []models.LogLine
-	err   error
+	lines     []models.LogLine
+	err       error
+	truncated bool
+	orgID     string
 }
 
-func (m *mockLokiClient) QueryRange(_ context.Context, _ loki.QueryRangeRequest) ([]models.LogLine, error) {
+func (m *mockLokiClient) QueryRange(ctx context.Context, _ loki.QueryRangeRequest) ([]models.LogLine, error) {
+	m.orgID = loki.OrgIDFromContext(ctx, "")
 	return m.lines, m.err
 }
+func (m *mockLokiClient) QueryRangeDetailed(ctx context.Context, req loki.QueryRangeRequest) (*loki.QueryRangeResponse, error) {
+	lines, err := m.QueryRange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &loki.QueryRangeResponse{Lines: lines, Truncated: m.truncated}, nil
+}
+func (m *mockLokiClient) Query(_ context.Context, _ loki.QueryRequest) (loki.QueryResult, error) {
+	return loki.QueryResult{}, nil
+}
 func (m *mockLokiClient) Labels(_ context.Context) ([]string, error)              { return nil, nil }
 func (m *mockLokiClient) LabelValues(_ context.Context, _ string) ([]string, error) { return nil, nil }
 func (m *mockLokiClient) Ready(_ context.Context) error                            { return nil }
@@ -31,6 +47,7 @@ func (m *mockLokiClient) Ready(_ context.Context) error
 
 type mockSearchStore struct {
 	clusters []*models.ErrorCluster
+	orgID    string
 }
 
 func (m *mockSearchStore) Ping(_ context.Context) error { return nil }
@@ -53,16 +70,18 @@ func (m *mockSearchStore) CreateJob(_ context.Context, _ *models.Job) error { re
 func (m *mockSearchStore) GetJob(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.Job, error) {
 	return nil, nil
 }
+
+func (m *mockSearchStore) JobExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
 func (m *mockSearchStore) UpdateJobStatus(_ context.Context, _ uuid.UUID, _ string, _ ...store.JobUpdateOption) error {
 	return nil
 }
 func (m *mockSearchStore) CreateAnalysisResult(_ context.Context, _ *models.AnalysisResult) error {
 	return nil
 }
-func (m *mockSearchStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (m *mockSearchStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, nil
 }
-func (m *mockSearchStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (m *mockSearchStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, nil
 }
 func (m *mockSearchStore) GetAPIKeyByPrefix(_ context.Context, _ string) ([]*models.APIKey, error) {
@@ -70,12 +89,55 @@ func (m *mockSearchStore) GetAPIKeyByPrefix(_ context.Context, _ string) ([]*mod
 }
 func (m *mockSearchStore) UpdateAPIKeyLastUsed(_ context.Context, _ uuid.UUID) error { return nil }
 func (m *mockSearchStore) CreateAPIKey(_ context.Context, _ *models.APIKey) error    { return nil }
-func (m *mockSearchStore) ListAPIKeys(_ context.Context, _ uuid.UUID) ([]*models.APIKey, error) {
+func (m *mockSearchStore) ListAPIKeys(_ context.Context, _ uuid.UUID, _ ...store.APIKeyListOption) ([]*models.APIKey, error) {
 	return nil, nil
 }
 func (m *mockSearchStore) RevokeAPIKey(_ context.Context, _ uuid.UUID, _ uuid.UUID) error {
 	return nil
 }
+func (m *mockSearchStore) JobStats(_ context.Context, _ uuid.UUID, _ time.Time) (store.JobStats, error) {
+	return store.JobStats{}, nil
+}
+func (m *mockSearchStore) GetTenantByAPIKeyPrefix(_ context.Context, _ string) (*models.Tenant, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockSearchStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
+func (m *mockSearchStore) SaveAnalysisContext(_ context.Context, _ *models.AnalysisContext) error { return nil }
+func (m *mockSearchStore) GetAnalysisContext(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisContext, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockSearchStore) ListAPIKeysPaged(_ context.Context, _ uuid.UUID, _, _ int, _ ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
+	return nil, 0, nil
+}
+func (m *mockSearchStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, _ string) (*models.ErrorCluster, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockSearchStore) GetJobsByIDs(_ context.Context, _ uuid.UUID, _ []uuid.UUID) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+func (m *mockSearchStore) CountErrorClusters(_ context.Context, _ store.ClusterFilter) (int, error) { return 0, nil }
+func (m *mockSearchStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error { return nil }
+func (m *mockSearchStore) SetClusterPinned(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ bool) error { return nil }
+func (m *mockSearchStore) RevokeAllAPIKeys(_ context.Context, _ uuid.UUID, _ ...uuid.UUID) (int, error) { return 0, nil }
+func (m *mockSearchStore) ErrorClusterExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+func (m *mockSearchStore) GetJobByID(_ context.Context, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (m *mockSearchStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) { return &store.MigrationStatus{}, nil }
+func (m *mockSearchStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return 0, nil
+}
+func (m *mockSearchStore) GetAnalysisResultByID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockSearchStore) ListJobs(_ context.Context, _ uuid.UUID, _ store.JobFilter) ([]*models.Job, int, error) { return nil, 0, nil }
+func (m *mockSearchStore) CreateClusterSuppression(_ context.Context, _ *models.ClusterSuppression) error { return nil }
+func (m *mockSearchStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) { return nil, nil }
+func (m *mockSearchStore) DeleteClusterSuppression(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (m *mockSearchStore) DeleteErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (m *mockSearchStore) GetTenant(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id, LokiOrgID: m.orgID}, nil
+}
 
 // --- mock cache ---
 
@@ -105,8 +167,11 @@ func (m *mockSearchCache) SetJobStatus(_ context.Context, _ uuid.UUID, _ string,
 func (m *mockSearchCache) GetJobStatus(_ context.Context, _ uuid.UUID) (string, bool, error) {
 	return "", false, nil
 }
-func (m *mockSearchCache) IncrWithExpiry(_ context.Context, _ string, _ time.Duration) (int64, error) {
-	return 0, nil
+func (m *mockSearchCache) IncrWithExpiry(_ context.Context, key string, _ time.Duration) (int64, error) {
+	n, _ := strconv.ParseInt(string(m.data[key]), 10, 64)
+	n++
+	m.data[key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
 }
 
 // --- tests ---
@@ -128,7 +193,7 @@ func TestSearch_CacheHit(t *testing.T) {
 	lokiClient := &mockLokiClient{} // should NOT be called
 	st := &mockSearchStore{}
 
-	svc := NewSearchService(lokiClient, st, mc)
+	svc := NewSearchService(lokiClient, st, mc, nil)
 	params := searchParams()
 
 	// Pre-populate cache
@@ -168,7 +233,7 @@ func TestSearch_CacheMiss_CallsLoki(t *testing.T) {
 	lokiClient := &mockLokiClient{lines: lines}
 	st := &mockSearchStore{}
 
-	svc := NewSearchService(lokiClient, st, mc)
+	svc := NewSearchService(lokiClient, st, mc, nil)
 	params := searchParams()
 
 	result, err := svc.Search(context.Background(), params)
@@ -189,6 +254,37 @@ func TestSearch_CacheMiss_CallsLoki(t *testing.T) {
 	}
 }
 
+func TestSearch_IncludesFields(t *testing.T) {
+	lines := []models.LogLine{
+		{Timestamp: time.Now(), Message: `{"msg":"timeout"}`, Fields: map[string]string{"msg": "timeout"}},
+	}
+	svc := NewSearchService(&mockLokiClient{lines: lines}, &mockSearchStore{}, newMockCache(), nil)
+
+	result, err := svc.Search(context.Background(), searchParams())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.Results[0].Fields["msg"]; got != "timeout" {
+		t.Errorf("expected field msg=timeout, got %q", got)
+	}
+}
+
+func TestSearch_ReportsTruncation(t *testing.T) {
+	lines := []models.LogLine{{Timestamp: time.Now(), Message: "timeout"}}
+	mc := newMockCache()
+	svc := NewSearchService(&mockLokiClient{lines: lines, truncated: true}, &mockSearchStore{}, mc, nil)
+
+	for i := 0; i < 2; i++ {
+		result, err := svc.Search(context.Background(), searchParams())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Truncated {
+			t.Errorf("call %d: expected the result to be marked truncated (cache hit %v)", i, result.CacheHit)
+		}
+	}
+}
+
 func TestSearch_HasNext_LimitPlusOne(t *testing.T) {
 	// When Loki returns limit+1 lines, we should only return limit lines
 	lines := make([]models.LogLine, 4) // limit is 3, so 4 means has_next
@@ -204,7 +300,7 @@ func TestSearch_HasNext_LimitPlusOne(t *testing.T) {
 	mc := newMockCache()
 	st := &mockSearchStore{}
 
-	svc := NewSearchService(lokiClient, st, mc)
+	svc := NewSearchService(lokiClient, st, mc, nil)
 	params := searchParams()
 	params.Limit = 3
 
@@ -232,7 +328,7 @@ func TestSearch_ClusterIDAttached(t *testing.T) {
 		},
 	}
 
-	svc := NewSearchService(lokiClient, st, mc)
+	svc := NewSearchService(lokiClient, st, mc, nil)
 	params := searchParams()
 
 	result, err := svc.Search(context.Background(), params)
@@ -247,12 +343,31 @@ func TestSearch_ClusterIDAttached(t *testing.T) {
 	}
 }
 
+func TestSearch_AllowedLabel(t *testing.T) {
+	lokiClient := &mockLokiClient{lines: []models.LogLine{{Timestamp: time.Now(), Message: "ok"}}}
+	svc := NewSearchService(lokiClient, &mockSearchStore{}, newMockCache(), []string{"service", "namespace"})
+
+	if _, err := svc.Search(context.Background(), searchParams()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSearch_DisallowedLabel(t *testing.T) {
+	lokiClient := &mockLokiClient{err: errors.New("loki should not be called")}
+	svc := NewSearchService(lokiClient, &mockSearchStore{}, newMockCache(), []string{"service"})
+
+	_, err := svc.Search(context.Background(), searchParams()) // uses namespace
+	if !errors.Is(err, logql.ErrInvalidLabel) {
+		t.Fatalf("expected ErrInvalidLabel, got %v", err)
+	}
+}
+
 func TestSearch_LokiError(t *testing.T) {
 	lokiClient := &mockLokiClient{err: loki.ErrLokiUnreachable}
 	mc := newMockCache()
 	st := &mockSearchStore{}
 
-	svc := NewSearchService(lokiClient, st, mc)
+	svc := NewSearchService(lokiClient, st, mc, nil)
 	params := searchParams()
 
 	_, err := svc.Search(context.Background(), params)
@@ -260,3 +375,93 @@ func TestSearch_LokiError(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestSearch_UsesTenantOrgID(t *testing.T) {
+	lokiClient := &mockLokiClient{lines: []models.LogLine{{Timestamp: time.Now(), Message: "ok"}}}
+	svc := NewSearchService(lokiClient, &mockSearchStore{orgID: "team-a"}, newMockCache(), nil)
+
+	if _, err := svc.Search(context.Background(), searchParams()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lokiClient.orgID != "team-a" {
+		t.Errorf("expected the query to run against the tenant's org, got %q", lokiClient.orgID)
+	}
+}
+
+func TestSearch_NoLogsFound(t *testing.T) {
+	mc := newMockCache()
+	svc := NewSearchService(&mockLokiClient{}, &mockSearchStore{}, mc, nil)
+
+	_, err := svc.Search(context.Background(), searchParams())
+	if !errors.Is(err, handler.ErrNoLogsFound) {
+		t.Fatalf("expected ErrNoLogsFound, got %v", err)
+	}
+	if mc.setCalled {
+		t.Error("expected an empty result not to be cached")
+	}
+}
+
+func TestSearch_Clusters(t *testing.T) {
+	existingID := uuid.New()
+	now := time.Now()
+	lines := []models.LogLine{
+		{Timestamp: now, Message: "connection timeout", Level: "ERROR"},
+		{Timestamp: now.Add(time.Second), Message: "connection timeout", Level: "ERROR"},
+		{Timestamp: now, Message: "disk full on /dev/sda1", Level: "ERROR"},
+	}
+	st := &mockSearchStore{clusters: []*models.ErrorCluster{
+		{ID: existingID, Fingerprint: Fingerprint("connection timeout")},
+	}}
+	svc := NewSearchService(&mockLokiClient{lines: lines}, st, newMockCache(), nil)
+
+	params := searchParams()
+	result, err := svc.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Clusters != nil {
+		t.Errorf("expected no clusters unless requested, got %d", len(result.Clusters))
+	}
+
+	params.Cluster = true
+	result, err = svc.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CacheHit {
+		t.Error("expected clustering to be cached separately")
+	}
+	if len(result.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(result.Clusters))
+	}
+	for _, c := range result.Clusters {
+		if c.ID != uuid.Nil {
+			t.Errorf("expected no ID on an unstored cluster, got %s", c.ID)
+		}
+		switch c.Fingerprint {
+		case Fingerprint("connection timeout"):
+			if c.Count != 2 || c.ExistingClusterID == nil || *c.ExistingClusterID != existingID {
+				t.Errorf("unexpected timeout cluster: %+v", c)
+			}
+		default:
+			if c.ExistingClusterID != nil {
+				t.Errorf("expected a new cluster to have no existing ID, got %s", *c.ExistingClusterID)
+			}
+		}
+	}
+}
+
+func TestSearch_ClusterLevelFromMessage(t *testing.T) {
+	lines := []models.LogLine{{Timestamp: time.Now(), Message: "FATAL: out of memory"}}
+	svc := NewSearchService(&mockLokiClient{lines: lines}, &mockSearchStore{}, newMockCache(), nil, WithLevelFromMessage(true))
+
+	params := searchParams()
+	params.Cluster = true
+	result, err := svc.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Clusters) != 1 || result.Clusters[0].Level != "fatal" {
+		t.Errorf("expected one fatal cluster, got %+v", result.Clusters)
+	}
+}
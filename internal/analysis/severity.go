@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// defaultLevelSeverities ranks the level names LevelSeverity knows without
+// configuration, keyed in upper case. Syslog names sit beside their closest
+// equivalents; info and below all rank 0 like unknown levels.
+var defaultLevelSeverities = map[string]int{
+	"FATAL":     4,
+	"PANIC":     4,
+	"EMERGENCY": 4,
+	"EMERG":     4,
+	"ALERT":     4,
+	"CRITICAL":  3,
+	"CRIT":      3,
+	"ERROR":     2,
+	"ERR":       2,
+	"WARN":      1,
+	"WARNING":   1,
+	"NOTICE":    0,
+	"INFO":      0,
+	"DEBUG":     0,
+	"TRACE":     0,
+}
+
+// LevelSeverities maps upper-case level names to numeric severities.
+// Clustering, auto-analysis, context sampling and the default detection levels
+// all rank levels through one. nil means the defaults.
+type LevelSeverities map[string]int
+
+// NewLevelSeverities returns the default mapping with overrides applied; level
+// names are case-insensitive.
+func NewLevelSeverities(overrides map[string]int) LevelSeverities {
+	m := maps.Clone(defaultLevelSeverities)
+	for level, severity := range overrides {
+		m[strings.ToUpper(level)] = severity
+	}
+	return m
+}
+
+func (s LevelSeverities) table() map[string]int {
+	if s == nil {
+		return defaultLevelSeverities
+	}
+	return s
+}
+
+// Severity maps a log level string to a numeric severity. Unknown levels rank
+// 0.
+func (s LevelSeverities) Severity(level string) int {
+	return s.table()[strings.ToUpper(level)]
+}
+
+// DetectionLevels returns the lower-case level names ranked at or above
+// minSeverity, most severe first, for use as a detection query's level filter.
+func (s LevelSeverities) DetectionLevels(minSeverity int) []string {
+	var levels []string
+	for level, severity := range s.table() {
+		if severity >= minSeverity {
+			levels = append(levels, strings.ToLower(level))
+		}
+	}
+	slices.SortFunc(levels, func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.Severity(b), s.Severity(a)), cmp.Compare(a, b))
+	})
+	return levels
+}
+
+// KnownLevels returns the lower-case names of every level in the mapping,
+// most severe first.
+func (s LevelSeverities) KnownLevels() []string {
+	return s.DetectionLevels(math.MinInt)
+}
+
+// CheckAutoAnalyzeLevel reports an error unless level ranks above 0, so that
+// it can serve as the minimum level for automatic analysis.
+func (s LevelSeverities) CheckAutoAnalyzeLevel(level string) error {
+	if s.Severity(level) < 1 {
+		return fmt.Errorf("AUTO_ANALYZE_MIN_LEVEL must be one of %s; got %q", strings.Join(s.DetectionLevels(1), ", "), level)
+	}
+	return nil
+}
+
+// LevelSeverity ranks level with the default mapping. Unknown levels rank 0.
+func LevelSeverity(level string) int {
+	return LevelSeverities(nil).Severity(level)
+}
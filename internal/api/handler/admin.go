@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -25,7 +24,7 @@ type KeyCreator interface {
 
 // KeyLister is the store interface needed by NewListKeysHandler.
 type KeyLister interface {
-	ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]*models.APIKey, error)
+	ListAPIKeysPaged(ctx context.Context, tenantID uuid.UUID, page, limit int, opts ...store.APIKeyListOption) ([]*models.APIKey, int, error)
 }
 
 // KeyRevoker is the store interface needed by NewRevokeKeyHandler.
@@ -33,8 +32,24 @@ type KeyRevoker interface {
 	RevokeAPIKey(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error
 }
 
+// AllKeysRevoker is the store interface needed by NewRevokeAllKeysHandler.
+type AllKeysRevoker interface {
+	RevokeAllAPIKeys(ctx context.Context, tenantID uuid.UUID, keep ...uuid.UUID) (int, error)
+}
+
+// MigrationVersionReader is the store interface needed by NewMigrationStatusHandler.
+type MigrationVersionReader interface {
+	MigrationVersion(ctx context.Context) (*store.MigrationStatus, error)
+}
+
+// OrphanedResultPruner is the store interface needed by NewPruneOrphanedResultsHandler.
+type OrphanedResultPruner interface {
+	DeleteOrphanedAnalysisResults(ctx context.Context) (int, error)
+}
+
 // NewCreateKeyHandler returns an http.HandlerFunc for POST /api/v1/admin/keys.
-func NewCreateKeyHandler(st KeyCreator) http.HandlerFunc {
+func NewCreateKeyHandler(st KeyCreator, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -46,8 +61,8 @@ func NewCreateKeyHandler(st KeyCreator) http.HandlerFunc {
 			Name   string   `json:"name"`
 			Scopes []string `json:"scopes"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON body", nil)
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
 			return
 		}
 
@@ -99,6 +114,9 @@ func NewCreateKeyHandler(st KeyCreator) http.HandlerFunc {
 }
 
 // NewListKeysHandler returns an http.HandlerFunc for GET /api/v1/admin/keys.
+// Supports ?page= and ?limit= with the same defaults as the other list endpoints.
+// Revoked keys are listed only with ?include_revoked=true; each key reports
+// whether it is active.
 func NewListKeysHandler(st KeyLister) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
@@ -107,7 +125,14 @@ func NewListKeysHandler(st KeyLister) http.HandlerFunc {
 			return
 		}
 
-		keys, err := st.ListAPIKeys(r.Context(), tenantID)
+		page, limit := response.ParseListParams(r)
+
+		var opts []store.APIKeyListOption
+		if r.URL.Query().Get("include_revoked") == "true" {
+			opts = append(opts, store.IncludeRevoked())
+		}
+
+		keys, total, err := st.ListAPIKeysPaged(r.Context(), tenantID, page, limit, opts...)
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list keys", nil)
 			return
@@ -121,10 +146,14 @@ func NewListKeysHandler(st KeyLister) http.HandlerFunc {
 				"key_prefix": k.KeyPrefix,
 				"scopes":     k.Scopes,
 				"created_at": k.CreatedAt,
+				"active":     k.DeletedAt == nil,
+			}
+			if k.DeletedAt != nil {
+				safeKeys[i]["revoked_at"] = k.DeletedAt
 			}
 		}
 
-		response.JSON(w, safeKeys)
+		response.Collection(w, safeKeys, response.NewPaginationMeta(page, limit, total))
 	}
 }
 
@@ -149,6 +178,83 @@ func NewRevokeKeyHandler(st KeyRevoker) http.HandlerFunc {
 			return
 		}
 
-		w.WriteHeader(http.StatusNoContent)
+		response.NoContent(w)
+	}
+}
+
+// NewRevokeAllKeysHandler returns an http.HandlerFunc for POST /api/v1/admin/keys/revoke-all.
+// It revokes every active key of the tenant except the one making the request,
+// so the admin is not locked out mid-incident; that key can be revoked separately.
+func NewRevokeAllKeysHandler(st AllKeysRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		var keep []uuid.UUID
+		callerKeyID, hasCaller := mw.GetAPIKeyID(r)
+		if hasCaller {
+			keep = append(keep, callerKeyID)
+		}
+
+		revoked, err := st.RevokeAllAPIKeys(r.Context(), tenantID, keep...)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		mw.LoggerFromContext(r.Context()).Warn("revoked all api keys", "revoked", revoked)
+
+		body := revokeAllResponse{Revoked: revoked}
+		if hasCaller {
+			body.KeptKeyID = &callerKeyID
+		}
+		response.JSON(w, body)
+	}
+}
+
+type revokeAllResponse struct {
+	Revoked   int        `json:"revoked"`
+	KeptKeyID *uuid.UUID `json:"kept_key_id,omitempty"`
+}
+
+// NewMigrationStatusHandler returns an http.HandlerFunc for GET /api/v1/admin/migrations.
+// It reports the applied schema version, so a deployment can be checked against
+// the migrations it shipped with.
+func NewMigrationStatusHandler(st MigrationVersionReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		migration, err := st.MigrationVersion(r.Context())
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+		response.JSON(w, migration)
+	}
+}
+
+// NewPruneOrphanedResultsHandler returns an http.HandlerFunc for
+// POST /api/v1/admin/maintenance/prune-orphaned-results. It deletes analysis
+// results whose cluster no longer exists. Orphans belong to no live cluster,
+// so the sweep is not limited to the caller's tenant.
+func NewPruneOrphanedResultsHandler(st OrphanedResultPruner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deleted, err := st.DeleteOrphanedAnalysisResults(r.Context())
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		mw.LoggerFromContext(r.Context()).Info("pruned orphaned analysis results", "deleted", deleted)
+
+		response.JSON(w, pruneOrphanedResponse{Deleted: deleted})
 	}
 }
+
+type pruneOrphanedResponse struct {
+	Deleted int `json:"deleted"`
+}
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
 	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
@@ -37,17 +41,35 @@ func (s *adminMockStore) CreateAPIKey(_ context.Context, key *models.APIKey) err
 	return nil
 }
 
-func (s *adminMockStore) ListAPIKeys(_ context.Context, tenantID uuid.UUID) ([]*models.APIKey, error) {
+func (s *adminMockStore) ListAPIKeysPaged(_ context.Context, tenantID uuid.UUID, page, limit int, opts ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
 	if s.listErr != nil {
-		return nil, s.listErr
+		return nil, 0, s.listErr
 	}
+	includeRevoked := store.ResolveAPIKeyListOptions(opts...).IncludeRevoked
 	var out []*models.APIKey
 	for _, k := range s.keys {
-		if k.TenantID == tenantID {
+		if k.TenantID == tenantID && (includeRevoked || k.DeletedAt == nil) {
 			out = append(out, k)
 		}
 	}
-	return out, nil
+	total := len(out)
+	start := min((page-1)*limit, total)
+	return out[start:min(start+limit, total)], total, nil
+}
+
+func (s *adminMockStore) RevokeAllAPIKeys(_ context.Context, tenantID uuid.UUID, keep ...uuid.UUID) (int, error) {
+	if s.revokeErr != nil {
+		return 0, s.revokeErr
+	}
+	n := 0
+	for _, k := range s.keys {
+		if k.TenantID == tenantID && k.DeletedAt == nil && !slices.Contains(keep, k.ID) {
+			now := time.Now()
+			k.DeletedAt = &now
+			n++
+		}
+	}
+	return n, nil
 }
 
 func (s *adminMockStore) RevokeAPIKey(_ context.Context, id uuid.UUID, tenantID uuid.UUID) error {
@@ -283,6 +305,48 @@ func TestListKeysHandler_Success(t *testing.T) {
 	}
 }
 
+func TestListKeysHandler_IncludeRevoked(t *testing.T) {
+	tenantID := uuid.New()
+	revokedAt := time.Now()
+	st := &adminMockStore{
+		keys: []*models.APIKey{
+			{ID: uuid.New(), TenantID: tenantID, Name: "active", KeyPrefix: "lhk_a", CreatedAt: time.Now()},
+			{ID: uuid.New(), TenantID: tenantID, Name: "revoked", KeyPrefix: "lhk_r", CreatedAt: time.Now(), DeletedAt: &revokedAt},
+		},
+	}
+	handler := NewListKeysHandler(st)
+
+	for _, tc := range []struct {
+		query  string
+		active []bool
+	}{
+		{"", []bool{true}},
+		{"?include_revoked=true", []bool{true, false}},
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/admin/keys"+tc.query, nil)
+		req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d", tc.query, rr.Code)
+		}
+		data := parseJSON(t, rr)["data"].([]any)
+		if len(data) != len(tc.active) {
+			t.Fatalf("%q: expected %d keys, got %d", tc.query, len(tc.active), len(data))
+		}
+		for i, want := range tc.active {
+			key := data[i].(map[string]any)
+			if key["active"] != want {
+				t.Errorf("%q: key %d: expected active=%v, got %v", tc.query, i, want, key["active"])
+			}
+			if _, ok := key["revoked_at"]; ok == want {
+				t.Errorf("%q: key %d: revoked_at present=%v for active=%v", tc.query, i, ok, want)
+			}
+		}
+	}
+}
+
 func TestListKeysHandler_NoTenant(t *testing.T) {
 	handler := NewListKeysHandler(&adminMockStore{})
 
@@ -321,6 +385,51 @@ func TestListKeysHandler_FiltersByTenant(t *testing.T) {
 	}
 }
 
+func TestListKeysHandler_Pagination(t *testing.T) {
+	tenantID := uuid.New()
+	st := &adminMockStore{}
+	for i := 0; i < 5; i++ {
+		st.keys = append(st.keys, &models.APIKey{ID: uuid.New(), TenantID: tenantID, Name: fmt.Sprintf("key-%d", i)})
+	}
+
+	handler := NewListKeysHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/keys?page=3&limit=2", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	resp := parseJSON(t, rr)
+	data := resp["data"].([]any)
+	if len(data) != 1 || data[0].(map[string]any)["name"] != "key-4" {
+		t.Fatalf("expected only key-4 on page 3, got %v", data)
+	}
+	meta := resp["meta"].(map[string]any)
+	if meta["page"] != float64(3) || meta["limit"] != float64(2) || meta["total"] != float64(5) || meta["has_next"] != false {
+		t.Errorf("unexpected meta: %v", meta)
+	}
+}
+
+func TestListKeysHandler_DefaultPagination(t *testing.T) {
+	tenantID := uuid.New()
+	st := &adminMockStore{keys: []*models.APIKey{{ID: uuid.New(), TenantID: tenantID, Name: "only"}}}
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/keys", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	NewListKeysHandler(st).ServeHTTP(rr, req)
+
+	meta := parseJSON(t, rr)["meta"].(map[string]any)
+	if meta["page"] != float64(1) || meta["limit"] != float64(20) || meta["has_next"] != false {
+		t.Errorf("unexpected default meta: %v", meta)
+	}
+}
+
 // --- RevokeKeyHandler tests ---
 
 func TestRevokeKeyHandler_Success(t *testing.T) {
@@ -424,3 +533,128 @@ func TestRevokeKeyHandler_NoTenant(t *testing.T) {
 		t.Fatalf("expected 401, got %d", rr.Code)
 	}
 }
+
+func TestRevokeAllKeysHandler_KeepsCallerKey(t *testing.T) {
+	tenantID := uuid.New()
+	callerID := uuid.New()
+	otherTenantKey := &models.APIKey{ID: uuid.New(), TenantID: uuid.New(), Name: "elsewhere"}
+	st := &adminMockStore{keys: []*models.APIKey{
+		{ID: callerID, TenantID: tenantID, Name: "admin"},
+		{ID: uuid.New(), TenantID: tenantID, Name: "ci"},
+		{ID: uuid.New(), TenantID: tenantID, Name: "grafana"},
+		otherTenantKey,
+	}}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/keys/revoke-all", nil)
+	ctx := setTenantCtx(req.Context(), tenantID)
+	req = req.WithContext(mw.SetAPIKeyID(ctx, callerID))
+	rr := httptest.NewRecorder()
+	NewRevokeAllKeysHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["revoked"] != float64(2) {
+		t.Errorf("expected 2 revoked, got %v", data["revoked"])
+	}
+	if data["kept_key_id"] != callerID.String() {
+		t.Errorf("expected kept_key_id %s, got %v", callerID, data["kept_key_id"])
+	}
+	if st.keys[0].DeletedAt != nil {
+		t.Error("expected the caller's key to survive")
+	}
+	if otherTenantKey.DeletedAt != nil {
+		t.Error("expected other tenants' keys to survive")
+	}
+}
+
+func TestRevokeAllKeysHandler_StoreError(t *testing.T) {
+	st := &adminMockStore{revokeErr: errors.New("db down")}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/keys/revoke-all", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+	NewRevokeAllKeysHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+type migrationMockStore struct {
+	status *store.MigrationStatus
+	err    error
+}
+
+func (s *migrationMockStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) {
+	return s.status, s.err
+}
+
+func TestMigrationStatusHandler_Success(t *testing.T) {
+	st := &migrationMockStore{status: &store.MigrationStatus{Version: 13, Dirty: false}}
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/migrations", nil)
+	rr := httptest.NewRecorder()
+	NewMigrationStatusHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["version"] != float64(13) {
+		t.Errorf("expected version 13, got %v", data["version"])
+	}
+	if data["dirty"] != false {
+		t.Errorf("expected dirty false, got %v", data["dirty"])
+	}
+}
+
+func TestMigrationStatusHandler_StoreError(t *testing.T) {
+	st := &migrationMockStore{err: errors.New("db down")}
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/migrations", nil)
+	rr := httptest.NewRecorder()
+	NewMigrationStatusHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+type pruneMockStore struct {
+	deleted int
+	err     error
+}
+
+func (s *pruneMockStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return s.deleted, s.err
+}
+
+func TestPruneOrphanedResultsHandler_Success(t *testing.T) {
+	st := &pruneMockStore{deleted: 3}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/maintenance/prune-orphaned-results", nil)
+	rr := httptest.NewRecorder()
+	NewPruneOrphanedResultsHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["deleted"] != float64(3) {
+		t.Errorf("expected deleted 3, got %v", data["deleted"])
+	}
+}
+
+func TestPruneOrphanedResultsHandler_StoreError(t *testing.T) {
+	st := &pruneMockStore{err: errors.New("db down")}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/maintenance/prune-orphaned-results", nil)
+	rr := httptest.NewRecorder()
+	NewPruneOrphanedResultsHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
@@ -2,30 +2,84 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/ai"
 	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
 	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
 // AnalysisClusterGetter retrieves a cluster for validation before triggering analysis.
 type AnalysisClusterGetter interface {
+	ClusterExistenceChecker
 	GetErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error)
 }
 
-// AnalysisTrigger starts an async analysis job for a cluster.
+// AnalysisTrigger starts an async analysis job for a cluster, or runs one inline.
 type AnalysisTrigger interface {
 	TriggerAnalysis(ctx context.Context, cluster *models.ErrorCluster) (*models.Job, error)
+	AnalyzeSync(ctx context.Context, cluster *models.ErrorCluster) (*models.AnalysisResult, error)
+	AnalyzeWithLogs(ctx context.Context, cluster *models.ErrorCluster, logs []models.LogLine) (*models.Job, error)
+}
+
+// AnalysisEstimator estimates the prompt an analysis of a cluster would send.
+type AnalysisEstimator interface {
+	EstimateAnalysis(ctx context.Context, cluster *models.ErrorCluster) (ai.TokenEstimate, error)
+}
+
+// AnalysisReplayer starts a fresh analysis job that re-runs an earlier one.
+type AnalysisReplayer interface {
+	ReplayAnalysis(ctx context.Context, cluster *models.ErrorCluster, originalJobID uuid.UUID) (*models.Job, error)
+}
+
+// JobCanceller stops a pending or running analysis job.
+type JobCanceller interface {
+	CancelJob(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) error
+}
+
+// ReplayJobGetter is the store interface needed by NewReplayAnalysisHandler.
+type ReplayJobGetter interface {
+	JobExistenceChecker
+	GetJob(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.Job, error)
+	GetErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error)
 }
 
 // JobPoller is the store interface needed by NewPollJobHandler.
 type JobPoller interface {
+	JobExistenceChecker
 	GetJob(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.Job, error)
-	GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID) (*models.AnalysisResult, error)
+	GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+}
+
+// BulkJobPoller is the store interface needed by NewBulkPollJobsHandler.
+type BulkJobPoller interface {
+	GetJobsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*models.Job, error)
+	GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+}
+
+// AnalysisResultGetter is the store interface needed by NewGetAnalysisHandler.
+type AnalysisResultGetter interface {
+	GetAnalysisResultByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+}
+
+// maxPollJobIDs caps the job IDs accepted by one bulk poll request.
+const maxPollJobIDs = 100
+
+// maxProvidedLogs caps the log lines accepted by one analyze request.
+const maxProvidedLogs = 1000
+
+// JobContextGetter is the store interface needed by NewJobLogsHandler.
+type JobContextGetter interface {
+	JobExistenceChecker
+	GetJob(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.Job, error)
+	GetAnalysisContext(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisContext, error)
 }
 
 // JobStatusCache provides a fast path for checking job status.
@@ -34,7 +88,12 @@ type JobStatusCache interface {
 }
 
 // NewAnalyzeHandler returns an http.HandlerFunc for POST /api/v1/analyze.
-func NewAnalyzeHandler(st AnalysisClusterGetter, trigger AnalysisTrigger) http.HandlerFunc {
+// With ?sync=true the analysis runs inline and the result is returned directly.
+// An optional model overrides the provider's default for this analysis, and an
+// optional logs array is analyzed in place of the lines LogHunter would fetch
+// from Loki.
+func NewAnalyzeHandler(st AnalysisClusterGetter, trigger AnalysisTrigger, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -43,26 +102,67 @@ func NewAnalyzeHandler(st AnalysisClusterGetter, trigger AnalysisTrigger) http.H
 		}
 
 		var req struct {
-			ClusterID string `json:"cluster_id"`
+			ClusterID string           `json:"cluster_id" validate:"required,uuid"`
+			Model     string           `json:"model"`
+			Logs      []models.LogLine `json:"logs"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON body", nil)
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
 			return
 		}
 
-		clusterID, err := uuid.Parse(req.ClusterID)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_CLUSTER_ID", "Invalid cluster_id format", nil)
+		if errs := validate(&req); errs != nil {
+			validationError(w, errs)
 			return
 		}
+		sync := r.URL.Query().Get("sync") == "true"
+		if len(req.Logs) > maxProvidedLogs {
+			validationError(w, map[string]string{
+				"logs": fmt.Sprintf("logs must contain %d entries or fewer", maxProvidedLogs),
+			})
+			return
+		}
+		if len(req.Logs) > 0 && sync {
+			validationError(w, map[string]string{"logs": "logs cannot be combined with sync=true"})
+			return
+		}
+		clusterID, _ := uuid.Parse(req.ClusterID)
 
 		cluster, err := st.GetErrorCluster(r.Context(), clusterID, tenantID)
 		if err != nil {
-			response.Error(w, http.StatusNotFound, "CLUSTER_NOT_FOUND", "Cluster not found", nil)
+			o.clusterNotFound(w, r, st, clusterID, "Cluster not found")
+			return
+		}
+
+		ctx := ai.WithModel(r.Context(), req.Model)
+
+		if len(req.Logs) > 0 {
+			job, err := trigger.AnalyzeWithLogs(ctx, cluster, req.Logs)
+			if err != nil {
+				status, code, msg := mapError(err)
+				response.Error(w, status, code, msg, nil)
+				return
+			}
+			response.Accepted(w, map[string]string{"job_id": job.ID.String()})
 			return
 		}
 
-		job, err := trigger.TriggerAnalysis(r.Context(), cluster)
+		if sync {
+			ar, err := trigger.AnalyzeSync(ctx, cluster)
+			if err != nil {
+				status, code, msg := mapError(err)
+				response.Error(w, status, code, msg, nil)
+				return
+			}
+			response.JSON(w, map[string]any{
+				"job_id": ar.JobID.String(),
+				"status": models.JobStatusCompleted,
+				"result": analysisResultBody(ar),
+			})
+			return
+		}
+
+		job, err := trigger.TriggerAnalysis(ctx, cluster)
 		if err != nil {
 			status, code, msg := mapError(err)
 			response.Error(w, status, code, msg, nil)
@@ -73,8 +173,105 @@ func NewAnalyzeHandler(st AnalysisClusterGetter, trigger AnalysisTrigger) http.H
 	}
 }
 
+// NewEstimateAnalysisHandler returns an http.HandlerFunc for POST /api/v1/analyze/estimate.
+// It reports the prompt tokens, and cost when pricing is configured, that
+// analyzing the cluster would use, without calling the provider.
+func NewEstimateAnalysisHandler(st AnalysisClusterGetter, est AnalysisEstimator, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		var req struct {
+			ClusterID string `json:"cluster_id" validate:"required,uuid"`
+			Model     string `json:"model"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+
+		if errs := validate(&req); errs != nil {
+			validationError(w, errs)
+			return
+		}
+		clusterID, _ := uuid.Parse(req.ClusterID)
+
+		cluster, err := st.GetErrorCluster(r.Context(), clusterID, tenantID)
+		if err != nil {
+			o.clusterNotFound(w, r, st, clusterID, "Cluster not found")
+			return
+		}
+
+		estimate, err := est.EstimateAnalysis(ai.WithModel(r.Context(), req.Model), cluster)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.JSON(w, estimate)
+	}
+}
+
+// NewReplayAnalysisHandler returns an http.HandlerFunc for POST /api/v1/analyze/{jobID}/replay.
+// It re-runs analysis of the job's cluster as a new job linked to the original.
+func NewReplayAnalysisHandler(st ReplayJobGetter, replayer AnalysisReplayer, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_JOB_ID", "Invalid job ID format", nil)
+			return
+		}
+
+		job, err := st.GetJob(r.Context(), jobID, tenantID)
+		if err != nil {
+			o.jobNotFound(w, r, st, jobID)
+			return
+		}
+		if job.ClusterID == nil {
+			response.Error(w, http.StatusNotFound, "CLUSTER_NOT_FOUND", "The job's cluster no longer exists", nil)
+			return
+		}
+
+		cluster, err := st.GetErrorCluster(r.Context(), *job.ClusterID, tenantID)
+		if errors.Is(err, store.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "CLUSTER_NOT_FOUND", "The job's cluster no longer exists", nil)
+			return
+		}
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		replay, err := replayer.ReplayAnalysis(r.Context(), cluster, job.ID)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.Accepted(w, map[string]string{
+			"job_id":        replay.ID.String(),
+			"replayed_from": job.ID.String(),
+		})
+	}
+}
+
 // NewPollJobHandler returns an http.HandlerFunc for GET /api/v1/analyze/{jobID}.
-func NewPollJobHandler(st JobPoller, cache JobStatusCache) http.HandlerFunc {
+func NewPollJobHandler(st JobPoller, cache JobStatusCache, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -91,33 +288,246 @@ func NewPollJobHandler(st JobPoller, cache JobStatusCache) http.HandlerFunc {
 
 		job, err := st.GetJob(r.Context(), jobID, tenantID)
 		if err != nil {
-			response.Error(w, http.StatusNotFound, "JOB_NOT_FOUND", "Job not found", nil)
+			o.jobNotFound(w, r, st, jobID)
 			return
 		}
 
-		// Check cache for a more recent status
-		status := job.Status
-		if cachedStatus, found, err := cache.GetJobStatus(r.Context(), jobID); err == nil && found {
-			status = cachedStatus
+		response.JSON(w, jobStatusBody(r.Context(), st, cache, tenantID, job))
+	}
+}
+
+// NewCancelJobHandler returns an http.HandlerFunc for DELETE /api/v1/analyze/{jobID}.
+// The job is marked cancelled with error code CANCELLED and kept for polling; a
+// job that already finished is left alone with 409 JOB_FINISHED. jobs is only
+// consulted in 403 cross-tenant mode and may be nil.
+func NewCancelJobHandler(svc JobCanceller, jobs JobExistenceChecker, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_JOB_ID", "Invalid job ID format", nil)
+			return
+		}
+
+		if err := svc.CancelJob(r.Context(), jobID, tenantID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				o.jobNotFound(w, r, jobs, jobID)
+				return
+			}
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.NoContent(w)
+	}
+}
+
+// NewBulkPollJobsHandler returns an http.HandlerFunc for POST /api/v1/analyze/poll.
+// Each entry has the same shape as GET /api/v1/analyze/{jobID}. Job IDs that
+// do not exist or belong to another tenant are omitted from the response.
+func NewBulkPollJobsHandler(st BulkJobPoller, cache JobStatusCache, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		var req struct {
+			JobIDs []string `json:"job_ids"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if len(req.JobIDs) == 0 {
+			validationError(w, map[string]string{"job_ids": "job_ids is required"})
+			return
+		}
+		if len(req.JobIDs) > maxPollJobIDs {
+			validationError(w, map[string]string{
+				"job_ids": fmt.Sprintf("job_ids must contain %d entries or fewer", maxPollJobIDs),
+			})
+			return
 		}
 
-		result := map[string]any{
-			"job_id": job.ID.String(),
-			"status": status,
+		ids := make([]uuid.UUID, 0, len(req.JobIDs))
+		for _, s := range req.JobIDs {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "INVALID_JOB_ID", "Invalid job ID format", map[string]string{"job_id": s})
+				return
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+
+		jobs, err := st.GetJobsByIDs(r.Context(), tenantID, ids)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
 		}
 
-		if status == models.JobStatusCompleted {
-			if ar, err := st.GetAnalysisResultByJobID(r.Context(), jobID); err == nil {
-				result["result"] = map[string]any{
-					"root_cause": ar.RootCause,
-					"confidence": ar.Confidence,
-					"summary":    ar.Summary,
-					"provider":   ar.Provider,
-					"model":      ar.Model,
-				}
+		// Answer in request order.
+		byID := make(map[uuid.UUID]*models.Job, len(jobs))
+		for _, j := range jobs {
+			byID[j.ID] = j
+		}
+		out := make([]map[string]any, 0, len(jobs))
+		for _, id := range ids {
+			if job, ok := byID[id]; ok {
+				out = append(out, jobStatusBody(r.Context(), st, cache, tenantID, job))
 			}
 		}
 
-		response.JSON(w, result)
+		response.JSON(w, out)
+	}
+}
+
+// analysisResultGetter looks up the result of a completed job.
+type analysisResultGetter interface {
+	GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+}
+
+// jobStatusBody is the JSON shape of a polled job. The cached status is
+// preferred over the stored one, as it may be more recent, except for a stored
+// cancellation: a worker still running the job may cache a later status.
+func jobStatusBody(ctx context.Context, st analysisResultGetter, cache JobStatusCache, tenantID uuid.UUID, job *models.Job) map[string]any {
+	status := job.Status
+	if status != models.JobStatusCancelled {
+		if cachedStatus, found, err := cache.GetJobStatus(ctx, job.ID); err == nil && found {
+			status = cachedStatus
+		}
+	}
+
+	result := map[string]any{
+		"job_id": job.ID.String(),
+		"status": status,
+	}
+	if job.ReplayedFrom != nil {
+		result["replayed_from"] = job.ReplayedFrom.String()
+	}
+	if len(job.Metadata) > 0 {
+		result["metadata"] = job.Metadata
+	}
+
+	if status == models.JobStatusFailed {
+		if job.ErrorCode != nil {
+			result["error_code"] = *job.ErrorCode
+		}
+		if job.ErrorMessage != nil {
+			result["error_message"] = *job.ErrorMessage
+		}
+	}
+
+	if status == models.JobStatusCompleted {
+		if ar, err := st.GetAnalysisResultByJobID(ctx, job.ID, tenantID); err == nil {
+			result["result"] = analysisResultBody(ar)
+		}
+	}
+
+	return result
+}
+
+// NewJobLogsHandler returns an http.HandlerFunc for GET /api/v1/analyze/{jobID}/logs.
+// It returns the sample of context logs that was sent to the AI provider.
+func NewJobLogsHandler(st JobContextGetter, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_JOB_ID", "Invalid job ID format", nil)
+			return
+		}
+
+		if _, err := st.GetJob(r.Context(), jobID, tenantID); err != nil {
+			o.jobNotFound(w, r, st, jobID)
+			return
+		}
+
+		ac, err := st.GetAnalysisContext(r.Context(), jobID, tenantID)
+		if errors.Is(err, store.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "CONTEXT_NOT_FOUND", "No context logs recorded for this job", nil)
+			return
+		}
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.JSON(w, map[string]any{
+			"job_id":      ac.JobID.String(),
+			"total_lines": ac.TotalLines,
+			"truncated":   len(ac.Lines) < ac.TotalLines,
+			"lines":       ac.Lines,
+		})
+	}
+}
+
+// NewGetAnalysisHandler returns an http.HandlerFunc for GET /api/v1/analyses/{analysisID}.
+// It fetches a result by its own ID, for links that name one analysis rather
+// than its job or cluster. The body is analysisResultBody plus the IDs that
+// place the result.
+func NewGetAnalysisHandler(st AnalysisResultGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		analysisID, err := uuid.Parse(chi.URLParam(r, "analysisID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_ANALYSIS_ID", "Invalid analysis ID", nil)
+			return
+		}
+
+		ar, err := st.GetAnalysisResultByID(r.Context(), analysisID, tenantID)
+		if errors.Is(err, store.ErrNotFound) {
+			response.Error(w, http.StatusNotFound, "ANALYSIS_NOT_FOUND", "Analysis not found", nil)
+			return
+		}
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		body := analysisResultBody(ar)
+		body["id"] = ar.ID.String()
+		body["job_id"] = ar.JobID.String()
+		body["cluster_id"] = ar.ClusterID.String()
+		body["created_at"] = ar.CreatedAt
+		response.JSON(w, body)
+	}
+}
+
+// analysisResultBody is the JSON shape of a completed analysis result.
+func analysisResultBody(ar *models.AnalysisResult) map[string]any {
+	return map[string]any{
+		"root_cause":         ar.RootCause,
+		"confidence":         ar.Confidence,
+		"confidence_clamped": ar.ConfidenceClamped,
+		"summary":            ar.Summary,
+		"provider":           ar.Provider,
+		"model":              ar.Model,
+		"suggested_action":   ar.SuggestedAction,
 	}
 }
@@ -2,13 +2,19 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/ai"
+	"github.com/kiranshivaraju/loghunter/internal/ai/shared"
 	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
@@ -25,6 +31,13 @@ type analysisMockStore struct {
 	analysisResult    *models.AnalysisResult
 	analysisResultErr error
 
+	analysisContext *models.AnalysisContext
+
+	// jobs and results back the bulk poll lookups.
+	jobs            []*models.Job
+	results         map[uuid.UUID]*models.AnalysisResult
+	capturedJobIDs  []uuid.UUID
+
 	createdJob *models.Job
 }
 
@@ -48,16 +61,60 @@ func (s *analysisMockStore) GetJob(_ context.Context, id uuid.UUID, tenantID uui
 	return nil, store.ErrNotFound
 }
 
-func (s *analysisMockStore) GetAnalysisResultByJobID(_ context.Context, jobID uuid.UUID) (*models.AnalysisResult, error) {
+func (s *analysisMockStore) ErrorClusterExists(_ context.Context, id uuid.UUID) (bool, error) {
+	return s.cluster != nil && s.cluster.ID == id, nil
+}
+
+func (s *analysisMockStore) JobExists(_ context.Context, id uuid.UUID) (bool, error) {
+	return s.job != nil && s.job.ID == id, nil
+}
+
+func (s *analysisMockStore) GetAnalysisResultByJobID(_ context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
+	if s.analysisResultErr != nil {
+		return nil, s.analysisResultErr
+	}
+	if s.analysisResult != nil && s.analysisResult.JobID == jobID && s.analysisResult.TenantID == tenantID {
+		return s.analysisResult, nil
+	}
+	if ar, ok := s.results[jobID]; ok && ar.TenantID == tenantID {
+		return ar, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *analysisMockStore) GetAnalysisResultByID(_ context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
 	if s.analysisResultErr != nil {
 		return nil, s.analysisResultErr
 	}
-	if s.analysisResult != nil && s.analysisResult.JobID == jobID {
+	if s.analysisResult != nil && s.analysisResult.ID == id && s.analysisResult.TenantID == tenantID {
 		return s.analysisResult, nil
 	}
 	return nil, store.ErrNotFound
 }
 
+func (s *analysisMockStore) GetJobsByIDs(_ context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*models.Job, error) {
+	s.capturedJobIDs = ids
+	if s.jobErr != nil {
+		return nil, s.jobErr
+	}
+	var out []*models.Job
+	// Reverse order so the handler must restore request order itself.
+	for i := len(s.jobs) - 1; i >= 0; i-- {
+		j := s.jobs[i]
+		if j.TenantID == tenantID && slices.Contains(ids, j.ID) {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+func (s *analysisMockStore) GetAnalysisContext(_ context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisContext, error) {
+	if s.analysisContext != nil && s.analysisContext.JobID == jobID && s.analysisContext.TenantID == tenantID {
+		return s.analysisContext, nil
+	}
+	return nil, store.ErrNotFound
+}
+
 func (s *analysisMockStore) CreateJob(_ context.Context, job *models.Job) error {
 	s.createdJob = job
 	return nil
@@ -66,13 +123,49 @@ func (s *analysisMockStore) CreateJob(_ context.Context, job *models.Job) error
 // --- mock analysis trigger ---
 
 type mockAnalysisTrigger struct {
-	triggered bool
-	job       *models.Job
-	err       error
+	triggered  bool
+	job        *models.Job
+	err        error
+	syncCalled bool
+	syncResult *models.AnalysisResult
+	logs       []models.LogLine
+
+	replayedCluster *models.ErrorCluster
+	replayedFrom    uuid.UUID
+
+	ctx context.Context
 }
 
-func (m *mockAnalysisTrigger) TriggerAnalysis(_ context.Context, cluster *models.ErrorCluster) (*models.Job, error) {
+func (m *mockAnalysisTrigger) TriggerAnalysis(ctx context.Context, cluster *models.ErrorCluster) (*models.Job, error) {
 	m.triggered = true
+	m.ctx = ctx
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.job, nil
+}
+
+func (m *mockAnalysisTrigger) AnalyzeSync(ctx context.Context, cluster *models.ErrorCluster) (*models.AnalysisResult, error) {
+	m.syncCalled = true
+	m.ctx = ctx
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.syncResult, nil
+}
+
+func (m *mockAnalysisTrigger) AnalyzeWithLogs(ctx context.Context, cluster *models.ErrorCluster, logs []models.LogLine) (*models.Job, error) {
+	m.logs = logs
+	m.ctx = ctx
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.job, nil
+}
+
+func (m *mockAnalysisTrigger) ReplayAnalysis(_ context.Context, cluster *models.ErrorCluster, originalJobID uuid.UUID) (*models.Job, error) {
+	m.replayedCluster = cluster
+	m.replayedFrom = originalJobID
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -128,6 +221,84 @@ func TestAnalyzeHandler_Success(t *testing.T) {
 	}
 }
 
+func TestAnalyzeHandler_ProvidedLogs(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	jobID := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+	}
+	trigger := &mockAnalysisTrigger{
+		job: &models.Job{ID: jobID, TenantID: tenantID, Status: models.JobStatusPending},
+	}
+
+	handler := NewAnalyzeHandler(st, trigger)
+
+	body := jsonBody(t, map[string]any{
+		"cluster_id": clusterID.String(),
+		"logs": []map[string]any{
+			{"timestamp": "2026-01-02T03:04:05Z", "message": "build failed", "level": "error"},
+		},
+	})
+	req := httptest.NewRequest("POST", "/api/v1/analyze", body)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if trigger.triggered {
+		t.Error("TriggerAnalysis must not be called when logs are provided")
+	}
+	if len(trigger.logs) != 1 || trigger.logs[0].Message != "build failed" || trigger.logs[0].Level != "error" {
+		t.Errorf("unexpected logs passed to AnalyzeWithLogs: %+v", trigger.logs)
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["job_id"] != jobID.String() {
+		t.Errorf("expected job_id %s, got %v", jobID, data["job_id"])
+	}
+}
+
+func TestAnalyzeHandler_ProvidedLogsInvalid(t *testing.T) {
+	oneLog := []map[string]any{{"timestamp": "2026-01-02T03:04:05Z", "message": "x"}}
+	tooMany := make([]map[string]any, maxProvidedLogs+1)
+	for i := range tooMany {
+		tooMany[i] = oneLog[0]
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		logs  []map[string]any
+	}{
+		{"too many", "", tooMany},
+		{"with sync", "?sync=true", oneLog},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := &mockAnalysisTrigger{}
+			handler := NewAnalyzeHandler(&analysisMockStore{}, trigger)
+
+			body := jsonBody(t, map[string]any{"cluster_id": uuid.New().String(), "logs": tt.logs})
+			req := httptest.NewRequest("POST", "/api/v1/analyze"+tt.query, body)
+			req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if trigger.logs != nil || trigger.syncCalled {
+				t.Error("trigger must not be called for an invalid request")
+			}
+		})
+	}
+}
+
 func TestAnalyzeHandler_InvalidClusterID(t *testing.T) {
 	handler := NewAnalyzeHandler(&analysisMockStore{}, &mockAnalysisTrigger{})
 
@@ -211,6 +382,57 @@ func TestAnalyzeHandler_WrongTenant(t *testing.T) {
 	}
 }
 
+func TestAnalyzeHandler_ModelOverride(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID},
+	}
+	trigger := &mockAnalysisTrigger{job: &models.Job{ID: uuid.New(), TenantID: tenantID}}
+
+	handler := NewAnalyzeHandler(st, trigger)
+	body := jsonBody(t, map[string]any{"cluster_id": clusterID.String(), "model": "strong-model"})
+	req := httptest.NewRequest("POST", "/api/v1/analyze", body)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := shared.ModelFromContext(trigger.ctx, ""); got != "strong-model" {
+		t.Errorf("expected model strong-model to reach the trigger, got %q", got)
+	}
+}
+
+func TestAnalyzeHandler_ModelNotAllowed(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID},
+	}
+	trigger := &mockAnalysisTrigger{err: fmt.Errorf("%w: %q", ai.ErrModelNotAllowed, "gpt-huge")}
+
+	handler := NewAnalyzeHandler(st, trigger)
+	body := jsonBody(t, map[string]any{"cluster_id": clusterID.String(), "model": "gpt-huge"})
+	req := httptest.NewRequest("POST", "/api/v1/analyze", body)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	errObj := parseJSON(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "MODEL_NOT_ALLOWED" {
+		t.Errorf("expected MODEL_NOT_ALLOWED, got %v", errObj["code"])
+	}
+}
+
 func TestAnalyzeHandler_TriggerError(t *testing.T) {
 	tenantID := uuid.New()
 	clusterID := uuid.New()
@@ -234,8 +456,109 @@ func TestAnalyzeHandler_TriggerError(t *testing.T) {
 	}
 }
 
+func TestAnalyzeHandler_Sync(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	jobID := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+	}
+	trigger := &mockAnalysisTrigger{
+		syncResult: &models.AnalysisResult{JobID: jobID, RootCause: "db down", Confidence: 0.9, Provider: "mock"},
+	}
+
+	handler := NewAnalyzeHandler(st, trigger)
+
+	body := jsonBody(t, map[string]any{"cluster_id": clusterID.String()})
+	req := httptest.NewRequest("POST", "/api/v1/analyze?sync=true", body)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !trigger.syncCalled || trigger.triggered {
+		t.Error("expected AnalyzeSync, not TriggerAnalysis, to be called")
+	}
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["job_id"] != jobID.String() || data["status"] != models.JobStatusCompleted {
+		t.Errorf("unexpected job fields: %v", data)
+	}
+	result := data["result"].(map[string]any)
+	if result["root_cause"] != "db down" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+func TestAnalyzeHandler_SyncProviderError(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID},
+	}
+	trigger := &mockAnalysisTrigger{err: ai.ErrInferenceTimeout}
+
+	handler := NewAnalyzeHandler(st, trigger)
+
+	body := jsonBody(t, map[string]any{"cluster_id": clusterID.String()})
+	req := httptest.NewRequest("POST", "/api/v1/analyze?sync=true", body)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", rr.Code)
+	}
+	if code := parseJSON(t, rr)["error"].(map[string]any)["code"]; code != "AI_INFERENCE_TIMEOUT" {
+		t.Errorf("expected AI_INFERENCE_TIMEOUT, got %v", code)
+	}
+}
+
 // --- PollJob (GET) tests ---
 
+func TestPollJobHandler_IncludesMetadata(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+
+	st := &analysisMockStore{
+		job: &models.Job{
+			ID:       jobID,
+			TenantID: tenantID,
+			Type:     models.JobTypeAnalysis,
+			Status:   models.JobStatusPending,
+			Metadata: map[string]string{models.JobMetaFingerprint: "fp-1", models.JobMetaModel: "strong-model"},
+		},
+	}
+	handler := NewPollJobHandler(st, &analysisMockCache{found: false})
+
+	req := httptest.NewRequest("GET", "/api/v1/analyze/"+jobID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("jobID", jobID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	meta, ok := data["metadata"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected metadata object, got %v", data["metadata"])
+	}
+	if meta["fingerprint"] != "fp-1" || meta["model"] != "strong-model" {
+		t.Errorf("unexpected metadata: %v", meta)
+	}
+}
+
 func TestPollJobHandler_Completed(t *testing.T) {
 	tenantID := uuid.New()
 	jobID := uuid.New()
@@ -283,6 +606,71 @@ func TestPollJobHandler_Completed(t *testing.T) {
 	if result["root_cause"] != "Null pointer in handler" {
 		t.Errorf("expected root_cause, got %v", result["root_cause"])
 	}
+	if result["confidence_clamped"] != false {
+		t.Errorf("expected confidence_clamped false, got %v", result["confidence_clamped"])
+	}
+}
+
+func TestPollJobHandler_ReportsClampedConfidence(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+
+	st := &analysisMockStore{
+		job: &models.Job{ID: jobID, TenantID: tenantID, Status: models.JobStatusCompleted},
+		analysisResult: &models.AnalysisResult{
+			JobID: jobID, TenantID: tenantID, RootCause: "OOM",
+			Confidence: 1.0, ConfidenceClamped: true,
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/analyze/"+jobID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("jobID", jobID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	NewPollJobHandler(st, &analysisMockCache{}).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	result := parseJSON(t, rr)["data"].(map[string]any)["result"].(map[string]any)
+	if result["confidence_clamped"] != true {
+		t.Errorf("expected confidence_clamped true, got %v", result["confidence_clamped"])
+	}
+}
+
+func TestPollJobHandler_CancelledWinsOverCache(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+
+	st := &analysisMockStore{
+		job: &models.Job{ID: jobID, TenantID: tenantID, Status: models.JobStatusCancelled},
+	}
+	cache := &analysisMockCache{status: models.JobStatusRunning, found: true}
+
+	handler := NewPollJobHandler(st, cache)
+
+	req := httptest.NewRequest("GET", "/api/v1/analyze/"+jobID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("jobID", jobID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["status"] != models.JobStatusCancelled {
+		t.Errorf("expected status 'cancelled', got %v", data["status"])
+	}
+	if _, ok := data["result"]; ok {
+		t.Error("expected no result for a cancelled job")
+	}
 }
 
 func TestPollJobHandler_Running(t *testing.T) {
@@ -473,6 +861,7 @@ func TestPollJobHandler_Failed(t *testing.T) {
 	tenantID := uuid.New()
 	jobID := uuid.New()
 	errMsg := "AI provider timed out"
+	errCode := models.JobErrorAITimeout
 
 	st := &analysisMockStore{
 		job: &models.Job{
@@ -480,6 +869,7 @@ func TestPollJobHandler_Failed(t *testing.T) {
 			TenantID:     tenantID,
 			Status:       models.JobStatusFailed,
 			ErrorMessage: &errMsg,
+			ErrorCode:    &errCode,
 		},
 	}
 	cache := &analysisMockCache{found: false}
@@ -504,6 +894,12 @@ func TestPollJobHandler_Failed(t *testing.T) {
 	if data["status"] != "failed" {
 		t.Errorf("expected status 'failed', got %v", data["status"])
 	}
+	if data["error_code"] != "AI_TIMEOUT" {
+		t.Errorf("expected error_code 'AI_TIMEOUT', got %v", data["error_code"])
+	}
+	if data["error_message"] != errMsg {
+		t.Errorf("expected error_message %q, got %v", errMsg, data["error_message"])
+	}
 }
 
 // --- Helper to verify timestamps parse correctly ---
@@ -538,3 +934,624 @@ func TestPollJobHandler_TimestampsIncluded(t *testing.T) {
 		t.Fatalf("expected 200, got %d", rr.Code)
 	}
 }
+
+// --- job logs tests ---
+
+func jobLogsRequest(jobID string, tenantID uuid.UUID) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/analyze/"+jobID+"/logs", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("jobID", jobID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestJobLogsHandler_CompletedJob(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	st := &analysisMockStore{
+		job: &models.Job{ID: jobID, TenantID: tenantID, Status: models.JobStatusCompleted},
+		analysisContext: &models.AnalysisContext{
+			JobID:    jobID,
+			TenantID: tenantID,
+			Lines: []models.LogLine{
+				{Timestamp: ts, Message: "connection refused", Level: "error"},
+				{Timestamp: ts.Add(time.Second), Message: "retrying", Level: "warn"},
+			},
+			TotalLines: 240,
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	NewJobLogsHandler(st).ServeHTTP(rr, jobLogsRequest(jobID.String(), tenantID))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["job_id"] != jobID.String() {
+		t.Errorf("expected job_id %s, got %v", jobID, data["job_id"])
+	}
+	if data["total_lines"] != float64(240) || data["truncated"] != true {
+		t.Errorf("expected total_lines 240 and truncated, got %v and %v", data["total_lines"], data["truncated"])
+	}
+	lines := data["lines"].([]any)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	first := lines[0].(map[string]any)
+	if first["message"] != "connection refused" || first["timestamp"] != "2024-01-15T10:00:00Z" {
+		t.Errorf("unexpected first line: %v", first)
+	}
+}
+
+func TestJobLogsHandler_NoContextRecorded(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+	st := &analysisMockStore{
+		job: &models.Job{ID: jobID, TenantID: tenantID, Status: models.JobStatusPending},
+	}
+
+	rr := httptest.NewRecorder()
+	NewJobLogsHandler(st).ServeHTTP(rr, jobLogsRequest(jobID.String(), tenantID))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	errObj := parseJSON(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "CONTEXT_NOT_FOUND" {
+		t.Errorf("expected CONTEXT_NOT_FOUND, got %v", errObj["code"])
+	}
+}
+
+func TestJobLogsHandler_WrongTenant(t *testing.T) {
+	tenantA := uuid.New()
+	jobID := uuid.New()
+	st := &analysisMockStore{
+		job:             &models.Job{ID: jobID, TenantID: tenantA, Status: models.JobStatusCompleted},
+		analysisContext: &models.AnalysisContext{JobID: jobID, TenantID: tenantA, TotalLines: 1},
+	}
+
+	rr := httptest.NewRecorder()
+	NewJobLogsHandler(st).ServeHTTP(rr, jobLogsRequest(jobID.String(), uuid.New()))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for wrong tenant, got %d", rr.Code)
+	}
+	errObj := parseJSON(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "JOB_NOT_FOUND" {
+		t.Errorf("expected JOB_NOT_FOUND, got %v", errObj["code"])
+	}
+}
+
+func TestJobLogsHandler_InvalidJobID(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewJobLogsHandler(&analysisMockStore{}).ServeHTTP(rr, jobLogsRequest("not-a-uuid", uuid.New()))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+// --- bulk poll tests ---
+
+func bulkPollRequest(body string, tenantID uuid.UUID) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/analyze/poll", strings.NewReader(body))
+	return req.WithContext(setTenantCtx(req.Context(), tenantID))
+}
+
+func TestBulkPollJobsHandler_ReturnsStatusesInRequestOrder(t *testing.T) {
+	tenantID := uuid.New()
+	completed := &models.Job{ID: uuid.New(), TenantID: tenantID, Status: models.JobStatusCompleted}
+	errCode := models.JobErrorAITimeout
+	failed := &models.Job{ID: uuid.New(), TenantID: tenantID, Status: models.JobStatusFailed, ErrorCode: &errCode}
+	running := &models.Job{ID: uuid.New(), TenantID: tenantID, Status: models.JobStatusRunning}
+
+	st := &analysisMockStore{
+		jobs: []*models.Job{completed, failed, running},
+		results: map[uuid.UUID]*models.AnalysisResult{
+			completed.ID: {JobID: completed.ID, TenantID: tenantID, RootCause: "disk full", Confidence: 0.8},
+		},
+	}
+
+	body := `{"job_ids":["` + running.ID.String() + `","` + completed.ID.String() + `","` + failed.ID.String() + `"]}`
+	rr := httptest.NewRecorder()
+	NewBulkPollJobsHandler(st, &analysisMockCache{}).ServeHTTP(rr, bulkPollRequest(body, tenantID))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].([]any)
+	if len(data) != 3 {
+		t.Fatalf("expected 3 jobs, got %d", len(data))
+	}
+	first, second, third := data[0].(map[string]any), data[1].(map[string]any), data[2].(map[string]any)
+	if first["job_id"] != running.ID.String() || first["status"] != "running" {
+		t.Errorf("unexpected first entry: %v", first)
+	}
+	if second["job_id"] != completed.ID.String() {
+		t.Errorf("expected completed job second, got %v", second["job_id"])
+	}
+	if result, ok := second["result"].(map[string]any); !ok || result["root_cause"] != "disk full" {
+		t.Errorf("expected result on completed job, got %v", second["result"])
+	}
+	if third["error_code"] != models.JobErrorAITimeout {
+		t.Errorf("expected error_code on failed job, got %v", third["error_code"])
+	}
+}
+
+func TestBulkPollJobsHandler_OmitsOtherTenantsJobs(t *testing.T) {
+	tenantA := uuid.New()
+	tenantB := uuid.New()
+	own := &models.Job{ID: uuid.New(), TenantID: tenantA, Status: models.JobStatusPending}
+	foreign := &models.Job{ID: uuid.New(), TenantID: tenantB, Status: models.JobStatusPending}
+	st := &analysisMockStore{jobs: []*models.Job{own, foreign}}
+
+	body := `{"job_ids":["` + own.ID.String() + `","` + foreign.ID.String() + `","` + uuid.NewString() + `"]}`
+	rr := httptest.NewRecorder()
+	NewBulkPollJobsHandler(st, &analysisMockCache{}).ServeHTTP(rr, bulkPollRequest(body, tenantA))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].([]any)
+	if len(data) != 1 || data[0].(map[string]any)["job_id"] != own.ID.String() {
+		t.Errorf("expected only tenant A's job, got %v", data)
+	}
+}
+
+func TestBulkPollJobsHandler_DeduplicatesIDs(t *testing.T) {
+	tenantID := uuid.New()
+	job := &models.Job{ID: uuid.New(), TenantID: tenantID, Status: models.JobStatusPending}
+	st := &analysisMockStore{jobs: []*models.Job{job}}
+
+	body := `{"job_ids":["` + job.ID.String() + `","` + job.ID.String() + `"]}`
+	rr := httptest.NewRecorder()
+	NewBulkPollJobsHandler(st, &analysisMockCache{}).ServeHTTP(rr, bulkPollRequest(body, tenantID))
+
+	if len(st.capturedJobIDs) != 1 {
+		t.Errorf("expected 1 unique ID passed to the store, got %d", len(st.capturedJobIDs))
+	}
+	if data := parseJSON(t, rr)["data"].([]any); len(data) != 1 {
+		t.Errorf("expected 1 entry, got %d", len(data))
+	}
+}
+
+func TestBulkPollJobsHandler_Validation(t *testing.T) {
+	tooMany := make([]string, maxPollJobIDs+1)
+	for i := range tooMany {
+		tooMany[i] = `"` + uuid.NewString() + `"`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"invalid json", `{`, "INVALID_REQUEST"},
+		{"missing ids", `{}`, "VALIDATION_ERROR"},
+		{"too many ids", `{"job_ids":[` + strings.Join(tooMany, ",") + `]}`, "VALIDATION_ERROR"},
+		{"malformed id", `{"job_ids":["not-a-uuid"]}`, "INVALID_JOB_ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &analysisMockStore{}
+			rr := httptest.NewRecorder()
+			NewBulkPollJobsHandler(st, &analysisMockCache{}).ServeHTTP(rr, bulkPollRequest(tt.body, uuid.New()))
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rr.Code)
+			}
+			if code := parseJSON(t, rr)["error"].(map[string]any)["code"]; code != tt.code {
+				t.Errorf("expected %s, got %v", tt.code, code)
+			}
+			if st.capturedJobIDs != nil {
+				t.Error("store must not be queried for an invalid request")
+			}
+		})
+	}
+}
+
+func TestBulkPollJobsHandler_NoTenant(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/analyze/poll", strings.NewReader(`{"job_ids":[]}`))
+	rr := httptest.NewRecorder()
+	NewBulkPollJobsHandler(&analysisMockStore{}, &analysisMockCache{}).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+// --- Replay tests ---
+
+func replayRequest(tenantID, jobID uuid.UUID) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/analyze/"+jobID.String()+"/replay", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("jobID", jobID.String())
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestReplayAnalysisHandler_Success(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	originalID := uuid.New()
+	replayID := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+		job:     &models.Job{ID: originalID, TenantID: tenantID, Status: models.JobStatusCompleted, ClusterID: &clusterID},
+	}
+	trigger := &mockAnalysisTrigger{job: &models.Job{ID: replayID, TenantID: tenantID, ReplayedFrom: &originalID}}
+
+	handler := NewReplayAnalysisHandler(st, trigger)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, replayRequest(tenantID, originalID))
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if trigger.replayedCluster == nil || trigger.replayedCluster.ID != clusterID {
+		t.Errorf("expected replay of cluster %s, got %v", clusterID, trigger.replayedCluster)
+	}
+	if trigger.replayedFrom != originalID {
+		t.Errorf("expected replayed_from %s, got %s", originalID, trigger.replayedFrom)
+	}
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["job_id"] != replayID.String() {
+		t.Errorf("expected job_id %s, got %v", replayID, data["job_id"])
+	}
+	if data["replayed_from"] != originalID.String() {
+		t.Errorf("expected replayed_from %s, got %v", originalID, data["replayed_from"])
+	}
+}
+
+func TestReplayAnalysisHandler_WrongTenant(t *testing.T) {
+	clusterID := uuid.New()
+	jobID := uuid.New()
+	owner := uuid.New()
+
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: owner},
+		job:     &models.Job{ID: jobID, TenantID: owner, ClusterID: &clusterID},
+	}
+	trigger := &mockAnalysisTrigger{}
+
+	handler := NewReplayAnalysisHandler(st, trigger)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, replayRequest(uuid.New(), jobID))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	errObj := parseJSON(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "JOB_NOT_FOUND" {
+		t.Errorf("expected JOB_NOT_FOUND, got %v", errObj["code"])
+	}
+	if trigger.replayedCluster != nil {
+		t.Error("expected no replay for another tenant's job")
+	}
+}
+
+func TestReplayAnalysisHandler_ClusterGone(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+	deletedClusterID := uuid.New()
+
+	for name, job := range map[string]*models.Job{
+		"deleted cluster": {ID: jobID, TenantID: tenantID, ClusterID: &deletedClusterID},
+		"no cluster":      {ID: jobID, TenantID: tenantID},
+	} {
+		st := &analysisMockStore{job: job}
+		trigger := &mockAnalysisTrigger{}
+
+		handler := NewReplayAnalysisHandler(st, trigger)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, replayRequest(tenantID, jobID))
+
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", name, rr.Code)
+		}
+		errObj := parseJSON(t, rr)["error"].(map[string]any)
+		if errObj["code"] != "CLUSTER_NOT_FOUND" {
+			t.Errorf("%s: expected CLUSTER_NOT_FOUND, got %v", name, errObj["code"])
+		}
+		if trigger.replayedCluster != nil {
+			t.Errorf("%s: expected no replay", name)
+		}
+	}
+}
+
+func TestPollJobHandler_IncludesReplayedFrom(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+	originalID := uuid.New()
+
+	st := &analysisMockStore{
+		job: &models.Job{ID: jobID, TenantID: tenantID, Status: models.JobStatusRunning, ReplayedFrom: &originalID},
+	}
+
+	handler := NewPollJobHandler(st, &analysisMockCache{})
+	req := httptest.NewRequest("GET", "/api/v1/analyze/"+jobID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("jobID", jobID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["replayed_from"] != originalID.String() {
+		t.Errorf("expected replayed_from %s, got %v", originalID, data["replayed_from"])
+	}
+}
+
+// --- GetAnalysis Tests ---
+
+func getAnalysisRequest(analysisID string, tenantID uuid.UUID) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/analyses/"+analysisID, nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("analysisID", analysisID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestGetAnalysisHandler_Success(t *testing.T) {
+	tenantID := uuid.New()
+	ar := &models.AnalysisResult{
+		ID: uuid.New(), ClusterID: uuid.New(), TenantID: tenantID, JobID: uuid.New(),
+		Provider: "ollama", Model: "llama3", RootCause: "OOM", Confidence: 0.8, Summary: "Out of memory",
+	}
+	st := &analysisMockStore{analysisResult: ar}
+
+	rr := httptest.NewRecorder()
+	NewGetAnalysisHandler(st).ServeHTTP(rr, getAnalysisRequest(ar.ID.String(), tenantID))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["id"] != ar.ID.String() || data["root_cause"] != "OOM" {
+		t.Errorf("unexpected analysis: %v", data)
+	}
+	if data["cluster_id"] != ar.ClusterID.String() || data["job_id"] != ar.JobID.String() {
+		t.Errorf("expected cluster and job IDs, got %v", data)
+	}	// The result fields match the other result endpoints, without the tenant.
+	for _, key := range []string{"confidence_clamped", "provider", "model", "suggested_action"} {
+		if _, ok := data[key]; !ok {
+			t.Errorf("expected %s in the body, got %v", key, data)
+		}
+	}
+	if _, ok := data["tenant_id"]; ok {
+		t.Errorf("expected no tenant_id in the body, got %v", data)
+	}
+}
+
+func TestGetAnalysisHandler_WrongTenant(t *testing.T) {
+	ar := &models.AnalysisResult{ID: uuid.New(), TenantID: uuid.New(), RootCause: "OOM"}
+	st := &analysisMockStore{analysisResult: ar}
+
+	rr := httptest.NewRecorder()
+	NewGetAnalysisHandler(st).ServeHTTP(rr, getAnalysisRequest(ar.ID.String(), uuid.New()))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	errObj := parseJSON(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "ANALYSIS_NOT_FOUND" {
+		t.Errorf("expected ANALYSIS_NOT_FOUND, got %v", errObj["code"])
+	}
+}
+
+func TestGetAnalysisHandler_InvalidID(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewGetAnalysisHandler(&analysisMockStore{}).ServeHTTP(rr, getAnalysisRequest("not-a-uuid", uuid.New()))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	errObj := parseJSON(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "INVALID_ANALYSIS_ID" {
+		t.Errorf("expected INVALID_ANALYSIS_ID, got %v", errObj["code"])
+	}
+}
+
+func TestGetAnalysisHandler_StoreError(t *testing.T) {
+	st := &analysisMockStore{analysisResultErr: errors.New("db down")}
+
+	rr := httptest.NewRecorder()
+	NewGetAnalysisHandler(st).ServeHTTP(rr, getAnalysisRequest(uuid.New().String(), uuid.New()))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+type estimatorFunc func(ctx context.Context, cluster *models.ErrorCluster) (ai.TokenEstimate, error)
+
+func (f estimatorFunc) EstimateAnalysis(ctx context.Context, cluster *models.ErrorCluster) (ai.TokenEstimate, error) {
+	return f(ctx, cluster)
+}
+
+func TestEstimateAnalysisHandler_Success(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+	}
+	cost := 0.25
+	est := estimatorFunc(func(_ context.Context, cluster *models.ErrorCluster) (ai.TokenEstimate, error) {
+		if cluster.ID != clusterID {
+			t.Errorf("expected cluster %s, got %s", clusterID, cluster.ID)
+		}
+		return ai.TokenEstimate{ContextLines: 40, PromptTokens: 1200, Provider: "mock", EstimatedCost: &cost}, nil
+	})
+
+	body := jsonBody(t, map[string]any{"cluster_id": clusterID.String()})
+	req := httptest.NewRequest("POST", "/api/v1/analyze/estimate", body)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+	NewEstimateAnalysisHandler(st, est).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["prompt_tokens"] != float64(1200) || data["context_lines"] != float64(40) {
+		t.Errorf("unexpected estimate: %v", data)
+	}
+	if data["estimated_cost"] != 0.25 {
+		t.Errorf("expected estimated_cost 0.25, got %v", data["estimated_cost"])
+	}
+}
+
+func TestEstimateAnalysisHandler_Errors(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	st := &analysisMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+	}
+
+	tests := []struct {
+		name       string
+		clusterID  string
+		estimate   error
+		wantStatus int
+	}{
+		{"invalid cluster id", "not-a-uuid", nil, http.StatusBadRequest},
+		{"unknown cluster", uuid.New().String(), nil, http.StatusNotFound},
+		{"model not allowed", clusterID.String(), ai.ErrModelNotAllowed, http.StatusBadRequest},
+		{"no logs", clusterID.String(), ai.ErrNoLogsFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := estimatorFunc(func(context.Context, *models.ErrorCluster) (ai.TokenEstimate, error) {
+				return ai.TokenEstimate{}, tt.estimate
+			})
+
+			body := jsonBody(t, map[string]any{"cluster_id": tt.clusterID})
+			req := httptest.NewRequest("POST", "/api/v1/analyze/estimate", body)
+			req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+			rr := httptest.NewRecorder()
+			NewEstimateAnalysisHandler(st, est).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+type cancellerFunc func(ctx context.Context, jobID, tenantID uuid.UUID) error
+
+func (f cancellerFunc) CancelJob(ctx context.Context, jobID, tenantID uuid.UUID) error {
+	return f(ctx, jobID, tenantID)
+}
+
+func TestCancelJobHandler(t *testing.T) {
+	tenantID := uuid.New()
+	jobID := uuid.New()
+
+	tests := []struct {
+		name       string
+		jobID      string
+		cancelErr  error
+		wantStatus int
+		wantCode   string
+	}{
+		{"cancelled", jobID.String(), nil, http.StatusNoContent, ""},
+		{"invalid job id", "not-a-uuid", nil, http.StatusBadRequest, "INVALID_JOB_ID"},
+		{"unknown job", jobID.String(), store.ErrNotFound, http.StatusNotFound, "JOB_NOT_FOUND"},
+		{"already finished", jobID.String(), ai.ErrJobFinished, http.StatusConflict, "JOB_FINISHED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotJob, gotTenant uuid.UUID
+			svc := cancellerFunc(func(_ context.Context, id, tenant uuid.UUID) error {
+				gotJob, gotTenant = id, tenant
+				return tt.cancelErr
+			})
+
+			req := httptest.NewRequest("DELETE", "/api/v1/analyze/"+tt.jobID, nil)
+			req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("jobID", tt.jobID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+			NewCancelJobHandler(svc, nil).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				body := parseJSON(t, rr)
+				errObj, _ := body["error"].(map[string]any)
+				if errObj["code"] != tt.wantCode {
+					t.Errorf("expected code %s, got %v", tt.wantCode, errObj["code"])
+				}
+				return
+			}
+			if gotJob != jobID || gotTenant != tenantID {
+				t.Errorf("expected job %s for tenant %s, got %s for %s", jobID, tenantID, gotJob, gotTenant)
+			}
+		})
+	}
+}
+
+func TestPollJobHandler_CrossTenant403Mode(t *testing.T) {
+	jobID := uuid.New()
+	st := &analysisMockStore{job: &models.Job{ID: jobID, TenantID: uuid.New(), Status: models.JobStatusPending}}
+
+	serve := func(id uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/analyze/"+id.String(), nil)
+		req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("jobID", id.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+		NewPollJobHandler(st, &analysisMockCache{}, WithCrossTenantStatus(http.StatusForbidden)).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(jobID)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another tenant's job, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if code := parseJSON(t, rr)["error"].(map[string]any)["code"]; code != "FORBIDDEN" {
+		t.Errorf("expected FORBIDDEN, got %v", code)
+	}
+	if rr := serve(uuid.New()); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a job no tenant owns, got %d", rr.Code)
+	}
+}
+
+func TestCancelJobHandler_CrossTenant403Mode(t *testing.T) {
+	jobID := uuid.New()
+	st := &analysisMockStore{job: &models.Job{ID: jobID, TenantID: uuid.New()}}
+	svc := cancellerFunc(func(context.Context, uuid.UUID, uuid.UUID) error { return store.ErrNotFound })
+
+	serve := func(opts ...Option) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/api/v1/analyze/"+jobID.String(), nil)
+		req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("jobID", jobID.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+		NewCancelJobHandler(svc, st, opts...).ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 in the default mode, got %d", rr.Code)
+	}
+
+	rr := serve(WithCrossTenantStatus(http.StatusForbidden))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another tenant's job, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if code := parseJSON(t, rr)["error"].(map[string]any)["code"]; code != "FORBIDDEN" {
+		t.Errorf("expected FORBIDDEN, got %v", code)
+	}
+}
@@ -2,8 +2,9 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -14,19 +15,70 @@ import (
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
+// defaultActiveWithin is the active window used when partitioning is requested
+// without an explicit active_within.
+const defaultActiveWithin = "1h"
+
+// WithClusterLevels sets the level names the cluster list's level filter
+// accepts, compared case-insensitively; the server passes every level in the
+// analysis severity table. Without it any level is accepted.
+func WithClusterLevels(levels []string) Option {
+	return func(o *options) {
+		if levels == nil {
+			o.clusterLevels, o.clusterLevelNames = nil, nil
+			return
+		}
+		o.clusterLevels = make(map[string]bool, len(levels))
+		o.clusterLevelNames = make([]string, 0, len(levels))
+		for _, level := range levels {
+			level = strings.ToLower(level)
+			if !o.clusterLevels[level] {
+				o.clusterLevels[level] = true
+				o.clusterLevelNames = append(o.clusterLevelNames, level)
+			}
+		}
+	}
+}
+
 // ClusterLister is the store interface needed by NewListClustersHandler.
 type ClusterLister interface {
 	ListErrorClusters(ctx context.Context, filter store.ClusterFilter) ([]*models.ErrorCluster, int, error)
+	CountErrorClusters(ctx context.Context, filter store.ClusterFilter) (int, error)
 }
 
 // ClusterGetter is the store interface needed by NewGetClusterHandler.
 type ClusterGetter interface {
+	ClusterExistenceChecker
 	GetErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error)
-	GetAnalysisResultByClusterID(ctx context.Context, clusterID uuid.UUID) (*models.AnalysisResult, error)
+	GetAnalysisResultByClusterID(ctx context.Context, clusterID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+}
+
+// ClusterUpdater is the store interface needed by NewPatchClusterHandler.
+type ClusterUpdater interface {
+	ClusterExistenceChecker
+	GetErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error)
+	SetClusterPinned(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, pinned bool) error
+}
+
+// ClusterDeleter is the store interface needed by NewDeleteClusterHandler.
+type ClusterDeleter interface {
+	ClusterExistenceChecker
+	DeleteErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error
 }
 
 // NewListClustersHandler returns an http.HandlerFunc for GET /api/v1/clusters.
-func NewListClustersHandler(st ClusterLister) http.HandlerFunc {
+// ?with_total=false skips counting the matching clusters; meta then omits total.
+// level may be repeated or comma-separated to match any of several levels.
+// sort is last_seen_desc (default) or first_seen_desc. The response echoes the
+// filters it applied as applied_filters alongside data and meta. Sample
+// messages are truncated to store.SampleMessagePreviewChars.
+//
+// ?page_cursor= switches to keyset pagination, which stays consistent while
+// new clusters arrive: pass it empty for the first page, then the previous
+// response's meta.next_cursor. It cannot be combined with ?page=, incremental
+// sync or include_counts_only, and meta never carries a total.
+func NewListClustersHandler(st ClusterLister, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -36,29 +88,49 @@ func NewListClustersHandler(st ClusterLister) http.HandlerFunc {
 
 		q := r.URL.Query()
 
-		page, _ := strconv.Atoi(q.Get("page"))
-		if page < 1 {
-			page = 1
-		}
-
-		limit, _ := strconv.Atoi(q.Get("limit"))
-		if limit < 1 {
-			limit = 20
-		}
-		if limit > 100 {
-			limit = 100
-		}
+		page, limit := response.ParseListParams(r)
 
 		filter := store.ClusterFilter{
 			TenantID:  tenantID,
 			Service:   q.Get("service"),
 			Namespace: q.Get("namespace"),
-			Level:     q.Get("level"),
 			Page:      page,
 			Limit:     limit,
+			// Lists carry a preview of each sample; GET /clusters/{id} has it in full.
+			Lightweight: true,
+		}
+
+		switch sort := q.Get("sort"); sort {
+		case "", store.ClusterSortLastSeen, store.ClusterSortFirstSeen:
+			filter.Sort = sort
+		default:
+			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "sort must be one of last_seen_desc, first_seen_desc", nil)
+			return
+		}
+
+		levels, ok := o.parseLevels(q["level"])
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "level must be one of "+strings.Join(o.clusterLevelNames, ", "), nil)
+			return
+		}
+		if len(levels) == 1 {
+			filter.Level = levels[0]
+		} else {
+			filter.Levels = levels
+		}
+
+		since := q.Get("since")
+		activeWithin := q.Get("active_within")
+		countsOnly := q.Get("include_counts_only") == "true"
+		if since != "" && activeWithin != "" {
+			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "since and active_within cannot be combined", nil)
+			return
+		}
+		if activeWithin == "" && countsOnly {
+			activeWithin = defaultActiveWithin
 		}
 
-		if since := q.Get("since"); since != "" {
+		if since != "" {
 			dur, err := time.ParseDuration(since)
 			if err != nil {
 				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "since must be a valid Go duration (e.g. 1h, 30m)", nil)
@@ -66,6 +138,78 @@ func NewListClustersHandler(st ClusterLister) http.HandlerFunc {
 			}
 			filter.Since = time.Now().Add(-dur)
 		}
+		if activeWithin != "" {
+			dur, err := time.ParseDuration(activeWithin)
+			if err != nil || dur <= 0 {
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "active_within must be a valid Go duration (e.g. 1h, 30m)", nil)
+				return
+			}
+			filter.Since = time.Now().Add(-dur)
+		}
+
+		// Incremental sync: a cursor from a previous response takes precedence
+		// over updated_since.
+		updatedSince := q.Get("updated_since")
+		cursor := q.Get("cursor")
+		syncMode := updatedSince != "" || cursor != ""
+		if syncMode && countsOnly {
+			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "updated_since cannot be combined with include_counts_only", nil)
+			return
+		}
+		if cursor != "" {
+			ts, id, err := store.DecodeClusterCursor(cursor)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "cursor is invalid", nil)
+				return
+			}
+			filter.UpdatedSince, filter.UpdatedAfterID = ts, id
+		} else if updatedSince != "" {
+			ts, err := time.Parse(time.RFC3339Nano, updatedSince)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "updated_since must be an RFC3339 timestamp", nil)
+				return
+			}
+			filter.UpdatedSince = ts
+		}
+
+		keyset := q.Has("page_cursor")
+		if keyset {
+			switch {
+			case syncMode:
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "page_cursor cannot be combined with cursor or updated_since", nil)
+				return
+			case countsOnly:
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "page_cursor cannot be combined with include_counts_only", nil)
+				return
+			case q.Get("page") != "":
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "page and page_cursor cannot be combined", nil)
+				return
+			}
+			if pc := q.Get("page_cursor"); pc != "" {
+				if _, _, err := store.DecodeClusterCursor(pc); err != nil {
+					response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "page_cursor is invalid", nil)
+					return
+				}
+				filter.Cursor = pc
+			}
+		}
+
+		// Totals are part of the sync and partition contracts, so only a plain
+		// listing may skip them. Keyset pages never count.
+		withTotal := (q.Get("with_total") != "false" || syncMode || countsOnly) && !keyset
+		filter.SkipTotal = !withTotal
+
+		applied := appliedClusterFilters{
+			Service:      filter.Service,
+			Namespace:    filter.Namespace,
+			Level:        levels,
+			Since:        since,
+			ActiveWithin: activeWithin,
+			Sort:         filter.Sort,
+		}
+		if applied.Sort == "" {
+			applied.Sort = store.ClusterSortLastSeen
+		}
 
 		clusters, total, err := st.ListErrorClusters(r.Context(), filter)
 		if err != nil {
@@ -74,17 +218,130 @@ func NewListClustersHandler(st ClusterLister) http.HandlerFunc {
 			return
 		}
 
-		response.Collection(w, clusters, response.PaginationMeta{
-			Page:    filter.Page,
-			Limit:   filter.Limit,
-			Total:   total,
-			HasNext: total > filter.Page*filter.Limit,
-		})
+		if syncMode {
+			meta := syncMeta{PaginationMeta: response.PaginationMeta{
+				Page:    1,
+				Limit:   filter.Limit,
+				Total:   total,
+				HasNext: total > len(clusters),
+			}}
+			if n := len(clusters); n > 0 {
+				last := clusters[n-1]
+				meta.NextCursor = store.EncodeClusterCursor(last.UpdatedAt, last.ID)
+			}
+			response.CollectionWithFilters(w, clusters, meta, applied)
+			return
+		}
+
+		if keyset {
+			meta := cursorMeta{Limit: filter.Limit, HasNext: total > len(clusters)}
+			if n := len(clusters); n > 0 && meta.HasNext {
+				meta.NextCursor = store.ClusterPageCursor(clusters[n-1], filter.Sort)
+			}
+			response.CollectionWithFilters(w, clusters, meta, applied)
+			return
+		}
+
+		meta := response.NewPaginationMeta(filter.Page, filter.Limit, total)
+
+		if !withTotal {
+			response.CollectionWithFilters(w, clusters, untotalledMeta{
+				Page:    meta.Page,
+				Limit:   meta.Limit,
+				HasNext: meta.HasNext,
+			}, applied)
+			return
+		}
+
+		if !countsOnly {
+			response.CollectionWithFilters(w, clusters, meta, applied)
+			return
+		}
+
+		// Noise partition: same filters, but only clusters that fell out of the
+		// active window. Only the total is needed.
+		noise := filter
+		noise.Since = time.Time{}
+		noise.SeenBefore = filter.Since
+		suppressed, err := st.CountErrorClusters(r.Context(), noise)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.CollectionWithFilters(w, clusters, partitionedMeta{
+			PaginationMeta:  meta,
+			ActiveWithin:    activeWithin,
+			SuppressedTotal: suppressed,
+		}, applied)
 	}
 }
 
+// appliedClusterFilters echoes the filters a cluster listing applied, so
+// clients can display them. Filters that were not given are omitted; sort is
+// always the effective order.
+type appliedClusterFilters struct {
+	Service      string   `json:"service,omitempty"`
+	Namespace    string   `json:"namespace,omitempty"`
+	Level        []string `json:"level,omitempty"`
+	Since        string   `json:"since,omitempty"`
+	ActiveWithin string   `json:"active_within,omitempty"`
+	Sort         string   `json:"sort"`
+}
+
+// partitionedMeta extends pagination meta with the count of inactive (noise) clusters.
+type partitionedMeta struct {
+	response.PaginationMeta
+	ActiveWithin    string `json:"active_within"`
+	SuppressedTotal int    `json:"suppressed_total"`
+}
+
+// untotalledMeta is the pagination meta for ?with_total=false.
+type untotalledMeta struct {
+	Page    int  `json:"page"`
+	Limit   int  `json:"limit"`
+	HasNext bool `json:"has_next"`
+}
+
+// cursorMeta is the pagination meta for ?page_cursor= listings. next_cursor
+// is passed back as page_cursor and is omitted on the last page.
+type cursorMeta struct {
+	Limit      int    `json:"limit"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// syncMeta extends pagination meta with the cursor for the next incremental sync
+// request. total counts the clusters remaining after the request's cursor;
+// next_cursor is omitted for an empty page, so clients keep their last cursor.
+type syncMeta struct {
+	response.PaginationMeta
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// parseLevels splits repeated and comma-separated level values, dropping empty
+// entries. It reports false if any value is not a known level.
+func (o *options) parseLevels(values []string) ([]string, bool) {
+	var levels []string
+	for _, v := range values {
+		for _, level := range strings.Split(v, ",") {
+			level = strings.TrimSpace(level)
+			if level == "" {
+				continue
+			}
+			if o.clusterLevels != nil && !o.clusterLevels[strings.ToLower(level)] {
+				return nil, false
+			}
+			levels = append(levels, level)
+		}
+	}
+	return levels, true
+}
+
 // NewGetClusterHandler returns an http.HandlerFunc for GET /api/v1/clusters/{clusterID}.
-func NewGetClusterHandler(st ClusterGetter) http.HandlerFunc {
+func NewGetClusterHandler(st ClusterGetter, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -101,7 +358,7 @@ func NewGetClusterHandler(st ClusterGetter) http.HandlerFunc {
 
 		cluster, err := st.GetErrorCluster(r.Context(), clusterID, tenantID)
 		if err != nil {
-			response.Error(w, http.StatusNotFound, "CLUSTER_NOT_FOUND", "Cluster not found", nil)
+			o.clusterNotFound(w, r, st, clusterID, "Cluster not found")
 			return
 		}
 
@@ -109,10 +366,92 @@ func NewGetClusterHandler(st ClusterGetter) http.HandlerFunc {
 			"cluster": cluster,
 		}
 
-		if ar, err := st.GetAnalysisResultByClusterID(r.Context(), clusterID); err == nil {
+		if ar, err := st.GetAnalysisResultByClusterID(r.Context(), clusterID, tenantID); err == nil {
 			result["analysis"] = ar
 		}
 
 		response.JSON(w, result)
 	}
 }
+
+// NewPatchClusterHandler returns an http.HandlerFunc for PATCH /api/v1/clusters/{clusterID}.
+// Only the fields present in the body are changed; it responds with the updated cluster.
+func NewPatchClusterHandler(st ClusterUpdater, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		clusterID, err := uuid.Parse(chi.URLParam(r, "clusterID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_CLUSTER_ID", "Invalid cluster ID", nil)
+			return
+		}
+
+		var req struct {
+			Pinned *bool `json:"pinned"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if req.Pinned == nil {
+			validationError(w, map[string]string{"pinned": "pinned is required"})
+			return
+		}
+
+		err = st.SetClusterPinned(r.Context(), clusterID, tenantID, *req.Pinned)
+		if errors.Is(err, store.ErrNotFound) {
+			o.clusterNotFound(w, r, st, clusterID, "Cluster not found")
+			return
+		}
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		cluster, err := st.GetErrorCluster(r.Context(), clusterID, tenantID)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+		response.JSON(w, cluster)
+	}
+}
+
+// NewDeleteClusterHandler returns an http.HandlerFunc for DELETE /api/v1/clusters/{clusterID}.
+// It removes the cluster and its analysis results; the cluster's jobs are kept.
+func NewDeleteClusterHandler(st ClusterDeleter, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		clusterID, err := uuid.Parse(chi.URLParam(r, "clusterID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_CLUSTER_ID", "Invalid cluster ID", nil)
+			return
+		}
+
+		err = st.DeleteErrorCluster(r.Context(), clusterID, tenantID)
+		if errors.Is(err, store.ErrNotFound) {
+			o.clusterNotFound(w, r, st, clusterID, "Cluster not found")
+			return
+		}
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.NoContent(w)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,17 +29,34 @@ type clusterMockStore struct {
 	analysis   *models.AnalysisResult
 	analysisErr error
 
-	capturedFilter *store.ClusterFilter
+	suppressedTotal int
+
+	deleted bool
+
+	capturedFilter  *store.ClusterFilter
+	capturedFilters []store.ClusterFilter
 }
 
 func (s *clusterMockStore) ListErrorClusters(_ context.Context, filter store.ClusterFilter) ([]*models.ErrorCluster, int, error) {
-	s.capturedFilter = &filter
+	s.capturedFilters = append(s.capturedFilters, filter)
 	if s.listErr != nil {
 		return nil, 0, s.listErr
 	}
+	s.capturedFilter = &filter
 	return s.clusters, s.total, nil
 }
 
+func (s *clusterMockStore) CountErrorClusters(_ context.Context, filter store.ClusterFilter) (int, error) {
+	s.capturedFilters = append(s.capturedFilters, filter)
+	if s.listErr != nil {
+		return 0, s.listErr
+	}
+	if !filter.SeenBefore.IsZero() {
+		return s.suppressedTotal, nil
+	}
+	return s.total, nil
+}
+
 func (s *clusterMockStore) GetErrorCluster(_ context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error) {
 	if s.getErr != nil {
 		return nil, s.getErr
@@ -48,11 +67,31 @@ func (s *clusterMockStore) GetErrorCluster(_ context.Context, id uuid.UUID, tena
 	return nil, store.ErrNotFound
 }
 
-func (s *clusterMockStore) GetAnalysisResultByClusterID(_ context.Context, clusterID uuid.UUID) (*models.AnalysisResult, error) {
+func (s *clusterMockStore) ErrorClusterExists(_ context.Context, id uuid.UUID) (bool, error) {
+	return s.cluster != nil && s.cluster.ID == id, nil
+}
+
+func (s *clusterMockStore) SetClusterPinned(_ context.Context, id uuid.UUID, tenantID uuid.UUID, pinned bool) error {
+	if s.cluster == nil || s.cluster.ID != id || s.cluster.TenantID != tenantID {
+		return store.ErrNotFound
+	}
+	s.cluster.Pinned = pinned
+	return nil
+}
+
+func (s *clusterMockStore) DeleteErrorCluster(_ context.Context, id uuid.UUID, tenantID uuid.UUID) error {
+	if s.cluster == nil || s.cluster.ID != id || s.cluster.TenantID != tenantID {
+		return store.ErrNotFound
+	}
+	s.deleted = true
+	return nil
+}
+
+func (s *clusterMockStore) GetAnalysisResultByClusterID(_ context.Context, clusterID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
 	if s.analysisErr != nil {
 		return nil, s.analysisErr
 	}
-	if s.analysis != nil && s.analysis.ClusterID == clusterID {
+	if s.analysis != nil && s.analysis.ClusterID == clusterID && s.analysis.TenantID == tenantID {
 		return s.analysis, nil
 	}
 	return nil, store.ErrNotFound
@@ -113,6 +152,23 @@ func TestListClustersHandler_Success(t *testing.T) {
 	}
 }
 
+func TestListClustersHandler_RequestsLightweightList(t *testing.T) {
+	tenantID := uuid.New()
+	st := &clusterMockStore{}
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+	NewListClustersHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !st.capturedFilter.Lightweight {
+		t.Error("expected the list to request truncated sample messages")
+	}
+}
+
 func TestListClustersHandler_Pagination(t *testing.T) {
 	tenantID := uuid.New()
 	st := &clusterMockStore{
@@ -184,6 +240,115 @@ func TestListClustersHandler_Filters(t *testing.T) {
 	}
 }
 
+func TestListClustersHandler_AppliedFilters(t *testing.T) {
+	st := &clusterMockStore{clusters: []*models.ErrorCluster{}, total: 0}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?service=api&namespace=production&level=error,fatal&since=2h", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	resp := parseJSON(t, rr)
+	if _, ok := resp["data"].([]any); !ok {
+		t.Errorf("expected data array, got %T", resp["data"])
+	}
+	if _, ok := resp["meta"].(map[string]any)["total"]; !ok {
+		t.Error("expected meta.total to be unchanged")
+	}
+	applied, ok := resp["applied_filters"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected applied_filters object, got %v", resp["applied_filters"])
+	}
+	want := map[string]any{
+		"service":   "api",
+		"namespace": "production",
+		"level":     []any{"error", "fatal"},
+		"since":     "2h",
+		"sort":      store.ClusterSortLastSeen,
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied_filters = %v, want %v", applied, want)
+	}
+}
+
+func TestListClustersHandler_MultipleLevels(t *testing.T) {
+	for _, query := range []string{"level=ERROR&level=FATAL", "level=ERROR,FATAL", "level=ERROR,&level=+FATAL"} {
+		st := &clusterMockStore{clusters: []*models.ErrorCluster{}}
+		handler := NewListClustersHandler(st)
+
+		req := httptest.NewRequest("GET", "/api/v1/clusters?"+query, nil)
+		req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rr.Code, rr.Body.String())
+		}
+		if st.capturedFilter.Level != "" {
+			t.Errorf("%s: expected single level unset, got %q", query, st.capturedFilter.Level)
+		}
+		if got := st.capturedFilter.Levels; len(got) != 2 || got[0] != "ERROR" || got[1] != "FATAL" {
+			t.Errorf("%s: expected levels [ERROR FATAL], got %v", query, got)
+		}
+	}
+}
+
+func TestListClustersHandler_UnknownLevel(t *testing.T) {
+	handler := NewListClustersHandler(&clusterMockStore{}, WithClusterLevels([]string{"fatal", "error", "warn"}))
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?level=error,loud", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	want := "level must be one of fatal, error, warn"
+	if msg := parseJSON(t, rr)["error"].(map[string]any)["message"]; msg != want {
+		t.Errorf("expected message %q, got %v", want, msg)
+	}
+}
+
+func TestListClustersHandler_Sort(t *testing.T) {
+	st := &clusterMockStore{clusters: []*models.ErrorCluster{}}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?sort=first_seen_desc", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if st.capturedFilter.Sort != store.ClusterSortFirstSeen {
+		t.Errorf("expected sort %q, got %q", store.ClusterSortFirstSeen, st.capturedFilter.Sort)
+	}
+}
+
+func TestListClustersHandler_UnknownSort(t *testing.T) {
+	handler := NewListClustersHandler(&clusterMockStore{})
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?sort=count", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
 func TestListClustersHandler_InvalidSince(t *testing.T) {
 	handler := NewListClustersHandler(&clusterMockStore{})
 
@@ -232,6 +397,310 @@ func TestListClustersHandler_LimitClamping(t *testing.T) {
 	}
 }
 
+func TestListClustersHandler_ActiveWithin(t *testing.T) {
+	st := &clusterMockStore{clusters: []*models.ErrorCluster{}, total: 0}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?active_within=30m", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if d := time.Since(st.capturedFilter.Since); d < 29*time.Minute || d > 31*time.Minute {
+		t.Errorf("expected since ~30m ago, got %v ago", d)
+	}
+	if len(st.capturedFilters) != 1 {
+		t.Errorf("expected a single store call without include_counts_only, got %d", len(st.capturedFilters))
+	}
+	meta := parseJSON(t, rr)["meta"].(map[string]any)
+	if _, ok := meta["suppressed_total"]; ok {
+		t.Error("expected no suppressed_total without include_counts_only")
+	}
+}
+
+func TestListClustersHandler_IncludeCountsOnly(t *testing.T) {
+	now := time.Now()
+	st := &clusterMockStore{
+		clusters: []*models.ErrorCluster{
+			{ID: uuid.New(), Service: "api", LastSeenAt: now},
+		},
+		total:           1,
+		suppressedTotal: 7,
+	}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?service=api&include_counts_only=true", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(st.capturedFilters) != 2 {
+		t.Fatalf("expected active and noise queries, got %d", len(st.capturedFilters))
+	}
+
+	active, noise := st.capturedFilters[0], st.capturedFilters[1]
+	if d := time.Since(active.Since); d < 59*time.Minute || d > 61*time.Minute {
+		t.Errorf("expected default active window of 1h, got %v", d)
+	}
+	if !noise.Since.IsZero() || !noise.SeenBefore.Equal(active.Since) {
+		t.Errorf("expected noise query to cover everything before the active window, got %+v", noise)
+	}
+	if noise.Service != "api" {
+		t.Errorf("expected noise query to keep other filters, got service %q", noise.Service)
+	}
+
+	resp := parseJSON(t, rr)
+	if data := resp["data"].([]any); len(data) != 1 {
+		t.Errorf("expected 1 active cluster, got %d", len(data))
+	}
+	meta := resp["meta"].(map[string]any)
+	if meta["suppressed_total"] != float64(7) {
+		t.Errorf("expected suppressed_total 7, got %v", meta["suppressed_total"])
+	}
+	if meta["total"] != float64(1) {
+		t.Errorf("expected total 1, got %v", meta["total"])
+	}
+	if meta["active_within"] != "1h" {
+		t.Errorf("expected active_within 1h, got %v", meta["active_within"])
+	}
+}
+
+func TestListClustersHandler_WithoutTotal(t *testing.T) {
+	st := &clusterMockStore{
+		clusters: []*models.ErrorCluster{{ID: uuid.New(), Service: "api"}},
+		total:    21, // the store's lower bound: one row past the first page
+	}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?with_total=false", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !st.capturedFilter.SkipTotal {
+		t.Error("expected SkipTotal to be set")
+	}
+	meta := parseJSON(t, rr)["meta"].(map[string]any)
+	if _, ok := meta["total"]; ok {
+		t.Errorf("expected no total with with_total=false, got %v", meta["total"])
+	}
+	if meta["has_next"] != true {
+		t.Errorf("expected has_next true, got %v", meta["has_next"])
+	}
+	if meta["page"] != float64(1) || meta["limit"] != float64(20) {
+		t.Errorf("expected page 1 limit 20, got %v/%v", meta["page"], meta["limit"])
+	}
+}
+
+func TestListClustersHandler_WithoutTotalIgnoredForPartitionAndSync(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"counts only", "?include_counts_only=true&with_total=false"},
+		{"sync", "?updated_since=2024-02-17T10:00:00Z&with_total=false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &clusterMockStore{clusters: []*models.ErrorCluster{}, total: 3}
+			handler := NewListClustersHandler(st)
+
+			req := httptest.NewRequest("GET", "/api/v1/clusters"+tt.query, nil)
+			req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if st.capturedFilter.SkipTotal {
+				t.Error("expected SkipTotal to stay unset")
+			}
+			meta := parseJSON(t, rr)["meta"].(map[string]any)
+			if meta["total"] != float64(3) {
+				t.Errorf("expected total 3, got %v", meta["total"])
+			}
+		})
+	}
+}
+
+func TestListClustersHandler_UpdatedSince(t *testing.T) {
+	base := time.Date(2024, 2, 17, 10, 0, 0, 0, time.UTC)
+	lastID := uuid.New()
+	st := &clusterMockStore{
+		clusters: []*models.ErrorCluster{
+			{ID: uuid.New(), UpdatedAt: base.Add(time.Minute)},
+			{ID: lastID, UpdatedAt: base.Add(2 * time.Minute)},
+		},
+		total: 5,
+	}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?updated_since=2024-02-17T10:00:00Z&limit=2", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !st.capturedFilter.UpdatedSince.Equal(base) || st.capturedFilter.UpdatedAfterID != uuid.Nil {
+		t.Errorf("unexpected sync filter: %+v", st.capturedFilter)
+	}
+
+	meta := parseJSON(t, rr)["meta"].(map[string]any)
+	if meta["has_next"] != true {
+		t.Errorf("expected has_next true, got %v", meta["has_next"])
+	}
+	cursor, _ := meta["next_cursor"].(string)
+	ts, id, err := store.DecodeClusterCursor(cursor)
+	if err != nil {
+		t.Fatalf("expected a decodable next_cursor, got %q: %v", cursor, err)
+	}
+	if !ts.Equal(base.Add(2*time.Minute)) || id != lastID {
+		t.Errorf("expected cursor at last row, got (%v, %v)", ts, id)
+	}
+
+	// Resuming from the cursor passes the keyset position to the store.
+	req = httptest.NewRequest("GET", "/api/v1/clusters?cursor="+cursor, nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !st.capturedFilter.UpdatedSince.Equal(ts) || st.capturedFilter.UpdatedAfterID != lastID {
+		t.Errorf("expected cursor position in filter, got %+v", st.capturedFilter)
+	}
+}
+
+func TestListClustersHandler_UpdatedSinceInvalid(t *testing.T) {
+	handler := NewListClustersHandler(&clusterMockStore{})
+
+	for _, query := range []string{"updated_since=yesterday", "cursor=garbage", "updated_since=2024-02-17T10:00:00Z&include_counts_only=true"} {
+		req := httptest.NewRequest("GET", "/api/v1/clusters?"+query, nil)
+		req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestListClustersHandler_PageCursor(t *testing.T) {
+	base := time.Date(2024, 2, 17, 10, 0, 0, 0, time.UTC)
+	lastID := uuid.New()
+	st := &clusterMockStore{
+		clusters: []*models.ErrorCluster{
+			{ID: uuid.New(), LastSeenAt: base.Add(2 * time.Minute)},
+			{ID: lastID, LastSeenAt: base.Add(time.Minute)},
+		},
+		// Without a count the store reports one row past the page.
+		total: 3,
+	}
+	handler := NewListClustersHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?page_cursor=&limit=2", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if st.capturedFilter.Cursor != "" || !st.capturedFilter.SkipTotal {
+		t.Errorf("expected an uncounted first keyset page, got %+v", st.capturedFilter)
+	}
+	meta := parseJSON(t, rr)["meta"].(map[string]any)
+	if meta["has_next"] != true {
+		t.Errorf("expected has_next true, got %v", meta["has_next"])
+	}
+	if _, ok := meta["total"]; ok {
+		t.Errorf("expected no total in keyset meta, got %v", meta)
+	}
+	cursor, _ := meta["next_cursor"].(string)
+	ts, id, err := store.DecodeClusterCursor(cursor)
+	if err != nil {
+		t.Fatalf("expected a decodable next_cursor, got %q: %v", cursor, err)
+	}
+	if !ts.Equal(base.Add(time.Minute)) || id != lastID {
+		t.Errorf("expected cursor at last row, got (%v, %v)", ts, id)
+	}
+
+	// The next page passes the cursor through; the last page has none.
+	st.total = 2
+	req = httptest.NewRequest("GET", "/api/v1/clusters?limit=2&page_cursor="+cursor, nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if st.capturedFilter.Cursor != cursor {
+		t.Errorf("expected cursor in filter, got %+v", st.capturedFilter)
+	}
+	meta = parseJSON(t, rr)["meta"].(map[string]any)
+	if meta["has_next"] != false || meta["next_cursor"] != nil {
+		t.Errorf("expected last page without next_cursor, got %v", meta)
+	}
+}
+
+func TestListClustersHandler_PageCursorInvalid(t *testing.T) {
+	handler := NewListClustersHandler(&clusterMockStore{})
+
+	for _, query := range []string{
+		"page_cursor=garbage",
+		"page_cursor=&page=2",
+		"page_cursor=&updated_since=2024-02-17T10:00:00Z",
+		"page_cursor=&include_counts_only=true",
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/clusters?"+query, nil)
+		req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestListClustersHandler_SinceAndActiveWithinConflict(t *testing.T) {
+	handler := NewListClustersHandler(&clusterMockStore{})
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters?since=1h&active_within=1h", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
 func TestListClustersHandler_NoTenant(t *testing.T) {
 	handler := NewListClustersHandler(&clusterMockStore{})
 
@@ -316,6 +785,38 @@ func TestGetClusterHandler_Success(t *testing.T) {
 	}
 }
 
+func TestGetClusterHandler_ReportsReopenedAt(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	reopenedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	st := &clusterMockStore{
+		cluster: &models.ErrorCluster{
+			ID:         clusterID,
+			TenantID:   tenantID,
+			Service:    "api",
+			Status:     models.ClusterStatusOpen,
+			ReopenedAt: &reopenedAt,
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters/"+clusterID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", clusterID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	NewGetClusterHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	cluster := parseJSON(t, rr)["data"].(map[string]any)["cluster"].(map[string]any)
+	if cluster["reopened_at"] != "2026-03-01T12:00:00Z" {
+		t.Errorf("expected reopened_at in response, got %v", cluster["reopened_at"])
+	}
+}
+
 func TestGetClusterHandler_WithAnalysis(t *testing.T) {
 	tenantID := uuid.New()
 	clusterID := uuid.New()
@@ -395,6 +896,41 @@ func TestGetClusterHandler_WithoutAnalysis(t *testing.T) {
 	}
 }
 
+func TestGetClusterHandler_OmitsOtherTenantsAnalysis(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	st := &clusterMockStore{
+		cluster: &models.ErrorCluster{
+			ID:       clusterID,
+			TenantID: tenantID,
+			Service:  "api",
+		},
+		analysis: &models.AnalysisResult{
+			ID:        uuid.New(),
+			ClusterID: clusterID,
+			TenantID:  uuid.New(),
+			RootCause: "another tenant's root cause",
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters/"+clusterID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", clusterID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	NewGetClusterHandler(st).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["analysis"] != nil {
+		t.Errorf("expected another tenant's analysis to be omitted, got %v", data["analysis"])
+	}
+}
+
 func TestGetClusterHandler_NotFound(t *testing.T) {
 	st := &clusterMockStore{getErr: store.ErrNotFound}
 
@@ -471,3 +1007,209 @@ func TestGetClusterHandler_WrongTenant(t *testing.T) {
 		t.Fatalf("expected 404 for wrong tenant, got %d", rr.Code)
 	}
 }
+
+// serveWrongTenantCluster fetches a cluster owned by one tenant as another.
+func serveWrongTenantCluster(t *testing.T, opts ...Option) *httptest.ResponseRecorder {
+	t.Helper()
+	clusterID := uuid.New()
+	st := &clusterMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: uuid.New(), Service: "api"},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters/"+clusterID.String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", clusterID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	NewGetClusterHandler(st, opts...).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGetClusterHandler_CrossTenant404Mode(t *testing.T) {
+	rr := serveWrongTenantCluster(t)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	resp := parseJSON(t, rr)
+	if code := resp["error"].(map[string]any)["code"]; code != "CLUSTER_NOT_FOUND" {
+		t.Errorf("expected CLUSTER_NOT_FOUND, got %v", code)
+	}
+}
+
+func TestGetClusterHandler_CrossTenant403Mode(t *testing.T) {
+	rr := serveWrongTenantCluster(t, WithCrossTenantStatus(http.StatusForbidden))
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	resp := parseJSON(t, rr)
+	if code := resp["error"].(map[string]any)["code"]; code != "FORBIDDEN" {
+		t.Errorf("expected FORBIDDEN, got %v", code)
+	}
+}
+
+func TestGetClusterHandler_403ModeUnknownClusterIs404(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/clusters/"+uuid.New().String(), nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", uuid.New().String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	NewGetClusterHandler(&clusterMockStore{}, WithCrossTenantStatus(http.StatusForbidden)).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a cluster no tenant owns, got %d", rr.Code)
+	}
+}
+
+// --- PatchCluster tests ---
+
+func patchClusterRequest(t *testing.T, clusterID string, tenantID uuid.UUID, body string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("PATCH", "/api/v1/clusters/"+clusterID, strings.NewReader(body))
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", clusterID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestPatchClusterHandler_Pin(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	st := &clusterMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+	}
+	handler := NewPatchClusterHandler(st)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, patchClusterRequest(t, clusterID.String(), tenantID, `{"pinned": true}`))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !st.cluster.Pinned {
+		t.Error("expected cluster to be pinned")
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["pinned"] != true {
+		t.Errorf("expected pinned true in response, got %v", data["pinned"])
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, patchClusterRequest(t, clusterID.String(), tenantID, `{"pinned": false}`))
+	if rr.Code != http.StatusOK || st.cluster.Pinned {
+		t.Errorf("expected cluster to be unpinned, got %d pinned=%v", rr.Code, st.cluster.Pinned)
+	}
+}
+
+func TestPatchClusterHandler_Errors(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+
+	tests := []struct {
+		name      string
+		clusterID string
+		tenantID  uuid.UUID
+		body      string
+		wantCode  int
+		wantError string
+	}{
+		{"invalid id", "not-a-uuid", tenantID, `{"pinned": true}`, http.StatusBadRequest, "INVALID_CLUSTER_ID"},
+		{"invalid json", clusterID.String(), tenantID, `{`, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"no fields", clusterID.String(), tenantID, `{}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"unknown cluster", uuid.NewString(), tenantID, `{"pinned": true}`, http.StatusNotFound, "CLUSTER_NOT_FOUND"},
+		{"wrong tenant", clusterID.String(), uuid.New(), `{"pinned": true}`, http.StatusNotFound, "CLUSTER_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &clusterMockStore{
+				cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+			}
+			rr := httptest.NewRecorder()
+			NewPatchClusterHandler(st).ServeHTTP(rr, patchClusterRequest(t, tt.clusterID, tt.tenantID, tt.body))
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			errObj := parseJSON(t, rr)["error"].(map[string]any)
+			if errObj["code"] != tt.wantError {
+				t.Errorf("expected %s, got %v", tt.wantError, errObj["code"])
+			}
+			if st.cluster.Pinned {
+				t.Error("cluster should not be pinned after a failed request")
+			}
+		})
+	}
+}
+
+// --- DeleteCluster tests ---
+
+func deleteClusterRequest(t *testing.T, clusterID string, tenantID uuid.UUID) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("DELETE", "/api/v1/clusters/"+clusterID, nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", clusterID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestDeleteClusterHandler(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+	st := &clusterMockStore{
+		cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+	}
+
+	rr := httptest.NewRecorder()
+	NewDeleteClusterHandler(st).ServeHTTP(rr, deleteClusterRequest(t, clusterID.String(), tenantID))
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !st.deleted {
+		t.Error("expected cluster to be deleted")
+	}
+}
+
+func TestDeleteClusterHandler_Errors(t *testing.T) {
+	tenantID := uuid.New()
+	clusterID := uuid.New()
+
+	tests := []struct {
+		name      string
+		clusterID string
+		tenantID  uuid.UUID
+		wantCode  int
+		wantError string
+	}{
+		{"invalid id", "not-a-uuid", tenantID, http.StatusBadRequest, "INVALID_CLUSTER_ID"},
+		{"unknown cluster", uuid.NewString(), tenantID, http.StatusNotFound, "CLUSTER_NOT_FOUND"},
+		{"wrong tenant", clusterID.String(), uuid.New(), http.StatusNotFound, "CLUSTER_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &clusterMockStore{
+				cluster: &models.ErrorCluster{ID: clusterID, TenantID: tenantID, Service: "api"},
+			}
+			rr := httptest.NewRecorder()
+			NewDeleteClusterHandler(st).ServeHTTP(rr, deleteClusterRequest(t, tt.clusterID, tt.tenantID))
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			errObj := parseJSON(t, rr)["error"].(map[string]any)
+			if errObj["code"] != tt.wantError {
+				t.Errorf("expected %s, got %v", tt.wantError, errObj["code"])
+			}
+			if st.deleted {
+				t.Error("cluster should not be deleted after a failed request")
+			}
+		})
+	}
+}
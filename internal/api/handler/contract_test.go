@@ -101,7 +101,7 @@ func (s *mockStore) CreateAPIKey(_ context.Context, key *models.APIKey) error {
 	return nil
 }
 
-func (s *mockStore) ListAPIKeys(_ context.Context, tenantID uuid.UUID) ([]*models.APIKey, error) {
+func (s *mockStore) ListAPIKeys(_ context.Context, tenantID uuid.UUID, _ ...store.APIKeyListOption) ([]*models.APIKey, error) {
 	var out []*models.APIKey
 	for _, k := range s.keys {
 		if k.TenantID == tenantID {
@@ -159,16 +159,16 @@ func (s *mockStore) CreateAnalysisResult(_ context.Context, r *models.AnalysisRe
 	return nil
 }
 
-func (s *mockStore) GetAnalysisResultByJobID(_ context.Context, jobID uuid.UUID) (*models.AnalysisResult, error) {
-	if r, ok := s.results[jobID]; ok {
+func (s *mockStore) GetAnalysisResultByJobID(_ context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
+	if r, ok := s.results[jobID]; ok && r.TenantID == tenantID {
 		return r, nil
 	}
 	return nil, store.ErrNotFound
 }
 
-func (s *mockStore) GetAnalysisResultByClusterID(_ context.Context, clusterID uuid.UUID) (*models.AnalysisResult, error) {
+func (s *mockStore) GetAnalysisResultByClusterID(_ context.Context, clusterID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
 	for _, r := range s.results {
-		if r.ClusterID == clusterID {
+		if r.ClusterID == clusterID && r.TenantID == tenantID {
 			return r, nil
 		}
 	}
@@ -187,6 +187,8 @@ func (s *mockStore) GetJob(_ context.Context, id uuid.UUID, tenantID uuid.UUID)
 	return nil, store.ErrNotFound
 }
 
+func (s *mockStore) JobExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+
 func (s *mockStore) UpdateJobStatus(_ context.Context, id uuid.UUID, status string, _ ...store.JobUpdateOption) error {
 	if j, ok := s.jobs[id]; ok {
 		j.Status = status
@@ -194,6 +196,49 @@ func (s *mockStore) UpdateJobStatus(_ context.Context, id uuid.UUID, status stri
 	}
 	return store.ErrNotFound
 }
+func (s *mockStore) JobStats(_ context.Context, _ uuid.UUID, _ time.Time) (store.JobStats, error) {
+	return store.JobStats{}, nil
+}
+func (s *mockStore) GetTenantByAPIKeyPrefix(_ context.Context, _ string) (*models.Tenant, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
+func (s *mockStore) SaveAnalysisContext(_ context.Context, _ *models.AnalysisContext) error { return nil }
+func (s *mockStore) GetAnalysisContext(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisContext, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) ListAPIKeysPaged(_ context.Context, _ uuid.UUID, _, _ int, _ ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
+	return nil, 0, nil
+}
+func (s *mockStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, _ string) (*models.ErrorCluster, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) GetJobsByIDs(_ context.Context, _ uuid.UUID, _ []uuid.UUID) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+func (s *mockStore) CountErrorClusters(_ context.Context, _ store.ClusterFilter) (int, error) { return 0, nil }
+func (s *mockStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error { return nil }
+func (s *mockStore) SetClusterPinned(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ bool) error { return nil }
+func (s *mockStore) RevokeAllAPIKeys(_ context.Context, _ uuid.UUID, _ ...uuid.UUID) (int, error) { return 0, nil }
+func (s *mockStore) ErrorClusterExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+func (s *mockStore) GetJobByID(_ context.Context, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (s *mockStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) { return &store.MigrationStatus{}, nil }
+func (s *mockStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return 0, nil
+}
+func (s *mockStore) GetAnalysisResultByID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
+	return nil, store.ErrNotFound
+}
+func (s *mockStore) ListJobs(_ context.Context, _ uuid.UUID, _ store.JobFilter) ([]*models.Job, int, error) { return nil, 0, nil }
+func (s *mockStore) CreateClusterSuppression(_ context.Context, _ *models.ClusterSuppression) error { return nil }
+func (s *mockStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) { return nil, nil }
+func (s *mockStore) DeleteClusterSuppression(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *mockStore) DeleteErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *mockStore) GetTenant(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id}, nil
+}
 
 var _ store.Store = (*mockStore)(nil)
 
@@ -242,7 +287,7 @@ func newTestServer(t *testing.T) *testServer {
 	completedJob := &models.Job{
 		ID:       testJobID,
 		TenantID: testTenantID,
-		Type:     "analyze",
+		Type:     models.JobTypeAnalysis,
 		Status:   models.JobStatusCompleted,
 	}
 	ms.jobs[testJobID] = completedJob
@@ -343,7 +388,9 @@ func analyzeHandler(s *mockStore) http.HandlerFunc {
 
 		clusterID, err := uuid.Parse(req.ClusterID)
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_CLUSTER_ID", "Invalid cluster_id format", nil)
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Request validation failed", map[string]string{
+				"cluster_id": "cluster_id must be a valid UUID",
+			})
 			return
 		}
 
@@ -356,7 +403,7 @@ func analyzeHandler(s *mockStore) http.HandlerFunc {
 		job := &models.Job{
 			ID:       jobID,
 			TenantID: tenantID,
-			Type:     "analyze",
+			Type:     models.JobTypeAnalysis,
 			Status:   models.JobStatusPending,
 		}
 		s.CreateJob(r.Context(), job)
@@ -401,7 +448,7 @@ func pollJobHandler(s *mockStore) http.HandlerFunc {
 		}
 
 		if job.Status == models.JobStatusCompleted {
-			if ar, err := s.GetAnalysisResultByJobID(r.Context(), jobID); err == nil {
+			if ar, err := s.GetAnalysisResultByJobID(r.Context(), jobID, tenantID); err == nil {
 				result["result"] = map[string]any{
 					"root_cause":  ar.RootCause,
 					"confidence":  ar.Confidence,
@@ -479,7 +526,7 @@ func getClusterHandler(s *mockStore) http.HandlerFunc {
 			"cluster": cluster,
 		}
 
-		if ar, err := s.GetAnalysisResultByClusterID(r.Context(), clusterID); err == nil {
+		if ar, err := s.GetAnalysisResultByClusterID(r.Context(), clusterID, tenantID); err == nil {
 			result["analysis"] = ar
 		}
 
@@ -650,7 +697,7 @@ func revokeKeyHandler(s *mockStore) http.HandlerFunc {
 			return
 		}
 
-		w.WriteHeader(http.StatusNoContent)
+		response.NoContent(w)
 	}
 }
 
@@ -727,7 +774,8 @@ func TestAnalyze_400_InvalidClusterID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	body := parseBody(t, resp)
 	errObj := body["error"].(map[string]any)
-	assert.Equal(t, "INVALID_CLUSTER_ID", errObj["code"])
+	assert.Equal(t, "VALIDATION_ERROR", errObj["code"])
+	assert.Contains(t, errObj["details"], "cluster_id")
 }
 
 func TestAnalyze_401_MissingToken(t *testing.T) {
@@ -784,7 +832,7 @@ func TestPollJob_200_StatusRunning(t *testing.T) {
 	ts.store.jobs[runningJobID] = &models.Job{
 		ID:       runningJobID,
 		TenantID: testTenantID,
-		Type:     "analyze",
+		Type:     models.JobTypeAnalysis,
 		Status:   models.JobStatusRunning,
 	}
 
@@ -808,7 +856,7 @@ func TestPollJob_404_WrongTenant(t *testing.T) {
 	ts.store.jobs[otherJobID] = &models.Job{
 		ID:       otherJobID,
 		TenantID: otherTenantID, // different tenant
-		Type:     "analyze",
+		Type:     models.JobTypeAnalysis,
 		Status:   models.JobStatusPending,
 	}
 
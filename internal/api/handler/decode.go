@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+)
+
+// WithStrictRequestBodies makes handlers reject request bodies carrying fields
+// they do not know, so a typo is reported instead of silently ignored. Off by
+// default.
+func WithStrictRequestBodies(strict bool) Option {
+	return func(o *options) { o.strictBodies = strict }
+}
+
+// decodeJSON decodes the request body into dst, rejecting unknown fields in
+// strict mode.
+func (o *options) decodeJSON(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	if o.strictBodies {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(dst)
+}
+
+// invalidBody responds 400 to a body decodeJSON rejected, naming the field
+// when it was unknown.
+func invalidBody(w http.ResponseWriter, err error) {
+	// encoding/json has no typed error for unknown fields.
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("Unknown field %s", name), nil)
+		return
+	}
+	response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON body", nil)
+}
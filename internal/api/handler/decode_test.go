@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSummarizeHandler_UnknownField(t *testing.T) {
+	// "servce" is a typo of "service".
+	body := map[string]any{
+		"servce": "payments-api",
+		"start":  "2024-02-17T00:00:00Z",
+		"end":    "2024-02-17T01:00:00Z",
+	}
+
+	tests := []struct {
+		name     string
+		strict   bool
+		wantCode string
+		wantMsg  string
+	}{
+		{"lenient ignores it", false, "VALIDATION_ERROR", "Request validation failed"},
+		{"strict names it", true, "INVALID_REQUEST", `Unknown field "servce"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewSummarizeHandler(successSummarizer(), WithStrictRequestBodies(tt.strict)).ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			errBody := parseJSON(t, rec)["error"].(map[string]any)
+			if errBody["code"] != tt.wantCode || errBody["message"] != tt.wantMsg {
+				t.Errorf("expected %s %q, got %v %q", tt.wantCode, tt.wantMsg, errBody["code"], errBody["message"])
+			}
+		})
+	}
+}
+
+func TestSummarizeHandler_StrictAcceptsKnownFields(t *testing.T) {
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	rec := httptest.NewRecorder()
+	NewSummarizeHandler(successSummarizer(), WithStrictRequestBodies(true)).ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
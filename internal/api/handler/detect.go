@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+const (
+	defaultPreviewLimit = 5000
+	maxPreviewLimit     = 50000
+)
+
+// PreviewParams holds validated parameters for a clustering preview.
+type PreviewParams struct {
+	TenantID  uuid.UUID
+	Service   string
+	Namespace string
+	Start     time.Time
+	End       time.Time
+	Levels    []string
+	Limit     int
+}
+
+// PreviewResult is the output of a clustering dry run.
+type PreviewResult struct {
+	Clusters     []PreviewCluster `json:"clusters"`
+	Query        string           `json:"query"`
+	LinesScanned int              `json:"lines_scanned"`
+}
+
+// PreviewCluster is a cluster the detection window would produce. ID and
+// TenantID are unset since nothing is stored; ExistingClusterID points at the
+// stored cluster the preview would merge into, if any. Suppressed clusters
+// match one of the tenant's suppressions and would not be stored.
+type PreviewCluster struct {
+	models.ErrorCluster
+	ExistingClusterID *uuid.UUID `json:"existing_cluster_id,omitempty"`
+	Suppressed        bool       `json:"suppressed,omitempty"`
+}
+
+// ClusterPreviewer defines the interface the detect preview handler depends on.
+type ClusterPreviewer interface {
+	Preview(ctx context.Context, params PreviewParams) (*PreviewResult, error)
+}
+
+// DetectResult is the output of a detection run: the clusters ingested from
+// the window, as stored. Clusters dropped by a suppression are left out.
+type DetectResult struct {
+	Clusters     []*models.ErrorCluster `json:"clusters"`
+	Query        string                 `json:"query"`
+	LinesScanned int                    `json:"lines_scanned"`
+}
+
+// ClusterDetector defines the interface the detect handler depends on.
+type ClusterDetector interface {
+	Detect(ctx context.Context, params PreviewParams) (*DetectResult, error)
+}
+
+// NewDetectPreviewHandler returns an http.HandlerFunc for POST /api/v1/detect/preview.
+// It runs detection and clustering over a window without persisting anything.
+func NewDetectPreviewHandler(svc ClusterPreviewer, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, ok := o.detectParams(w, r)
+		if !ok {
+			return
+		}
+
+		result, err := svc.Preview(r.Context(), params)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.JSON(w, result)
+	}
+}
+
+// NewDetectHandler returns an http.HandlerFunc for POST /api/v1/detect. It
+// takes the same body as the preview, but stores the clusters it finds, which
+// may start automatic analysis of new ones.
+func NewDetectHandler(svc ClusterDetector, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, ok := o.detectParams(w, r)
+		if !ok {
+			return
+		}
+
+		result, err := svc.Detect(r.Context(), params)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.JSON(w, result)
+	}
+}
+
+// detectParams authenticates and validates a detect or preview request. On
+// failure it writes the error response and returns false.
+func (o *options) detectParams(w http.ResponseWriter, r *http.Request) (PreviewParams, bool) {
+	tenantID, ok := mw.GetTenantID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+		return PreviewParams{}, false
+	}
+
+	var req struct {
+		Service   string   `json:"service"   validate:"required"`
+		Namespace string   `json:"namespace"`
+		Start     string   `json:"start"     validate:"required,rfc3339"`
+		End       string   `json:"end"       validate:"required,rfc3339"`
+		Levels    []string `json:"levels"`
+		Limit     int      `json:"limit"`
+	}
+	if err := o.decodeJSON(r, &req); err != nil {
+		invalidBody(w, err)
+		return PreviewParams{}, false
+	}
+
+	if errs := validate(&req); errs != nil {
+		validationError(w, errs)
+		return PreviewParams{}, false
+	}
+	startTime, _ := time.Parse(time.RFC3339, req.Start)
+	endTime, _ := time.Parse(time.RFC3339, req.End)
+	if !endTime.After(startTime) {
+		validationError(w, map[string]string{"end": "end must be after start"})
+		return PreviewParams{}, false
+	}
+
+	ns := req.Namespace
+	if ns == "" {
+		ns = "default"
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultPreviewLimit
+	}
+	if limit > maxPreviewLimit {
+		limit = maxPreviewLimit
+	}
+
+	return PreviewParams{
+		TenantID:  tenantID,
+		Service:   req.Service,
+		Namespace: ns,
+		Start:     startTime,
+		End:       endTime,
+		Levels:    req.Levels,
+		Limit:     limit,
+	}, true
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// --- mock previewer ---
+
+type mockPreviewer struct {
+	result   *PreviewResult
+	err      error
+	captured *PreviewParams
+}
+
+func (p *mockPreviewer) Preview(_ context.Context, params PreviewParams) (*PreviewResult, error) {
+	p.captured = &params
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.result, nil
+}
+
+// --- mock detector ---
+
+type mockDetector struct {
+	result   *DetectResult
+	err      error
+	captured *PreviewParams
+}
+
+func (d *mockDetector) Detect(_ context.Context, params PreviewParams) (*DetectResult, error) {
+	d.captured = &params
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.result, nil
+}
+
+// --- tests ---
+
+func TestDetectPreviewHandler_Success(t *testing.T) {
+	existing := uuid.New()
+	svc := &mockPreviewer{result: &PreviewResult{
+		Clusters: []PreviewCluster{
+			{ErrorCluster: models.ErrorCluster{Service: "api", Fingerprint: "fp-new", Count: 3}},
+			{ErrorCluster: models.ErrorCluster{Service: "api", Fingerprint: "fp-old", Count: 1}, ExistingClusterID: &existing},
+		},
+		Query:        `{service="api"}`,
+		LinesScanned: 4,
+	}}
+	handler := NewDetectPreviewHandler(svc)
+
+	tenantID := uuid.New()
+	req := httptest.NewRequest("POST", "/api/v1/detect/preview", searchBody(t, map[string]any{
+		"service": "api",
+		"start":   time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"end":     time.Now().Format(time.RFC3339),
+	}))
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.captured.TenantID != tenantID {
+		t.Errorf("expected tenant %s, got %s", tenantID, svc.captured.TenantID)
+	}
+	if svc.captured.Namespace != "default" {
+		t.Errorf("expected default namespace, got %q", svc.captured.Namespace)
+	}
+	if svc.captured.Limit != defaultPreviewLimit {
+		t.Errorf("expected default limit %d, got %d", defaultPreviewLimit, svc.captured.Limit)
+	}
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["lines_scanned"] != float64(4) {
+		t.Errorf("expected lines_scanned 4, got %v", data["lines_scanned"])
+	}
+	clusters := data["clusters"].([]any)
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+	if _, ok := clusters[0].(map[string]any)["existing_cluster_id"]; ok {
+		t.Error("expected no existing_cluster_id on a new cluster")
+	}
+	if got := clusters[1].(map[string]any)["existing_cluster_id"]; got != existing.String() {
+		t.Errorf("expected existing_cluster_id %s, got %v", existing, got)
+	}
+}
+
+func TestDetectPreviewHandler_LimitClamped(t *testing.T) {
+	svc := &mockPreviewer{result: &PreviewResult{Clusters: []PreviewCluster{}}}
+	handler := NewDetectPreviewHandler(svc)
+
+	req := httptest.NewRequest("POST", "/api/v1/detect/preview", searchBody(t, map[string]any{
+		"service": "api",
+		"start":   "2024-02-17T10:00:00Z",
+		"end":     "2024-02-17T11:00:00Z",
+		"limit":   maxPreviewLimit + 1,
+	}))
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.captured.Limit != maxPreviewLimit {
+		t.Errorf("expected limit clamped to %d, got %d", maxPreviewLimit, svc.captured.Limit)
+	}
+}
+
+func TestDetectPreviewHandler_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  map[string]any
+		field string
+	}{
+		{"missing service", map[string]any{"start": "2024-02-17T10:00:00Z", "end": "2024-02-17T11:00:00Z"}, "service"},
+		{"bad start", map[string]any{"service": "api", "start": "yesterday", "end": "2024-02-17T11:00:00Z"}, "start"},
+		{"end before start", map[string]any{"service": "api", "start": "2024-02-17T11:00:00Z", "end": "2024-02-17T10:00:00Z"}, "end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockPreviewer{}
+			handler := NewDetectPreviewHandler(svc)
+
+			req := httptest.NewRequest("POST", "/api/v1/detect/preview", searchBody(t, tt.body))
+			req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			details := parseJSON(t, rr)["error"].(map[string]any)["details"].(map[string]any)
+			if _, ok := details[tt.field]; !ok {
+				t.Errorf("expected a %s error, got %v", tt.field, details)
+			}
+			if svc.captured != nil {
+				t.Error("previewer should not be called for an invalid request")
+			}
+		})
+	}
+}
+
+func TestDetectPreviewHandler_LokiUnreachable(t *testing.T) {
+	handler := NewDetectPreviewHandler(&mockPreviewer{err: loki.ErrLokiUnreachable})
+
+	req := httptest.NewRequest("POST", "/api/v1/detect/preview", searchBody(t, map[string]any{
+		"service": "api",
+		"start":   "2024-02-17T10:00:00Z",
+		"end":     "2024-02-17T11:00:00Z",
+	}))
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rr.Code)
+	}
+}
+
+func TestDetectHandler_Success(t *testing.T) {
+	stored := uuid.New()
+	svc := &mockDetector{result: &DetectResult{
+		Clusters:     []*models.ErrorCluster{{ID: stored, Service: "api", Fingerprint: "fp-new", Count: 3}},
+		Query:        `{service="api"}`,
+		LinesScanned: 3,
+	}}
+	handler := NewDetectHandler(svc)
+
+	tenantID := uuid.New()
+	req := httptest.NewRequest("POST", "/api/v1/detect", searchBody(t, map[string]any{
+		"service":   "api",
+		"namespace": "prod",
+		"start":     "2024-02-17T10:00:00Z",
+		"end":       "2024-02-17T11:00:00Z",
+	}))
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.captured.TenantID != tenantID || svc.captured.Namespace != "prod" {
+		t.Errorf("expected tenant %s in prod, got %+v", tenantID, svc.captured)
+	}
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	clusters := data["clusters"].([]any)
+	if len(clusters) != 1 || clusters[0].(map[string]any)["id"] != stored.String() {
+		t.Errorf("expected stored cluster %s, got %v", stored, clusters)
+	}
+}
+
+func TestDetectHandler_InvalidRequest(t *testing.T) {
+	svc := &mockDetector{}
+	handler := NewDetectHandler(svc)
+
+	req := httptest.NewRequest("POST", "/api/v1/detect", searchBody(t, map[string]any{
+		"start": "2024-02-17T10:00:00Z",
+		"end":   "2024-02-17T11:00:00Z",
+	}))
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.captured != nil {
+		t.Error("detector should not be called for an invalid request")
+	}
+}
+
+func TestDetectHandler_NoTenant(t *testing.T) {
+	handler := NewDetectHandler(&mockDetector{})
+
+	req := httptest.NewRequest("POST", "/api/v1/detect", searchBody(t, map[string]any{}))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
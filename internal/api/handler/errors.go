@@ -7,6 +7,7 @@ import (
 	"github.com/kiranshivaraju/loghunter/internal/ai"
 	"github.com/kiranshivaraju/loghunter/internal/loki"
 	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
 )
 
 // mapError maps a service-layer error to an HTTP status code, error code, and message.
@@ -21,8 +22,20 @@ func mapError(err error) (httpStatus int, code string, message string) {
 		return http.StatusBadGateway, "AI_PROVIDER_UNAVAILABLE", "The AI provider is not available"
 	case errors.Is(err, ai.ErrInferenceTimeout):
 		return http.StatusGatewayTimeout, "AI_INFERENCE_TIMEOUT", "AI inference timed out"
+	case errors.Is(err, ai.ErrSummarizeBusy):
+		return http.StatusServiceUnavailable, "SUMMARIZE_BUSY", "Too many summaries in progress, retry later"
+	case errors.Is(err, ai.ErrAnalyzeQueueTimeout):
+		return http.StatusServiceUnavailable, "ANALYZE_BUSY", "Too many analyses in progress, retry later"
+	case errors.Is(err, ai.ErrJobFinished):
+		return http.StatusConflict, "JOB_FINISHED", "Job has already finished"
+	case errors.Is(err, ai.ErrAnalysisInProgress):
+		return http.StatusConflict, "ANALYSIS_IN_PROGRESS", "An analysis of this cluster is already in progress, retry later"
+	case errors.Is(err, ai.ErrModelNotAllowed):
+		return http.StatusBadRequest, "MODEL_NOT_ALLOWED", "The requested model is not allowed"
 	case errors.Is(err, ai.ErrNoLogsFound):
 		return http.StatusNotFound, "NO_LOGS_FOUND", "No logs found for the given parameters"
+	case errors.Is(err, logql.ErrInvalidLabel):
+		return http.StatusBadRequest, "INVALID_LABEL", "Query references a label that is not allowed"
 	case errors.Is(err, store.ErrNotFound):
 		return http.StatusNotFound, "RESOURCE_NOT_FOUND", "Resource not found"
 	default:
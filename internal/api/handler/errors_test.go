@@ -9,6 +9,7 @@ import (
 	"github.com/kiranshivaraju/loghunter/internal/ai"
 	"github.com/kiranshivaraju/loghunter/internal/loki"
 	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
 )
 
 func TestMapError(t *testing.T) {
@@ -26,6 +27,34 @@ func TestMapError(t *testing.T) {
 			wantCode:   "LOKI_UNREACHABLE",
 			wantMsg:    "Loki is unreachable",
 		},
+		{
+			name:       "summarize busy",
+			err:        ai.ErrSummarizeBusy,
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "SUMMARIZE_BUSY",
+			wantMsg:    "Too many summaries in progress, retry later",
+		},
+		{
+			name:       "analysis in progress",
+			err:        ai.ErrAnalysisInProgress,
+			wantStatus: http.StatusConflict,
+			wantCode:   "ANALYSIS_IN_PROGRESS",
+			wantMsg:    "An analysis of this cluster is already in progress, retry later",
+		},
+		{
+			name:       "analyze queue timeout",
+			err:        ai.ErrAnalyzeQueueTimeout,
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "ANALYZE_BUSY",
+			wantMsg:    "Too many analyses in progress, retry later",
+		},
+		{
+			name:       "invalid label",
+			err:        fmt.Errorf("%w: %q", logql.ErrInvalidLabel, "pod"),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_LABEL",
+			wantMsg:    "Query references a label that is not allowed",
+		},
 		{
 			name:       "loki query error",
 			err:        loki.ErrLokiQueryError,
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"net/http"
 	"sync"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
 // DBPinger checks database connectivity.
@@ -18,6 +21,12 @@ type CachePinger interface {
 	Ping(ctx context.Context) error
 }
 
+// CacheDegradedReporter is implemented by caches standing in for one that was
+// unavailable at startup, such as cache.NopCache.
+type CacheDegradedReporter interface {
+	Degraded() bool
+}
+
 // LokiReadyChecker checks Loki availability.
 type LokiReadyChecker interface {
 	Ready(ctx context.Context) error
@@ -28,10 +37,65 @@ type AIProviderNamer interface {
 	Name() string
 }
 
+// AIInferenceChecker is implemented by AI providers that can prove they serve
+// inference, not just that they are configured. See DeepAIHealthCheck.
+type AIInferenceChecker interface {
+	CheckInference(ctx context.Context) error
+}
+
+// deepAIHealthTimeout bounds the inference run by DeepAIHealthCheck.
+const deepAIHealthTimeout = 5 * time.Second
+
+// DeepAIHealthCheck wraps an AI provider so the health check runs a tiny
+// Summarize against it, catching a missing or misconfigured model that a
+// reachability check would not. Each inference costs a provider call, so its
+// result is reused for an interval.
+type DeepAIHealthCheck struct {
+	models.AIProvider
+	interval time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	lastErr   error
+}
+
+// NewDeepAIHealthCheck wraps provider for deep health checks, running at most
+// one inference per interval. interval <= 0 runs one on every check.
+func NewDeepAIHealthCheck(provider models.AIProvider, interval time.Duration) *DeepAIHealthCheck {
+	return &DeepAIHealthCheck{AIProvider: provider, interval: interval}
+}
+
+// CheckInference summarizes a one-line synthetic log, or returns the previous
+// result if it is younger than the interval. Concurrent checks share one
+// inference.
+func (d *DeepAIHealthCheck) CheckInference(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.checkedAt.IsZero() && time.Since(d.checkedAt) < d.interval {
+		return d.lastErr
+	}
+
+	// Detached from the request, so a client hanging up is not cached as a
+	// provider failure.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deepAIHealthTimeout)
+	defer cancel()
+	_, err := d.Summarize(ctx, models.SummarizeRequest{Logs: []models.LogLine{{
+		Timestamp: time.Now().UTC(),
+		Level:     "info",
+		Message:   "loghunter health check",
+	}}})
+	d.checkedAt, d.lastErr = time.Now(), err
+	return err
+}
+
 var errNotConfigured = errors.New("not configured")
 
 // NewHealthHandler returns an http.HandlerFunc for GET /api/v1/health.
-// All dependency checks run concurrently.
+// All dependency checks run concurrently. When cache also implements
+// CacheStatsReporter, its hit and miss counters are included. A cache that
+// reports itself degraded is checked as "degraded": the server is running
+// without it on purpose, so that alone does not fail the health check. When ai
+// implements AIInferenceChecker, a failed inference reports it as "degraded".
 func NewHealthHandler(db DBPinger, cache CachePinger, loki LokiReadyChecker, ai AIProviderNamer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -47,36 +111,66 @@ func NewHealthHandler(db DBPinger, cache CachePinger, loki LokiReadyChecker, ai
 		wg.Add(4)
 
 		go func() { defer wg.Done(); s := "ok"; if db.Ping(ctx) != nil { s = "error" }; ch <- result{"database", s} }()
-		go func() { defer wg.Done(); s := "ok"; if cache.Ping(ctx) != nil { s = "error" }; ch <- result{"redis", s} }()
+		go func() { defer wg.Done(); ch <- result{"redis", cacheStatus(ctx, cache)} }()
 		go func() { defer wg.Done(); s := "ok"; if loki.Ready(ctx) != nil { s = "error" }; ch <- result{"loki", s} }()
-		go func() { defer wg.Done(); s := "ok"; if ai == nil { s = "error" }; ch <- result{"ai_provider", s} }()
+		go func() { defer wg.Done(); ch <- result{"ai_provider", aiStatus(ctx, ai)} }()
 
 		wg.Wait()
 		close(ch)
 
 		checks := make(map[string]string, 4)
-		degraded := false
+		degraded, failed := false, false
 		for res := range ch {
 			checks[res.name] = res.status
 			if res.status != "ok" {
 				degraded = true
 			}
+			if res.status == "error" {
+				failed = true
+			}
 		}
 
 		status := "ok"
 		httpStatus := http.StatusOK
 		if degraded {
 			status = "degraded"
+		}
+		if failed {
 			httpStatus = http.StatusServiceUnavailable
 		}
 
+		data := map[string]any{
+			"status": status,
+			"checks": checks,
+		}
+		if sr, ok := cache.(CacheStatsReporter); ok {
+			data["cache"] = sr.Stats()
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(httpStatus)
-		json.NewEncoder(w).Encode(map[string]any{
-			"data": map[string]any{
-				"status": status,
-				"checks": checks,
-			},
-		})
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}
+}
+
+// cacheStatus returns the health check status of cache.
+func cacheStatus(ctx context.Context, cache CachePinger) string {
+	if dr, ok := cache.(CacheDegradedReporter); ok && dr.Degraded() {
+		return "degraded"
+	}
+	if cache.Ping(ctx) != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// aiStatus returns the health check status of the AI provider.
+func aiStatus(ctx context.Context, ai AIProviderNamer) string {
+	if ai == nil {
+		return "error"
+	}
+	if ic, ok := ai.(AIInferenceChecker); ok && ic.CheckInference(ctx) != nil {
+		return "degraded"
 	}
+	return "ok"
 }
@@ -6,6 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/internal/cache"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
 // --- mock health checkers ---
@@ -115,6 +119,29 @@ func TestHealthHandler_RedisDown(t *testing.T) {
 	}
 }
 
+func TestHealthHandler_CacheDisabled(t *testing.T) {
+	handler := NewHealthHandler(&healthMockDB{}, cache.NopCache{}, &healthMockLoki{}, &healthMockAI{name: "openai"})
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	// Running without Redis was configured, so the server still reports ready.
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["status"] != "degraded" {
+		t.Errorf("expected status 'degraded', got %v", data["status"])
+	}
+	checks := data["checks"].(map[string]any)
+	if checks["redis"] != "degraded" {
+		t.Errorf("expected redis 'degraded', got %v", checks["redis"])
+	}
+}
+
 func TestHealthHandler_LokiDown(t *testing.T) {
 	handler := NewHealthHandler(
 		&healthMockDB{},
@@ -245,3 +272,129 @@ func TestHealthHandler_AllDepsDown(t *testing.T) {
 		}
 	}
 }
+
+func TestHealthHandler_IncludesCacheStats(t *testing.T) {
+	handler := NewHealthHandler(
+		&healthMockDB{},
+		&statsMockCache{stats: cache.CacheStats{Hits: 4, Misses: 1}},
+		&healthMockLoki{},
+		&healthMockAI{name: "openai"},
+	)
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	resp := parseJSON(t, rr)
+	data := resp["data"].(map[string]any)
+	stats, ok := data["cache"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected cache stats in health response, got %v", data)
+	}
+	if stats["hits"] != float64(4) || stats["misses"] != float64(1) {
+		t.Errorf("unexpected cache stats %v", stats)
+	}
+}
+
+func TestHealthHandler_OmitsCacheStatsWhenUnsupported(t *testing.T) {
+	handler := NewHealthHandler(&healthMockDB{}, &healthMockCache{}, &healthMockLoki{}, &healthMockAI{name: "openai"})
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if _, ok := data["cache"]; ok {
+		t.Errorf("expected no cache stats, got %v", data["cache"])
+	}
+}
+
+// healthMockProvider is an AI provider whose Summarize returns err.
+type healthMockProvider struct {
+	err   error
+	calls int
+}
+
+func (p *healthMockProvider) Name() string { return "mock" }
+func (p *healthMockProvider) Analyze(_ context.Context, _ models.AnalysisRequest) (models.AnalysisResult, error) {
+	return models.AnalysisResult{}, nil
+}
+func (p *healthMockProvider) Summarize(ctx context.Context, req models.SummarizeRequest) (models.Summary, error) {
+	p.calls++
+	if _, ok := ctx.Deadline(); !ok {
+		return models.Summary{}, errors.New("expected a deadline on the deep health check")
+	}
+	if len(req.Logs) != 1 {
+		return models.Summary{}, errors.New("expected a one-line synthetic log")
+	}
+	return models.Summary{Text: "ok"}, p.err
+}
+
+func TestHealthHandler_DeepAICheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCheck  string
+		wantStatus string
+	}{
+		{"inference succeeds", nil, "ok", "ok"},
+		{"inference fails", errors.New("model not found"), "degraded", "degraded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &healthMockProvider{err: tt.err}
+			handler := NewHealthHandler(&healthMockDB{}, &healthMockCache{}, &healthMockLoki{},
+				NewDeepAIHealthCheck(provider, 0))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/health", nil))
+
+			// A degraded provider does not take the server out of rotation.
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			data := parseJSON(t, rr)["data"].(map[string]any)
+			if got := data["checks"].(map[string]any)["ai_provider"]; got != tt.wantCheck {
+				t.Errorf("expected ai_provider %q, got %v", tt.wantCheck, got)
+			}
+			if data["status"] != tt.wantStatus {
+				t.Errorf("expected status %q, got %v", tt.wantStatus, data["status"])
+			}
+			if provider.calls != 1 {
+				t.Errorf("expected one inference, got %d", provider.calls)
+			}
+		})
+	}
+}
+
+func TestHealthHandler_DeepAICheckCachedForInterval(t *testing.T) {
+	provider := &healthMockProvider{err: errors.New("model not found")}
+	handler := NewHealthHandler(&healthMockDB{}, &healthMockCache{}, &healthMockLoki{},
+		NewDeepAIHealthCheck(provider, time.Minute))
+
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/health", nil))
+		if got := parseJSON(t, rr)["data"].(map[string]any)["checks"].(map[string]any)["ai_provider"]; got != "degraded" {
+			t.Errorf("request %d: expected cached ai_provider 'degraded', got %v", i, got)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected one inference per interval, got %d", provider.calls)
+	}
+}
+
+func TestHealthHandler_ShallowAICheckSkipsInference(t *testing.T) {
+	provider := &healthMockProvider{err: errors.New("model not found")}
+	handler := NewHealthHandler(&healthMockDB{}, &healthMockCache{}, &healthMockLoki{}, provider)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/health", nil))
+
+	if got := parseJSON(t, rr)["data"].(map[string]any)["checks"].(map[string]any)["ai_provider"]; got != "ok" {
+		t.Errorf("expected ai_provider 'ok', got %v", got)
+	}
+	if provider.calls != 0 {
+		t.Errorf("expected no inference without deep health, got %d", provider.calls)
+	}
+}
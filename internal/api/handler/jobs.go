@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+const defaultJobStatsWindow = 24 * time.Hour
+
+// JobStatsGetter is the store interface needed by NewJobStatsHandler.
+type JobStatsGetter interface {
+	JobStats(ctx context.Context, tenantID uuid.UUID, since time.Time) (store.JobStats, error)
+}
+
+// NewJobStatsHandler returns an http.HandlerFunc for GET /api/v1/jobs/stats.
+func NewJobStatsHandler(st JobStatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		window := defaultJobStatsWindow
+		if since := r.URL.Query().Get("since"); since != "" {
+			dur, err := time.ParseDuration(since)
+			if err != nil || dur <= 0 {
+				response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "since must be a valid Go duration (e.g. 1h, 30m)", nil)
+				return
+			}
+			window = dur
+		}
+
+		stats, err := st.JobStats(r.Context(), tenantID, time.Now().Add(-window))
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		byStatus := map[string]int{
+			models.JobStatusPending:   0,
+			models.JobStatusRunning:   0,
+			models.JobStatusCompleted: 0,
+			models.JobStatusFailed:    0,
+			models.JobStatusCancelled: 0,
+		}
+		for s, n := range stats.ByStatus {
+			byStatus[s] = n
+		}
+
+		response.JSON(w, map[string]any{
+			"since":           window.String(),
+			"total":           stats.Total,
+			"by_status":       byStatus,
+			"avg_duration_ms": stats.AvgDuration.Milliseconds(),
+		})
+	}
+}
+
+// JobLister is the store interface needed by NewListJobsHandler.
+type JobLister interface {
+	ListJobs(ctx context.Context, tenantID uuid.UUID, filter store.JobFilter) ([]*models.Job, int, error)
+}
+
+// NewListJobsHandler returns an http.HandlerFunc for GET /api/v1/admin/jobs.
+// Supports ?status=, ?type= and ?error_code= filters plus the usual ?page= and ?limit=;
+// failed jobs carry their error_message and error_code.
+func NewListJobsHandler(st JobLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		q := r.URL.Query()
+		page, limit := response.ParseListParams(r)
+		filter := store.JobFilter{
+			Status:    q.Get("status"),
+			Type:      q.Get("type"),
+			ErrorCode: q.Get("error_code"),
+			Page:      page,
+			Limit:     limit,
+		}
+		switch filter.Status {
+		case "", models.JobStatusPending, models.JobStatusRunning, models.JobStatusCompleted,
+			models.JobStatusFailed, models.JobStatusCancelled:
+		default:
+			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "status must be one of pending, running, completed, failed, cancelled", nil)
+			return
+		}
+
+		jobs, total, err := st.ListJobs(r.Context(), tenantID, filter)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.Collection(w, jobs, response.NewPaginationMeta(page, limit, total))
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// --- mock job stats store ---
+
+type jobStatsMockStore struct {
+	stats store.JobStats
+	err   error
+
+	capturedTenant uuid.UUID
+	capturedSince  time.Time
+}
+
+func (s *jobStatsMockStore) JobStats(_ context.Context, tenantID uuid.UUID, since time.Time) (store.JobStats, error) {
+	s.capturedTenant = tenantID
+	s.capturedSince = since
+	return s.stats, s.err
+}
+
+func TestJobStatsHandler_Success(t *testing.T) {
+	tenantID := uuid.New()
+	st := &jobStatsMockStore{stats: store.JobStats{
+		Total:       5,
+		ByStatus:    map[string]int{"completed": 3, "failed": 2},
+		AvgDuration: 1500 * time.Millisecond,
+	}}
+
+	handler := NewJobStatsHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/jobs/stats?since=1h", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if st.capturedTenant != tenantID {
+		t.Errorf("expected tenant %s, got %s", tenantID, st.capturedTenant)
+	}
+	if d := time.Since(st.capturedSince); d < 59*time.Minute || d > 61*time.Minute {
+		t.Errorf("expected since ~1h ago, got %v ago", d)
+	}
+
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["total"] != float64(5) {
+		t.Errorf("expected total 5, got %v", data["total"])
+	}
+	if data["avg_duration_ms"] != float64(1500) {
+		t.Errorf("expected avg_duration_ms 1500, got %v", data["avg_duration_ms"])
+	}
+	byStatus := data["by_status"].(map[string]any)
+	if byStatus["completed"] != float64(3) || byStatus["failed"] != float64(2) {
+		t.Errorf("unexpected by_status: %v", byStatus)
+	}
+	if byStatus["pending"] != float64(0) || byStatus["running"] != float64(0) {
+		t.Errorf("expected zero counts for missing statuses, got %v", byStatus)
+	}
+}
+
+func TestJobStatsHandler_DefaultWindow(t *testing.T) {
+	st := &jobStatsMockStore{}
+	handler := NewJobStatsHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/jobs/stats", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if d := time.Since(st.capturedSince); d < 23*time.Hour || d > 25*time.Hour {
+		t.Errorf("expected default window of 24h, got %v", d)
+	}
+}
+
+func TestJobStatsHandler_InvalidSince(t *testing.T) {
+	handler := NewJobStatsHandler(&jobStatsMockStore{})
+
+	req := httptest.NewRequest("GET", "/api/v1/jobs/stats?since=yesterday", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestJobStatsHandler_NoTenant(t *testing.T) {
+	handler := NewJobStatsHandler(&jobStatsMockStore{})
+
+	req := httptest.NewRequest("GET", "/api/v1/jobs/stats", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestJobStatsHandler_StoreError(t *testing.T) {
+	handler := NewJobStatsHandler(&jobStatsMockStore{err: errors.New("db down")})
+
+	req := httptest.NewRequest("GET", "/api/v1/jobs/stats", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+// --- mock job lister ---
+
+type jobListMockStore struct {
+	jobs  []*models.Job
+	total int
+	err   error
+
+	capturedTenant uuid.UUID
+	captured       store.JobFilter
+}
+
+func (s *jobListMockStore) ListJobs(_ context.Context, tenantID uuid.UUID, filter store.JobFilter) ([]*models.Job, int, error) {
+	s.capturedTenant = tenantID
+	s.captured = filter
+	return s.jobs, s.total, s.err
+}
+
+func TestListJobsHandler_Filters(t *testing.T) {
+	tenantID := uuid.New()
+	code, msg := models.JobErrorAITimeout, "inference timed out"
+	st := &jobListMockStore{
+		jobs: []*models.Job{{
+			ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+			Status: models.JobStatusFailed, ErrorCode: &code, ErrorMessage: &msg,
+		}},
+		total: 1,
+	}
+	handler := NewListJobsHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/jobs?status=failed&type=analysis&error_code=AI_TIMEOUT&page=2&limit=10", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if st.capturedTenant != tenantID {
+		t.Errorf("expected tenant %s, got %s", tenantID, st.capturedTenant)
+	}
+	want := store.JobFilter{Status: "failed", Type: "analysis", ErrorCode: "AI_TIMEOUT", Page: 2, Limit: 10}
+	if st.captured != want {
+		t.Errorf("expected filter %+v, got %+v", want, st.captured)
+	}
+
+	resp := parseJSON(t, rr)
+	data := resp["data"].([]any)
+	if len(data) != 1 {
+		t.Fatalf("expected 1 job, got %d", len(data))
+	}
+	job := data[0].(map[string]any)
+	if job["error_code"] != "AI_TIMEOUT" || job["error_message"] != "inference timed out" {
+		t.Errorf("expected error code and message, got %v", job)
+	}
+	meta := resp["meta"].(map[string]any)
+	if meta["total"] != float64(1) || meta["page"] != float64(2) {
+		t.Errorf("unexpected meta: %v", meta)
+	}
+}
+
+func TestListJobsHandler_InvalidStatus(t *testing.T) {
+	st := &jobListMockStore{}
+	handler := NewListJobsHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/jobs?status=broken", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestListJobsHandler_NoTenant(t *testing.T) {
+	handler := NewListJobsHandler(&jobListMockStore{})
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/jobs", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestListJobsHandler_StoreError(t *testing.T) {
+	handler := NewListJobsHandler(&jobListMockStore{err: errors.New("db down")})
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/jobs", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
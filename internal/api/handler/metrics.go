@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kiranshivaraju/loghunter/internal/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// CacheStatsReporter exposes cache hit and miss counters.
+type CacheStatsReporter interface {
+	Stats() cache.CacheStats
+}
+
+// NewMetricsHandler returns an http.HandlerFunc for GET /metrics, serving
+// everything registered with the default Prometheus registry (request and job
+// metrics, Go runtime stats) along with the cache counters of c.
+func NewMetricsHandler(c CacheStatsReporter) http.HandlerFunc {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "loghunter_cache_hits_total",
+			Help: "Cache lookups that found a value.",
+		}, func() float64 { return float64(c.Stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "loghunter_cache_misses_total",
+			Help: "Cache lookups that found no value.",
+		}, func() float64 { return float64(c.Stats().Misses) }),
+	)
+	return promhttp.HandlerFor(
+		prometheus.Gatherers{prometheus.DefaultGatherer, reg},
+		promhttp.HandlerOpts{},
+	).ServeHTTP
+}
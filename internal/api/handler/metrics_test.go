@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kiranshivaraju/loghunter/internal/cache"
+)
+
+type statsMockCache struct {
+	healthMockCache
+	stats cache.CacheStats
+}
+
+func (m *statsMockCache) Stats() cache.CacheStats { return m.stats }
+
+func TestMetricsHandler_CacheCounters(t *testing.T) {
+	handler := NewMetricsHandler(&statsMockCache{stats: cache.CacheStats{Hits: 7, Misses: 3}})
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"# TYPE loghunter_cache_hits_total counter\n",
+		"loghunter_cache_hits_total 7\n",
+		"# TYPE loghunter_cache_misses_total counter\n",
+		"loghunter_cache_misses_total 3\n",
+		// Default registry collectors are served too.
+		"# TYPE go_goroutines gauge\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures behavior shared by the handlers. The server builds one set
+// of options from its config and passes it to every constructor that takes
+// them; without options the handlers keep their defaults.
+type Option func(*options)
+
+type options struct {
+	crossTenantStatus  int
+	strictBodies       bool
+	clusterLevels      map[string]bool
+	clusterLevelNames  []string
+	maxSummarizeWindow time.Duration
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		crossTenantStatus:  http.StatusNotFound,
+		maxSummarizeWindow: DefaultMaxSummarizeWindow,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -2,7 +2,8 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 	"unicode"
@@ -10,6 +11,8 @@ import (
 	"github.com/google/uuid"
 	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
 	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
+	"github.com/kiranshivaraju/loghunter/pkg/logql"
 )
 
 // SearchParams holds validated parameters for a search request.
@@ -22,6 +25,8 @@ type SearchParams struct {
 	Levels    []string
 	Keyword   string
 	Limit     int
+	// Cluster also groups the returned lines into clusters.
+	Cluster bool
 }
 
 // SearchResult is the output of a search operation.
@@ -29,6 +34,12 @@ type SearchResult struct {
 	Results  []SearchResultLine `json:"results"`
 	Query    string             `json:"query"`
 	CacheHit bool              `json:"cache_hit"`
+	// Truncated is set when Loki returned more lines than the client's
+	// max-lines cap, so the results are incomplete.
+	Truncated bool `json:"truncated"`
+	// Clusters groups Results when clustering was requested. As in a
+	// preview, ExistingClusterID points at the stored cluster, if any.
+	Clusters []PreviewCluster `json:"clusters,omitempty"`
 }
 
 // SearchResultLine represents a single log line in search results.
@@ -37,6 +48,8 @@ type SearchResultLine struct {
 	Message   string            `json:"message"`
 	Level     string            `json:"level"`
 	Labels    map[string]string `json:"labels"`
+	// Fields are the structured fields of a JSON log line, absent for plaintext.
+	Fields    map[string]string `json:"fields,omitempty"`
 	ClusterID *uuid.UUID        `json:"cluster_id,omitempty"`
 }
 
@@ -46,7 +59,11 @@ type Searcher interface {
 }
 
 // NewSearchHandler returns an http.HandlerFunc for POST /api/v1/search.
-func NewSearchHandler(svc Searcher) http.HandlerFunc {
+// The text to match is given as query, or as its older name keyword; set
+// cluster to also group the matching lines. It answers 404 NO_LOGS_FOUND when
+// nothing matches.
+func NewSearchHandler(svc Searcher, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -55,42 +72,34 @@ func NewSearchHandler(svc Searcher) http.HandlerFunc {
 		}
 
 		var req struct {
-			Service   string   `json:"service"`
+			Service   string   `json:"service"   validate:"required"`
 			Namespace string   `json:"namespace"`
-			Start     string   `json:"start"`
-			End       string   `json:"end"`
+			Start     string   `json:"start"     validate:"required,rfc3339"`
+			End       string   `json:"end"       validate:"required,rfc3339"`
 			Levels    []string `json:"levels"`
+			Query     string   `json:"query"`
 			Keyword   string   `json:"keyword"`
 			Limit     int      `json:"limit"`
+			Cluster   bool     `json:"cluster"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON body", nil)
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
 			return
 		}
 
-		if req.Service == "" {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "service is required", nil)
+		if errs := validate(&req); errs != nil {
+			validationError(w, errs)
 			return
 		}
+		startTime, _ := time.Parse(time.RFC3339, req.Start)
+		endTime, _ := time.Parse(time.RFC3339, req.End)
 
-		if req.Start == "" {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "start is required", nil)
-			return
-		}
-		startTime, err := time.Parse(time.RFC3339, req.Start)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "start must be a valid RFC3339 timestamp", nil)
-			return
-		}
-
-		if req.End == "" {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "end is required", nil)
-			return
-		}
-		endTime, err := time.Parse(time.RFC3339, req.End)
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "end must be a valid RFC3339 timestamp", nil)
-			return
+		if req.Query != "" {
+			if req.Keyword != "" {
+				validationError(w, map[string]string{"query": "query cannot be combined with keyword"})
+				return
+			}
+			req.Keyword = req.Query
 		}
 
 		// Validate keyword
@@ -130,6 +139,7 @@ func NewSearchHandler(svc Searcher) http.HandlerFunc {
 			Levels:    req.Levels,
 			Keyword:   req.Keyword,
 			Limit:     limit,
+			Cluster:   req.Cluster,
 		})
 		if err != nil {
 			status, code, msg := mapError(err)
@@ -140,3 +150,64 @@ func NewSearchHandler(svc Searcher) http.HandlerFunc {
 		response.JSON(w, result)
 	}
 }
+
+// maxValidateQueryLen caps the LogQL expression accepted for validation.
+const maxValidateQueryLen = 4096
+
+// QueryValidator checks whether Loki accepts a LogQL expression.
+type QueryValidator interface {
+	ValidateQuery(ctx context.Context, expr string) error
+}
+
+// NewValidateQueryHandler returns an http.HandlerFunc for POST /api/v1/search/validate.
+// It answers {"valid": true}, or {"valid": false, "error": ...} with Loki's
+// parse message, so a UI can check a query before running an expensive search.
+// Label names in the query's stream selectors must be in allowedLabels (nil
+// means logql.DefaultAllowedLabels), as for every other query path.
+func NewValidateQueryHandler(v QueryValidator, allowedLabels []string, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	qb := logql.QueryBuilder{AllowedLabels: allowedLabels}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := mw.GetTenantID(r); !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		var req struct {
+			Query string `json:"query" validate:"required"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if errs := validate(&req); errs != nil {
+			validationError(w, errs)
+			return
+		}
+		if len(req.Query) > maxValidateQueryLen {
+			validationError(w, map[string]string{
+				"query": fmt.Sprintf("query must be %d bytes or fewer", maxValidateQueryLen),
+			})
+			return
+		}
+
+		if err := qb.CheckQuery(req.Query); err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		err := v.ValidateQuery(r.Context(), req.Query)
+		var parseErr *loki.QueryParseError
+		switch {
+		case err == nil:
+			response.JSON(w, map[string]any{"valid": true})
+		case errors.As(err, &parseErr):
+			response.JSON(w, map[string]any{"valid": false, "error": parseErr.Message})
+		default:
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/loki"
 )
 
 // --- mock searcher ---
@@ -91,6 +92,41 @@ func TestSearchHandler_Success(t *testing.T) {
 	}
 }
 
+func TestSearchHandler_Fields(t *testing.T) {
+	now := time.Now()
+	svc := &mockSearcher{
+		result: &SearchResult{
+			Results: []SearchResultLine{
+				{Timestamp: now, Message: `{"msg":"timeout","user":"42"}`, Fields: map[string]string{"msg": "timeout", "user": "42"}},
+				{Timestamp: now, Message: "plain timeout"},
+			},
+		},
+	}
+
+	body := searchBody(t, map[string]any{
+		"service": "api",
+		"start":   now.Add(-1 * time.Hour).Format(time.RFC3339),
+		"end":     now.Format(time.RFC3339),
+	})
+	req := httptest.NewRequest("POST", "/api/v1/search", body)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	NewSearchHandler(svc).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	results := parseSearchResp(t, rr)["data"].(map[string]any)["results"].([]any)
+	fields, ok := results[0].(map[string]any)["fields"].(map[string]any)
+	if !ok || fields["user"] != "42" || fields["msg"] != "timeout" {
+		t.Errorf("expected fields on the JSON line, got %v", results[0])
+	}
+	if _, ok := results[1].(map[string]any)["fields"]; ok {
+		t.Errorf("expected no fields on the plaintext line, got %v", results[1])
+	}
+}
+
 func TestSearchHandler_CacheHit(t *testing.T) {
 	svc := &mockSearcher{
 		result: &SearchResult{
@@ -446,3 +482,163 @@ func TestSearchHandler_EmptyKeywordAllowed(t *testing.T) {
 		t.Fatalf("expected 200 for empty keyword (browse mode), got %d: %s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestSearchHandler_QueryAndCluster(t *testing.T) {
+	svc := &mockSearcher{result: &SearchResult{Results: []SearchResultLine{}, Query: "timeout"}}
+	handler := NewSearchHandler(svc)
+
+	body := searchBody(t, map[string]any{
+		"service": "api",
+		"query":   "timeout",
+		"cluster": true,
+		"start":   time.Now().Add(-1 * time.Hour).Format(time.RFC3339),
+		"end":     time.Now().Format(time.RFC3339),
+	})
+	req := httptest.NewRequest("POST", "/api/v1/search", body)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.captured.Keyword != "timeout" || !svc.captured.Cluster {
+		t.Errorf("expected query and cluster to be passed through, got %+v", svc.captured)
+	}
+}
+
+func TestSearchHandler_QueryWithKeyword(t *testing.T) {
+	svc := &mockSearcher{}
+	handler := NewSearchHandler(svc)
+
+	body := searchBody(t, map[string]any{
+		"service": "api",
+		"query":   "timeout",
+		"keyword": "timeout",
+		"start":   time.Now().Add(-1 * time.Hour).Format(time.RFC3339),
+		"end":     time.Now().Format(time.RFC3339),
+	})
+	req := httptest.NewRequest("POST", "/api/v1/search", body)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	if svc.captured != nil {
+		t.Error("service should not be called when query and keyword are both set")
+	}
+}
+
+func TestSearchHandler_NoLogsFound(t *testing.T) {
+	svc := &mockSearcher{err: ErrNoLogsFound}
+	handler := NewSearchHandler(svc)
+
+	body := searchBody(t, map[string]any{
+		"service": "api",
+		"query":   "timeout",
+		"start":   time.Now().Add(-1 * time.Hour).Format(time.RFC3339),
+		"end":     time.Now().Format(time.RFC3339),
+	})
+	req := httptest.NewRequest("POST", "/api/v1/search", body)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	errObj := parseSearchResp(t, rr)["error"].(map[string]any)
+	if errObj["code"] != "NO_LOGS_FOUND" {
+		t.Errorf("expected NO_LOGS_FOUND, got %v", errObj["code"])
+	}
+}
+
+// --- validate query tests ---
+
+type mockQueryValidator struct {
+	err  error
+	expr string
+}
+
+func (v *mockQueryValidator) ValidateQuery(_ context.Context, expr string) error {
+	v.expr = expr
+	return v.err
+}
+
+func serveValidateQuery(t *testing.T, v QueryValidator, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/v1/search/validate", searchBody(t, body))
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+	NewValidateQueryHandler(v, nil).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestValidateQueryHandler_Valid(t *testing.T) {
+	v := &mockQueryValidator{}
+	rr := serveValidateQuery(t, v, map[string]any{"query": `{service="api"}`})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseSearchResp(t, rr)["data"].(map[string]any)
+	if data["valid"] != true {
+		t.Errorf("expected valid=true, got %v", data)
+	}
+	if v.expr != `{service="api"}` {
+		t.Errorf("expected query to be passed through, got %q", v.expr)
+	}
+}
+
+func TestValidateQueryHandler_Invalid(t *testing.T) {
+	v := &mockQueryValidator{err: &loki.QueryParseError{Message: "parse error at line 1"}}
+	rr := serveValidateQuery(t, v, map[string]any{"query": `{service="api"} oops`})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	data := parseSearchResp(t, rr)["data"].(map[string]any)
+	if data["valid"] != false || data["error"] != "parse error at line 1" {
+		t.Errorf("expected valid=false with Loki's message, got %v", data)
+	}
+}
+
+func TestValidateQueryHandler_LokiUnreachable(t *testing.T) {
+	rr := serveValidateQuery(t, &mockQueryValidator{err: loki.ErrLokiUnreachable}, map[string]any{"query": "{}"})
+
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestValidateQueryHandler_DisallowedLabel(t *testing.T) {
+	v := &mockQueryValidator{}
+	rr := serveValidateQuery(t, v, map[string]any{"query": `{service="api", pod=~".+"}`})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if code := parseSearchResp(t, rr)["error"].(map[string]any)["code"]; code != "INVALID_LABEL" {
+		t.Errorf("expected INVALID_LABEL, got %v", code)
+	}
+	if v.expr != "" {
+		t.Error("query with a disallowed label must not reach Loki")
+	}
+}
+
+func TestValidateQueryHandler_MissingQuery(t *testing.T) {
+	v := &mockQueryValidator{}
+	rr := serveValidateQuery(t, v, map[string]any{})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if v.expr != "" {
+		t.Error("validator must not be called without a query")
+	}
+}
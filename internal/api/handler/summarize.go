@@ -1,19 +1,47 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/kiranshivaraju/loghunter/internal/ai"
 	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
 	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/internal/store"
 )
 
 // ErrNoLogsFound is returned when no logs match the query parameters.
 var ErrNoLogsFound = ai.ErrNoLogsFound
 
+// DefaultMaxSummarizeWindow is the longest window a summarize request may
+// cover unless WithMaxSummarizeWindow says otherwise.
+const DefaultMaxSummarizeWindow = 7 * 24 * time.Hour
+
+// WithMaxSummarizeWindow sets the longest start/end or lookback window the
+// summarize handlers accept; 0 removes the bound.
+func WithMaxSummarizeWindow(d time.Duration) Option {
+	return func(o *options) { o.maxSummarizeWindow = d }
+}
+
+// checkSummarizeWindow returns why start..end cannot be summarized, or "" if
+// it can.
+func (o *options) checkSummarizeWindow(start, end time.Time) string {
+	if !end.After(start) {
+		return "end must be after start"
+	}
+	if o.maxSummarizeWindow > 0 && end.Sub(start) > o.maxSummarizeWindow {
+		return fmt.Sprintf("window must not exceed %s", o.maxSummarizeWindow)
+	}
+	return ""
+}
+
 // SummarizeParams holds validated parameters for a summarization request.
 type SummarizeParams struct {
 	TenantID  uuid.UUID
@@ -22,6 +50,13 @@ type SummarizeParams struct {
 	Start     time.Time
 	End       time.Time
 	MaxLines  int
+	// Model overrides the provider's default model; empty uses the default.
+	Model string
+	// Levels and Fingerprint narrow the summary to one cluster's lines.
+	Levels      []string
+	Fingerprint string
+	// NoCache bypasses the cached summary and Loki lines.
+	NoCache bool
 }
 
 // SummarizeResult is the output of a summarization operation.
@@ -32,15 +67,26 @@ type SummarizeResult struct {
 	To            time.Time `json:"to"`
 	Provider      string    `json:"provider"`
 	Model         string    `json:"model"`
+	CacheHit      bool      `json:"cache_hit"`
+	// LogsCacheHit is set when the lines came from the Loki query cache.
+	LogsCacheHit bool `json:"logs_cache_hit"`
+	// Truncated is set when Loki returned more lines than the client's
+	// max-lines cap, so the summary covers only part of the window.
+	Truncated bool `json:"truncated"`
 }
 
 // Summarizer defines the interface the handler depends on.
+// ctx is the request context, so a client disconnect cancels Loki and AI work.
 type Summarizer interface {
-	Summarize(params SummarizeParams) (*SummarizeResult, error)
+	Summarize(ctx context.Context, params SummarizeParams) (*SummarizeResult, error)
 }
 
 // NewSummarizeHandler returns an http.HandlerFunc for POST /api/v1/summarize.
-func NewSummarizeHandler(svc Summarizer) http.HandlerFunc {
+// The window is given as start/end, or as a lookback duration such as "1h"
+// ending now; the two forms are mutually exclusive. Every invalid field is
+// reported in a single 400.
+func NewSummarizeHandler(svc Summarizer, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID, ok := mw.GetTenantID(r)
 		if !ok {
@@ -48,83 +94,332 @@ func NewSummarizeHandler(svc Summarizer) http.HandlerFunc {
 			return
 		}
 
+		// The window is either an explicit start/end or a lookback ending now.
 		var req struct {
-			Service   string `json:"service"`
+			Service   string `json:"service"    validate:"required"`
 			Namespace string `json:"namespace"`
-			Start     string `json:"start"`
-			End       string `json:"end"`
+			Start     string `json:"start"      validate:"rfc3339"`
+			End       string `json:"end"        validate:"rfc3339"`
+			Lookback  string `json:"lookback"`
 			MaxLines  int    `json:"max_lines"`
+			Model     string `json:"model"`
+			NoCache   bool   `json:"no_cache"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON body", nil)
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
 			return
 		}
 
-		if req.Service == "" {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "service is required", nil)
+		errs := validate(&req)
+		addErr := func(field, msg string) {
+			if errs == nil {
+				errs = make(map[string]string)
+			}
+			if _, ok := errs[field]; !ok {
+				errs[field] = msg
+			}
+		}
+		var startTime, endTime time.Time
+		if req.Lookback != "" {
+			if req.Start != "" || req.End != "" {
+				addErr("lookback", "lookback cannot be combined with start or end")
+			} else if d, err := time.ParseDuration(req.Lookback); err != nil || d <= 0 {
+				addErr("lookback", "lookback must be a positive duration (e.g. 1h, 30m)")
+			} else {
+				endTime = time.Now().UTC()
+				startTime = endTime.Add(-d)
+				if msg := o.checkSummarizeWindow(startTime, endTime); msg != "" {
+					addErr("lookback", msg)
+				}
+			}
+		} else {
+			if req.Start == "" {
+				addErr("start", "start is required")
+			}
+			if req.End == "" {
+				addErr("end", "end is required")
+			}
+			_, badStart := errs["start"]
+			_, badEnd := errs["end"]
+			if !badStart && !badEnd {
+				startTime, _ = time.Parse(time.RFC3339, req.Start)
+				endTime, _ = time.Parse(time.RFC3339, req.End)
+				if msg := o.checkSummarizeWindow(startTime, endTime); msg != "" {
+					addErr("end", msg)
+				}
+			}
+		}
+		if errs != nil {
+			validationError(w, errs)
 			return
 		}
 
-		if req.Start == "" {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "start is required", nil)
-			return
+		ns := req.Namespace
+		if ns == "" {
+			ns = "default"
 		}
-		startTime, err := time.Parse(time.RFC3339, req.Start)
+
+		result, err := svc.Summarize(r.Context(), SummarizeParams{
+			TenantID:  tenantID,
+			Service:   req.Service,
+			Namespace: ns,
+			Start:     startTime,
+			End:       endTime,
+			MaxLines:  clampMaxLines(req.MaxLines),
+			Model:     req.Model,
+			NoCache:   req.NoCache,
+		})
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "start must be a valid RFC3339 timestamp", nil)
+			status, code, msg := mapError(err)
+			log := mw.LoggerFromContext(r.Context()).With(
+				"service", req.Service, "namespace", ns, "code", code, "error", err)
+			if status >= http.StatusInternalServerError {
+				log.Error("summarize failed")
+			} else {
+				log.Warn("summarize rejected")
+			}
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.JSON(w, newSummarizeResponse(result))
+	}
+}
+
+const (
+	// maxBatchSummarizeEntries caps the entries accepted by one batch summarize request.
+	maxBatchSummarizeEntries = 10
+	// batchSummarizeConcurrency bounds how many summaries of a batch run at once.
+	batchSummarizeConcurrency = 3
+)
+
+// NewBatchSummarizeHandler returns an http.HandlerFunc for POST /api/v1/summarize/batch.
+// It summarizes each entry's service, namespace, and start/end window, up to
+// batchSummarizeConcurrency at a time, and answers in request order. An entry
+// that fails carries its own error instead of failing the batch; max_lines and
+// model apply to every entry.
+func NewBatchSummarizeHandler(svc Summarizer, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		type entry struct {
+			Service   string `json:"service"   validate:"required"`
+			Namespace string `json:"namespace"`
+			Start     string `json:"start"     validate:"required,rfc3339"`
+			End       string `json:"end"       validate:"required,rfc3339"`
+		}
+		var req struct {
+			Entries  []entry `json:"entries"`
+			MaxLines int     `json:"max_lines"`
+			Model    string  `json:"model"`
+			NoCache  bool    `json:"no_cache"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if len(req.Entries) == 0 {
+			validationError(w, map[string]string{"entries": "entries is required"})
+			return
+		}
+		if len(req.Entries) > maxBatchSummarizeEntries {
+			validationError(w, map[string]string{
+				"entries": fmt.Sprintf("entries must contain %d entries or fewer", maxBatchSummarizeEntries),
+			})
 			return
 		}
 
-		if req.End == "" {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "end is required", nil)
+		params := make([]SummarizeParams, len(req.Entries))
+		errs := make(map[string]string)
+		for i, e := range req.Entries {
+			entryErrs := validate(&e)
+			for field, msg := range entryErrs {
+				errs[fmt.Sprintf("entries[%d].%s", i, field)] = msg
+			}
+			ns := e.Namespace
+			if ns == "" {
+				ns = "default"
+			}
+			startTime, _ := time.Parse(time.RFC3339, e.Start)
+			endTime, _ := time.Parse(time.RFC3339, e.End)
+			_, badStart := entryErrs["start"]
+			_, badEnd := entryErrs["end"]
+			if !badStart && !badEnd {
+				if msg := o.checkSummarizeWindow(startTime, endTime); msg != "" {
+					errs[fmt.Sprintf("entries[%d].end", i)] = msg
+				}
+			}
+			params[i] = SummarizeParams{
+				TenantID:  tenantID,
+				Service:   e.Service,
+				Namespace: ns,
+				Start:     startTime,
+				End:       endTime,
+				MaxLines:  clampMaxLines(req.MaxLines),
+				Model:     req.Model,
+				NoCache:   req.NoCache,
+			}
+		}
+		if len(errs) > 0 {
+			validationError(w, errs)
 			return
 		}
-		endTime, err := time.Parse(time.RFC3339, req.End)
+
+		results := make([]batchSummarizeResult, len(params))
+		sem := make(chan struct{}, batchSummarizeConcurrency)
+		var wg sync.WaitGroup
+		for i, p := range params {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				results[i] = batchSummarizeResult{Service: p.Service, Namespace: p.Namespace}
+				result, err := svc.Summarize(r.Context(), p)
+				if err != nil {
+					status, code, msg := mapError(err)
+					if status >= http.StatusInternalServerError {
+						mw.LoggerFromContext(r.Context()).Error("batch summarize entry failed",
+							"service", p.Service, "namespace", p.Namespace, "code", code, "error", err)
+					}
+					results[i].Error = &batchEntryError{Code: code, Message: msg}
+					return
+				}
+				summary := newSummarizeResponse(result)
+				results[i].Result = &summary
+			}()
+		}
+		wg.Wait()
+
+		response.JSON(w, results)
+	}
+}
+
+// batchSummarizeResult is one entry of a batch summarize response: exactly one
+// of Result and Error is set.
+type batchSummarizeResult struct {
+	Service   string             `json:"service"`
+	Namespace string             `json:"namespace"`
+	Result    *summarizeResponse `json:"result,omitempty"`
+	Error     *batchEntryError   `json:"error,omitempty"`
+}
+
+type batchEntryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// clusterSummaryPadding widens a cluster's window on either side when
+// summarizing it, matching the analysis context window.
+const clusterSummaryPadding = 5 * time.Minute
+
+// NewClusterSummarizeHandler returns an http.HandlerFunc for
+// POST /api/v1/clusters/{clusterID}/summarize. It summarizes the cluster's own
+// lines: its service, namespace, and level over its first/last seen window,
+// filtered to its fingerprint. A window longer than the summarize bound keeps
+// its most recent part. The body is optional and may set max_lines,
+// model and no_cache.
+func NewClusterSummarizeHandler(st AnalysisClusterGetter, svc Summarizer, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		clusterID, err := uuid.Parse(chi.URLParam(r, "clusterID"))
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "end must be a valid RFC3339 timestamp", nil)
+			response.Error(w, http.StatusBadRequest, "INVALID_CLUSTER_ID", "Invalid cluster ID", nil)
 			return
 		}
 
-		ns := req.Namespace
-		if ns == "" {
-			ns = "default"
+		var req struct {
+			MaxLines int    `json:"max_lines"`
+			Model    string `json:"model"`
+			NoCache  bool   `json:"no_cache"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+			invalidBody(w, err)
+			return
 		}
 
-		maxLines := req.MaxLines
-		if maxLines == 0 {
-			maxLines = 500
+		cluster, err := st.GetErrorCluster(r.Context(), clusterID, tenantID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				o.clusterNotFound(w, r, st, clusterID, "Cluster not found")
+				return
+			}
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get cluster", nil)
+			return
 		}
-		if maxLines < 10 {
-			maxLines = 10
+
+		params := SummarizeParams{
+			TenantID:    tenantID,
+			Service:     cluster.Service,
+			Namespace:   cluster.Namespace,
+			Start:       cluster.FirstSeenAt.Add(-clusterSummaryPadding),
+			End:         cluster.LastSeenAt.Add(clusterSummaryPadding),
+			MaxLines:    clampMaxLines(req.MaxLines),
+			Model:       req.Model,
+			Fingerprint: cluster.Fingerprint,
+			NoCache:     req.NoCache,
 		}
-		if maxLines > 1000 {
-			maxLines = 1000
+		if o.maxSummarizeWindow > 0 && params.End.Sub(params.Start) > o.maxSummarizeWindow {
+			params.Start = params.End.Add(-o.maxSummarizeWindow)
+		}
+		if cluster.Level != "" {
+			params.Levels = []string{cluster.Level}
 		}
 
-		result, err := svc.Summarize(SummarizeParams{
-			TenantID:  tenantID,
-			Service:   req.Service,
-			Namespace: ns,
-			Start:     startTime,
-			End:       endTime,
-			MaxLines:  maxLines,
-		})
+		result, err := svc.Summarize(r.Context(), params)
 		if err != nil {
 			status, code, msg := mapError(err)
+			if status >= http.StatusInternalServerError {
+				mw.LoggerFromContext(r.Context()).Error("cluster summarize failed",
+					"cluster_id", clusterID, "code", code, "error", err)
+			}
 			response.Error(w, status, code, msg, nil)
 			return
 		}
 
-		response.JSON(w, summarizeResponse{
-			Summary:       result.Summary,
-			LinesAnalyzed: result.LinesAnalyzed,
-			TimeRange: timeRange{
-				From: result.From.UTC().Format(time.RFC3339),
-				To:   result.To.UTC().Format(time.RFC3339),
-			},
-			Provider: result.Provider,
-			Model:    result.Model,
-		})
+		response.JSON(w, newSummarizeResponse(result))
+	}
+}
+
+// clampMaxLines applies the default and bounds for a summary's max_lines.
+func clampMaxLines(n int) int {
+	if n == 0 {
+		return 500
+	}
+	if n < 10 {
+		return 10
+	}
+	if n > 1000 {
+		return 1000
+	}
+	return n
+}
+
+func newSummarizeResponse(result *SummarizeResult) summarizeResponse {
+	return summarizeResponse{
+		Summary:       result.Summary,
+		LinesAnalyzed: result.LinesAnalyzed,
+		TimeRange: timeRange{
+			From: result.From.UTC().Format(time.RFC3339),
+			To:   result.To.UTC().Format(time.RFC3339),
+		},
+		Provider:     result.Provider,
+		Model:        result.Model,
+		CacheHit:     result.CacheHit,
+		LogsCacheHit: result.LogsCacheHit,
+		Truncated:    result.Truncated,
 	}
 }
 
@@ -134,6 +429,9 @@ type summarizeResponse struct {
 	TimeRange     timeRange `json:"time_range"`
 	Provider      string    `json:"provider"`
 	Model         string    `json:"model"`
+	CacheHit      bool      `json:"cache_hit"`
+	LogsCacheHit  bool      `json:"logs_cache_hit"`
+	Truncated     bool      `json:"truncated"`
 }
 
 type timeRange struct {
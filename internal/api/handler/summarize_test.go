@@ -5,14 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/kiranshivaraju/loghunter/internal/ai"
 	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
 )
 
 func setTenantCtx(ctx context.Context, id uuid.UUID) context.Context {
@@ -22,10 +28,12 @@ func setTenantCtx(ctx context.Context, id uuid.UUID) context.Context {
 // --- mock Summarizer ---
 
 type mockSummarizer struct {
-	fn func(params SummarizeParams) (*SummarizeResult, error)
+	fn  func(params SummarizeParams) (*SummarizeResult, error)
+	ctx context.Context
 }
 
-func (m *mockSummarizer) Summarize(params SummarizeParams) (*SummarizeResult, error) {
+func (m *mockSummarizer) Summarize(ctx context.Context, params SummarizeParams) (*SummarizeResult, error) {
+	m.ctx = ctx
 	return m.fn(params)
 }
 
@@ -103,6 +111,168 @@ func TestSummarizeHandler_Success(t *testing.T) {
 	}
 }
 
+func TestSummarizeHandler_ModelOverride(t *testing.T) {
+	var got SummarizeParams
+	mock := &mockSummarizer{fn: func(params SummarizeParams) (*SummarizeResult, error) {
+		got = params
+		return &SummarizeResult{Summary: "s", Model: params.Model}, nil
+	}}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+		"model":   "small-model",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	data := parseSummarizeOK(t, rec)
+	if got.Model != "small-model" {
+		t.Errorf("expected model small-model to reach the summarizer, got %q", got.Model)
+	}
+	if data["model"] != "small-model" {
+		t.Errorf("unexpected model: %v", data["model"])
+	}
+}
+
+func TestSummarizeHandler_ModelNotAllowed(t *testing.T) {
+	mock := &mockSummarizer{fn: func(_ SummarizeParams) (*SummarizeResult, error) {
+		return nil, fmt.Errorf("%w: %q", ai.ErrModelNotAllowed, "gpt-huge")
+	}}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+		"model":   "gpt-huge",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	errObj := parseJSON(t, rec)["error"].(map[string]any)
+	if errObj["code"] != "MODEL_NOT_ALLOWED" {
+		t.Errorf("expected MODEL_NOT_ALLOWED, got %v", errObj["code"])
+	}
+}
+
+func TestSummarizeHandler_PropagatesRequestContext(t *testing.T) {
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	req := summarizeReq(t, body, uuid.New())
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	mock := &mockSummarizer{}
+	mock.fn = func(_ SummarizeParams) (*SummarizeResult, error) {
+		cancel() // simulate the client disconnecting mid-request
+		<-mock.ctx.Done()
+		return nil, mock.ctx.Err()
+	}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req.WithContext(ctx))
+
+	if !errors.Is(mock.ctx.Err(), context.Canceled) {
+		t.Errorf("expected summarizer context to be cancelled with the request, got %v", mock.ctx.Err())
+	}
+}
+
+func TestSummarizeHandler_CacheHitFlag(t *testing.T) {
+	mock := &mockSummarizer{fn: func(params SummarizeParams) (*SummarizeResult, error) {
+		return &SummarizeResult{Summary: "ok", CacheHit: true}, nil
+	}}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	data := parseSummarizeOK(t, rec)
+	if data["cache_hit"] != true {
+		t.Errorf("expected cache_hit true, got %v", data["cache_hit"])
+	}
+}
+
+func TestSummarizeHandler_TruncatedFlag(t *testing.T) {
+	mock := &mockSummarizer{fn: func(params SummarizeParams) (*SummarizeResult, error) {
+		return &SummarizeResult{Summary: "ok", Truncated: true}, nil
+	}}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	data := parseSummarizeOK(t, rec)
+	if data["truncated"] != true {
+		t.Errorf("expected truncated true, got %v", data["truncated"])
+	}
+}
+
+func TestSummarizeHandler_NoCache(t *testing.T) {
+	var captured SummarizeParams
+	mock := &mockSummarizer{fn: func(params SummarizeParams) (*SummarizeResult, error) {
+		captured = params
+		return &SummarizeResult{Summary: "ok", LogsCacheHit: false}, nil
+	}}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service":  "payments-api",
+		"start":    "2024-02-17T00:00:00Z",
+		"end":      "2024-02-17T01:00:00Z",
+		"no_cache": true,
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	data := parseSummarizeOK(t, rec)
+	if !captured.NoCache {
+		t.Error("expected no_cache to reach the summarizer")
+	}
+	if data["logs_cache_hit"] != false {
+		t.Errorf("expected logs_cache_hit false, got %v", data["logs_cache_hit"])
+	}
+}
+
+func TestSummarizeHandler_LogsCacheHitFlag(t *testing.T) {
+	mock := &mockSummarizer{fn: func(params SummarizeParams) (*SummarizeResult, error) {
+		return &SummarizeResult{Summary: "ok", LogsCacheHit: true}, nil
+	}}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service": "payments-api",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	data := parseSummarizeOK(t, rec)
+	if data["logs_cache_hit"] != true {
+		t.Errorf("expected logs_cache_hit true, got %v", data["logs_cache_hit"])
+	}
+}
+
 func TestSummarizeHandler_DefaultNamespace(t *testing.T) {
 	var captured SummarizeParams
 	mock := &mockSummarizer{fn: func(params SummarizeParams) (*SummarizeResult, error) {
@@ -209,8 +379,8 @@ func TestSummarizeHandler_MissingService(t *testing.T) {
 	if status != http.StatusBadRequest {
 		t.Errorf("expected 400, got %d", status)
 	}
-	if code != "INVALID_REQUEST" {
-		t.Errorf("expected INVALID_REQUEST, got %s", code)
+	if code != "VALIDATION_ERROR" {
+		t.Errorf("expected VALIDATION_ERROR, got %s", code)
 	}
 }
 
@@ -228,8 +398,8 @@ func TestSummarizeHandler_MissingStart(t *testing.T) {
 	if status != http.StatusBadRequest {
 		t.Errorf("expected 400, got %d", status)
 	}
-	if code != "INVALID_REQUEST" {
-		t.Errorf("expected INVALID_REQUEST, got %s", code)
+	if code != "VALIDATION_ERROR" {
+		t.Errorf("expected VALIDATION_ERROR, got %s", code)
 	}
 }
 
@@ -247,8 +417,104 @@ func TestSummarizeHandler_MissingEnd(t *testing.T) {
 	if status != http.StatusBadRequest {
 		t.Errorf("expected 400, got %d", status)
 	}
-	if code != "INVALID_REQUEST" {
-		t.Errorf("expected INVALID_REQUEST, got %s", code)
+	if code != "VALIDATION_ERROR" {
+		t.Errorf("expected VALIDATION_ERROR, got %s", code)
+	}
+}
+
+func TestSummarizeHandler_ReportsAllFieldErrors(t *testing.T) {
+	h := NewSummarizeHandler(successSummarizer())
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"end": "not-a-timestamp",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	var env struct {
+		Error struct {
+			Code    string            `json:"code"`
+			Details map[string]string `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.Error.Code != "VALIDATION_ERROR" {
+		t.Errorf("expected VALIDATION_ERROR, got %s", env.Error.Code)
+	}
+	for _, field := range []string{"service", "start", "end"} {
+		if _, ok := env.Error.Details[field]; !ok {
+			t.Errorf("expected details to include %q, got %v", field, env.Error.Details)
+		}
+	}
+}
+
+func TestSummarizeHandler_WindowErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  map[string]any
+		field string
+		want  string
+	}{
+		{"end before start", map[string]any{"service": "svc", "start": "2024-02-17T01:00:00Z", "end": "2024-02-17T00:00:00Z"},
+			"end", "end must be after start"},
+		{"end equals start", map[string]any{"service": "svc", "start": "2024-02-17T00:00:00Z", "end": "2024-02-17T00:00:00Z"},
+			"end", "end must be after start"},
+		{"window too long", map[string]any{"service": "svc", "start": "2024-02-17T00:00:00Z", "end": "2024-02-18T00:00:01Z"},
+			"end", "window must not exceed 24h0m0s"},
+		{"lookback too long", map[string]any{"service": "svc", "lookback": "25h"},
+			"lookback", "window must not exceed 24h0m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewSummarizeHandler(successSummarizer(), WithMaxSummarizeWindow(24*time.Hour)).ServeHTTP(rec, summarizeReq(t, tt.body, uuid.New()))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+			var env struct {
+				Error struct {
+					Details map[string]string `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&env); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got := env.Error.Details[tt.field]; got != tt.want {
+				t.Errorf("expected %s to fail with %q, got %v", tt.field, tt.want, env.Error.Details)
+			}
+		})
+	}
+}
+
+func TestSummarizeHandler_WindowErrorsReportedWithOthers(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := map[string]any{"start": "2024-02-17T01:00:00Z", "end": "2024-02-17T00:00:00Z"}
+	NewSummarizeHandler(successSummarizer()).ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	details := parseJSON(t, rec)["error"].(map[string]any)["details"].(map[string]any)
+	for _, field := range []string{"service", "end"} {
+		if _, ok := details[field]; !ok {
+			t.Errorf("expected details to include %q, got %v", field, details)
+		}
+	}
+}
+
+func TestSummarizeHandler_NoMaxWindow(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := map[string]any{"service": "svc", "start": "2024-01-01T00:00:00Z", "end": "2024-03-01T00:00:00Z"}
+	NewSummarizeHandler(successSummarizer(), WithMaxSummarizeWindow(0)).ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with no window bound, got %d: %s", rec.Code, rec.Body.String())
 	}
 }
 
@@ -477,3 +743,433 @@ func TestSummarizeHandler_ResponseShape(t *testing.T) {
 		t.Errorf("unexpected to: %v", tr["to"])
 	}
 }
+
+func TestSummarizeHandler_LogsFailureWithRequestLogger(t *testing.T) {
+	mock := &mockSummarizer{fn: func(_ SummarizeParams) (*SummarizeResult, error) {
+		return nil, ai.ErrProviderUnavailable
+	}}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil)).With("request_id", "req-42")
+
+	body := map[string]any{
+		"service": "svc",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	req := summarizeReq(t, body, uuid.New())
+	req = req.WithContext(mw.WithLogger(req.Context(), logger))
+	rec := httptest.NewRecorder()
+	NewSummarizeHandler(mock).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "summarize failed" || entry["level"] != "ERROR" {
+		t.Errorf("expected an ERROR summarize failed entry, got %v", entry)
+	}
+	if entry["request_id"] != "req-42" {
+		t.Errorf("expected request_id from the context logger, got %v", entry["request_id"])
+	}
+	if entry["code"] != "AI_PROVIDER_UNAVAILABLE" {
+		t.Errorf("expected code AI_PROVIDER_UNAVAILABLE, got %v", entry["code"])
+	}
+}
+
+func TestSummarizeHandler_Lookback(t *testing.T) {
+	mock := successSummarizer()
+	var got SummarizeParams
+	fn := mock.fn
+	mock.fn = func(params SummarizeParams) (*SummarizeResult, error) {
+		got = params
+		return fn(params)
+	}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	before := time.Now()
+	h.ServeHTTP(rec, summarizeReq(t, map[string]any{"service": "svc", "lookback": "1h"}, uuid.New()))
+	parseSummarizeOK(t, rec)
+
+	if got.End.Before(before.Add(-time.Second)) || got.End.After(time.Now()) {
+		t.Errorf("expected end ~now, got %v", got.End)
+	}
+	if d := got.End.Sub(got.Start); d != time.Hour {
+		t.Errorf("expected a 1h window, got %v", d)
+	}
+}
+
+func TestSummarizeHandler_ExplicitRangeStillWorks(t *testing.T) {
+	mock := successSummarizer()
+	var got SummarizeParams
+	fn := mock.fn
+	mock.fn = func(params SummarizeParams) (*SummarizeResult, error) {
+		got = params
+		return fn(params)
+	}
+	h := NewSummarizeHandler(mock)
+	rec := httptest.NewRecorder()
+
+	body := map[string]any{
+		"service": "svc",
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+	h.ServeHTTP(rec, summarizeReq(t, body, uuid.New()))
+	parseSummarizeOK(t, rec)
+
+	want := time.Date(2024, 2, 17, 0, 0, 0, 0, time.UTC)
+	if !got.Start.Equal(want) || !got.End.Equal(want.Add(time.Hour)) {
+		t.Errorf("expected the explicit range, got %v - %v", got.Start, got.End)
+	}
+}
+
+func TestSummarizeHandler_LookbackErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body map[string]any
+	}{
+		{"combined with start", map[string]any{"service": "svc", "lookback": "1h", "start": "2024-02-17T00:00:00Z"}},
+		{"combined with both", map[string]any{"service": "svc", "lookback": "1h", "start": "2024-02-17T00:00:00Z", "end": "2024-02-17T01:00:00Z"}},
+		{"not a duration", map[string]any{"service": "svc", "lookback": "an hour"}},
+		{"negative", map[string]any{"service": "svc", "lookback": "-1h"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewSummarizeHandler(successSummarizer())
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, summarizeReq(t, tt.body, uuid.New()))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+			var env struct {
+				Error struct {
+					Code    string            `json:"code"`
+					Details map[string]string `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&env); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if env.Error.Code != "VALIDATION_ERROR" {
+				t.Errorf("expected VALIDATION_ERROR, got %s", env.Error.Code)
+			}
+			if _, ok := env.Error.Details["lookback"]; !ok {
+				t.Errorf("expected a lookback error, got %v", env.Error.Details)
+			}
+		})
+	}
+}
+
+// --- Cluster summarize tests ---
+
+func clusterSummarizeReq(tenantID, clusterID uuid.UUID) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/clusters/"+clusterID.String()+"/summarize", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clusterID", clusterID.String())
+	ctx := context.WithValue(setTenantCtx(r.Context(), tenantID), chi.RouteCtxKey, rctx)
+	return r.WithContext(ctx)
+}
+
+func TestClusterSummarizeHandler_Success(t *testing.T) {
+	tenantID := uuid.New()
+	first := time.Date(2024, 2, 17, 10, 0, 0, 0, time.UTC)
+	cluster := &models.ErrorCluster{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Service:     "payments",
+		Namespace:   "prod",
+		Fingerprint: "fp-123",
+		Level:       "error",
+		FirstSeenAt: first,
+		LastSeenAt:  first.Add(time.Hour),
+	}
+	var got SummarizeParams
+	mock := successSummarizer()
+	inner := mock.fn
+	mock.fn = func(params SummarizeParams) (*SummarizeResult, error) {
+		got = params
+		return inner(params)
+	}
+
+	h := NewClusterSummarizeHandler(&analysisMockStore{cluster: cluster}, mock)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, clusterSummarizeReq(tenantID, cluster.ID))
+
+	data := parseSummarizeOK(t, rec)
+	if data["summary"] != "Summary of log stream for testing" {
+		t.Errorf("unexpected summary: %v", data["summary"])
+	}
+	if got.TenantID != tenantID || got.Service != "payments" || got.Namespace != "prod" {
+		t.Errorf("expected params derived from cluster, got %+v", got)
+	}
+	if got.Fingerprint != "fp-123" || len(got.Levels) != 1 || got.Levels[0] != "error" {
+		t.Errorf("expected fingerprint and level filters, got %+v", got)
+	}
+	if !got.Start.Equal(first.Add(-clusterSummaryPadding)) || !got.End.Equal(first.Add(time.Hour+clusterSummaryPadding)) {
+		t.Errorf("expected padded cluster window, got %s - %s", got.Start, got.End)
+	}
+	if got.MaxLines != 500 {
+		t.Errorf("expected default max_lines 500, got %d", got.MaxLines)
+	}
+}
+
+func TestClusterSummarizeHandler_LongWindowClamped(t *testing.T) {
+	tenantID := uuid.New()
+	last := time.Date(2024, 2, 17, 10, 0, 0, 0, time.UTC)
+	cluster := &models.ErrorCluster{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Service:     "payments",
+		FirstSeenAt: last.Add(-30 * 24 * time.Hour),
+		LastSeenAt:  last,
+	}
+	var got SummarizeParams
+	mock := successSummarizer()
+	inner := mock.fn
+	mock.fn = func(params SummarizeParams) (*SummarizeResult, error) {
+		got = params
+		return inner(params)
+	}
+
+	h := NewClusterSummarizeHandler(&analysisMockStore{cluster: cluster}, mock, WithMaxSummarizeWindow(24*time.Hour))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, clusterSummarizeReq(tenantID, cluster.ID))
+
+	parseSummarizeOK(t, rec)
+	end := last.Add(clusterSummaryPadding)
+	if !got.End.Equal(end) || !got.Start.Equal(end.Add(-24*time.Hour)) {
+		t.Errorf("expected the most recent 24h of the window, got %s - %s", got.Start, got.End)
+	}
+}
+
+func TestClusterSummarizeHandler_WrongTenant(t *testing.T) {
+	cluster := &models.ErrorCluster{ID: uuid.New(), TenantID: uuid.New(), Service: "payments"}
+	called := false
+	mock := &mockSummarizer{fn: func(_ SummarizeParams) (*SummarizeResult, error) {
+		called = true
+		return nil, nil
+	}}
+
+	h := NewClusterSummarizeHandler(&analysisMockStore{cluster: cluster}, mock)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, clusterSummarizeReq(uuid.New(), cluster.ID))
+
+	status, code := parseSummarizeErr(t, rec)
+	if status != http.StatusNotFound || code != "CLUSTER_NOT_FOUND" {
+		t.Errorf("expected 404 CLUSTER_NOT_FOUND, got %d %s", status, code)
+	}
+	if called {
+		t.Error("summarizer must not be called for another tenant's cluster")
+	}
+}
+
+func TestClusterSummarizeHandler_NoLogs(t *testing.T) {
+	tenantID := uuid.New()
+	cluster := &models.ErrorCluster{ID: uuid.New(), TenantID: tenantID, Service: "payments"}
+	mock := &mockSummarizer{fn: func(_ SummarizeParams) (*SummarizeResult, error) {
+		return nil, ErrNoLogsFound
+	}}
+
+	h := NewClusterSummarizeHandler(&analysisMockStore{cluster: cluster}, mock)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, clusterSummarizeReq(tenantID, cluster.ID))
+
+	status, code := parseSummarizeErr(t, rec)
+	if status != http.StatusNotFound || code != "NO_LOGS_FOUND" {
+		t.Errorf("expected 404 NO_LOGS_FOUND, got %d %s", status, code)
+	}
+}
+
+// --- batch summarize ---
+
+// summarizerFunc adapts a function to Summarizer. Unlike mockSummarizer it
+// keeps no state, so batch tests can call it concurrently.
+type summarizerFunc func(ctx context.Context, params SummarizeParams) (*SummarizeResult, error)
+
+func (f summarizerFunc) Summarize(ctx context.Context, params SummarizeParams) (*SummarizeResult, error) {
+	return f(ctx, params)
+}
+
+func batchEntry(service string) map[string]any {
+	return map[string]any{
+		"service": service,
+		"start":   "2024-02-17T00:00:00Z",
+		"end":     "2024-02-17T01:00:00Z",
+	}
+}
+
+func batchSummarizeReq(t *testing.T, body any, tenantID uuid.UUID) *http.Request {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/summarize/batch", bytes.NewReader(b))
+	r.Header.Set("Content-Type", "application/json")
+	return r.WithContext(setTenantCtx(r.Context(), tenantID))
+}
+
+func TestBatchSummarizeHandler_MixedResults(t *testing.T) {
+	tid := uuid.New()
+	svc := summarizerFunc(func(_ context.Context, params SummarizeParams) (*SummarizeResult, error) {
+		if params.TenantID != tid {
+			t.Errorf("expected tenant %s, got %s", tid, params.TenantID)
+		}
+		switch params.Service {
+		case "quiet-api":
+			return nil, ErrNoLogsFound
+		case "broken-api":
+			return nil, errors.New("provider exploded")
+		}
+		return &SummarizeResult{
+			Summary: "summary of " + params.Service, LinesAnalyzed: 7,
+			From: params.Start, To: params.End, Provider: "mock", Model: "mock-v1",
+		}, nil
+	})
+
+	body := map[string]any{"entries": []any{
+		batchEntry("payments-api"), batchEntry("quiet-api"), batchEntry("broken-api"), batchEntry("orders-api"),
+	}}
+	rec := httptest.NewRecorder()
+	NewBatchSummarizeHandler(svc).ServeHTTP(rec, batchSummarizeReq(t, body, tid))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var env struct {
+		Data []struct {
+			Service   string         `json:"service"`
+			Namespace string         `json:"namespace"`
+			Result    map[string]any `json:"result"`
+			Error     *struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(env.Data) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(env.Data))
+	}
+
+	wantServices := []string{"payments-api", "quiet-api", "broken-api", "orders-api"}
+	for i, want := range wantServices {
+		if env.Data[i].Service != want || env.Data[i].Namespace != "default" {
+			t.Errorf("result %d: expected %s/default, got %s/%s", i, want, env.Data[i].Service, env.Data[i].Namespace)
+		}
+	}
+	for _, i := range []int{0, 3} {
+		if env.Data[i].Error != nil {
+			t.Errorf("result %d: unexpected error %+v", i, env.Data[i].Error)
+		}
+		if env.Data[i].Result["summary"] != "summary of "+wantServices[i] {
+			t.Errorf("result %d: unexpected summary %v", i, env.Data[i].Result["summary"])
+		}
+	}
+	if env.Data[1].Result != nil || env.Data[1].Error == nil || env.Data[1].Error.Code != "NO_LOGS_FOUND" {
+		t.Errorf("expected NO_LOGS_FOUND for quiet-api, got %+v", env.Data[1])
+	}
+	if env.Data[2].Result != nil || env.Data[2].Error == nil || env.Data[2].Error.Code != "INTERNAL_ERROR" {
+		t.Errorf("expected INTERNAL_ERROR for broken-api, got %+v", env.Data[2])
+	}
+}
+
+func TestBatchSummarizeHandler_NoCache(t *testing.T) {
+	var noCache atomic.Int32
+	svc := summarizerFunc(func(_ context.Context, params SummarizeParams) (*SummarizeResult, error) {
+		if params.NoCache {
+			noCache.Add(1)
+		}
+		return &SummarizeResult{Summary: "ok"}, nil
+	})
+
+	body := map[string]any{"entries": []any{batchEntry("payments-api"), batchEntry("orders-api")}, "no_cache": true}
+	rec := httptest.NewRecorder()
+	NewBatchSummarizeHandler(svc).ServeHTTP(rec, batchSummarizeReq(t, body, uuid.New()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := noCache.Load(); got != 2 {
+		t.Errorf("expected no_cache on both entries, got %d", got)
+	}
+}
+
+func TestBatchSummarizeHandler_BoundsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	svc := summarizerFunc(func(_ context.Context, params SummarizeParams) (*SummarizeResult, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return &SummarizeResult{Summary: "ok", From: params.Start, To: params.End}, nil
+	})
+
+	entries := make([]any, maxBatchSummarizeEntries)
+	for i := range entries {
+		entries[i] = batchEntry(fmt.Sprintf("svc-%d", i))
+	}
+	rec := httptest.NewRecorder()
+	NewBatchSummarizeHandler(svc).ServeHTTP(rec, batchSummarizeReq(t, map[string]any{"entries": entries}, uuid.New()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if peak > batchSummarizeConcurrency {
+		t.Errorf("expected at most %d concurrent summaries, got %d", batchSummarizeConcurrency, peak)
+	}
+}
+
+func TestBatchSummarizeHandler_Validation(t *testing.T) {
+	tooMany := make([]any, maxBatchSummarizeEntries+1)
+	for i := range tooMany {
+		tooMany[i] = batchEntry("svc")
+	}
+	missingStart := batchEntry("svc")
+	delete(missingStart, "start")
+	backwards := batchEntry("svc")
+	backwards["start"], backwards["end"] = backwards["end"], backwards["start"]
+
+	tests := []struct {
+		name  string
+		body  map[string]any
+		field string
+	}{
+		{"no entries", map[string]any{}, "entries"},
+		{"too many entries", map[string]any{"entries": tooMany}, "entries"},
+		{"invalid entry", map[string]any{"entries": []any{batchEntry("svc"), missingStart}}, "entries[1].start"},
+		{"backwards window", map[string]any{"entries": []any{backwards}}, "entries[0].end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			svc := summarizerFunc(func(context.Context, SummarizeParams) (*SummarizeResult, error) {
+				called = true
+				return nil, nil
+			})
+			rec := httptest.NewRecorder()
+			NewBatchSummarizeHandler(svc).ServeHTTP(rec, batchSummarizeReq(t, tt.body, uuid.New()))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+			details := parseJSON(t, rec)["error"].(map[string]any)["details"].(map[string]any)
+			if _, ok := details[tt.field]; !ok {
+				t.Errorf("expected a %q validation error, got %v", tt.field, details)
+			}
+			if called {
+				t.Error("expected no summaries for an invalid batch")
+			}
+		})
+	}
+}
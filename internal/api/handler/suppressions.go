@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// SuppressionCreator is the store interface needed by NewCreateSuppressionHandler.
+type SuppressionCreator interface {
+	CreateClusterSuppression(ctx context.Context, sup *models.ClusterSuppression) error
+}
+
+// SuppressionLister is the store interface needed by NewListSuppressionsHandler.
+type SuppressionLister interface {
+	ListClusterSuppressions(ctx context.Context, tenantID uuid.UUID) ([]*models.ClusterSuppression, error)
+}
+
+// SuppressionDeleter is the store interface needed by NewDeleteSuppressionHandler.
+type SuppressionDeleter interface {
+	DeleteClusterSuppression(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error
+}
+
+// NewCreateSuppressionHandler returns an http.HandlerFunc for POST /api/v1/admin/suppressions.
+// The body sets exactly one of fingerprint or pattern (a Go regular expression
+// matched against a cluster's sample message), plus an optional reason.
+// Matching clusters are dropped at ingestion from then on; clusters already
+// stored are left alone.
+func NewCreateSuppressionHandler(st SuppressionCreator, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		var req struct {
+			Fingerprint string `json:"fingerprint" validate:"max=64"`
+			Pattern     string `json:"pattern"     validate:"max=1024"`
+			Reason      string `json:"reason"      validate:"max=1024"`
+		}
+		if err := o.decodeJSON(r, &req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+
+		if errs := validate(&req); errs != nil {
+			validationError(w, errs)
+			return
+		}
+		if (req.Fingerprint == "") == (req.Pattern == "") {
+			validationError(w, map[string]string{"fingerprint": "exactly one of fingerprint or pattern is required"})
+			return
+		}
+		if req.Pattern != "" {
+			if _, err := regexp.Compile(req.Pattern); err != nil {
+				validationError(w, map[string]string{"pattern": "pattern must be a valid regular expression"})
+				return
+			}
+		}
+
+		sup := &models.ClusterSuppression{
+			ID:          uuid.New(),
+			TenantID:    tenantID,
+			Fingerprint: req.Fingerprint,
+			Pattern:     req.Pattern,
+			Reason:      req.Reason,
+			CreatedAt:   time.Now().UTC(),
+		}
+		if err := st.CreateClusterSuppression(r.Context(), sup); err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.Created(w, sup)
+	}
+}
+
+// NewListSuppressionsHandler returns an http.HandlerFunc for GET /api/v1/admin/suppressions.
+// Suppression lists are short, so the whole list is returned.
+func NewListSuppressionsHandler(st SuppressionLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		sups, err := st.ListClusterSuppressions(r.Context(), tenantID)
+		if err != nil {
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.JSON(w, sups)
+	}
+}
+
+// NewDeleteSuppressionHandler returns an http.HandlerFunc for DELETE /api/v1/admin/suppressions/{suppressionID}.
+func NewDeleteSuppressionHandler(st SuppressionDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := mw.GetTenantID(r)
+		if !ok {
+			response.Error(w, http.StatusUnauthorized, "INVALID_TOKEN", "Missing tenant", nil)
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "suppressionID"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "INVALID_SUPPRESSION_ID", "Invalid suppression ID", nil)
+			return
+		}
+
+		if err := st.DeleteClusterSuppression(r.Context(), id, tenantID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				response.Error(w, http.StatusNotFound, "SUPPRESSION_NOT_FOUND", "Suppression not found", nil)
+				return
+			}
+			status, code, msg := mapError(err)
+			response.Error(w, status, code, msg, nil)
+			return
+		}
+
+		response.NoContent(w)
+	}
+}
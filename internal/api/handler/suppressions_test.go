@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// --- mock suppression store ---
+
+type suppressionMockStore struct {
+	created   *models.ClusterSuppression
+	list      []*models.ClusterSuppression
+	deleted   uuid.UUID
+	createErr error
+	listErr   error
+	deleteErr error
+}
+
+func (s *suppressionMockStore) CreateClusterSuppression(_ context.Context, sup *models.ClusterSuppression) error {
+	s.created = sup
+	return s.createErr
+}
+
+func (s *suppressionMockStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) {
+	return s.list, s.listErr
+}
+
+func (s *suppressionMockStore) DeleteClusterSuppression(_ context.Context, id uuid.UUID, _ uuid.UUID) error {
+	s.deleted = id
+	return s.deleteErr
+}
+
+func TestCreateSuppressionHandler_Pattern(t *testing.T) {
+	tenantID := uuid.New()
+	st := &suppressionMockStore{}
+	handler := NewCreateSuppressionHandler(st)
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/suppressions",
+		jsonBody(t, map[string]any{"pattern": `GET /healthz .* 404`, "reason": "probe noise"}))
+	req = req.WithContext(setTenantCtx(req.Context(), tenantID))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if st.created == nil || st.created.TenantID != tenantID || st.created.Pattern != `GET /healthz .* 404` {
+		t.Fatalf("unexpected stored suppression: %+v", st.created)
+	}
+	data := parseJSON(t, rr)["data"].(map[string]any)
+	if data["id"] != st.created.ID.String() || data["reason"] != "probe noise" {
+		t.Errorf("unexpected response: %v", data)
+	}
+}
+
+func TestCreateSuppressionHandler_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  map[string]any
+		field string
+	}{
+		{"neither", map[string]any{"reason": "x"}, "fingerprint"},
+		{"both", map[string]any{"fingerprint": "abc", "pattern": "x"}, "fingerprint"},
+		{"bad regex", map[string]any{"pattern": "(unclosed"}, "pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &suppressionMockStore{}
+			handler := NewCreateSuppressionHandler(st)
+
+			req := httptest.NewRequest("POST", "/api/v1/admin/suppressions", jsonBody(t, tt.body))
+			req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rr.Code)
+			}
+			details := parseJSON(t, rr)["error"].(map[string]any)["details"].(map[string]any)
+			if _, ok := details[tt.field]; !ok {
+				t.Errorf("expected error on %s, got %v", tt.field, details)
+			}
+			if st.created != nil {
+				t.Error("expected nothing stored")
+			}
+		})
+	}
+}
+
+func TestListSuppressionsHandler(t *testing.T) {
+	st := &suppressionMockStore{list: []*models.ClusterSuppression{
+		{ID: uuid.New(), Fingerprint: "abc"},
+		{ID: uuid.New(), Pattern: "broken pipe"},
+	}}
+	handler := NewListSuppressionsHandler(st)
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/suppressions", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if data := parseJSON(t, rr)["data"].([]any); len(data) != 2 {
+		t.Errorf("expected 2 suppressions, got %d", len(data))
+	}
+}
+
+func TestListSuppressionsHandler_StoreError(t *testing.T) {
+	handler := NewListSuppressionsHandler(&suppressionMockStore{listErr: errors.New("db down")})
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/suppressions", nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+func deleteSuppressionRequest(t *testing.T, id string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("DELETE", "/api/v1/admin/suppressions/"+id, nil)
+	req = req.WithContext(setTenantCtx(req.Context(), uuid.New()))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("suppressionID", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestDeleteSuppressionHandler(t *testing.T) {
+	id := uuid.New()
+	st := &suppressionMockStore{}
+	rr := httptest.NewRecorder()
+
+	NewDeleteSuppressionHandler(st).ServeHTTP(rr, deleteSuppressionRequest(t, id.String()))
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	if st.deleted != id {
+		t.Errorf("expected %s deleted, got %s", id, st.deleted)
+	}
+}
+
+func TestDeleteSuppressionHandler_NotFound(t *testing.T) {
+	st := &suppressionMockStore{deleteErr: store.ErrNotFound}
+	rr := httptest.NewRecorder()
+
+	NewDeleteSuppressionHandler(st).ServeHTTP(rr, deleteSuppressionRequest(t, uuid.New().String()))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestDeleteSuppressionHandler_InvalidID(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	NewDeleteSuppressionHandler(&suppressionMockStore{}).ServeHTTP(rr, deleteSuppressionRequest(t, "not-a-uuid"))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
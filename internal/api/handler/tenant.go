@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+)
+
+// WithCrossTenantStatus chooses the response for a request that names another
+// tenant's resource: http.StatusNotFound (the default) hides that it exists,
+// http.StatusForbidden admits it. Other values are ignored.
+func WithCrossTenantStatus(status int) Option {
+	return func(o *options) {
+		if status == http.StatusNotFound || status == http.StatusForbidden {
+			o.crossTenantStatus = status
+		}
+	}
+}
+
+// ClusterExistenceChecker is implemented by stores that can tell whether a
+// cluster exists regardless of tenant. Handlers only consult it in 403 mode.
+type ClusterExistenceChecker interface {
+	ErrorClusterExists(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// JobExistenceChecker is implemented by stores that can tell whether a job
+// exists regardless of tenant. Handlers only consult it in 403 mode.
+type JobExistenceChecker interface {
+	JobExists(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// clusterNotFound answers a cluster lookup that found nothing for the caller's
+// tenant. In 403 mode, if st confirms the cluster exists for another tenant,
+// it responds 403 FORBIDDEN; otherwise 404 CLUSTER_NOT_FOUND with msg.
+func (o *options) clusterNotFound(w http.ResponseWriter, r *http.Request, st ClusterExistenceChecker, clusterID uuid.UUID, msg string) {
+	if o.crossTenantStatus == http.StatusForbidden {
+		if exists, err := st.ErrorClusterExists(r.Context(), clusterID); err == nil && exists {
+			response.Error(w, http.StatusForbidden, "FORBIDDEN", "Cluster belongs to another tenant", nil)
+			return
+		}
+	}
+	response.Error(w, http.StatusNotFound, "CLUSTER_NOT_FOUND", msg, nil)
+}
+
+// jobNotFound is clusterNotFound for jobs: 403 FORBIDDEN in 403 mode when st
+// confirms the job exists for another tenant, otherwise 404 JOB_NOT_FOUND.
+func (o *options) jobNotFound(w http.ResponseWriter, r *http.Request, st JobExistenceChecker, jobID uuid.UUID) {
+	if o.crossTenantStatus == http.StatusForbidden {
+		if exists, err := st.JobExists(r.Context(), jobID); err == nil && exists {
+			response.Error(w, http.StatusForbidden, "FORBIDDEN", "Job belongs to another tenant", nil)
+			return
+		}
+	}
+	response.Error(w, http.StatusNotFound, "JOB_NOT_FOUND", "Job not found", nil)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+)
+
+// validate checks the fields of the struct pointed to by v against their
+// `validate` tags and returns a map of JSON field name to error message.
+// A nil map means v is valid.
+//
+// Supported rules (comma-separated): required, rfc3339, uuid, max=N.
+// Format rules are skipped for empty values so optional fields may be omitted.
+func validate(v any) map[string]string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+
+	var errs map[string]string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if msg := checkRules(rv.Field(i), name, strings.Split(tag, ",")); msg != "" {
+			if errs == nil {
+				errs = make(map[string]string)
+			}
+			errs[name] = msg
+		}
+	}
+	return errs
+}
+
+// checkRules applies rules to a single field and returns the first failure message.
+func checkRules(fv reflect.Value, name string, rules []string) string {
+	for _, rule := range rules {
+		rule, arg, _ := strings.Cut(rule, "=")
+
+		if rule == "required" {
+			if fv.IsZero() {
+				return name + " is required"
+			}
+			continue
+		}
+
+		if fv.Kind() != reflect.String || fv.String() == "" {
+			continue
+		}
+		s := fv.String()
+
+		switch rule {
+		case "rfc3339":
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return name + " must be a valid RFC3339 timestamp"
+			}
+		case "uuid":
+			if _, err := uuid.Parse(s); err != nil {
+				return name + " must be a valid UUID"
+			}
+		case "max":
+			n, err := strconv.Atoi(arg)
+			if err == nil && len(s) > n {
+				return fmt.Sprintf("%s must be %d characters or fewer", name, n)
+			}
+		}
+	}
+	return ""
+}
+
+// jsonFieldName returns the JSON name of a struct field, falling back to the Go name.
+func jsonFieldName(f reflect.StructField) string {
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+// validationError writes a 400 VALIDATION_ERROR response with per-field details.
+func validationError(w http.ResponseWriter, errs map[string]string) {
+	response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Request validation failed", errs)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"testing"
+)
+
+func TestValidate_Valid(t *testing.T) {
+	req := struct {
+		Service   string `json:"service"    validate:"required"`
+		Start     string `json:"start"      validate:"required,rfc3339"`
+		ClusterID string `json:"cluster_id" validate:"uuid"`
+	}{
+		Service:   "api",
+		Start:     "2024-02-17T00:00:00Z",
+		ClusterID: "6f1c1c7e-4a5e-4d7e-9a61-0f3f0c1b2a3d",
+	}
+
+	if errs := validate(&req); errs != nil {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidate_MultipleErrors(t *testing.T) {
+	req := struct {
+		Service   string `json:"service"    validate:"required"`
+		Start     string `json:"start"      validate:"required,rfc3339"`
+		End       string `json:"end"        validate:"required,rfc3339"`
+		ClusterID string `json:"cluster_id" validate:"uuid"`
+		Keyword   string `json:"keyword"    validate:"max=5"`
+	}{
+		End:       "yesterday",
+		ClusterID: "not-a-uuid",
+		Keyword:   "toolong",
+	}
+
+	errs := validate(&req)
+
+	expected := map[string]string{
+		"service":    "service is required",
+		"start":      "start is required",
+		"end":        "end must be a valid RFC3339 timestamp",
+		"cluster_id": "cluster_id must be a valid UUID",
+		"keyword":    "keyword must be 5 characters or fewer",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for field, msg := range expected {
+		if errs[field] != msg {
+			t.Errorf("field %s: expected %q, got %q", field, msg, errs[field])
+		}
+	}
+}
+
+func TestValidate_OptionalFormatSkippedWhenEmpty(t *testing.T) {
+	req := struct {
+		Start string `json:"start" validate:"rfc3339"`
+	}{}
+
+	if errs := validate(&req); errs != nil {
+		t.Fatalf("expected no errors for empty optional field, got %v", errs)
+	}
+}
+
+func TestValidate_FallsBackToGoFieldName(t *testing.T) {
+	req := struct {
+		Name string `validate:"required"`
+	}{}
+
+	errs := validate(&req)
+	if errs["Name"] != "Name is required" {
+		t.Errorf("expected error keyed by Go field name, got %v", errs)
+	}
+}
@@ -56,6 +56,8 @@ func (a *Auth) Authenticate(next http.Handler) http.Handler {
 				ctx = SetTenantID(ctx, key.TenantID)
 				ctx = setKeyPrefix(ctx, prefix)
 				ctx = setScopes(ctx, key.Scopes)
+				ctx = SetAPIKeyID(ctx, key.ID)
+				ctx = WithLogger(ctx, LoggerFromContext(ctx).With("tenant_id", key.TenantID))
 				r = r.WithContext(ctx)
 				matched = true
 
@@ -75,14 +77,18 @@ func (a *Auth) Authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// AdminScope is the API key scope for admin routes. It also satisfies every
+// other scope.
+const AdminScope = "admin"
+
 // RequireScope returns middleware that checks whether the authenticated
-// API key has the specified scope.
+// API key has the specified scope, or AdminScope.
 func (a *Auth) RequireScope(scope string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			scopes := getScopes(r)
 			for _, s := range scopes {
-				if s == scope {
+				if s == scope || s == AdminScope {
 					next.ServeHTTP(w, r)
 					return
 				}
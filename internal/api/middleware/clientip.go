@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the peers allowed to report a client's address in
+// X-Forwarded-For or X-Real-IP. A nil *TrustedProxies trusts none.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// NewTrustedProxies parses entries, each an IP address or a CIDR range. No
+// entries means forwarding headers are never trusted.
+func NewTrustedProxies(entries []string) (*TrustedProxies, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			p, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return &TrustedProxies{prefixes: prefixes}, nil
+}
+
+// ClientIP returns the address of the client that made r. Forwarding headers
+// are only honored when the immediate peer is a trusted proxy, so a client
+// talking to the server directly cannot spoof its address. X-Forwarded-For is
+// read right to left, skipping trusted proxies, so entries a client prepended
+// are ignored; X-Real-IP is the fallback.
+func (t *TrustedProxies) ClientIP(r *http.Request) string {
+	peer := remoteIP(r.RemoteAddr)
+	if !peer.IsValid() {
+		return r.RemoteAddr
+	}
+	if !t.trusts(peer) {
+		return peer.String()
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = addr.Unmap()
+			if !t.trusts(client) {
+				break
+			}
+		}
+		if client.IsValid() {
+			return client.String()
+		}
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
+	}
+	return peer.String()
+}
+
+// remoteIP parses the host part of an http.Request RemoteAddr.
+func remoteIP(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func (t *TrustedProxies) trusts(addr netip.Addr) bool {
+	if t == nil {
+		return false
+	}
+	for _, p := range t.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
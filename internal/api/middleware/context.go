@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -13,6 +14,9 @@ const (
 	tenantIDKey contextKey = "tenant_id"
 	keyPrefixKey contextKey = "key_prefix"
 	apiKeyScopesKey contextKey = "api_key_scopes"
+	requestIDKey contextKey = "request_id"
+	apiKeyIDKey contextKey = "api_key_id"
+	loggerKey contextKey = "logger"
 )
 
 func SetTenantID(ctx context.Context, id uuid.UUID) context.Context {
@@ -42,6 +46,41 @@ func getScopes(r *http.Request) []string {
 	return scopes
 }
 
+func SetAPIKeyID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, apiKeyIDKey, id)
+}
+
+// GetAPIKeyID returns the ID of the API key that authenticated the request.
+func GetAPIKeyID(r *http.Request) (uuid.UUID, bool) {
+	id, ok := r.Context().Value(apiKeyIDKey).(uuid.UUID)
+	return id, ok
+}
+
+func setRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// GetRequestID returns the request ID assigned by the RequestID middleware.
+func GetRequestID(r *http.Request) (string, bool) {
+	id, ok := r.Context().Value(requestIDKey).(string)
+	return id, ok
+}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// LoggerFromContext returns the request-scoped logger seeded by the Logger
+// middleware, which carries the request ID and, once authenticated, the
+// tenant ID. Falls back to slog.Default() outside a request.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
 // ExportedKeyPrefixKey returns the context key for key_prefix (for testing).
 func ExportedKeyPrefixKey() contextKey {
 	return keyPrefixKey
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	corsAllowMethods  = "GET, POST, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Authorization, Content-Type, " + RequestIDHeader
+	corsExposeHeaders = RequestIDHeader
+	corsMaxAge        = "600"
+)
+
+// wildcardLabels is what a * in an origin pattern expands to: one or more DNS
+// labels, so it can never swallow a scheme, port or path separator.
+const wildcardLabels = `[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*`
+
+// CORS answers cross-origin requests from an allow-list of origin patterns.
+type CORS struct {
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewCORS creates a CORS middleware from origin patterns. Each pattern is one of:
+//
+//   - an exact origin, e.g. https://app.example.com
+//   - a wildcard origin, e.g. https://*.example.com, where * matches one or
+//     more subdomain labels
+//   - a regular expression starting with ^, e.g. ^https://pr-[0-9]+\.example\.com$
+//
+// Patterns always match the whole Origin header.
+func NewCORS(origins []string) (*CORS, error) {
+	c := &CORS{exact: make(map[string]bool)}
+	for _, origin := range origins {
+		switch {
+		case strings.HasPrefix(origin, "^"):
+			expr := strings.TrimSuffix(strings.TrimPrefix(origin, "^"), "$")
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid CORS origin pattern %q: %w", origin, err)
+			}
+			c.patterns = append(c.patterns, re)
+		case strings.Contains(origin, "*"):
+			parts := strings.Split(origin, "*")
+			for i, part := range parts {
+				parts[i] = regexp.QuoteMeta(part)
+			}
+			c.patterns = append(c.patterns, regexp.MustCompile("^"+strings.Join(parts, wildcardLabels)+"$"))
+		default:
+			c.exact[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+	return c, nil
+}
+
+// Allowed reports whether origin matches one of the configured patterns.
+func (c *CORS) Allowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if c.exact[origin] {
+		return true
+	}
+	for _, re := range c.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler sets CORS headers for allowed origins and answers their preflight
+// requests. The matched origin is echoed back rather than *, so responses stay
+// valid for credentialed requests. Requests from other origins pass through
+// without CORS headers and are blocked by the browser. X-Request-ID may be
+// sent and is exposed to scripts, so clients can correlate requests with logs.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if !c.Allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
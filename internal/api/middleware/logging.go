@@ -16,19 +16,34 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
-func Logger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
-
-		next.ServeHTTP(rec, r)
-
-		slog.Info("request",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"status", rec.status,
-			"duration_ms", time.Since(start).Milliseconds(),
-			"remote_addr", r.RemoteAddr,
-		)
-	})
+// Logger is NewLogger without trusted proxies.
+var Logger = NewLogger(nil)
+
+// NewLogger returns middleware that seeds the request context with a logger
+// carrying the request ID assigned by RequestID (see LoggerFromContext), and
+// logs the completed request with its client IP as proxies report it.
+func NewLogger(proxies *TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			logger := slog.Default()
+			if requestID, ok := GetRequestID(r); ok {
+				logger = logger.With("request_id", requestID)
+			}
+			r = r.WithContext(WithLogger(r.Context(), logger))
+
+			next.ServeHTTP(rec, r)
+
+			logger.Info("request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote_addr", r.RemoteAddr,
+				"client_ip", proxies.ClientIP(r),
+			)
+		})
+	}
 }
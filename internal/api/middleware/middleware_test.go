@@ -1,17 +1,23 @@
 package middleware_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	mw "github.com/kiranshivaraju/loghunter/internal/api/middleware"
 	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
@@ -33,7 +39,7 @@ func (m *mockStore) GetDefaultTenant(_ context.Context) (*models.Tenant, error)
 	return nil, store.ErrNotFound
 }
 func (m *mockStore) CreateAPIKey(_ context.Context, _ *models.APIKey) error  { return nil }
-func (m *mockStore) ListAPIKeys(_ context.Context, _ uuid.UUID) ([]*models.APIKey, error) {
+func (m *mockStore) ListAPIKeys(_ context.Context, _ uuid.UUID, _ ...store.APIKeyListOption) ([]*models.APIKey, error) {
 	return nil, nil
 }
 func (m *mockStore) RevokeAPIKey(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
@@ -52,19 +58,64 @@ func (m *mockStore) GetClustersByFingerprints(_ context.Context, _ uuid.UUID, _
 func (m *mockStore) CreateAnalysisResult(_ context.Context, _ *models.AnalysisResult) error {
 	return nil
 }
-func (m *mockStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (m *mockStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, store.ErrNotFound
 }
-func (m *mockStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (m *mockStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, store.ErrNotFound
 }
 func (m *mockStore) CreateJob(_ context.Context, _ *models.Job) error { return nil }
 func (m *mockStore) GetJob(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.Job, error) {
 	return nil, store.ErrNotFound
 }
+
+func (m *mockStore) JobExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
 func (m *mockStore) UpdateJobStatus(_ context.Context, _ uuid.UUID, _ string, _ ...store.JobUpdateOption) error {
 	return nil
 }
+func (m *mockStore) JobStats(_ context.Context, _ uuid.UUID, _ time.Time) (store.JobStats, error) {
+	return store.JobStats{}, nil
+}
+func (m *mockStore) GetTenantByAPIKeyPrefix(_ context.Context, _ string) (*models.Tenant, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
+func (m *mockStore) SaveAnalysisContext(_ context.Context, _ *models.AnalysisContext) error { return nil }
+func (m *mockStore) GetAnalysisContext(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisContext, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockStore) ListAPIKeysPaged(_ context.Context, _ uuid.UUID, _, _ int, _ ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
+	return nil, 0, nil
+}
+func (m *mockStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, _ string) (*models.ErrorCluster, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockStore) GetJobsByIDs(_ context.Context, _ uuid.UUID, _ []uuid.UUID) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+func (m *mockStore) CountErrorClusters(_ context.Context, _ store.ClusterFilter) (int, error) { return 0, nil }
+func (m *mockStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error { return nil }
+func (m *mockStore) SetClusterPinned(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ bool) error { return nil }
+func (m *mockStore) RevokeAllAPIKeys(_ context.Context, _ uuid.UUID, _ ...uuid.UUID) (int, error) { return 0, nil }
+func (m *mockStore) ErrorClusterExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+func (m *mockStore) GetJobByID(_ context.Context, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (m *mockStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) { return &store.MigrationStatus{}, nil }
+func (m *mockStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return 0, nil
+}
+func (m *mockStore) GetAnalysisResultByID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
+	return nil, store.ErrNotFound
+}
+func (m *mockStore) ListJobs(_ context.Context, _ uuid.UUID, _ store.JobFilter) ([]*models.Job, int, error) { return nil, 0, nil }
+func (m *mockStore) CreateClusterSuppression(_ context.Context, _ *models.ClusterSuppression) error { return nil }
+func (m *mockStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) { return nil, nil }
+func (m *mockStore) DeleteClusterSuppression(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (m *mockStore) DeleteErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (m *mockStore) GetTenant(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id}, nil
+}
 
 // --- Mock Cache ---
 
@@ -195,10 +246,11 @@ func TestAuth_ValidKey(t *testing.T) {
 	}}}
 	auth := mw.NewAuth(ms)
 
-	var gotTenantID uuid.UUID
+	var gotTenantID, gotKeyID uuid.UUID
 	var gotOK bool
 	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gotTenantID, gotOK = mw.GetTenantID(r)
+		gotKeyID, _ = mw.GetAPIKeyID(r)
 		w.WriteHeader(http.StatusOK)
 	})
 	handler := auth.Authenticate(inner)
@@ -211,6 +263,7 @@ func TestAuth_ValidKey(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.True(t, gotOK)
 	assert.Equal(t, tenantID, gotTenantID)
+	assert.Equal(t, ms.keys[0].ID, gotKeyID)
 }
 
 func TestAuth_RequireScope_Allowed(t *testing.T) {
@@ -234,6 +287,29 @@ func TestAuth_RequireScope_Allowed(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestAuth_RequireScope_AdminSatisfiesAnyScope(t *testing.T) {
+	rawKey := "lh_admin_1234567890abcdef"
+	ms := &mockStore{keys: []*models.APIKey{{
+		ID:        uuid.New(),
+		TenantID:  uuid.New(),
+		KeyHash:   hashKey(t, rawKey),
+		KeyPrefix: rawKey[:8],
+		Scopes:    []string{"admin"},
+	}}}
+	auth := mw.NewAuth(ms)
+
+	for _, scope := range []string{"read", "write"} {
+		handler := auth.Authenticate(auth.RequireScope(scope)(okHandler()))
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+rawKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "scope %s", scope)
+	}
+}
+
 func TestAuth_RequireScope_Denied(t *testing.T) {
 	rawKey := "lh_read__1234567890abcdef"
 	ms := &mockStore{keys: []*models.APIKey{{
@@ -331,6 +407,26 @@ func TestRecovery_CatchesPanic(t *testing.T) {
 	assert.Equal(t, "INTERNAL_ERROR", errBody(t, w)["code"])
 }
 
+func TestRecovery_LogsRequestID(t *testing.T) {
+	buf := captureDefaultLogger(t)
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(mw.RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+	mw.RequestID(mw.Logger(mw.Recovery(panicking))).ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", errBody(t, w)["request_id"])
+
+	records := logRecords(t, buf)
+	require.NotEmpty(t, records)
+	assert.Equal(t, "panic recovered", records[0]["msg"])
+	assert.Equal(t, "req-42", records[0]["request_id"])
+	assert.NotEmpty(t, records[0]["stack"])
+}
+
 func TestRecovery_NoPanic(t *testing.T) {
 	handler := mw.Recovery(okHandler())
 
@@ -354,3 +450,312 @@ func TestLogger_SetsStatus(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+// scrapeMetric returns the value of the sample whose name and labels are
+// exactly series (e.g. `foo_total{a="b"}`) in the default registry, or 0.
+func scrapeMetric(t *testing.T, series string) float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return v
+		}
+	}
+	return 0
+}
+
+func TestMetrics_LabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(mw.Metrics)
+	r.Get("/metrics-test/{id}", okHandler())
+	r.Post("/metrics-test/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	okSeries := `http_requests_total{method="GET",route="/metrics-test/{id}",status="200"}`
+	teapotSeries := `http_requests_total{method="POST",route="/metrics-test/{id}",status="418"}`
+	unmatchedSeries := `http_requests_total{method="GET",route="unmatched",status="404"}`
+	otherSeries := `http_requests_total{method="OTHER",route="unmatched",status="405"}`
+	latencySeries := `http_request_duration_seconds_count{method="GET",route="/metrics-test/{id}"}`
+	before := map[string]float64{}
+	for _, series := range []string{okSeries, teapotSeries, unmatchedSeries, otherSeries, latencySeries} {
+		before[series] = scrapeMetric(t, series)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/metrics-test/1", nil),
+		httptest.NewRequest("GET", "/metrics-test/2", nil),
+		httptest.NewRequest("POST", "/metrics-test/3", nil),
+		httptest.NewRequest("GET", "/no-such-route", nil),
+		httptest.NewRequest("BREW", "/metrics-test/4", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Both IDs share one series.
+	assert.Equal(t, 2.0, scrapeMetric(t, okSeries)-before[okSeries])
+	assert.Equal(t, 2.0, scrapeMetric(t, latencySeries)-before[latencySeries])
+	assert.Equal(t, 1.0, scrapeMetric(t, teapotSeries)-before[teapotSeries])
+	assert.Equal(t, 1.0, scrapeMetric(t, unmatchedSeries)-before[unmatchedSeries])
+	assert.Equal(t, 1.0, scrapeMetric(t, otherSeries)-before[otherSeries])
+}
+
+// captureDefaultLogger routes slog.Default() into a buffer for the test.
+func captureDefaultLogger(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// logRecords decodes every JSON log line in buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var rec map[string]any
+		require.NoError(t, dec.Decode(&rec))
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestLogger_ContextLoggerIncludesRequestID(t *testing.T) {
+	buf := captureDefaultLogger(t)
+
+	var gotID string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, _ = mw.GetRequestID(r)
+		mw.LoggerFromContext(r.Context()).Info("inside handler")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	mw.RequestID(mw.Logger(inner)).ServeHTTP(w, req)
+
+	requestID := w.Header().Get(mw.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, gotID)
+
+	records := logRecords(t, buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, "inside handler", records[0]["msg"])
+	assert.Equal(t, requestID, records[0]["request_id"])
+	assert.Equal(t, "request", records[1]["msg"])
+	assert.Equal(t, requestID, records[1]["request_id"])
+}
+
+func TestRequestID_Header(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{"reuses incoming", "abc-123", true},
+		{"replaces spaces", "abc 123", false},
+		{"replaces control characters", "abc\x00", false},
+		{"replaces oversized", string(bytes.Repeat([]byte("a"), 129)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set(mw.RequestIDHeader, tt.incoming)
+			w := httptest.NewRecorder()
+			mw.RequestID(okHandler()).ServeHTTP(w, req)
+
+			got := w.Header().Get(mw.RequestIDHeader)
+			if tt.reused {
+				assert.Equal(t, tt.incoming, got)
+			} else {
+				_, err := uuid.Parse(got)
+				assert.NoError(t, err, "expected a generated UUID, got %q", got)
+			}
+		})
+	}
+}
+
+func TestAuth_ContextLoggerIncludesTenant(t *testing.T) {
+	buf := captureDefaultLogger(t)
+
+	rawKey := "lh_test1234567890abcdef"
+	tenantID := uuid.New()
+	ms := &mockStore{keys: []*models.APIKey{{
+		ID: uuid.New(), TenantID: tenantID, KeyHash: hashKey(t, rawKey), KeyPrefix: rawKey[:8],
+	}}}
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mw.LoggerFromContext(r.Context()).Info("inside handler")
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+rawKey)
+	w := httptest.NewRecorder()
+	mw.RequestID(mw.Logger(mw.NewAuth(ms).Authenticate(inner))).ServeHTTP(w, req)
+
+	records := logRecords(t, buf)
+	require.NotEmpty(t, records)
+	assert.Equal(t, tenantID.String(), records[0]["tenant_id"])
+	assert.Equal(t, w.Header().Get(mw.RequestIDHeader), records[0]["request_id"])
+}
+
+func TestLogger_WithoutRequestID(t *testing.T) {
+	buf := captureDefaultLogger(t)
+
+	w := httptest.NewRecorder()
+	mw.Logger(okHandler()).ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+	records := logRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.NotContains(t, records[0], "request_id")
+}
+
+func TestLoggerFromContext_DefaultsOutsideRequest(t *testing.T) {
+	assert.Same(t, slog.Default(), mw.LoggerFromContext(context.Background()))
+}
+
+// ========================================
+// CORS Middleware Tests
+// ========================================
+
+func TestCORS_WildcardSubdomainMatch(t *testing.T) {
+	cors, err := mw.NewCORS([]string{"https://*.example.com"})
+	require.NoError(t, err)
+
+	for _, origin := range []string{"https://pr-42.example.com", "https://a.preview.example.com"} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		cors.Handler(okHandler()).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, mw.RequestIDHeader, w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	}
+}
+
+func TestCORS_NonMatchingOrigin(t *testing.T) {
+	cors, err := mw.NewCORS([]string{"https://*.example.com", "https://app.other.com"})
+	require.NoError(t, err)
+
+	for _, origin := range []string{
+		"https://example.com",            // wildcard needs a subdomain
+		"https://evil.com/.example.com",  // * cannot span a path
+		"https://pr-1.example.com.evil",  // suffix must match exactly
+		"http://pr-1.example.com",        // scheme must match
+		"https://app.other.com.evil.com", // exact origins are exact
+	} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		cors.Handler(okHandler()).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, origin)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+	}
+}
+
+func TestCORS_RegexPattern(t *testing.T) {
+	cors, err := mw.NewCORS([]string{`^https://pr-[0-9]+\.example\.com`})
+	require.NoError(t, err)
+
+	assert.True(t, cors.Allowed("https://pr-7.example.com"))
+	assert.False(t, cors.Allowed("https://pr-x.example.com"))
+	assert.False(t, cors.Allowed("https://pr-7.example.com.evil.com"), "regex must match the whole origin")
+}
+
+func TestCORS_InvalidRegex(t *testing.T) {
+	_, err := mw.NewCORS([]string{"^https://(unclosed"})
+	assert.Error(t, err)
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cors, err := mw.NewCORS([]string{"https://app.example.com"})
+	require.NoError(t, err)
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/clusters", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	cors.Handler(next).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, called, "preflight should not reach the next handler")
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	// PATCH /api/v1/clusters/{clusterID} is reachable cross-origin.
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Request-ID")
+}
+
+// --- ClientIP Tests ---
+
+func TestClientIP(t *testing.T) {
+	proxies, err := mw.NewTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer spoofing XFF", "203.0.113.7:5000",
+			map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.7"},
+		{"untrusted peer spoofing X-Real-IP", "203.0.113.7:5000",
+			map[string]string{"X-Real-IP": "1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy forwards", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.9"}, "198.51.100.9"},
+		{"trusted single IP forwards", "192.168.1.5:5000",
+			map[string]string{"X-Forwarded-For": "198.51.100.9"}, "198.51.100.9"},
+		{"client-prepended hops ignored", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 10.4.4.4"}, "198.51.100.9"},
+		{"X-Real-IP from trusted proxy", "10.1.2.3:5000",
+			map[string]string{"X-Real-IP": "198.51.100.9"}, "198.51.100.9"},
+		{"trusted proxy without headers", "10.1.2.3:5000", nil, "10.1.2.3"},
+		{"garbage header", "10.1.2.3:5000",
+			map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, proxies.ClientIP(req))
+		})
+	}
+}
+
+func TestClientIP_NoTrustedProxies(t *testing.T) {
+	none, err := mw.NewTrustedProxies(nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	assert.Equal(t, "10.1.2.3", none.ClientIP(req))
+
+	var unset *mw.TrustedProxies
+	assert.Equal(t, "10.1.2.3", unset.ClientIP(req))
+}
+
+func TestNewTrustedProxies_Invalid(t *testing.T) {
+	_, err := mw.NewTrustedProxies([]string{"10.0.0.0/99"})
+	assert.Error(t, err)
+	_, err = mw.NewTrustedProxies([]string{"proxy.internal"})
+	assert.Error(t, err)
+}
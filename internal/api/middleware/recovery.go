@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"log/slog"
 	"net/http"
 	"runtime/debug"
 
@@ -12,7 +11,9 @@ func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				slog.Error("panic recovered",
+				// The context logger carries the request ID, which is also
+				// returned in the error response for the client to quote.
+				LoggerFromContext(r.Context()).Error("panic recovered",
 					"error", err,
 					"stack", string(debug.Stack()),
 					"method", r.Method,
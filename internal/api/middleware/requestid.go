@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = response.RequestIDHeader
+
+// maxRequestIDLen bounds a client-supplied request ID.
+const maxRequestIDLen = 128
+
+// RequestID assigns each request an ID, reusing a well-formed incoming
+// X-Request-ID, stores it in the request context (see GetRequestID) and echoes
+// it in the response. Install it first, so every later middleware and error
+// response can see the ID.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(setRequestID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to
+// propagate into logs and response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, ch := range id {
+		if ch > unicode.MaxASCII || !unicode.IsPrint(ch) || ch == ' ' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kiranshivaraju/loghunter/internal/store"
+)
+
+// ParseListParams reads the ?page= and ?limit= query parameters of a list
+// request. Missing or non-numeric values fall back to the defaults, and both
+// are clamped by store.NormalizePagination, so the bounds configured with
+// store.ConfigurePagination apply to every list endpoint.
+func ParseListParams(r *http.Request) (page, limit int) {
+	q := r.URL.Query()
+	page, _ = strconv.Atoi(q.Get("page"))
+	limit, _ = strconv.Atoi(q.Get("limit"))
+	return store.NormalizePagination(page, limit)
+}
+
+// NewPaginationMeta returns the meta for a page of a list with total items.
+func NewPaginationMeta(page, limit, total int) PaginationMeta {
+	return PaginationMeta{
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+		HasNext: total > page*limit,
+	}
+}
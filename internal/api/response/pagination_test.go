@@ -0,0 +1,54 @@
+package response_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kiranshivaraju/loghunter/internal/api/response"
+	"github.com/kiranshivaraju/loghunter/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantPage  int
+		wantLimit int
+	}{
+		{"defaults", "", 1, 20},
+		{"explicit values", "?page=3&limit=50", 3, 50},
+		{"limit above max", "?page=2&limit=500", 2, 100},
+		{"zero and negative", "?page=0&limit=-5", 1, 20},
+		{"non-numeric", "?page=two&limit=lots", 1, 20},
+		{"fractional", "?page=1.5&limit=10.2", 1, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/clusters"+tt.query, nil)
+			page, limit := response.ParseListParams(r)
+			assert.Equal(t, tt.wantPage, page)
+			assert.Equal(t, tt.wantLimit, limit)
+		})
+	}
+}
+
+func TestParseListParams_ConfiguredBounds(t *testing.T) {
+	store.ConfigurePagination(10, 30)
+	t.Cleanup(func() { store.ConfigurePagination(20, 100) })
+
+	page, limit := response.ParseListParams(httptest.NewRequest("GET", "/api/v1/clusters", nil))
+	assert.Equal(t, 1, page)
+	assert.Equal(t, 10, limit)
+
+	_, limit = response.ParseListParams(httptest.NewRequest("GET", "/api/v1/clusters?limit=50", nil))
+	assert.Equal(t, 30, limit)
+}
+
+func TestNewPaginationMeta(t *testing.T) {
+	assert.Equal(t, response.PaginationMeta{Page: 1, Limit: 20, Total: 45, HasNext: true},
+		response.NewPaginationMeta(1, 20, 45))
+	assert.Equal(t, response.PaginationMeta{Page: 3, Limit: 20, Total: 45, HasNext: false},
+		response.NewPaginationMeta(3, 20, 45))
+	assert.False(t, response.NewPaginationMeta(2, 20, 40).HasNext)
+}
@@ -10,8 +10,14 @@ type envelope struct {
 }
 
 type collectionEnvelope struct {
-	Data any            `json:"data"`
-	Meta PaginationMeta `json:"meta"`
+	Data any `json:"data"`
+	Meta any `json:"meta"`
+}
+
+type filteredCollectionEnvelope struct {
+	Data           any `json:"data"`
+	Meta           any `json:"meta"`
+	AppliedFilters any `json:"applied_filters"`
 }
 
 type errorEnvelope struct {
@@ -19,9 +25,10 @@ type errorEnvelope struct {
 }
 
 type errorBody struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details any    `json:"details,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type PaginationMeta struct {
@@ -43,15 +50,40 @@ func Accepted(w http.ResponseWriter, data any) {
 	writeJSON(w, http.StatusAccepted, envelope{Data: data})
 }
 
+// NoContent writes a 204 with no body, for deletes and other actions with
+// nothing to return.
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func Collection(w http.ResponseWriter, data any, meta PaginationMeta) {
 	writeJSON(w, http.StatusOK, collectionEnvelope{Data: data, Meta: meta})
 }
 
+// CollectionWithMeta writes a collection with an extended meta object. meta should
+// embed PaginationMeta so the standard pagination fields are always present.
+func CollectionWithMeta(w http.ResponseWriter, data any, meta any) {
+	writeJSON(w, http.StatusOK, collectionEnvelope{Data: data, Meta: meta})
+}
+
+// CollectionWithFilters writes a collection like CollectionWithMeta, echoing
+// the filters the server applied as a top-level applied_filters object.
+func CollectionWithFilters(w http.ResponseWriter, data any, meta any, filters any) {
+	writeJSON(w, http.StatusOK, filteredCollectionEnvelope{Data: data, Meta: meta, AppliedFilters: filters})
+}
+
+// RequestIDHeader is the response header the request ID middleware sets.
+const RequestIDHeader = "X-Request-ID"
+
+// Error writes an error envelope. It includes the request ID already set on
+// w's RequestIDHeader, if any, so a client can quote it when reporting the
+// error.
 func Error(w http.ResponseWriter, status int, code, message string, details any) {
 	writeJSON(w, status, errorEnvelope{Error: errorBody{
-		Code:    code,
-		Message: message,
-		Details: details,
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: w.Header().Get(RequestIDHeader),
 	}})
 }
 
@@ -73,6 +73,30 @@ func TestCollection(t *testing.T) {
 	assert.Equal(t, true, m["has_next"])
 }
 
+func TestCollectionWithFilters(t *testing.T) {
+	w := httptest.NewRecorder()
+	meta := response.PaginationMeta{Page: 1, Limit: 20, Total: 1}
+
+	response.CollectionWithFilters(w, []string{"a"}, meta, map[string]string{"service": "api"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body["data"].([]any), 1)
+	assert.Equal(t, float64(1), body["meta"].(map[string]any)["total"])
+	assert.Equal(t, "api", body["applied_filters"].(map[string]any)["service"])
+}
+
+func TestNoContent(t *testing.T) {
+	w := httptest.NewRecorder()
+	response.NoContent(w)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	assert.Empty(t, w.Header().Get("Content-Type"))
+}
+
 func TestError(t *testing.T) {
 	w := httptest.NewRecorder()
 	response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid params", map[string][]string{
@@ -105,3 +129,21 @@ func TestError_NoDetails(t *testing.T) {
 	_, hasDetails := errObj["details"]
 	assert.False(t, hasDetails)
 }
+
+func TestError_IncludesRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(response.RequestIDHeader, "req-123")
+	response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Boom", nil)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "req-123", body["error"].(map[string]any)["request_id"])
+
+	// Without a request ID the field is omitted.
+	w = httptest.NewRecorder()
+	response.Error(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", "Not found", nil)
+	body = nil
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	_, hasRequestID := body["error"].(map[string]any)["request_id"]
+	assert.False(t, hasRequestID)
+}
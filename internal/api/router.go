@@ -12,17 +12,72 @@ import (
 type Dependencies struct {
 	Auth      *mw.Auth
 	RateLimit *mw.RateLimit
+	// CORS is optional; cross-origin requests get no CORS headers when nil.
+	CORS *mw.CORS
+	// TrustedProxies may report client IPs for request logs; nil trusts none.
+	TrustedProxies *mw.TrustedProxies
+	// RouteScopes overrides DefaultRouteScopes entry by entry, keyed the same
+	// way. An empty scope lifts a route's requirement.
+	RouteScopes map[string]string
 
 	HealthHandler   http.HandlerFunc
+	MetricsHandler  http.HandlerFunc
 	AnalyzeHandler  http.HandlerFunc
 	PollJobHandler  http.HandlerFunc
+	JobLogsHandler  http.HandlerFunc
+	ReplayHandler   http.HandlerFunc
+	CancelJobHandler http.HandlerFunc
+	GetAnalysisHandler http.HandlerFunc
+	BulkPollHandler http.HandlerFunc
+	EstimateAnalysisHandler http.HandlerFunc
 	ListClusters    http.HandlerFunc
 	GetCluster      http.HandlerFunc
+	PatchCluster    http.HandlerFunc
+	DeleteCluster   http.HandlerFunc
+	ClusterSummarizeHandler http.HandlerFunc
 	SummarizeHandler http.HandlerFunc
+	BatchSummarizeHandler http.HandlerFunc
 	SearchHandler   http.HandlerFunc
+	ValidateQueryHandler http.HandlerFunc
+	DetectHandler   http.HandlerFunc
+	DetectPreviewHandler http.HandlerFunc
 	CreateKeyHandler http.HandlerFunc
 	ListKeysHandler  http.HandlerFunc
 	RevokeKeyHandler http.HandlerFunc
+	RevokeAllKeysHandler http.HandlerFunc
+	MigrationsHandler    http.HandlerFunc
+	PruneOrphanedResultsHandler http.HandlerFunc
+	JobStatsHandler  http.HandlerFunc
+	ListJobsHandler  http.HandlerFunc
+	CreateSuppressionHandler http.HandlerFunc
+	ListSuppressionsHandler  http.HandlerFunc
+	DeleteSuppressionHandler http.HandlerFunc
+}
+
+// DefaultRouteScopes is the API key scope each non-admin route requires, keyed
+// by method and route pattern. Reads need "read"; anything that starts work on
+// a provider or changes state needs "write". Admin routes always need "admin",
+// which also satisfies every other scope.
+var DefaultRouteScopes = map[string]string{
+	"POST /api/v1/analyze":                        "write",
+	"POST /api/v1/analyze/poll":                   "read",
+	"POST /api/v1/analyze/estimate":               "read",
+	"GET /api/v1/analyze/{jobID}":                 "read",
+	"GET /api/v1/analyze/{jobID}/logs":            "read",
+	"POST /api/v1/analyze/{jobID}/replay":         "write",
+	"DELETE /api/v1/analyze/{jobID}":              "write",
+	"GET /api/v1/analyses/{analysisID}":           "read",
+	"GET /api/v1/clusters":                        "read",
+	"GET /api/v1/clusters/{clusterID}":            "read",
+	"PATCH /api/v1/clusters/{clusterID}":          "write",
+	"POST /api/v1/clusters/{clusterID}/summarize": "write",
+	"POST /api/v1/summarize":                      "write",
+	"POST /api/v1/summarize/batch":                "write",
+	"POST /api/v1/search":                         "read",
+	"POST /api/v1/search/validate":                "read",
+	"POST /api/v1/detect":                         "write",
+	"POST /api/v1/detect/preview":                 "read",
+	"GET /api/v1/jobs/stats":                      "read",
 }
 
 // NewRouter builds the Chi router with middleware stack and all routes.
@@ -30,33 +85,80 @@ func NewRouter(deps Dependencies) http.Handler {
 	r := chi.NewRouter()
 
 	// Global middleware
-	r.Use(mw.Logger)
+	r.Use(mw.RequestID)
+	r.Use(mw.NewLogger(deps.TrustedProxies))
+	r.Use(mw.Metrics)
 	r.Use(mw.Recovery)
+	if deps.CORS != nil {
+		// Before auth, so unauthenticated preflight requests are answered.
+		r.Use(deps.CORS.Handler)
+	}
 
 	// Public health check
 	r.Get("/api/v1/health", orNotImplemented(deps.HealthHandler))
+	r.Get("/metrics", orNotImplemented(deps.MetricsHandler))
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
 		r.Use(deps.Auth.Authenticate)
 		r.Use(deps.RateLimit.Limit)
 
-		r.Post("/api/v1/analyze", orNotImplemented(deps.AnalyzeHandler))
-		r.Get("/api/v1/analyze/{jobID}", orNotImplemented(deps.PollJobHandler))
+		scopes := make(map[string]string, len(DefaultRouteScopes))
+		for route, scope := range DefaultRouteScopes {
+			scopes[route] = scope
+		}
+		for route, scope := range deps.RouteScopes {
+			scopes[route] = scope
+		}
+		handle := func(method, pattern string, h http.HandlerFunc) {
+			if scope := scopes[method+" "+pattern]; scope != "" {
+				r.With(deps.Auth.RequireScope(scope)).Method(method, pattern, orNotImplemented(h))
+				return
+			}
+			r.Method(method, pattern, orNotImplemented(h))
+		}
 
-		r.Get("/api/v1/clusters", orNotImplemented(deps.ListClusters))
-		r.Get("/api/v1/clusters/{clusterID}", orNotImplemented(deps.GetCluster))
+		handle("POST", "/api/v1/analyze", deps.AnalyzeHandler)
+		handle("POST", "/api/v1/analyze/poll", deps.BulkPollHandler)
+		handle("POST", "/api/v1/analyze/estimate", deps.EstimateAnalysisHandler)
+		handle("GET", "/api/v1/analyze/{jobID}", deps.PollJobHandler)
+		handle("GET", "/api/v1/analyze/{jobID}/logs", deps.JobLogsHandler)
+		handle("POST", "/api/v1/analyze/{jobID}/replay", deps.ReplayHandler)
+		handle("DELETE", "/api/v1/analyze/{jobID}", deps.CancelJobHandler)
+		handle("GET", "/api/v1/analyses/{analysisID}", deps.GetAnalysisHandler)
 
-		r.Post("/api/v1/summarize", orNotImplemented(deps.SummarizeHandler))
-		r.Post("/api/v1/search", orNotImplemented(deps.SearchHandler))
+		handle("GET", "/api/v1/clusters", deps.ListClusters)
+		handle("GET", "/api/v1/clusters/{clusterID}", deps.GetCluster)
+		handle("PATCH", "/api/v1/clusters/{clusterID}", deps.PatchCluster)
+		handle("POST", "/api/v1/clusters/{clusterID}/summarize", deps.ClusterSummarizeHandler)
+
+		handle("POST", "/api/v1/summarize", deps.SummarizeHandler)
+		handle("POST", "/api/v1/summarize/batch", deps.BatchSummarizeHandler)
+		handle("POST", "/api/v1/search", deps.SearchHandler)
+		handle("POST", "/api/v1/search/validate", deps.ValidateQueryHandler)
+		handle("POST", "/api/v1/detect", deps.DetectHandler)
+		handle("POST", "/api/v1/detect/preview", deps.DetectPreviewHandler)
+
+		handle("GET", "/api/v1/jobs/stats", deps.JobStatsHandler)
 
 		// Admin routes
 		r.Group(func(r chi.Router) {
-			r.Use(deps.Auth.RequireScope("admin"))
+			r.Use(deps.Auth.RequireScope(mw.AdminScope))
 
 			r.Post("/api/v1/admin/keys", orNotImplemented(deps.CreateKeyHandler))
 			r.Get("/api/v1/admin/keys", orNotImplemented(deps.ListKeysHandler))
+			r.Post("/api/v1/admin/keys/revoke-all", orNotImplemented(deps.RevokeAllKeysHandler))
 			r.Delete("/api/v1/admin/keys/{keyID}", orNotImplemented(deps.RevokeKeyHandler))
+			r.Get("/api/v1/admin/migrations", orNotImplemented(deps.MigrationsHandler))
+			r.Post("/api/v1/admin/maintenance/prune-orphaned-results", orNotImplemented(deps.PruneOrphanedResultsHandler))
+			r.Get("/api/v1/admin/jobs", orNotImplemented(deps.ListJobsHandler))
+			r.Post("/api/v1/admin/suppressions", orNotImplemented(deps.CreateSuppressionHandler))
+			r.Get("/api/v1/admin/suppressions", orNotImplemented(deps.ListSuppressionsHandler))
+			r.Delete("/api/v1/admin/suppressions/{suppressionID}", orNotImplemented(deps.DeleteSuppressionHandler))
+
+			// Deleting a cluster destroys its analyses, so it is admin-only
+			// despite living under the cluster routes.
+			r.Delete("/api/v1/clusters/{clusterID}", orNotImplemented(deps.DeleteCluster))
 		})
 	})
 
@@ -16,6 +16,7 @@ import (
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // --- stub store that returns empty results (all auth fails) ---
@@ -31,7 +32,7 @@ func (s *stubStore) GetAPIKeyByPrefix(_ context.Context, _ string) ([]*models.AP
 }
 func (s *stubStore) UpdateAPIKeyLastUsed(_ context.Context, _ uuid.UUID) error       { return nil }
 func (s *stubStore) CreateAPIKey(_ context.Context, _ *models.APIKey) error           { return nil }
-func (s *stubStore) ListAPIKeys(_ context.Context, _ uuid.UUID) ([]*models.APIKey, error) {
+func (s *stubStore) ListAPIKeys(_ context.Context, _ uuid.UUID, _ ...store.APIKeyListOption) ([]*models.APIKey, error) {
 	return nil, nil
 }
 func (s *stubStore) RevokeAPIKey(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
@@ -50,19 +51,64 @@ func (s *stubStore) GetClustersByFingerprints(_ context.Context, _ uuid.UUID, _
 func (s *stubStore) CreateAnalysisResult(_ context.Context, _ *models.AnalysisResult) error {
 	return nil
 }
-func (s *stubStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (s *stubStore) GetAnalysisResultByJobID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, store.ErrNotFound
 }
-func (s *stubStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID) (*models.AnalysisResult, error) {
+func (s *stubStore) GetAnalysisResultByClusterID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
 	return nil, store.ErrNotFound
 }
 func (s *stubStore) CreateJob(_ context.Context, _ *models.Job) error { return nil }
 func (s *stubStore) GetJob(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.Job, error) {
 	return nil, store.ErrNotFound
 }
+
+func (s *stubStore) JobExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
 func (s *stubStore) UpdateJobStatus(_ context.Context, _ uuid.UUID, _ string, _ ...store.JobUpdateOption) error {
 	return nil
 }
+func (s *stubStore) JobStats(_ context.Context, _ uuid.UUID, _ time.Time) (store.JobStats, error) {
+	return store.JobStats{}, nil
+}
+func (s *stubStore) GetTenantByAPIKeyPrefix(_ context.Context, _ string) (*models.Tenant, error) {
+	return nil, store.ErrNotFound
+}
+func (s *stubStore) AutoResolveClusters(_ context.Context, _ time.Time) (map[uuid.UUID]int, error) {
+	return nil, nil
+}
+func (s *stubStore) SaveAnalysisContext(_ context.Context, _ *models.AnalysisContext) error { return nil }
+func (s *stubStore) GetAnalysisContext(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisContext, error) {
+	return nil, store.ErrNotFound
+}
+func (s *stubStore) ListAPIKeysPaged(_ context.Context, _ uuid.UUID, _, _ int, _ ...store.APIKeyListOption) ([]*models.APIKey, int, error) {
+	return nil, 0, nil
+}
+func (s *stubStore) GetErrorClusterByFingerprint(_ context.Context, _ uuid.UUID, _, _, _ string) (*models.ErrorCluster, error) {
+	return nil, store.ErrNotFound
+}
+func (s *stubStore) GetJobsByIDs(_ context.Context, _ uuid.UUID, _ []uuid.UUID) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+func (s *stubStore) CountErrorClusters(_ context.Context, _ store.ClusterFilter) (int, error) { return 0, nil }
+func (s *stubStore) TouchErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ time.Time) error { return nil }
+func (s *stubStore) SetClusterPinned(_ context.Context, _ uuid.UUID, _ uuid.UUID, _ bool) error { return nil }
+func (s *stubStore) RevokeAllAPIKeys(_ context.Context, _ uuid.UUID, _ ...uuid.UUID) (int, error) { return 0, nil }
+func (s *stubStore) ErrorClusterExists(_ context.Context, _ uuid.UUID) (bool, error) { return false, nil }
+func (s *stubStore) GetJobByID(_ context.Context, _ uuid.UUID) (*models.Job, error) { return nil, nil }
+func (s *stubStore) MigrationVersion(_ context.Context) (*store.MigrationStatus, error) { return &store.MigrationStatus{}, nil }
+func (s *stubStore) DeleteOrphanedAnalysisResults(_ context.Context) (int, error) {
+	return 0, nil
+}
+func (s *stubStore) GetAnalysisResultByID(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*models.AnalysisResult, error) {
+	return nil, store.ErrNotFound
+}
+func (s *stubStore) ListJobs(_ context.Context, _ uuid.UUID, _ store.JobFilter) ([]*models.Job, int, error) { return nil, 0, nil }
+func (s *stubStore) CreateClusterSuppression(_ context.Context, _ *models.ClusterSuppression) error { return nil }
+func (s *stubStore) ListClusterSuppressions(_ context.Context, _ uuid.UUID) ([]*models.ClusterSuppression, error) { return nil, nil }
+func (s *stubStore) DeleteClusterSuppression(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *stubStore) DeleteErrorCluster(_ context.Context, _ uuid.UUID, _ uuid.UUID) error { return nil }
+func (s *stubStore) GetTenant(_ context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id}, nil
+}
 
 // --- stub cache ---
 
@@ -105,6 +151,22 @@ func TestRouter_HealthEndpoint_Public(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestRouter_MetricsEndpoint_Public(t *testing.T) {
+	router := api.NewRouter(api.Dependencies{
+		Auth:      mw.NewAuth(&stubStore{}),
+		RateLimit: mw.NewRateLimit(&stubCache{}, 60),
+		MetricsHandler: func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("loghunter_cache_hits_total 0\n"))
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestRouter_ProtectedEndpoints_RequireAuth(t *testing.T) {
 	router := newTestRouter()
 
@@ -113,11 +175,30 @@ func TestRouter_ProtectedEndpoints_RequireAuth(t *testing.T) {
 		path   string
 	}{
 		{"POST", "/api/v1/analyze"},
+		{"POST", "/api/v1/analyze/estimate"},
+		{"POST", "/api/v1/analyze/00000000-0000-0000-0000-000000000001/replay"},
+		{"DELETE", "/api/v1/analyze/00000000-0000-0000-0000-000000000001"},
+		{"GET", "/api/v1/analyses/00000000-0000-0000-0000-000000000001"},
 		{"GET", "/api/v1/clusters"},
+		{"PATCH", "/api/v1/clusters/00000000-0000-0000-0000-000000000001"},
+		{"POST", "/api/v1/clusters/00000000-0000-0000-0000-000000000001/summarize"},
 		{"POST", "/api/v1/summarize"},
+		{"POST", "/api/v1/summarize/batch"},
 		{"POST", "/api/v1/search"},
+		{"POST", "/api/v1/search/validate"},
+		{"POST", "/api/v1/detect"},
+		{"POST", "/api/v1/detect/preview"},
+		{"GET", "/api/v1/jobs/stats"},
 		{"POST", "/api/v1/admin/keys"},
 		{"GET", "/api/v1/admin/keys"},
+		{"POST", "/api/v1/admin/keys/revoke-all"},
+		{"GET", "/api/v1/admin/migrations"},
+		{"POST", "/api/v1/admin/maintenance/prune-orphaned-results"},
+		{"DELETE", "/api/v1/clusters/00000000-0000-0000-0000-000000000001"},
+		{"GET", "/api/v1/admin/jobs"},
+		{"POST", "/api/v1/admin/suppressions"},
+		{"GET", "/api/v1/admin/suppressions"},
+		{"DELETE", "/api/v1/admin/suppressions/00000000-0000-0000-0000-000000000001"},
 	}
 
 	for _, ep := range endpoints {
@@ -136,6 +217,100 @@ func TestRouter_ProtectedEndpoints_RequireAuth(t *testing.T) {
 	}
 }
 
+// keyStore authenticates one raw key with the given scopes.
+type keyStore struct {
+	stubStore
+	key *models.APIKey
+}
+
+func (s *keyStore) GetAPIKeyByPrefix(_ context.Context, _ string) ([]*models.APIKey, error) {
+	return []*models.APIKey{s.key}, nil
+}
+
+const testRawKey = "lh_testkey_0123456789"
+
+func newScopedRouter(t *testing.T, scopes []string, routeScopes map[string]string) http.Handler {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testRawKey), bcrypt.MinCost)
+	require.NoError(t, err)
+	st := &keyStore{key: &models.APIKey{
+		ID:       uuid.New(),
+		TenantID: uuid.New(),
+		KeyHash:  string(hash),
+		Scopes:   scopes,
+	}}
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	return api.NewRouter(api.Dependencies{
+		Auth:           mw.NewAuth(st),
+		RateLimit:      mw.NewRateLimit(&stubCache{}, 60),
+		RouteScopes:    routeScopes,
+		AnalyzeHandler: ok,
+		ListClusters:   ok,
+		DeleteCluster:  ok,
+	})
+}
+
+func serveWithKey(router http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+testRawKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRouter_ReadOnlyKey(t *testing.T) {
+	router := newScopedRouter(t, []string{"read"}, nil)
+
+	w := serveWithKey(router, "POST", "/api/v1/analyze")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "FORBIDDEN", body["error"].(map[string]any)["code"])
+
+	w = serveWithKey(router, "GET", "/api/v1/clusters")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRouter_RouteScopeOverrides(t *testing.T) {
+	router := newScopedRouter(t, []string{"read"}, map[string]string{
+		"POST /api/v1/analyze": "",
+		"GET /api/v1/clusters": "write",
+	})
+
+	assert.Equal(t, http.StatusOK, serveWithKey(router, "POST", "/api/v1/analyze").Code)
+	assert.Equal(t, http.StatusForbidden, serveWithKey(router, "GET", "/api/v1/clusters").Code)
+}
+
+func TestRouter_DeleteClusterRequiresAdmin(t *testing.T) {
+	path := "/api/v1/clusters/00000000-0000-0000-0000-000000000001"
+
+	writer := newScopedRouter(t, []string{"read", "write"}, nil)
+	assert.Equal(t, http.StatusForbidden, serveWithKey(writer, "DELETE", path).Code)
+	assert.Equal(t, http.StatusOK, serveWithKey(writer, "GET", "/api/v1/clusters").Code)
+
+	admin := newScopedRouter(t, []string{"admin"}, nil)
+	assert.Equal(t, http.StatusOK, serveWithKey(admin, "DELETE", path).Code)
+}
+
+func TestRouter_CORSPreflightSkipsAuth(t *testing.T) {
+	cors, err := mw.NewCORS([]string{"https://*.example.com"})
+	require.NoError(t, err)
+	router := api.NewRouter(api.Dependencies{
+		Auth:      mw.NewAuth(&stubStore{}),
+		RateLimit: mw.NewRateLimit(&stubCache{}, 60),
+		CORS:      cors,
+	})
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/clusters", nil)
+	req.Header.Set("Origin", "https://pr-3.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://pr-3.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
 func TestRouter_NotFound(t *testing.T) {
 	router := newTestRouter()
 
@@ -146,6 +321,25 @@ func TestRouter_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestRouter_ErrorIncludesRequestID(t *testing.T) {
+	router := newTestRouter()
+
+	req := httptest.NewRequest("GET", "/api/v1/clusters", nil)
+	req.Header.Set(mw.RequestIDHeader, "incident-7")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "incident-7", w.Header().Get(mw.RequestIDHeader))
+	var body struct {
+		Error struct {
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "incident-7", body.Error.RequestID)
+}
+
 // Verify unused interfaces are satisfied
 var _ store.Store = (*stubStore)(nil)
 var _ cache.Cache = (*stubCache)(nil)
@@ -23,6 +23,7 @@ type Cache interface {
 // RedisCache implements the Cache interface using go-redis/v9.
 type RedisCache struct {
 	client *redis.Client
+	stats  hitCounter
 }
 
 // NewRedisCache creates a new RedisCache from a Redis URL.
@@ -45,11 +46,13 @@ func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time
 func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
 	val, err := c.client.Get(ctx, key).Bytes()
 	if err == redis.Nil {
+		c.stats.record(false)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
+	c.stats.record(true)
 	return val, true, nil
 }
 
@@ -64,11 +67,13 @@ func (c *RedisCache) SetJobStatus(ctx context.Context, jobID uuid.UUID, status s
 func (c *RedisCache) GetJobStatus(ctx context.Context, jobID uuid.UUID) (string, bool, error) {
 	val, err := c.client.Get(ctx, JobStatusKey(jobID)).Result()
 	if err == redis.Nil {
+		c.stats.record(false)
 		return "", false, nil
 	}
 	if err != nil {
 		return "", false, err
 	}
+	c.stats.record(true)
 	return val, true, nil
 }
 
@@ -82,6 +87,11 @@ func (c *RedisCache) IncrWithExpiry(ctx context.Context, key string, expiry time
 	return incr.Val(), nil
 }
 
+// Stats returns the hit and miss counts of Get and GetJobStatus since startup.
+func (c *RedisCache) Stats() CacheStats {
+	return c.stats.snapshot()
+}
+
 // Close closes the underlying Redis client connection.
 func (c *RedisCache) Close() error {
 	return c.client.Close()
@@ -208,6 +208,56 @@ func TestIncrWithExpiry_Expires(t *testing.T) {
 	assert.Equal(t, int64(1), val)
 }
 
+// --- TryLock ---
+
+func TestTryLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	rc := setupRedis(t)
+	ctx := context.Background()
+	key := "lock:test:" + uuid.NewString()[:8]
+
+	acquired, unlock, err := rc.TryLock(ctx, key, 10*time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, other, err := rc.TryLock(ctx, key, 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Nil(t, other)
+
+	unlock()
+	unlock() // a second call is a no-op
+	acquired, _, err = rc.TryLock(ctx, key, 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestTryLock_UnlockKeepsNewHolder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	rc := setupRedis(t)
+	ctx := context.Background()
+	key := "lock:expiry:" + uuid.NewString()[:8]
+
+	acquired, staleUnlock, err := rc.TryLock(ctx, key, time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	time.Sleep(1500 * time.Millisecond)
+	acquired, _, err = rc.TryLock(ctx, key, 10*time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired, "an expired lock can be taken again")
+
+	// The first holder's unlock must not release the new holder's lock.
+	staleUnlock()
+	acquired, _, err = rc.TryLock(ctx, key, 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+}
+
 // --- Cache Key Builders ---
 
 func TestLokiQueryKey(t *testing.T) {
@@ -245,3 +295,35 @@ func TestKeyBuilders_NonColliding(t *testing.T) {
 	}
 	assert.Len(t, keys, 4, "all keys should be unique")
 }
+
+// --- Stats ---
+
+func TestStats_CountsHitsAndMisses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	rc := setupRedis(t)
+	ctx := context.Background()
+	assert.Equal(t, cache.CacheStats{}, rc.Stats())
+
+	_, found, err := rc.Get(ctx, "stats:missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, cache.CacheStats{Misses: 1}, rc.Stats())
+
+	require.NoError(t, rc.Set(ctx, "stats:present", []byte("v"), time.Minute))
+	_, found, err = rc.Get(ctx, "stats:present")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, cache.CacheStats{Hits: 1, Misses: 1}, rc.Stats())
+
+	jobID := uuid.New()
+	_, found, err = rc.GetJobStatus(ctx, jobID)
+	require.NoError(t, err)
+	assert.False(t, found)
+	require.NoError(t, rc.SetJobStatus(ctx, jobID, "running", time.Minute))
+	_, found, err = rc.GetJobStatus(ctx, jobID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, cache.CacheStats{Hits: 2, Misses: 2}, rc.Stats())
+}
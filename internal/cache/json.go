@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SetJSON stores v under key as JSON.
+func SetJSON(ctx context.Context, c Cache, key string, v any, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding cache value %q: %w", key, err)
+	}
+	return c.Set(ctx, key, data, ttl)
+}
+
+// GetJSON decodes the JSON stored under key into dest. It returns (false, nil)
+// on a miss, leaving dest untouched. A value that does not decode into dest is
+// reported as an error, so callers that treat errors as a miss recompute it.
+func GetJSON(ctx context.Context, c Cache, key string, dest any) (bool, error) {
+	data, found, err := c.Get(ctx, key)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("decoding cache value %q: %w", key, err)
+	}
+	return true, nil
+}
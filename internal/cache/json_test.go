@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	c := newFlakyCache()
+
+	require.NoError(t, SetJSON(ctx, c, "k", jsonValue{Name: "a", Count: 2}, time.Minute))
+	assert.JSONEq(t, `{"name":"a","count":2}`, string(c.values["k"]))
+
+	var got jsonValue
+	found, err := GetJSON(ctx, c, "k", &got)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, jsonValue{Name: "a", Count: 2}, got)
+}
+
+func TestGetJSON_Miss(t *testing.T) {
+	got := jsonValue{Name: "unchanged"}
+	found, err := GetJSON(context.Background(), newFlakyCache(), "missing", &got)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, "unchanged", got.Name)
+}
+
+func TestGetJSON_Errors(t *testing.T) {
+	ctx := context.Background()
+	c := newFlakyCache()
+	c.values["bad"] = []byte("not json")
+
+	var got jsonValue
+	found, err := GetJSON(ctx, c, "bad", &got)
+	assert.Error(t, err)
+	assert.False(t, found)
+
+	c.setDown(true)
+	found, err = GetJSON(ctx, c, "bad", &got)
+	assert.ErrorIs(t, err, errRemoteDown)
+	assert.False(t, found)
+	assert.ErrorIs(t, SetJSON(ctx, c, "k", jsonValue{}, time.Minute), errRemoteDown)
+}
+
+func TestSetJSON_UnencodableValue(t *testing.T) {
+	c := newFlakyCache()
+	err := SetJSON(context.Background(), c, "k", make(chan int), time.Minute)
+	assert.Error(t, err)
+	assert.Empty(t, c.values)
+}
@@ -18,6 +18,26 @@ func RateLimitKey(keyPrefix string) string {
 	return fmt.Sprintf("ratelimit:%s", keyPrefix)
 }
 
+func SummarizeResultKey(tenantID uuid.UUID, paramsHash string) string {
+	return fmt.Sprintf("ai:summarize:%s:%s", tenantID, paramsHash)
+}
+
 func SearchResultKey(tenantID uuid.UUID, filterHash string) string {
 	return fmt.Sprintf("loki:search:%s:%s", tenantID, filterHash)
 }
+
+func ClusterListKey(tenantID uuid.UUID, version int64, filterHash string) string {
+	return fmt.Sprintf("clusters:list:%s:%d:%s", tenantID, version, filterHash)
+}
+
+func ClusterListVersionKey(tenantID uuid.UUID) string {
+	return fmt.Sprintf("clusters:version:%s", tenantID)
+}
+
+func AnalysisLockKey(tenantID, clusterID uuid.UUID) string {
+	return fmt.Sprintf("analysis:lock:%s:%s", tenantID, clusterID)
+}
+
+func AnalysisInFlightKey(tenantID, clusterID uuid.UUID) string {
+	return fmt.Sprintf("analysis:inflight:%s:%s", tenantID, clusterID)
+}
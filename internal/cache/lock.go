@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// lockReleaseTimeout bounds the call made by a lock's unlock func, which runs
+// after the caller's context may have ended.
+const lockReleaseTimeout = 5 * time.Second
+
+// Locker is implemented by caches that can hold short-lived locks shared by
+// every replica using the cache.
+type Locker interface {
+	// TryLock takes the lock on key for ttl without waiting, reporting false
+	// if it is already held. unlock releases the lock unless it expired and
+	// was taken by someone else; it is nil when the lock was not acquired and
+	// safe to call more than once otherwise.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (acquired bool, unlock func(), err error)
+}
+
+// releaseLockScript deletes a lock only if it still holds this holder's token.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// TryLock takes the lock with SET NX PX under a random token, so unlock does
+// not release a lock that expired and was taken by another holder.
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, func(), error) {
+	token := uuid.NewString()
+	acquired, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !acquired {
+		return false, nil, err
+	}
+
+	var once sync.Once
+	unlock := func() {
+		once.Do(func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockReleaseTimeout)
+			defer cancel()
+			_ = releaseLockScript.Run(ctx, c.client, []string{key}, token).Err()
+		})
+	}
+	return true, unlock, nil
+}
+
+// TryLock goes to the remote only: a lock held in memory would not be seen by
+// other replicas. It returns ErrDisabled if the remote cannot lock.
+func (c *TieredCache) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, func(), error) {
+	locker, ok := c.remote.(Locker)
+	if !ok {
+		return false, nil, ErrDisabled
+	}
+	return locker.TryLock(ctx, key, ttl)
+}
+
+// Compile-time checks that the shared caches implement Locker.
+var (
+	_ Locker = (*RedisCache)(nil)
+	_ Locker = (*TieredCache)(nil)
+)
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDisabled is returned by NopCache for operations that cannot pretend to
+// succeed, such as counters and pings.
+var ErrDisabled = errors.New("cache disabled")
+
+// NopCache is a Cache that stores nothing. It stands in for Redis when the
+// server runs degraded: every lookup misses, writes are dropped, and
+// IncrWithExpiry fails so rate limiting fails open.
+type NopCache struct{}
+
+func (NopCache) Set(_ context.Context, _ string, _ []byte, _ time.Duration) error { return nil }
+
+func (NopCache) Get(_ context.Context, _ string) ([]byte, bool, error) { return nil, false, nil }
+
+func (NopCache) Delete(_ context.Context, _ string) error { return nil }
+
+func (NopCache) Ping(_ context.Context) error { return ErrDisabled }
+
+func (NopCache) SetJobStatus(_ context.Context, _ uuid.UUID, _ string, _ time.Duration) error {
+	return nil
+}
+
+func (NopCache) GetJobStatus(_ context.Context, _ uuid.UUID) (string, bool, error) {
+	return "", false, nil
+}
+
+func (NopCache) IncrWithExpiry(_ context.Context, _ string, _ time.Duration) (int64, error) {
+	return 0, ErrDisabled
+}
+
+// Stats always reports zero; NopCache has nothing to hit.
+func (NopCache) Stats() CacheStats { return CacheStats{} }
+
+// Close is a no-op.
+func (NopCache) Close() error { return nil }
+
+// Degraded reports that this cache is a stand-in for an unavailable one.
+func (NopCache) Degraded() bool { return true }
+
+// Compile-time check that NopCache implements Cache.
+var _ Cache = NopCache{}
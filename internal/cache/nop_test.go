@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNopCache(t *testing.T) {
+	ctx := context.Background()
+	var c NopCache
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	_, found, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, found, "NopCache must not return what was set")
+
+	require.NoError(t, c.SetJobStatus(ctx, uuid.New(), "running", time.Minute))
+	_, found, err = c.GetJobStatus(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = c.IncrWithExpiry(ctx, "k", time.Minute)
+	assert.ErrorIs(t, err, ErrDisabled)
+	assert.ErrorIs(t, c.Ping(ctx), ErrDisabled)
+	assert.True(t, c.Degraded())
+}
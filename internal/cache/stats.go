@@ -0,0 +1,36 @@
+package cache
+
+import "sync/atomic"
+
+// CacheStats is a snapshot of a cache's lookup counters since startup.
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// HitRatio returns hits / (hits + misses), or 0 before any lookup.
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// hitCounter counts cache lookups. Failed lookups are neither hits nor misses.
+type hitCounter struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func (c *hitCounter) record(found bool) {
+	if found {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (c *hitCounter) snapshot() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
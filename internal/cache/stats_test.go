@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHitCounter_Record(t *testing.T) {
+	var c hitCounter
+	assert.Equal(t, CacheStats{}, c.snapshot())
+
+	c.record(true)
+	c.record(true)
+	c.record(false)
+
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, c.snapshot())
+}
+
+func TestHitCounter_Concurrent(t *testing.T) {
+	var c hitCounter
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(found bool) {
+			defer wg.Done()
+			c.record(found)
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	assert.Equal(t, CacheStats{Hits: 25, Misses: 25}, c.snapshot())
+}
+
+func TestCacheStats_HitRatio(t *testing.T) {
+	assert.Equal(t, 0.0, CacheStats{}.HitRatio())
+	assert.Equal(t, 0.75, CacheStats{Hits: 3, Misses: 1}.HitRatio())
+}
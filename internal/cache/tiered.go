@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTieredCacheSize is the number of entries TieredCache keeps in memory
+// when given no size.
+const DefaultTieredCacheSize = 10000
+
+// TieredCache wraps a remote Cache (normally a *RedisCache) with a bounded
+// in-memory LRU, so cached values and job statuses survive brief remote
+// outages. Every Set and SetJobStatus is also written to memory with the same
+// TTL; the memory copy is only read when the remote lookup fails. Counters and
+// pings always go to the remote, so rate limiting still fails open when it is
+// down.
+//
+// Writes made by other replicas are not seen in memory, so the fallback
+// serves this process's own recent writes.
+type TieredCache struct {
+	remote Cache
+	local  *lru
+	stats  hitCounter
+}
+
+// NewTieredCache wraps remote with an in-memory layer of up to size entries;
+// size <= 0 uses DefaultTieredCacheSize.
+func NewTieredCache(remote Cache, size int) *TieredCache {
+	if size <= 0 {
+		size = DefaultTieredCacheSize
+	}
+	return &TieredCache{remote: remote, local: newLRU(size)}
+}
+
+func (c *TieredCache) Ping(ctx context.Context) error {
+	return c.remote.Ping(ctx)
+}
+
+// Set writes to both layers. A remote failure is not reported, as the value
+// is still served from memory until the remote recovers.
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.local.set(key, value, ttl)
+	_ = c.remote.Set(ctx, key, value, ttl)
+	return nil
+}
+
+// Get reads from the remote, falling back to memory if the remote fails. The
+// remote error is returned only when memory has nothing either.
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.get(ctx, key, c.remote.Get)
+}
+
+// Delete removes key from both layers and reports the remote result.
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	c.local.delete(key)
+	return c.remote.Delete(ctx, key)
+}
+
+func (c *TieredCache) SetJobStatus(ctx context.Context, jobID uuid.UUID, status string, ttl time.Duration) error {
+	c.local.set(JobStatusKey(jobID), []byte(status), ttl)
+	_ = c.remote.SetJobStatus(ctx, jobID, status, ttl)
+	return nil
+}
+
+func (c *TieredCache) GetJobStatus(ctx context.Context, jobID uuid.UUID) (string, bool, error) {
+	val, found, err := c.get(ctx, JobStatusKey(jobID), func(ctx context.Context, _ string) ([]byte, bool, error) {
+		status, found, err := c.remote.GetJobStatus(ctx, jobID)
+		return []byte(status), found, err
+	})
+	return string(val), found, err
+}
+
+// IncrWithExpiry goes to the remote only: a per-process count would let each
+// replica grant the full rate limit.
+func (c *TieredCache) IncrWithExpiry(ctx context.Context, key string, expiry time.Duration) (int64, error) {
+	return c.remote.IncrWithExpiry(ctx, key, expiry)
+}
+
+// get looks key up with remoteGet, falling back to memory on error. A remote
+// miss also drops the memory copy, so an entry that expired or was deleted
+// remotely is not served during a later outage.
+func (c *TieredCache) get(ctx context.Context, key string, remoteGet func(context.Context, string) ([]byte, bool, error)) ([]byte, bool, error) {
+	val, found, err := remoteGet(ctx, key)
+	if err == nil {
+		if !found {
+			c.local.delete(key)
+		}
+		return val, found, nil
+	}
+	if val, ok := c.local.get(key); ok {
+		c.stats.record(true)
+		return val, true, nil
+	}
+	return nil, false, err
+}
+
+// Stats returns the remote's lookup counters, if it keeps them, plus lookups
+// answered from memory.
+func (c *TieredCache) Stats() CacheStats {
+	stats := c.stats.snapshot()
+	if r, ok := c.remote.(interface{ Stats() CacheStats }); ok {
+		rs := r.Stats()
+		stats.Hits += rs.Hits
+		stats.Misses += rs.Misses
+	}
+	return stats
+}
+
+// Close closes the remote, if it can be closed.
+func (c *TieredCache) Close() error {
+	if closer, ok := c.remote.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Compile-time check that TieredCache implements Cache.
+var _ Cache = (*TieredCache)(nil)
@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errRemoteDown = errors.New("remote down")
+
+// flakyCache is an in-memory remote whose every operation fails while down.
+type flakyCache struct {
+	mu     sync.Mutex
+	down   bool
+	values map[string][]byte
+	incrs  int
+}
+
+func newFlakyCache() *flakyCache {
+	return &flakyCache{values: make(map[string][]byte)}
+}
+
+func (f *flakyCache) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *flakyCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errRemoteDown
+	}
+	f.values[key] = value
+	return nil
+}
+
+func (f *flakyCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return nil, false, errRemoteDown
+	}
+	v, ok := f.values[key]
+	return v, ok, nil
+}
+
+func (f *flakyCache) Delete(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errRemoteDown
+	}
+	delete(f.values, key)
+	return nil
+}
+
+func (f *flakyCache) Ping(_ context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errRemoteDown
+	}
+	return nil
+}
+
+func (f *flakyCache) SetJobStatus(ctx context.Context, jobID uuid.UUID, status string, ttl time.Duration) error {
+	return f.Set(ctx, JobStatusKey(jobID), []byte(status), ttl)
+}
+
+func (f *flakyCache) GetJobStatus(ctx context.Context, jobID uuid.UUID) (string, bool, error) {
+	v, ok, err := f.Get(ctx, JobStatusKey(jobID))
+	return string(v), ok, err
+}
+
+func (f *flakyCache) IncrWithExpiry(_ context.Context, _ string, _ time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return 0, errRemoteDown
+	}
+	f.incrs++
+	return int64(f.incrs), nil
+}
+
+func TestTieredCache_ServesFromMemoryWhileRemoteDown(t *testing.T) {
+	ctx := context.Background()
+	remote := newFlakyCache()
+	c := NewTieredCache(remote, 10)
+
+	jobID := uuid.New()
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	require.NoError(t, c.SetJobStatus(ctx, jobID, "running", time.Minute))
+
+	remote.setDown(true)
+
+	v, found, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("v"), v)
+
+	status, found, err := c.GetJobStatus(ctx, jobID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "running", status)
+
+	// Writes during the outage are absorbed by memory.
+	require.NoError(t, c.SetJobStatus(ctx, jobID, "completed", time.Minute))
+	status, _, _ = c.GetJobStatus(ctx, jobID)
+	assert.Equal(t, "completed", status)
+
+	// Nothing in memory either: the remote error comes through.
+	_, found, err = c.Get(ctx, "missing")
+	assert.ErrorIs(t, err, errRemoteDown)
+	assert.False(t, found)
+
+	assert.Equal(t, CacheStats{Hits: 3}, c.Stats())
+}
+
+func TestTieredCache_RemoteOnlyOperations(t *testing.T) {
+	ctx := context.Background()
+	remote := newFlakyCache()
+	c := NewTieredCache(remote, 10)
+
+	n, err := c.IncrWithExpiry(ctx, "rl", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	require.NoError(t, c.Ping(ctx))
+
+	remote.setDown(true)
+	_, err = c.IncrWithExpiry(ctx, "rl", time.Minute)
+	assert.ErrorIs(t, err, errRemoteDown, "rate limit counters must not fall back to memory")
+	assert.ErrorIs(t, c.Ping(ctx), errRemoteDown)
+}
+
+func TestTieredCache_RemoteMissDropsMemoryCopy(t *testing.T) {
+	ctx := context.Background()
+	remote := newFlakyCache()
+	c := NewTieredCache(remote, 10)
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	// Another replica deletes the key remotely.
+	delete(remote.values, "k")
+	_, found, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	remote.setDown(true)
+	_, found, _ = c.Get(ctx, "k")
+	assert.False(t, found, "a key the remote reported missing must not be served later")
+}
+
+func TestTieredCache_DeleteClearsMemory(t *testing.T) {
+	ctx := context.Background()
+	remote := newFlakyCache()
+	c := NewTieredCache(remote, 10)
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	require.NoError(t, c.Delete(ctx, "k"))
+
+	remote.setDown(true)
+	_, found, _ := c.Get(ctx, "k")
+	assert.False(t, found)
+	assert.ErrorIs(t, c.Delete(ctx, "k"), errRemoteDown)
+}
+
+func TestTieredCache_TryLockNeedsRemoteLocker(t *testing.T) {
+	c := NewTieredCache(newFlakyCache(), 10)
+	acquired, unlock, err := c.TryLock(context.Background(), "lock", time.Minute)
+	assert.ErrorIs(t, err, ErrDisabled)
+	assert.False(t, acquired)
+	assert.Nil(t, unlock)
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLRU(2)
+	l.set("a", []byte("1"), 0)
+	l.set("b", []byte("2"), 0)
+	_, _ = l.get("a") // b is now least recently used
+	l.set("c", []byte("3"), 0)
+
+	assert.Equal(t, 2, l.len())
+	_, ok := l.get("b")
+	assert.False(t, ok)
+	_, ok = l.get("a")
+	assert.True(t, ok)
+	_, ok = l.get("c")
+	assert.True(t, ok)
+}
+
+func TestLRU_ExpiresEntries(t *testing.T) {
+	now := time.Now()
+	l := newLRU(10)
+	l.now = func() time.Time { return now }
+
+	l.set("short", []byte("v"), time.Second)
+	l.set("forever", []byte("v"), 0)
+
+	now = now.Add(time.Second)
+	_, ok := l.get("short")
+	assert.False(t, ok)
+	_, ok = l.get("forever")
+	assert.True(t, ok)
+	assert.Equal(t, 1, l.len(), "expired entries are removed on lookup")
+}
+
+func TestLRU_ConcurrentUse(t *testing.T) {
+	l := newLRU(50)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("k%d", i%100)
+				l.set(key, []byte("v"), time.Minute)
+				l.get(key)
+				if i%7 == 0 {
+					l.delete(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, l.len(), 50)
+}
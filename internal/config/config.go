@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -15,11 +16,31 @@ type Config struct {
 	Redis    RedisConfig
 	Loki     LokiConfig
 	AI       AIConfig
+	Analysis AnalysisConfig
 }
 
 type ServerConfig struct {
-	Port int
-	Env  string
+	Port             int
+	Env              string
+	DefaultPageLimit int
+	MaxPageLimit     int
+	// CORSAllowedOrigins lists exact, wildcard (https://*.example.com) or
+	// regex (^...) origin patterns. Empty disables CORS.
+	CORSAllowedOrigins []string
+	// TrustedProxies lists the proxy IPs or CIDR ranges whose X-Forwarded-For
+	// and X-Real-IP headers are honored. Empty trusts none.
+	TrustedProxies []string
+	// CrossTenantResponse is the status (404 or 403) returned when a request
+	// names a resource owned by another tenant.
+	CrossTenantResponse int
+	// StrictRequestBodies rejects request bodies with unknown fields.
+	StrictRequestBodies bool
+	// ClusterListCacheTTL is how long a cluster listing is cached per tenant
+	// and filter. 0 disables the cache.
+	ClusterListCacheTTL time.Duration
+	// RouteScopes overrides the API key scope a route requires, keyed by
+	// "METHOD /pattern". An empty scope lifts the requirement.
+	RouteScopes map[string]string
 }
 
 type DatabaseConfig struct {
@@ -27,10 +48,19 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// ConnectAttempts and ConnectBackoff bound the startup wait for the database.
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
 }
 
 type RedisConfig struct {
 	URL string
+	// Required makes an unreachable Redis fatal at startup. When false the
+	// server starts degraded instead, without caching or rate limiting.
+	Required bool
+	// FallbackSize is how many cached values and job statuses are also kept
+	// in memory, to be served while Redis errors. 0 disables the fallback.
+	FallbackSize int
 }
 
 type LokiConfig struct {
@@ -39,15 +69,90 @@ type LokiConfig struct {
 	Password string
 	OrgID    string
 	Timeout  time.Duration
+	// QueryTimeout bounds each range query on its own; 0 leaves only Timeout.
+	QueryTimeout time.Duration
+	// PathPrefix is prepended to every Loki API path, for gateway deployments.
+	PathPrefix string
+	// Transport timeouts; Timeout above still bounds each request overall.
+	// ResponseHeaderTimeout includes query evaluation, so it must not be set
+	// below Timeout or QueryTimeout; 0 disables it.
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	KeepAlive             time.Duration
+	IdleConnTimeout       time.Duration
+	// MaxLines caps the lines decoded from a single query response.
+	MaxLines int
+	// AllowedLabels lists the label names queries may reference.
+	AllowedLabels []string
+	// MaxRetries is how many times a Loki request is retried when Loki is
+	// unreachable or returns a 5xx, starting RetryBackoff apart and doubling.
+	// 0 disables retries.
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// QueryCacheTTL is how long the lines fetched for a summary are cached, so
+	// repeated summaries of the same window skip Loki. 0 disables the cache.
+	QueryCacheTTL time.Duration
+}
+
+type AnalysisConfig struct {
+	// AutoResolveAfter resolves open clusters not seen for this long; 0 disables the sweep.
+	AutoResolveAfter    time.Duration
+	AutoResolveInterval time.Duration
+	// ContextDirection is the Loki direction used to fetch analysis context logs.
+	ContextDirection string
+	// AutoAnalyze starts analysis for newly-seen clusters at or above AutoAnalyzeMinLevel.
+	// The level is checked against the level severities at startup.
+	AutoAnalyze         bool
+	AutoAnalyzeMinLevel string
+	// LevelFromMessage makes clustering take a line's level from its message
+	// (e.g. "FATAL: ...") when Loki reports none.
+	LevelFromMessage bool
+	// LevelSeverities overrides or extends the built-in level ranking
+	// (fatal=4 ... warn=1, info=0), keyed by lower-case level name.
+	LevelSeverities map[string]int
 }
 
 type AIConfig struct {
 	Provider         string
 	InferenceTimeout time.Duration
-	Ollama           OllamaConfig
-	VLLM             VLLMConfig
-	OpenAI           OpenAIConfig
-	Anthropic        AnthropicConfig
+	AnalyzeTimeout   time.Duration
+	SummarizeTimeout time.Duration
+	// ContextLimit caps the context lines sent to the provider for an analysis;
+	// ContextStrategy (recent, spread or errors_first) picks which are kept.
+	// At most 1000 lines are fetched, so a limit of 1000 or more never samples.
+	ContextLimit    int
+	ContextStrategy string
+	// SummarizeRetries is how many times a synchronous summarize retries a
+	// transient Loki or provider failure. 0 disables retries.
+	SummarizeRetries int
+	// SummarizeMaxInflight caps concurrent synchronous summaries. 0 sets no cap.
+	SummarizeMaxInflight int
+	// AnalyzeMaxConcurrency caps the analyses running at once; further jobs
+	// wait up to AnalyzeQueueTimeout for a slot. 0 sets no cap.
+	AnalyzeMaxConcurrency int
+	// AnalyzeQueueTimeout must be positive; there is no way to wait forever.
+	AnalyzeQueueTimeout time.Duration
+	// SummarizeMaxWindow bounds the time range a summarize request may cover.
+	// 0 sets no bound.
+	SummarizeMaxWindow time.Duration
+	// HTTPMaxIdleConns sizes the idle connection pool to the AI backend.
+	HTTPMaxIdleConns int
+	// PromptCostPer1KTokens prices analysis estimates; 0 leaves them unpriced.
+	PromptCostPer1KTokens float64
+	// DeepHealth makes the health check run a tiny inference against the
+	// provider instead of only checking it is configured.
+	DeepHealth bool
+	// DeepHealthInterval is how long a deep health check's inference result
+	// is reused; 0 runs an inference on every health check.
+	DeepHealthInterval time.Duration
+	// AllowedModels lists the models a request may select instead of the
+	// provider's configured one. Empty disables per-request overrides.
+	AllowedModels []string
+	Ollama        OllamaConfig
+	VLLM          VLLMConfig
+	OpenAI        OpenAIConfig
+	Anthropic     AnthropicConfig
 }
 
 type OllamaConfig struct {
@@ -75,35 +180,86 @@ var validProviders = map[string]bool{
 	"vllm":      true,
 	"openai":    true,
 	"anthropic": true,
+	"mock":      true,
+}
+
+var validContextStrategies = map[string]bool{
+	"recent":       true,
+	"spread":       true,
+	"errors_first": true,
 }
 
+// Bounds for AI_INFERENCE_TIMEOUT_SECS.
+const (
+	minInferenceTimeout = time.Second
+	maxInferenceTimeout = 600 * time.Second
+)
+
+// maxSummarizeRetries bounds SUMMARIZE_RETRIES.
+const maxSummarizeRetries = 5
+
 // Load reads configuration from environment variables and returns a validated Config.
 // Returns an error with a descriptive message if any required value is missing or invalid.
 func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: envInt("LOGHUNTER_PORT", 8080),
-			Env:  envString("LOGHUNTER_ENV", "development"),
+			Port:                envInt("LOGHUNTER_PORT", 8080),
+			Env:                 envString("LOGHUNTER_ENV", "development"),
+			DefaultPageLimit:    envInt("LOGHUNTER_DEFAULT_PAGE_LIMIT", 20),
+			MaxPageLimit:        envInt("LOGHUNTER_MAX_PAGE_LIMIT", 100),
+			CORSAllowedOrigins:  envList("CORS_ALLOWED_ORIGINS", nil),
+			TrustedProxies:      envList("TRUSTED_PROXIES", nil),
+			CrossTenantResponse: envInt("CROSS_TENANT_RESPONSE", 404),
+			StrictRequestBodies: envBool("STRICT_REQUEST_BODIES", false),
+			ClusterListCacheTTL: envDuration("CLUSTER_LIST_CACHE_TTL", 10*time.Second),
 		},
 		Database: DatabaseConfig{
 			URL:             os.Getenv("DATABASE_URL"),
 			MaxOpenConns:    envInt("DATABASE_MAX_OPEN_CONNS", 25),
 			MaxIdleConns:    envInt("DATABASE_MAX_IDLE_CONNS", 5),
 			ConnMaxLifetime: envDuration("DATABASE_CONN_MAX_LIFETIME", 5*time.Minute),
+			ConnectAttempts: envInt("DATABASE_CONNECT_ATTEMPTS", 5),
+			ConnectBackoff:  envDuration("DATABASE_CONNECT_BACKOFF", time.Second),
 		},
 		Redis: RedisConfig{
-			URL: os.Getenv("REDIS_URL"),
+			URL:          os.Getenv("REDIS_URL"),
+			Required:     envBool("REDIS_REQUIRED", true),
+			FallbackSize: envInt("REDIS_FALLBACK_SIZE", 10000),
 		},
 		Loki: LokiConfig{
-			BaseURL:  os.Getenv("LOKI_BASE_URL"),
-			Username: os.Getenv("LOKI_USERNAME"),
-			Password: os.Getenv("LOKI_PASSWORD"),
-			OrgID:    envString("LOKI_ORG_ID", "default"),
-			Timeout:  envDuration("LOKI_TIMEOUT", 30*time.Second),
+			BaseURL:               os.Getenv("LOKI_BASE_URL"),
+			Username:              os.Getenv("LOKI_USERNAME"),
+			Password:              os.Getenv("LOKI_PASSWORD"),
+			OrgID:                 envString("LOKI_ORG_ID", "default"),
+			Timeout:               envDuration("LOKI_TIMEOUT", 30*time.Second),
+			QueryTimeout:          envDuration("LOKI_QUERY_TIMEOUT", 0),
+			PathPrefix:            os.Getenv("LOKI_PATH_PREFIX"),
+			DialTimeout:           envDuration("LOKI_DIAL_TIMEOUT", 5*time.Second),
+			TLSHandshakeTimeout:   envDuration("LOKI_TLS_HANDSHAKE_TIMEOUT", 5*time.Second),
+			ResponseHeaderTimeout: envDuration("LOKI_RESPONSE_HEADER_TIMEOUT", 0),
+			KeepAlive:             envDuration("LOKI_KEEP_ALIVE", 30*time.Second),
+			IdleConnTimeout:       envDuration("LOKI_IDLE_CONN_TIMEOUT", 90*time.Second),
+			MaxLines:              envInt("LOKI_MAX_LINES", 50000),
+			AllowedLabels:         envList("LOKI_ALLOWED_LABELS", []string{"service", "namespace", "level"}),
+			MaxRetries:            envInt("LOKI_MAX_RETRIES", 2),
+			RetryBackoff:          envDuration("LOKI_RETRY_BACKOFF", 200*time.Millisecond),
+			QueryCacheTTL:         envDuration("LOKI_QUERY_CACHE_TTL", 60*time.Second),
 		},
 		AI: AIConfig{
-			Provider:         os.Getenv("AI_PROVIDER"),
-			InferenceTimeout: envDurationSecs("AI_INFERENCE_TIMEOUT_SECS", 60*time.Second),
+			Provider:              os.Getenv("AI_PROVIDER"),
+			InferenceTimeout:      envDurationSecs("AI_INFERENCE_TIMEOUT_SECS", 60*time.Second),
+			SummarizeRetries:      envInt("SUMMARIZE_RETRIES", 0),
+			SummarizeMaxInflight:  envInt("SUMMARIZE_MAX_INFLIGHT", 0),
+			SummarizeMaxWindow:    envDuration("SUMMARIZE_MAX_WINDOW", 7*24*time.Hour),
+			AnalyzeMaxConcurrency: envInt("ANALYZE_MAX_CONCURRENCY", 0),
+			AnalyzeQueueTimeout:   envDuration("ANALYZE_QUEUE_TIMEOUT", 5*time.Minute),
+			ContextLimit:          envInt("AI_CONTEXT_LIMIT", 200),
+			ContextStrategy:       strings.ToLower(envString("AI_CONTEXT_STRATEGY", "recent")),
+			HTTPMaxIdleConns:      envInt("AI_HTTP_MAX_IDLE_CONNS", 32),
+			AllowedModels:         envList("AI_ALLOWED_MODELS", nil),
+			PromptCostPer1KTokens: envFloat("AI_PROMPT_COST_PER_1K_TOKENS", 0),
+			DeepHealth:            envBool("AI_DEEP_HEALTH", false),
+			DeepHealthInterval:    envDuration("AI_DEEP_HEALTH_INTERVAL", time.Minute),
 			Ollama: OllamaConfig{
 				BaseURL: envString("OLLAMA_BASE_URL", "http://localhost:11434"),
 				Model:   envString("OLLAMA_MODEL", "llama3"),
@@ -121,8 +277,32 @@ func Load() (*Config, error) {
 				Model:  envString("ANTHROPIC_MODEL", "claude-sonnet-4-5-20250929"),
 			},
 		},
+		Analysis: AnalysisConfig{
+			AutoResolveAfter:    envDuration("AUTO_RESOLVE_AFTER", 0),
+			AutoResolveInterval: envDuration("AUTO_RESOLVE_INTERVAL", 5*time.Minute),
+			ContextDirection:    envString("ANALYSIS_CONTEXT_DIRECTION", "forward"),
+			AutoAnalyze:         envBool("AUTO_ANALYZE", false),
+			AutoAnalyzeMinLevel: strings.ToLower(envString("AUTO_ANALYZE_MIN_LEVEL", "error")),
+			LevelFromMessage:    envBool("CLUSTER_LEVEL_FROM_MESSAGE", false),
+		},
 	}
 
+	// Per-operation timeouts fall back to the shared inference timeout.
+	cfg.AI.AnalyzeTimeout = envDurationSecs("AI_ANALYZE_TIMEOUT_SECS", cfg.AI.InferenceTimeout)
+	cfg.AI.SummarizeTimeout = envDurationSecs("AI_SUMMARIZE_TIMEOUT_SECS", cfg.AI.InferenceTimeout)
+
+	routeScopes, err := parseRouteScopes(envList("ROUTE_SCOPES", nil))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Server.RouteScopes = routeScopes
+
+	levelSeverities, err := parseLevelSeverities(envList("LEVEL_SEVERITIES", nil))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Analysis.LevelSeverities = levelSeverities
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -130,14 +310,90 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// parseRouteScopes parses "METHOD /pattern=scope" entries into a map.
+func parseRouteScopes(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	scopes := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		route, scope, ok := strings.Cut(entry, "=")
+		method, pattern, _ := strings.Cut(strings.TrimSpace(route), " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || method == "" || !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("ROUTE_SCOPES entry %q must look like \"METHOD /pattern=scope\"", entry)
+		}
+		scopes[strings.ToUpper(method)+" "+pattern] = strings.TrimSpace(scope)
+	}
+	return scopes, nil
+}
+
+// levelNamePattern restricts configured level names to what can be placed in
+// a LogQL level filter unescaped.
+var levelNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// parseLevelSeverities parses "level=severity" entries into a map keyed by
+// lower-case level name.
+func parseLevelSeverities(entries []string) (map[string]int, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	severities := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		level, value, ok := strings.Cut(entry, "=")
+		level = strings.TrimSpace(level)
+		severity, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || !levelNamePattern.MatchString(level) {
+			return nil, fmt.Errorf("LEVEL_SEVERITIES entry %q must look like \"level=severity\" with an integer severity", entry)
+		}
+		severities[strings.ToLower(level)] = severity
+	}
+	return severities, nil
+}
+
 func (c *Config) validate() error {
+	if c.Analysis.AutoResolveAfter < 0 {
+		return fmt.Errorf("AUTO_RESOLVE_AFTER must not be negative, got %s", c.Analysis.AutoResolveAfter)
+	}
+	if c.Analysis.AutoResolveAfter > 0 && c.Analysis.AutoResolveInterval <= 0 {
+		return fmt.Errorf("AUTO_RESOLVE_INTERVAL must be positive when AUTO_RESOLVE_AFTER is set")
+	}
+	if d := c.Analysis.ContextDirection; d != "forward" && d != "backward" {
+		return fmt.Errorf("ANALYSIS_CONTEXT_DIRECTION must be forward or backward, got %q", d)
+	}
+	if c.AI.DeepHealthInterval < 0 {
+		return fmt.Errorf("AI_DEEP_HEALTH_INTERVAL must not be negative, got %s", c.AI.DeepHealthInterval)
+	}
+	if c.Server.DefaultPageLimit < 1 || c.Server.MaxPageLimit < 1 {
+		return fmt.Errorf("LOGHUNTER_DEFAULT_PAGE_LIMIT and LOGHUNTER_MAX_PAGE_LIMIT must be positive")
+	}
+	if c.Server.DefaultPageLimit > c.Server.MaxPageLimit {
+		return fmt.Errorf("LOGHUNTER_DEFAULT_PAGE_LIMIT (%d) must not exceed LOGHUNTER_MAX_PAGE_LIMIT (%d)",
+			c.Server.DefaultPageLimit, c.Server.MaxPageLimit)
+	}
+	if c.Server.CrossTenantResponse != 404 && c.Server.CrossTenantResponse != 403 {
+		return fmt.Errorf("CROSS_TENANT_RESPONSE must be 404 or 403, got %d", c.Server.CrossTenantResponse)
+	}
+	if c.Server.ClusterListCacheTTL < 0 {
+		return fmt.Errorf("CLUSTER_LIST_CACHE_TTL must not be negative, got %s", c.Server.ClusterListCacheTTL)
+	}
+
 	if c.Database.URL == "" {
 		return fmt.Errorf("DATABASE_URL is required")
 	}
+	if c.Database.ConnectAttempts < 1 {
+		return fmt.Errorf("DATABASE_CONNECT_ATTEMPTS must be at least 1, got %d", c.Database.ConnectAttempts)
+	}
+	if c.Database.ConnectBackoff < 0 {
+		return fmt.Errorf("DATABASE_CONNECT_BACKOFF must not be negative, got %s", c.Database.ConnectBackoff)
+	}
 
 	if c.Redis.URL == "" {
 		return fmt.Errorf("REDIS_URL is required")
 	}
+	if c.Redis.FallbackSize < 0 {
+		return fmt.Errorf("REDIS_FALLBACK_SIZE must not be negative, got %d", c.Redis.FallbackSize)
+	}
 
 	if c.Loki.BaseURL == "" {
 		return fmt.Errorf("LOKI_BASE_URL is required")
@@ -145,12 +401,63 @@ func (c *Config) validate() error {
 	if !strings.HasPrefix(c.Loki.BaseURL, "http://") && !strings.HasPrefix(c.Loki.BaseURL, "https://") {
 		return fmt.Errorf("LOKI_BASE_URL must start with http:// or https://, got %q", c.Loki.BaseURL)
 	}
+	if c.Loki.QueryTimeout < 0 {
+		return fmt.Errorf("LOKI_QUERY_TIMEOUT must not be negative, got %s", c.Loki.QueryTimeout)
+	}
+	if c.Loki.MaxRetries < 0 {
+		return fmt.Errorf("LOKI_MAX_RETRIES must not be negative, got %d", c.Loki.MaxRetries)
+	}
+	if c.Loki.RetryBackoff < 0 {
+		return fmt.Errorf("LOKI_RETRY_BACKOFF must not be negative, got %s", c.Loki.RetryBackoff)
+	}
+	if c.Loki.QueryCacheTTL < 0 {
+		return fmt.Errorf("LOKI_QUERY_CACHE_TTL must not be negative, got %s", c.Loki.QueryCacheTTL)
+	}
+	if c.Loki.MaxLines <= 0 {
+		return fmt.Errorf("LOKI_MAX_LINES must be positive, got %d", c.Loki.MaxLines)
+	}
 
 	if c.AI.Provider == "" {
 		return fmt.Errorf("AI_PROVIDER is required")
 	}
 	if !validProviders[c.AI.Provider] {
-		return fmt.Errorf("AI_PROVIDER must be one of ollama, vllm, openai, anthropic; got %q", c.AI.Provider)
+		return fmt.Errorf("AI_PROVIDER must be one of ollama, vllm, openai, anthropic, mock; got %q", c.AI.Provider)
+	}
+	if c.AI.Provider == "mock" && c.Server.Env == "production" {
+		return fmt.Errorf("AI_PROVIDER mock is not allowed when LOGHUNTER_ENV is production")
+	}
+
+	if c.AI.InferenceTimeout < minInferenceTimeout || c.AI.InferenceTimeout > maxInferenceTimeout {
+		return fmt.Errorf("AI_INFERENCE_TIMEOUT_SECS must be between %d and %d, got %d",
+			int(minInferenceTimeout.Seconds()), int(maxInferenceTimeout.Seconds()), int(c.AI.InferenceTimeout.Seconds()))
+	}
+
+	if c.AI.SummarizeRetries < 0 || c.AI.SummarizeRetries > maxSummarizeRetries {
+		return fmt.Errorf("SUMMARIZE_RETRIES must be between 0 and %d, got %d", maxSummarizeRetries, c.AI.SummarizeRetries)
+	}
+	if c.AI.SummarizeMaxInflight < 0 {
+		return fmt.Errorf("SUMMARIZE_MAX_INFLIGHT must not be negative, got %d", c.AI.SummarizeMaxInflight)
+	}
+	if c.AI.SummarizeMaxWindow < 0 {
+		return fmt.Errorf("SUMMARIZE_MAX_WINDOW must not be negative, got %s", c.AI.SummarizeMaxWindow)
+	}
+	if c.AI.AnalyzeMaxConcurrency < 0 {
+		return fmt.Errorf("ANALYZE_MAX_CONCURRENCY must not be negative, got %d", c.AI.AnalyzeMaxConcurrency)
+	}
+	if c.AI.AnalyzeQueueTimeout <= 0 {
+		return fmt.Errorf("ANALYZE_QUEUE_TIMEOUT must be positive, got %s", c.AI.AnalyzeQueueTimeout)
+	}
+	if c.AI.ContextLimit < 1 {
+		return fmt.Errorf("AI_CONTEXT_LIMIT must be at least 1, got %d", c.AI.ContextLimit)
+	}
+	if !validContextStrategies[c.AI.ContextStrategy] {
+		return fmt.Errorf("AI_CONTEXT_STRATEGY must be one of recent, spread, errors_first; got %q", c.AI.ContextStrategy)
+	}
+	if c.AI.PromptCostPer1KTokens < 0 {
+		return fmt.Errorf("AI_PROMPT_COST_PER_1K_TOKENS must not be negative, got %g", c.AI.PromptCostPer1KTokens)
+	}
+	if c.AI.HTTPMaxIdleConns < 1 {
+		return fmt.Errorf("AI_HTTP_MAX_IDLE_CONNS must be at least 1, got %d", c.AI.HTTPMaxIdleConns)
 	}
 
 	if c.AI.Provider == "openai" && c.AI.OpenAI.APIKey == "" {
@@ -182,6 +489,47 @@ func envInt(key string, defaultVal int) int {
 	return i
 }
 
+func envFloat(key string, defaultVal float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return defaultVal
+	}
+	return f
+}
+
+func envBool(key string, defaultVal bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return defaultVal
+	}
+	return b
+}
+
+func envList(key string, defaultVal []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	if len(out) == 0 {
+		return defaultVal
+	}
+	return out
+}
+
 func envDuration(key string, defaultVal time.Duration) time.Duration {
 	v := os.Getenv(key)
 	if v == "" {
@@ -137,7 +137,7 @@ func TestLoad_InvalidAIProvider(t *testing.T) {
 }
 
 func TestLoad_AllValidAIProviders(t *testing.T) {
-	providers := []string{"ollama", "vllm", "openai", "anthropic"}
+	providers := []string{"ollama", "vllm", "openai", "anthropic", "mock"}
 
 	for _, provider := range providers {
 		t.Run(provider, func(t *testing.T) {
@@ -209,6 +209,10 @@ func TestLoad_LokiDefaults(t *testing.T) {
 
 	assert.Equal(t, "default", cfg.Loki.OrgID)
 	assert.Equal(t, 30*time.Second, cfg.Loki.Timeout)
+	assert.Equal(t, 50000, cfg.Loki.MaxLines)
+	assert.Equal(t, 5*time.Second, cfg.Loki.DialTimeout)
+	assert.Zero(t, cfg.Loki.ResponseHeaderTimeout)
+	assert.Equal(t, []string{"service", "namespace", "level"}, cfg.Loki.AllowedLabels)
 }
 
 func TestLoad_AIDefaults(t *testing.T) {
@@ -218,6 +222,50 @@ func TestLoad_AIDefaults(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, 60*time.Second, cfg.AI.InferenceTimeout)
+	assert.Equal(t, 32, cfg.AI.HTTPMaxIdleConns)
+}
+
+func TestLoad_AIHTTPMaxIdleConns(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("AI_HTTP_MAX_IDLE_CONNS", "64")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 64, cfg.AI.HTTPMaxIdleConns)
+
+	t.Setenv("AI_HTTP_MAX_IDLE_CONNS", "0")
+	_, err = config.Load()
+	assert.ErrorContains(t, err, "AI_HTTP_MAX_IDLE_CONNS")
+}
+
+func TestLoad_AIAllowedModels(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.AI.AllowedModels)
+
+	t.Setenv("AI_ALLOWED_MODELS", "gpt-4o-mini, gpt-4o")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpt-4o-mini", "gpt-4o"}, cfg.AI.AllowedModels)
+}
+
+func TestLoad_LokiQueryTimeout(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Loki.QueryTimeout)
+
+	t.Setenv("LOKI_QUERY_TIMEOUT", "10s")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.Loki.QueryTimeout)
+
+	t.Setenv("LOKI_QUERY_TIMEOUT", "-1s")
+	_, err = config.Load()
+	assert.ErrorContains(t, err, "LOKI_QUERY_TIMEOUT")
 }
 
 func TestLoad_LokiHTTPSURL(t *testing.T) {
@@ -261,3 +309,542 @@ func TestLoad_CustomInferenceTimeout(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 120*time.Second, cfg.AI.InferenceTimeout)
 }
+
+func TestLoad_InferenceTimeoutBounds(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"0", true},
+		{"-5", true},
+		{"601", true},
+		{"86400", true},
+		{"1", false},
+		{"600", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			setEnv(t, validEnv())
+			t.Setenv("AI_INFERENCE_TIMEOUT_SECS", tt.value)
+
+			_, err := config.Load()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "AI_INFERENCE_TIMEOUT_SECS")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoad_OperationTimeoutsDefaultToInferenceTimeout(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("AI_INFERENCE_TIMEOUT_SECS", "90")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, cfg.AI.AnalyzeTimeout)
+	assert.Equal(t, 90*time.Second, cfg.AI.SummarizeTimeout)
+}
+
+func TestLoad_CustomOperationTimeouts(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("AI_ANALYZE_TIMEOUT_SECS", "30")
+	t.Setenv("AI_SUMMARIZE_TIMEOUT_SECS", "180")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.AI.AnalyzeTimeout)
+	assert.Equal(t, 180*time.Second, cfg.AI.SummarizeTimeout)
+}
+
+func TestLoad_ClusterListCacheTTL(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.Server.ClusterListCacheTTL)
+
+	t.Setenv("CLUSTER_LIST_CACHE_TTL", "-1s")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CLUSTER_LIST_CACHE_TTL")
+}
+
+func TestLoad_LokiQueryCacheTTL(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 60*time.Second, cfg.Loki.QueryCacheTTL)
+
+	t.Setenv("LOKI_QUERY_CACHE_TTL", "0")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Loki.QueryCacheTTL)
+
+	t.Setenv("LOKI_QUERY_CACHE_TTL", "-1s")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LOKI_QUERY_CACHE_TTL")
+}
+
+func TestLoad_SummarizeMaxWindow(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, cfg.AI.SummarizeMaxWindow)
+
+	t.Setenv("SUMMARIZE_MAX_WINDOW", "24h")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, cfg.AI.SummarizeMaxWindow)
+
+	t.Setenv("SUMMARIZE_MAX_WINDOW", "-1h")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SUMMARIZE_MAX_WINDOW")
+}
+
+func TestLoad_AnalyzeConcurrency(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.AI.AnalyzeMaxConcurrency)
+	assert.Equal(t, 5*time.Minute, cfg.AI.AnalyzeQueueTimeout)
+
+	t.Setenv("ANALYZE_MAX_CONCURRENCY", "4")
+	t.Setenv("ANALYZE_QUEUE_TIMEOUT", "30s")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 4, cfg.AI.AnalyzeMaxConcurrency)
+	assert.Equal(t, 30*time.Second, cfg.AI.AnalyzeQueueTimeout)
+
+	t.Setenv("ANALYZE_MAX_CONCURRENCY", "-1")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ANALYZE_MAX_CONCURRENCY")
+
+	t.Setenv("ANALYZE_MAX_CONCURRENCY", "4")
+	t.Setenv("ANALYZE_QUEUE_TIMEOUT", "-1s")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ANALYZE_QUEUE_TIMEOUT")
+
+	t.Setenv("ANALYZE_QUEUE_TIMEOUT", "0")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ANALYZE_QUEUE_TIMEOUT")
+}
+
+func TestLoad_LokiRetry(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.Loki.MaxRetries)
+	assert.Equal(t, 200*time.Millisecond, cfg.Loki.RetryBackoff)
+
+	t.Setenv("LOKI_MAX_RETRIES", "0")
+	t.Setenv("LOKI_RETRY_BACKOFF", "1s")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Loki.MaxRetries)
+	assert.Equal(t, time.Second, cfg.Loki.RetryBackoff)
+
+	t.Setenv("LOKI_MAX_RETRIES", "-1")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LOKI_MAX_RETRIES")
+
+	t.Setenv("LOKI_MAX_RETRIES", "1")
+	t.Setenv("LOKI_RETRY_BACKOFF", "-1s")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LOKI_RETRY_BACKOFF")
+}
+
+func TestLoad_PromptCost(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.AI.PromptCostPer1KTokens)
+
+	t.Setenv("AI_PROMPT_COST_PER_1K_TOKENS", "0.003")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.InDelta(t, 0.003, cfg.AI.PromptCostPer1KTokens, 1e-12)
+
+	t.Setenv("AI_PROMPT_COST_PER_1K_TOKENS", "-1")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AI_PROMPT_COST_PER_1K_TOKENS")
+}
+
+func TestLoad_ContextSampling(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 200, cfg.AI.ContextLimit)
+	assert.Equal(t, "recent", cfg.AI.ContextStrategy)
+
+	t.Setenv("AI_CONTEXT_LIMIT", "500")
+	t.Setenv("AI_CONTEXT_STRATEGY", "Errors_First")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 500, cfg.AI.ContextLimit)
+	assert.Equal(t, "errors_first", cfg.AI.ContextStrategy)
+
+	t.Setenv("AI_CONTEXT_STRATEGY", "random")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AI_CONTEXT_STRATEGY")
+}
+
+func TestLoad_SummarizeRetries(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.AI.SummarizeRetries)
+
+	t.Setenv("SUMMARIZE_RETRIES", "3")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 3, cfg.AI.SummarizeRetries)
+
+	t.Setenv("SUMMARIZE_RETRIES", "-1")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SUMMARIZE_RETRIES")
+}
+
+func TestLoad_SummarizeMaxInflight(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.AI.SummarizeMaxInflight)
+
+	t.Setenv("SUMMARIZE_MAX_INFLIGHT", "8")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.AI.SummarizeMaxInflight)
+
+	t.Setenv("SUMMARIZE_MAX_INFLIGHT", "-1")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SUMMARIZE_MAX_INFLIGHT")
+}
+
+func TestLoad_LokiTransportTimeouts(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("LOKI_DIAL_TIMEOUT", "2s")
+	t.Setenv("LOKI_TLS_HANDSHAKE_TIMEOUT", "3s")
+	t.Setenv("LOKI_RESPONSE_HEADER_TIMEOUT", "10s")
+	t.Setenv("LOKI_KEEP_ALIVE", "15s")
+	t.Setenv("LOKI_IDLE_CONN_TIMEOUT", "1m")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, cfg.Loki.DialTimeout)
+	assert.Equal(t, 3*time.Second, cfg.Loki.TLSHandshakeTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Loki.ResponseHeaderTimeout)
+	assert.Equal(t, 15*time.Second, cfg.Loki.KeepAlive)
+	assert.Equal(t, time.Minute, cfg.Loki.IdleConnTimeout)
+}
+
+func TestLoad_LokiPathPrefix(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Loki.PathPrefix)
+
+	t.Setenv("LOKI_PATH_PREFIX", "/loki-gateway")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/loki-gateway", cfg.Loki.PathPrefix)
+}
+
+func TestLoad_LokiMaxLinesMustBePositive(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("LOKI_MAX_LINES", "0")
+
+	_, err := config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LOKI_MAX_LINES")
+}
+
+func TestLoad_LokiAllowedLabels(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("LOKI_ALLOWED_LABELS", " service, app ,")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"service", "app"}, cfg.Loki.AllowedLabels)
+}
+
+func TestLoad_CORSAllowedOrigins(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.CORSAllowedOrigins)
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://*.preview.example.com")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com", "https://*.preview.example.com"}, cfg.Server.CORSAllowedOrigins)
+}
+
+func TestLoad_LevelSeverities(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Analysis.LevelSeverities)
+
+	t.Setenv("LEVEL_SEVERITIES", "Notice=1, trace=-1")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"notice": 1, "trace": -1}, cfg.Analysis.LevelSeverities)
+
+	for _, bad := range []string{"notice", "notice=high", "no tice=1", "a|b=2"} {
+		t.Setenv("LEVEL_SEVERITIES", bad)
+		_, err = config.Load()
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLoad_RedisRequired(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Redis.Required)
+
+	t.Setenv("REDIS_REQUIRED", "false")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Redis.Required)
+}
+
+func TestLoad_RedisFallbackSize(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10000, cfg.Redis.FallbackSize)
+
+	t.Setenv("REDIS_FALLBACK_SIZE", "0")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.Redis.FallbackSize)
+
+	t.Setenv("REDIS_FALLBACK_SIZE", "-1")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "REDIS_FALLBACK_SIZE")
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.TrustedProxies)
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.5"}, cfg.Server.TrustedProxies)
+}
+
+func TestLoad_CrossTenantResponse(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 404, cfg.Server.CrossTenantResponse)
+
+	t.Setenv("CROSS_TENANT_RESPONSE", "403")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 403, cfg.Server.CrossTenantResponse)
+
+	t.Setenv("CROSS_TENANT_RESPONSE", "401")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CROSS_TENANT_RESPONSE")
+}
+
+func TestLoad_StrictRequestBodies(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Server.StrictRequestBodies)
+
+	t.Setenv("STRICT_REQUEST_BODIES", "true")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Server.StrictRequestBodies)
+}
+
+func TestLoad_DeepHealth(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.AI.DeepHealth)
+	assert.Equal(t, time.Minute, cfg.AI.DeepHealthInterval)
+
+	t.Setenv("AI_DEEP_HEALTH", "true")
+	t.Setenv("AI_DEEP_HEALTH_INTERVAL", "30s")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.AI.DeepHealth)
+	assert.Equal(t, 30*time.Second, cfg.AI.DeepHealthInterval)
+
+	t.Setenv("AI_DEEP_HEALTH_INTERVAL", "-1s")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AI_DEEP_HEALTH_INTERVAL")
+}
+
+func TestLoad_AutoResolveDisabledByDefault(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), cfg.Analysis.AutoResolveAfter)
+	assert.Equal(t, 5*time.Minute, cfg.Analysis.AutoResolveInterval)
+}
+
+func TestLoad_AutoResolveCustom(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("AUTO_RESOLVE_AFTER", "72h")
+	t.Setenv("AUTO_RESOLVE_INTERVAL", "10m")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, cfg.Analysis.AutoResolveAfter)
+	assert.Equal(t, 10*time.Minute, cfg.Analysis.AutoResolveInterval)
+}
+
+func TestLoad_AnalysisContextDirection(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "forward", cfg.Analysis.ContextDirection)
+
+	t.Setenv("ANALYSIS_CONTEXT_DIRECTION", "backward")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "backward", cfg.Analysis.ContextDirection)
+
+	t.Setenv("ANALYSIS_CONTEXT_DIRECTION", "sideways")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ANALYSIS_CONTEXT_DIRECTION")
+}
+
+func TestLoad_DatabaseConnectRetry(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.Database.ConnectAttempts)
+	assert.Equal(t, time.Second, cfg.Database.ConnectBackoff)
+
+	t.Setenv("DATABASE_CONNECT_ATTEMPTS", "0")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DATABASE_CONNECT_ATTEMPTS")
+}
+
+func TestLoad_MockProviderRejectedInProduction(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("AI_PROVIDER", "mock")
+	t.Setenv("LOGHUNTER_ENV", "production")
+
+	_, err := config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AI_PROVIDER mock")
+}
+
+func TestLoad_AutoAnalyze(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Analysis.AutoAnalyze)
+	assert.Equal(t, "error", cfg.Analysis.AutoAnalyzeMinLevel)
+
+	t.Setenv("AUTO_ANALYZE", "true")
+	t.Setenv("AUTO_ANALYZE_MIN_LEVEL", "FATAL")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Analysis.AutoAnalyze)
+	assert.Equal(t, "fatal", cfg.Analysis.AutoAnalyzeMinLevel)
+}
+
+func TestLoad_LevelFromMessage(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Analysis.LevelFromMessage)
+
+	t.Setenv("CLUSTER_LEVEL_FROM_MESSAGE", "true")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Analysis.LevelFromMessage)
+}
+
+func TestLoad_PaginationDefaults(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 20, cfg.Server.DefaultPageLimit)
+	assert.Equal(t, 100, cfg.Server.MaxPageLimit)
+}
+
+func TestLoad_PaginationDefaultExceedsMax(t *testing.T) {
+	setEnv(t, validEnv())
+	t.Setenv("LOGHUNTER_DEFAULT_PAGE_LIMIT", "200")
+	t.Setenv("LOGHUNTER_MAX_PAGE_LIMIT", "100")
+
+	_, err := config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LOGHUNTER_DEFAULT_PAGE_LIMIT")
+}
+
+func TestLoad_RouteScopes(t *testing.T) {
+	setEnv(t, validEnv())
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Server.RouteScopes)
+
+	t.Setenv("ROUTE_SCOPES", "post /api/v1/search=write, GET /api/v1/clusters=")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"POST /api/v1/search":  "write",
+		"GET /api/v1/clusters": "",
+	}, cfg.Server.RouteScopes)
+
+	t.Setenv("ROUTE_SCOPES", "/api/v1/search=write")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ROUTE_SCOPES")
+}
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kiranshivaraju/loghunter/pkg/models"
@@ -21,9 +25,23 @@ var (
 	ErrLokiTimeout     = errors.New("loki query timeout")
 )
 
+// QueryParseError is returned by ValidateQuery when Loki rejects a query. It
+// wraps ErrLokiQueryError and carries Loki's explanation.
+type QueryParseError struct {
+	Message string
+}
+
+func (e *QueryParseError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrLokiQueryError, e.Message)
+}
+
+func (e *QueryParseError) Unwrap() error { return ErrLokiQueryError }
+
 // Client is the interface for querying Loki.
 type Client interface {
 	QueryRange(ctx context.Context, req QueryRangeRequest) ([]models.LogLine, error)
+	QueryRangeDetailed(ctx context.Context, req QueryRangeRequest) (*QueryRangeResponse, error)
+	Query(ctx context.Context, req QueryRequest) (QueryResult, error)
 	Labels(ctx context.Context) ([]string, error)
 	LabelValues(ctx context.Context, label string) ([]string, error)
 	Ready(ctx context.Context) error
@@ -36,29 +54,252 @@ type QueryRangeRequest struct {
 	End       time.Time
 	Limit     int
 	Direction string
+	// Timeout bounds this query alone, on top of the client-wide timeout, which
+	// applies to every request. Zero uses the client's WithQueryTimeout default.
+	Timeout time.Duration
+}
+
+// QueryRangeResponse is the result of a range query.
+type QueryRangeResponse struct {
+	Lines []models.LogLine
+	// Truncated is true when Loki returned more lines than the client's
+	// max-lines cap and the remainder was discarded.
+	Truncated bool
+}
+
+// QueryRequest defines parameters for a Loki instant query, used for metric
+// queries such as sum(rate({service="api"} |= "error" [5m])).
+type QueryRequest struct {
+	Query string
+	// Time is the instant the query is evaluated at. Zero uses the current time.
+	Time time.Time
+	// Timeout bounds this query alone, as for QueryRangeRequest.Timeout.
+	Timeout time.Duration
+}
+
+// Result types returned by an instant query.
+const (
+	ResultTypeVector = "vector"
+	ResultTypeScalar = "scalar"
+)
+
+// QueryResult is the result of an instant query. ResultType says which of
+// Vector and Scalar is set.
+type QueryResult struct {
+	ResultType string
+	// Vector holds one sample per label set for ResultTypeVector.
+	Vector []Sample
+	// Scalar holds the single unlabelled sample for ResultTypeScalar.
+	Scalar Sample
+}
+
+// Sample is one value of an instant query result.
+type Sample struct {
+	Labels    map[string]string
+	Timestamp time.Time
+	Value     float64
+}
+
+// DefaultMaxLines caps the lines decoded from a single Loki response.
+const DefaultMaxLines = 50000
+
+// TransportConfig tunes the connection-level timeouts of the Loki HTTP
+// transport, so a slow-to-connect Loki fails fast instead of consuming the
+// whole request timeout. Zero fields fall back to DefaultTransportConfig.
+type TransportConfig struct {
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout also covers query evaluation, as Loki sends its
+	// headers only once a query has finished. Keep it at least as long as the
+	// overall and per-query timeouts, or it cuts every slow query short. The
+	// default, 0, disables it.
+	ResponseHeaderTimeout time.Duration
+	KeepAlive             time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConnsPerHost   int
+}
+
+// DefaultTransportConfig is used for any TransportConfig field left unset.
+var DefaultTransportConfig = TransportConfig{
+	DialTimeout:           5 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ResponseHeaderTimeout: 0,
+	KeepAlive:             30 * time.Second,
+	IdleConnTimeout:       90 * time.Second,
+	MaxIdleConnsPerHost:   10,
 }
 
 // HTTPClient implements Client using Loki's HTTP API.
 type HTTPClient struct {
-	baseURL  string
-	username string
-	password string
-	orgID    string
-	client   *http.Client
+	baseURL    string
+	pathPrefix string
+	username   string
+	password   string
+	orgID      string
+	maxLines   int
+	transport  TransportConfig
+	client     *http.Client
+	// queryTimeout is the default QueryRangeRequest.Timeout.
+	queryTimeout time.Duration
+	// maxRetries and retryBackoff configure get; see WithRetry.
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// DefaultRetryBackoff is the base retry delay used when WithRetry is given none.
+const DefaultRetryBackoff = 200 * time.Millisecond
+
+// HTTPClientOption configures optional HTTPClient behavior.
+type HTTPClientOption func(*HTTPClient)
+
+// WithMaxLines overrides DefaultMaxLines. Values <= 0 are ignored.
+func WithMaxLines(n int) HTTPClientOption {
+	return func(c *HTTPClient) {
+		if n > 0 {
+			c.maxLines = n
+		}
+	}
+}
+
+// WithQueryTimeout sets the timeout applied to range queries that do not set
+// QueryRangeRequest.Timeout themselves. Values <= 0 are ignored.
+func WithQueryTimeout(d time.Duration) HTTPClientOption {
+	return func(c *HTTPClient) {
+		if d > 0 {
+			c.queryTimeout = d
+		}
+	}
+}
+
+// WithRetry retries API requests up to maxRetries times when Loki is
+// unreachable or answers with a 5xx status. The delay doubles from
+// baseBackoff with each attempt, with jitter; baseBackoff <= 0 uses
+// DefaultRetryBackoff. Client errors such as a rejected query are never
+// retried. maxRetries <= 0 (the default) disables retries.
+func WithRetry(maxRetries int, baseBackoff time.Duration) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.maxRetries = max(maxRetries, 0)
+		c.retryBackoff = baseBackoff
+		if c.retryBackoff <= 0 {
+			c.retryBackoff = DefaultRetryBackoff
+		}
+	}
+}
+
+// WithTransportConfig overrides the connection-level timeouts of the transport.
+func WithTransportConfig(tc TransportConfig) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.transport = tc
+	}
+}
+
+// WithPathPrefix prepends prefix to every request path, for Loki deployments
+// behind a gateway (e.g. "/loki-gateway" gives /loki-gateway/loki/api/v1/...).
+func WithPathPrefix(prefix string) HTTPClientOption {
+	return func(c *HTTPClient) {
+		prefix = strings.Trim(prefix, "/")
+		if prefix != "" {
+			c.pathPrefix = "/" + prefix
+		}
+	}
 }
 
 // NewHTTPClient creates a new Loki HTTP client.
-func NewHTTPClient(baseURL, username, password, orgID string, timeout time.Duration) *HTTPClient {
-	return &HTTPClient{
+// timeout bounds each request end to end, on top of the transport timeouts.
+func NewHTTPClient(baseURL, username, password, orgID string, timeout time.Duration, opts ...HTTPClientOption) *HTTPClient {
+	c := &HTTPClient{
 		baseURL:  baseURL,
 		username: username,
 		password: password,
 		orgID:    orgID,
-		client:   &http.Client{Timeout: timeout},
+		maxLines: DefaultMaxLines,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.client = &http.Client{
+		Timeout:   timeout,
+		Transport: newTransport(c.transport),
+	}
+	return c
+}
+
+// url joins the base URL, optional path prefix and an API path.
+func (c *HTTPClient) url(path string) string {
+	return c.baseURL + c.pathPrefix + path
+}
+
+// newTransport builds an http.Transport from tc, filling unset fields from
+// DefaultTransportConfig.
+func newTransport(tc TransportConfig) *http.Transport {
+	def := DefaultTransportConfig
+	if tc.DialTimeout <= 0 {
+		tc.DialTimeout = def.DialTimeout
+	}
+	if tc.TLSHandshakeTimeout <= 0 {
+		tc.TLSHandshakeTimeout = def.TLSHandshakeTimeout
+	}
+	if tc.ResponseHeaderTimeout <= 0 {
+		tc.ResponseHeaderTimeout = def.ResponseHeaderTimeout
+	}
+	if tc.KeepAlive <= 0 {
+		tc.KeepAlive = def.KeepAlive
+	}
+	if tc.IdleConnTimeout <= 0 {
+		tc.IdleConnTimeout = def.IdleConnTimeout
+	}
+	if tc.MaxIdleConnsPerHost <= 0 {
+		tc.MaxIdleConnsPerHost = def.MaxIdleConnsPerHost
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   tc.DialTimeout,
+		KeepAlive: tc.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   tc.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: tc.ResponseHeaderTimeout,
+		IdleConnTimeout:       tc.IdleConnTimeout,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   tc.MaxIdleConnsPerHost,
+		ForceAttemptHTTP2:     true,
 	}
 }
 
+// QueryRange runs a range query and returns at most the client's max-lines cap.
+// Use QueryRangeDetailed to learn whether the result was truncated.
 func (c *HTTPClient) QueryRange(ctx context.Context, req QueryRangeRequest) ([]models.LogLine, error) {
+	resp, err := c.QueryRangeDetailed(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		slog.Warn("loki response truncated", "query", req.Query, "max_lines", c.maxLines)
+	}
+	return resp.Lines, nil
+}
+
+// QueryRangeDetailed runs a range query, decoding the response as a stream and
+// stopping once the max-lines cap is reached. A request with a missing or
+// inverted time range fails with ErrLokiQueryError without reaching Loki, and
+// Limit is clamped to one past the max-lines cap.
+func (c *HTTPClient) QueryRangeDetailed(ctx context.Context, req QueryRangeRequest) (*QueryRangeResponse, error) {
+	if err := checkTimeRange(req.Start, req.End); err != nil {
+		return nil, err
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = c.queryTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	direction := req.Direction
 	if direction == "" {
 		direction = "backward"
@@ -71,20 +312,18 @@ func (c *HTTPClient) QueryRange(ctx context.Context, req QueryRangeRequest) ([]m
 		"direction": {direction},
 	}
 	if req.Limit > 0 {
-		params.Set("limit", strconv.Itoa(req.Limit))
+		// More than the cap would be discarded anyway, and Loki rejects
+		// limits above its own max_entries_limit_per_query. The one extra
+		// line lets decodeStreams tell a truncated result from one that
+		// exactly fills the cap.
+		params.Set("limit", strconv.Itoa(min(req.Limit, c.maxLines+1)))
 	}
 
-	u := fmt.Sprintf("%s/loki/api/v1/query_range?%s", c.baseURL, params.Encode())
+	u := c.url("/loki/api/v1/query_range?" + params.Encode())
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	resp, err := c.get(ctx, u)
 	if err != nil {
-		return nil, fmt.Errorf("building request: %w", err)
-	}
-	c.setHeaders(httpReq)
-
-	resp, err := c.client.Do(httpReq)
-	if err != nil {
-		return nil, classifyError(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -92,26 +331,122 @@ func (c *HTTPClient) QueryRange(ctx context.Context, req QueryRangeRequest) ([]m
 		return nil, fmt.Errorf("%w: status %d", ErrLokiQueryError, resp.StatusCode)
 	}
 
-	var lokiResp lokiQueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&lokiResp); err != nil {
+	lines, truncated, err := decodeStreams(resp.Body, c.maxLines)
+	if err != nil {
+		// A deadline hit mid-body surfaces as a read error; report it as a timeout.
+		if ctx.Err() != nil {
+			return nil, classifyError(ctx.Err())
+		}
 		return nil, fmt.Errorf("decoding loki response: %w", err)
 	}
 
-	return parseStreams(lokiResp.Data.Result), nil
+	return &QueryRangeResponse{Lines: lines, Truncated: truncated}, nil
 }
 
-func (c *HTTPClient) Labels(ctx context.Context) ([]string, error) {
-	u := fmt.Sprintf("%s/loki/api/v1/labels", c.baseURL)
+// checkTimeRange rejects a range query window Loki would misread or refuse.
+func checkTimeRange(start, end time.Time) error {
+	switch {
+	case start.IsZero():
+		return fmt.Errorf("%w: start is required", ErrLokiQueryError)
+	case end.IsZero():
+		return fmt.Errorf("%w: end is required", ErrLokiQueryError)
+	case end.Before(start):
+		return fmt.Errorf("%w: end %s is before start %s", ErrLokiQueryError,
+			end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	return nil
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+// Query runs an instant query. Only metric queries are supported: a log query,
+// which Loki answers with streams, fails with ErrLokiQueryError, as does a
+// non-200 response. Transport failures are classified as for QueryRange.
+func (c *HTTPClient) Query(ctx context.Context, req QueryRequest) (QueryResult, error) {
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = c.queryTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	at := req.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	params := url.Values{
+		"query": {req.Query},
+		"time":  {strconv.FormatInt(at.UnixNano(), 10)},
+	}
+	u := c.url("/loki/api/v1/query?" + params.Encode())
+
+	resp, err := c.get(ctx, u)
 	if err != nil {
-		return nil, fmt.Errorf("building request: %w", err)
+		return QueryResult{}, err
 	}
-	c.setHeaders(httpReq)
+	defer resp.Body.Close()
 
-	resp, err := c.client.Do(httpReq)
+	if resp.StatusCode != http.StatusOK {
+		return QueryResult{}, fmt.Errorf("%w: status %d", ErrLokiQueryError, resp.StatusCode)
+	}
+
+	result, err := decodeQueryResult(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return QueryResult{}, classifyError(ctx.Err())
+		}
+		return QueryResult{}, err
+	}
+	return result, nil
+}
+
+// maxQueryErrorBytes bounds how much of a rejection body ValidateQuery keeps.
+const maxQueryErrorBytes = 1024
+
+// ValidateQuery checks that Loki accepts expr without running it in full: it
+// issues a range query over the last second with limit=1. A query Loki
+// rejects returns a *QueryParseError; other failures are classified as for
+// QueryRange.
+func (c *HTTPClient) ValidateQuery(ctx context.Context, expr string) error {
+	if c.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.queryTimeout)
+		defer cancel()
+	}
+
+	end := time.Now()
+	params := url.Values{
+		"query": {expr},
+		"start": {strconv.FormatInt(end.Add(-time.Second).UnixNano(), 10)},
+		"end":   {strconv.FormatInt(end.UnixNano(), 10)},
+		"limit": {"1"},
+	}
+	u := c.url("/loki/api/v1/query_range?" + params.Encode())
+
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxQueryErrorBytes))
+		return &QueryParseError{Message: strings.TrimSpace(string(body))}
+	default:
+		return fmt.Errorf("%w: status %d", ErrLokiQueryError, resp.StatusCode)
+	}
+}
+
+func (c *HTTPClient) Labels(ctx context.Context) ([]string, error) {
+	u := c.url("/loki/api/v1/labels")
+
+	resp, err := c.get(ctx, u)
 	if err != nil {
-		return nil, classifyError(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -128,17 +463,11 @@ func (c *HTTPClient) Labels(ctx context.Context) ([]string, error) {
 }
 
 func (c *HTTPClient) LabelValues(ctx context.Context, label string) ([]string, error) {
-	u := fmt.Sprintf("%s/loki/api/v1/label/%s/values", c.baseURL, url.PathEscape(label))
+	u := c.url("/loki/api/v1/label/" + url.PathEscape(label) + "/values")
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	resp, err := c.get(ctx, u)
 	if err != nil {
-		return nil, fmt.Errorf("building request: %w", err)
-	}
-	c.setHeaders(httpReq)
-
-	resp, err := c.client.Do(httpReq)
-	if err != nil {
-		return nil, classifyError(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -155,7 +484,7 @@ func (c *HTTPClient) LabelValues(ctx context.Context, label string) ([]string, e
 }
 
 func (c *HTTPClient) Ready(ctx context.Context) error {
-	u := fmt.Sprintf("%s/ready", c.baseURL)
+	u := c.url("/ready")
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
@@ -176,12 +505,78 @@ func (c *HTTPClient) Ready(ctx context.Context) error {
 	return nil
 }
 
+// get issues a GET to u, retrying as configured by WithRetry. Transport
+// errors are classified; a non-2xx response is returned for the caller to
+// interpret. Waits between attempts end early if ctx is done.
+func (c *HTTPClient) get(ctx context.Context, u string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("building request: %w", err)
+		}
+		c.setHeaders(httpReq)
+
+		resp, err := c.client.Do(httpReq)
+		if err != nil {
+			err = classifyError(err)
+		}
+		retryable := errors.Is(err, ErrLokiUnreachable) ||
+			(err == nil && resp.StatusCode >= http.StatusInternalServerError)
+		if !retryable || attempt >= c.maxRetries {
+			return resp, err
+		}
+
+		if err == nil {
+			slog.Warn("retrying loki request", "attempt", attempt+1, "status", resp.StatusCode)
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxQueryErrorBytes))
+			resp.Body.Close()
+		} else {
+			slog.Warn("retrying loki request", "attempt", attempt+1, "error", err)
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, classifyError(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before retry attempt+1: retryBackoff doubled for
+// each earlier attempt, jittered to between half and all of that.
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	d := c.retryBackoff << min(attempt, 16)
+	return d/2 + rand.N(d/2+1)
+}
+
+type orgIDKey struct{}
+
+// WithOrgID returns a context that makes requests sent with it use orgID as
+// their X-Scope-OrgID instead of the client's configured one, so a query runs
+// against a tenant's own Loki org. An empty orgID leaves ctx unchanged.
+func WithOrgID(ctx context.Context, orgID string) context.Context {
+	if orgID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, orgIDKey{}, orgID)
+}
+
+// OrgIDFromContext returns the org set by WithOrgID, or fallback if none was set.
+func OrgIDFromContext(ctx context.Context, fallback string) string {
+	if o, ok := ctx.Value(orgIDKey{}).(string); ok {
+		return o
+	}
+	return fallback
+}
+
 func (c *HTTPClient) setHeaders(req *http.Request) {
 	if c.username != "" && c.password != "" {
 		req.SetBasicAuth(c.username, c.password)
 	}
-	if c.orgID != "" {
-		req.Header.Set("X-Scope-OrgID", c.orgID)
+	if orgID := OrgIDFromContext(req.Context(), c.orgID); orgID != "" {
+		req.Header.Set("X-Scope-OrgID", orgID)
 	}
 }
 
@@ -207,27 +602,6 @@ func classifyError(err error) error {
 	return fmt.Errorf("%w: %v", ErrLokiUnreachable, err)
 }
 
-// parseStreams converts Loki stream results into LogLine slices.
-func parseStreams(streams []lokiStream) []models.LogLine {
-	var lines []models.LogLine
-	for _, stream := range streams {
-		level := stream.Stream["level"]
-		for _, v := range stream.Values {
-			ts, _ := strconv.ParseInt(v[0], 10, 64)
-			lines = append(lines, models.LogLine{
-				Timestamp: time.Unix(0, ts).UTC(),
-				Message:   v[1],
-				Labels:    stream.Stream,
-				Level:     level,
-			})
-		}
-	}
-	if lines == nil {
-		return []models.LogLine{}
-	}
-	return lines
-}
-
 // --- Loki response types ---
 
 type lokiQueryResponse struct {
@@ -244,6 +618,21 @@ type lokiStream struct {
 	Values [][2]string       `json:"values"`
 }
 
+type lokiInstantResponse struct {
+	Data struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type lokiVectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  lokiSampleValue   `json:"value"`
+}
+
+// lokiSampleValue is a [<unix seconds>, "<value>"] pair.
+type lokiSampleValue [2]json.RawMessage
+
 type lokiLabelsResponse struct {
 	Status string   `json:"status"`
 	Data   []string `json:"data"`
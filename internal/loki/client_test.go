@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -22,6 +26,69 @@ func newTestClient(t *testing.T, baseURL string) *HTTPClient {
 	return NewHTTPClient(baseURL, "", "", "", 5*time.Second)
 }
 
+// --- transport tests ---
+
+func TestNewHTTPClient_TransportConfig(t *testing.T) {
+	c := NewHTTPClient("http://loki:3100", "", "", "", 45*time.Second, WithTransportConfig(TransportConfig{
+		TLSHandshakeTimeout:   2 * time.Second,
+		ResponseHeaderTimeout: 7 * time.Second,
+		IdleConnTimeout:       time.Minute,
+		MaxIdleConnsPerHost:   4,
+	}))
+
+	if c.client.Timeout != 45*time.Second {
+		t.Errorf("expected overall timeout 45s, got %v", c.client.Timeout)
+	}
+	tr, ok := c.client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", c.client.Transport)
+	}
+	if tr.TLSHandshakeTimeout != 2*time.Second {
+		t.Errorf("expected TLS handshake timeout 2s, got %v", tr.TLSHandshakeTimeout)
+	}
+	if tr.ResponseHeaderTimeout != 7*time.Second {
+		t.Errorf("expected response header timeout 7s, got %v", tr.ResponseHeaderTimeout)
+	}
+	if tr.IdleConnTimeout != time.Minute {
+		t.Errorf("expected idle conn timeout 1m, got %v", tr.IdleConnTimeout)
+	}
+	if tr.MaxIdleConnsPerHost != 4 {
+		t.Errorf("expected 4 idle conns per host, got %d", tr.MaxIdleConnsPerHost)
+	}
+	if tr.DialContext == nil {
+		t.Error("expected a custom DialContext")
+	}
+}
+
+func TestNewHTTPClient_TransportDefaults(t *testing.T) {
+	c := newTestClient(t, "http://loki:3100")
+
+	tr := c.client.Transport.(*http.Transport)
+	if tr.TLSHandshakeTimeout != DefaultTransportConfig.TLSHandshakeTimeout {
+		t.Errorf("expected default TLS handshake timeout, got %v", tr.TLSHandshakeTimeout)
+	}
+	if tr.ResponseHeaderTimeout != 0 {
+		t.Errorf("expected no response header timeout by default, as it would cut off slow queries; got %v", tr.ResponseHeaderTimeout)
+	}
+}
+
+func TestQueryRange_ResponseHeaderTimeout(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second,
+		WithTransportConfig(TransportConfig{ResponseHeaderTimeout: 50 * time.Millisecond}))
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`, Start: time.Now().Add(-time.Hour), End: time.Now(),
+	})
+	if !errors.Is(err, ErrLokiTimeout) {
+		t.Fatalf("expected ErrLokiTimeout, got %v", err)
+	}
+}
+
 // --- QueryRange tests ---
 
 func TestQueryRange_ValidResponse(t *testing.T) {
@@ -184,6 +251,182 @@ func TestQueryRange_EmptyResult(t *testing.T) {
 	}
 }
 
+func TestQueryRange_MaxLinesTruncates(t *testing.T) {
+	const streams, perStream = 3, 2000
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		resp := lokiQueryResponse{Data: lokiData{ResultType: "streams"}}
+		for s := 0; s < streams; s++ {
+			stream := lokiStream{Stream: map[string]string{"service": "api", "level": "error"}}
+			for i := 0; i < perStream; i++ {
+				stream.Values = append(stream.Values, [2]string{"1708128000000000000", "synthetic line"})
+			}
+			resp.Data.Result = append(resp.Data.Result, stream)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithMaxLines(2500))
+	resp, err := c.QueryRangeDetailed(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Lines) != 2500 {
+		t.Errorf("expected 2500 lines, got %d", len(resp.Lines))
+	}
+	if !resp.Truncated {
+		t.Error("expected truncated to be true")
+	}
+	if last := resp.Lines[len(resp.Lines)-1]; last.Level != "error" || last.Labels["service"] != "api" {
+		t.Errorf("expected labels on lines of a partially read stream, got %+v", last)
+	}
+
+	// QueryRange returns the same capped lines.
+	lines, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`, Start: time.Now().Add(-time.Hour), End: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2500 {
+		t.Errorf("expected 2500 lines from QueryRange, got %d", len(lines))
+	}
+}
+
+func TestQueryRange_UnderMaxLinesNotTruncated(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Values before stream, plus unrelated fields, exercise the streaming decoder.
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[` +
+			`{"values":[["1708128000000000000","a"],["1708128060000000000","b"]],"stream":{"level":"warn"}}` +
+			`],"stats":{"summary":{"totalLinesProcessed":2}}}}`))
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithMaxLines(2))
+	resp, err := c.QueryRangeDetailed(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`, Start: time.Now().Add(-time.Hour), End: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Truncated {
+		t.Error("expected truncated to be false when lines equal the cap")
+	}
+	if len(resp.Lines) != 2 || resp.Lines[1].Message != "b" || resp.Lines[1].Level != "warn" {
+		t.Errorf("unexpected lines: %+v", resp.Lines)
+	}
+}
+
+func TestQueryRange_InvalidTimeRange(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		start, end time.Time
+	}{
+		{"zero start", time.Time{}, now},
+		{"zero end", now.Add(-time.Hour), time.Time{}},
+		{"end before start", now, now.Add(-time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusOK)
+			})
+			defer ts.Close()
+
+			c := newTestClient(t, ts.URL)
+			_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+				Query: `{service="api"}`, Start: tt.start, End: tt.end,
+			})
+			if !errors.Is(err, ErrLokiQueryError) {
+				t.Fatalf("expected ErrLokiQueryError, got %v", err)
+			}
+			if n := calls.Load(); n != 0 {
+				t.Errorf("expected no request to Loki, got %d", n)
+			}
+		})
+	}
+}
+
+func TestQueryRange_ClampsLimitToMaxLines(t *testing.T) {
+	var gotLimit string
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithMaxLines(1000))
+	now := time.Now()
+	for _, tt := range []struct {
+		limit int
+		want  string
+	}{
+		{1_000_000, "1001"},
+		{1000, "1000"},
+		{500, "500"},
+	} {
+		_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+			Query: `{service="api"}`, Start: now.Add(-time.Hour), End: now, Limit: tt.limit,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotLimit != tt.want {
+			t.Errorf("limit %d: expected %s sent to Loki, got %q", tt.limit, tt.want, gotLimit)
+		}
+	}
+}
+
+func TestQueryRange_LimitAboveMaxLinesReportsTruncation(t *testing.T) {
+	const maxLines = 10
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// Honor the limit the way Loki does.
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		stream := lokiStream{Stream: map[string]string{"service": "api"}}
+		for i := 0; i < limit; i++ {
+			stream.Values = append(stream.Values, [2]string{"1708128000000000000", "line"})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lokiQueryResponse{Data: lokiData{ResultType: "streams", Result: []lokiStream{stream}}})
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithMaxLines(maxLines))
+	now := time.Now()
+	resp, err := c.QueryRangeDetailed(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`, Start: now.Add(-time.Hour), End: now, Limit: 1000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Lines) != maxLines {
+		t.Errorf("expected %d lines, got %d", maxLines, len(resp.Lines))
+	}
+	if !resp.Truncated {
+		t.Error("expected truncated when Loki returns maxLines+1 lines")
+	}
+
+	resp, err = c.QueryRangeDetailed(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`, Start: now.Add(-time.Hour), End: now, Limit: maxLines,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Lines) != maxLines || resp.Truncated {
+		t.Errorf("expected exactly %d lines, not truncated, got %d (truncated=%v)", maxLines, len(resp.Lines), resp.Truncated)
+	}
+}
+
 func TestQueryRange_Loki400_QueryError(t *testing.T) {
 	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -242,6 +485,146 @@ func TestQueryRange_ConnectionRefused(t *testing.T) {
 	}
 }
 
+// flakyServer fails the first failures requests with status, then returns an
+// empty result. It reports how many requests it received.
+func flakyServer(t *testing.T, failures int, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(lokiQueryResponse{Data: lokiData{ResultType: "streams"}})
+	})
+	return ts, &calls
+}
+
+func TestQueryRange_RetriesTransient5xx(t *testing.T) {
+	ts, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithRetry(2, time.Millisecond))
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestQueryRange_RetriesExhausted(t *testing.T) {
+	ts, calls := flakyServer(t, 10, http.StatusBadGateway)
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithRetry(2, time.Millisecond))
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if !errors.Is(err, ErrLokiQueryError) {
+		t.Fatalf("expected ErrLokiQueryError, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestQueryRange_NoRetryOn4xx(t *testing.T) {
+	ts, calls := flakyServer(t, 10, http.StatusBadRequest)
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithRetry(3, time.Millisecond))
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{bad query`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if !errors.Is(err, ErrLokiQueryError) {
+		t.Fatalf("expected ErrLokiQueryError, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestQueryRange_NoRetryByDefault(t *testing.T) {
+	ts, calls := flakyServer(t, 1, http.StatusInternalServerError)
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if err == nil {
+		t.Fatal("expected error without retries")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestQueryRange_RetriesConnectionRefused(t *testing.T) {
+	c := NewHTTPClient("http://127.0.0.1:1", "", "", "", 5*time.Second, WithRetry(2, 20*time.Millisecond))
+	start := time.Now()
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if !errors.Is(err, ErrLokiUnreachable) {
+		t.Fatalf("expected ErrLokiUnreachable, got %v", err)
+	}
+	// Two backoffs of at least 10ms and 20ms.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected retries to back off, returned after %v", elapsed)
+	}
+}
+
+func TestQueryRange_RetryBackoffRespectsContext(t *testing.T) {
+	ts, calls := flakyServer(t, 10, http.StatusServiceUnavailable)
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithRetry(5, 10*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.QueryRange(ctx, QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if !errors.Is(err, ErrLokiTimeout) {
+		t.Fatalf("expected ErrLokiTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected prompt return on deadline, took %v", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected no attempt after the deadline, got %d", calls.Load())
+	}
+}
+
+func TestHTTPClient_Backoff(t *testing.T) {
+	c := NewHTTPClient("http://loki:3100", "", "", "", time.Second, WithRetry(3, 100*time.Millisecond))
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		for range 20 {
+			if d := c.backoff(attempt); d < want/2 || d > want {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+}
+
 func TestQueryRange_Timeout(t *testing.T) {
 	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(2 * time.Second)
@@ -268,6 +651,56 @@ func TestQueryRange_Timeout(t *testing.T) {
 	}
 }
 
+func TestQueryRange_PerRequestTimeout(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	// The client allows far longer than the query's own deadline.
+	c := NewHTTPClient(ts.URL, "", "", "", 30*time.Second)
+
+	start := time.Now()
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query:   `{service="api"}`,
+		Start:   time.Now().Add(-1 * time.Hour),
+		End:     time.Now(),
+		Timeout: 50 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrLokiTimeout) {
+		t.Fatalf("expected ErrLokiTimeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the per-request timeout to fire, took %v", elapsed)
+	}
+}
+
+func TestQueryRange_ClientQueryTimeoutDefault(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 30*time.Second, WithQueryTimeout(50*time.Millisecond))
+
+	_, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if !errors.Is(err, ErrLokiTimeout) {
+		t.Fatalf("expected ErrLokiTimeout, got: %v", err)
+	}
+}
+
 func TestQueryRange_DirectionParam(t *testing.T) {
 	var capturedDirection string
 	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
@@ -301,6 +734,29 @@ func TestQueryRange_DirectionParam(t *testing.T) {
 	}
 }
 
+func TestQueryRange_OrgIDFromContext(t *testing.T) {
+	var orgIDs []string
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		orgIDs = append(orgIDs, r.Header.Get("X-Scope-OrgID"))
+		resp := lokiQueryResponse{Data: lokiData{ResultType: "streams"}}
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "default-org", 5*time.Second)
+	req := QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	}
+	c.QueryRange(WithOrgID(context.Background(), "tenant-2"), req)
+	c.QueryRange(WithOrgID(context.Background(), ""), req)
+
+	if len(orgIDs) != 2 || orgIDs[0] != "tenant-2" || orgIDs[1] != "default-org" {
+		t.Errorf("expected the context org and then the configured one, got %v", orgIDs)
+	}
+}
+
 func TestQueryRange_AuthHeaders(t *testing.T) {
 	var capturedHeaders http.Header
 	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
@@ -365,6 +821,181 @@ func TestQueryRange_LevelExtraction(t *testing.T) {
 	}
 }
 
+func TestQueryRange_JSONFields(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		resp := lokiQueryResponse{
+			Data: lokiData{
+				ResultType: "streams",
+				Result: []lokiStream{
+					{
+						Stream: map[string]string{"service": "api"},
+						Values: [][2]string{
+							{"1708128000000000000", `{"msg":"db timeout","status":503,"ctx":{"retry":true}}`},
+							{"1708128010000000000", "plain text line"},
+							{"1708128020000000000", "{not json"},
+						},
+					},
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	lines, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: `{service="api"}`,
+		Start: time.Now().Add(-1 * time.Hour),
+		End:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"msg": "db timeout", "status": "503", "ctx": `{"retry":true}`}
+	if !reflect.DeepEqual(lines[0].Fields, want) {
+		t.Errorf("expected fields %v, got %v", want, lines[0].Fields)
+	}
+	for _, l := range lines[1:] {
+		if l.Fields != nil {
+			t.Errorf("expected nil fields for %q, got %v", l.Message, l.Fields)
+		}
+	}
+}
+
+// --- Query tests ---
+
+func TestQuery_Vector(t *testing.T) {
+	at := time.Date(2024, 2, 17, 1, 0, 0, 0, time.UTC)
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("query") != `sum by (service) (rate({service=~".+"} |= "error" [5m]))` {
+			t.Errorf("unexpected query: %s", q.Get("query"))
+		}
+		if q.Get("time") != "1708131600000000000" {
+			t.Errorf("unexpected time: %s", q.Get("time"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"service":"api"},"value":[1708131600.123,"0.25"]},
+			{"metric":{"service":"worker"},"value":[1708131600.123,"NaN"]}
+		]}}`))
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	result, err := c.Query(context.Background(), QueryRequest{
+		Query: `sum by (service) (rate({service=~".+"} |= "error" [5m]))`,
+		Time:  at,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ResultType != ResultTypeVector {
+		t.Fatalf("expected vector result, got %q", result.ResultType)
+	}
+	if len(result.Vector) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(result.Vector))
+	}
+	first := result.Vector[0]
+	if first.Labels["service"] != "api" || first.Value != 0.25 {
+		t.Errorf("unexpected first sample: %+v", first)
+	}
+	if want := at.Add(123 * time.Millisecond); !first.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v", want, first.Timestamp)
+	}
+	if !math.IsNaN(result.Vector[1].Value) {
+		t.Errorf("expected NaN, got %v", result.Vector[1].Value)
+	}
+}
+
+func TestQuery_Scalar(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("time") == "" {
+			t.Error("expected the evaluation time to default to now")
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1708131600,"42"]}}`))
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	result, err := c.Query(context.Background(), QueryRequest{Query: `vector(42)`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ResultType != ResultTypeScalar {
+		t.Fatalf("expected scalar result, got %q", result.ResultType)
+	}
+	if result.Scalar.Value != 42 || result.Scalar.Labels != nil {
+		t.Errorf("unexpected scalar: %+v", result.Scalar)
+	}
+	if result.Vector != nil {
+		t.Errorf("expected no vector for a scalar result, got %v", result.Vector)
+	}
+}
+
+func TestQuery_StreamsUnsupported(t *testing.T) {
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(lokiQueryResponse{Data: lokiData{ResultType: "streams"}})
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+	_, err := c.Query(context.Background(), QueryRequest{Query: `{service="api"}`})
+	if !errors.Is(err, ErrLokiQueryError) {
+		t.Errorf("expected ErrLokiQueryError for a log query, got: %v", err)
+	}
+}
+
+func TestQuery_Errors(t *testing.T) {
+	slow := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	defer slow.Close()
+	rejecting := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`parse error at line 1, col 5`))
+	})
+	defer rejecting.Close()
+	malformed := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"resultType":"vector","result":[{"metric":{},"value":[1708131600,"fast"]}]}}`))
+	})
+	defer malformed.Close()
+
+	tests := []struct {
+		name    string
+		baseURL string
+		timeout time.Duration
+		want    error
+	}{
+		{"non-200", rejecting.URL, 0, ErrLokiQueryError},
+		{"timeout", slow.URL, 50 * time.Millisecond, ErrLokiTimeout},
+		{"unreachable", "http://127.0.0.1:1", 0, ErrLokiUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.baseURL)
+			_, err := c.Query(context.Background(), QueryRequest{Query: `vector(1)`, Timeout: tt.timeout})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Run("malformed value", func(t *testing.T) {
+		c := newTestClient(t, malformed.URL)
+		_, err := c.Query(context.Background(), QueryRequest{Query: `vector(1)`})
+		if err == nil {
+			t.Fatal("expected a decoding error")
+		}
+	})
+}
+
 // --- Labels tests ---
 
 func TestLabels_Success(t *testing.T) {
@@ -493,9 +1124,117 @@ func TestReady_ConnectionRefused(t *testing.T) {
 	}
 }
 
+// --- path prefix tests ---
+
+func TestPathPrefix_AppliedToAllRequests(t *testing.T) {
+	var paths []string
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch {
+		case r.URL.Path == "/loki-gateway/ready":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/loki-gateway/loki/api/v1/query_range":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+		case r.URL.Path == "/loki-gateway/loki/api/v1/query":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		default:
+			json.NewEncoder(w).Encode(lokiLabelsResponse{Status: "success", Data: []string{"service"}})
+		}
+	})
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL, "", "", "", 5*time.Second, WithPathPrefix("/loki-gateway/"))
+	ctx := context.Background()
+
+	if _, err := c.QueryRange(ctx, QueryRangeRequest{Query: `{service="api"}`, Start: time.Now().Add(-time.Minute), End: time.Now()}); err != nil {
+		t.Fatalf("QueryRange: %v", err)
+	}
+	if _, err := c.Query(ctx, QueryRequest{Query: `vector(1)`}); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if _, err := c.Labels(ctx); err != nil {
+		t.Fatalf("Labels: %v", err)
+	}
+	if _, err := c.LabelValues(ctx, "service"); err != nil {
+		t.Fatalf("LabelValues: %v", err)
+	}
+	if err := c.Ready(ctx); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+
+	want := []string{
+		"/loki-gateway/loki/api/v1/query_range",
+		"/loki-gateway/loki/api/v1/query",
+		"/loki-gateway/loki/api/v1/labels",
+		"/loki-gateway/loki/api/v1/label/service/values",
+		"/loki-gateway/ready",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %d requests, got %v", len(want), paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("request %d: expected path %s, got %s", i, want[i], paths[i])
+		}
+	}
+}
+
+func TestPathPrefix_NormalizesSlashes(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"gw", "/gw"},
+		{"/gw/", "/gw"},
+		{"a/b", "/a/b"},
+	}
+	for _, tt := range tests {
+		c := NewHTTPClient("http://loki:3100", "", "", "", time.Second, WithPathPrefix(tt.prefix))
+		if got := c.url("/ready"); got != "http://loki:3100"+tt.want+"/ready" {
+			t.Errorf("prefix %q: got %s", tt.prefix, got)
+		}
+	}
+}
+
 // --- helper to parse basic auth ---
 
 func parseBasicAuth(auth string) (string, string, bool) {
 	r := &http.Request{Header: http.Header{"Authorization": {auth}}}
 	return r.BasicAuth()
 }
+
+func TestValidateQuery(t *testing.T) {
+	var gotLimit string
+	ts := lokiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		if r.URL.Query().Get("query") == `{service="api"}` {
+			json.NewEncoder(w).Encode(lokiQueryResponse{Data: lokiData{ResultType: "streams"}})
+			return
+		}
+		http.Error(w, "parse error at line 1, col 9: syntax error: unexpected IDENTIFIER", http.StatusBadRequest)
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, ts.URL)
+
+	if err := c.ValidateQuery(context.Background(), `{service="api"}`); err != nil {
+		t.Fatalf("expected valid query, got %v", err)
+	}
+	if gotLimit != "1" {
+		t.Errorf("expected limit=1, got %q", gotLimit)
+	}
+
+	err := c.ValidateQuery(context.Background(), `{service="api"} oops`)
+	if !errors.Is(err, ErrLokiQueryError) {
+		t.Fatalf("expected ErrLokiQueryError, got %v", err)
+	}
+	var parseErr *QueryParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *QueryParseError, got %T", err)
+	}
+	if parseErr.Message != "parse error at line 1, col 9: syntax error: unexpected IDENTIFIER" {
+		t.Errorf("unexpected message: %q", parseErr.Message)
+	}
+}
@@ -0,0 +1,230 @@
+package loki
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// decodeStreams reads a query_range response body token by token, converting
+// stream values into LogLines as it goes. Parsing stops as soon as maxLines
+// lines have been collected, so an oversized response is never held in memory
+// in full. maxLines <= 0 means no cap. The returned bool reports whether more
+// lines were available than were returned.
+func decodeStreams(r io.Reader, maxLines int) ([]models.LogLine, bool, error) {
+	d := &streamDecoder{dec: json.NewDecoder(r), maxLines: maxLines, lines: []models.LogLine{}}
+	err := d.object(func(key string) error {
+		if key != "data" {
+			return d.skip()
+		}
+		return d.object(func(key string) error {
+			if key != "result" {
+				return d.skip()
+			}
+			return d.array(d.stream)
+		})
+	})
+	if err == errLineCap {
+		return d.lines, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return d.lines, false, nil
+}
+
+// errLineCap aborts decoding once the line cap is reached.
+var errLineCap = errors.New("line cap reached")
+
+type streamDecoder struct {
+	dec      *json.Decoder
+	maxLines int
+	lines    []models.LogLine
+}
+
+// stream decodes one {"stream": {...}, "values": [[ts, line], ...]} object.
+// Labels are applied after the object ends, since key order is not guaranteed.
+func (d *streamDecoder) stream() error {
+	first := len(d.lines)
+	var labels map[string]string
+
+	err := d.object(func(key string) error {
+		switch key {
+		case "stream":
+			return d.dec.Decode(&labels)
+		case "values":
+			return d.array(func() error {
+				if d.maxLines > 0 && len(d.lines) >= d.maxLines {
+					return errLineCap
+				}
+				var v [2]string
+				if err := d.dec.Decode(&v); err != nil {
+					return err
+				}
+				ts, _ := strconv.ParseInt(v[0], 10, 64)
+				d.lines = append(d.lines, models.LogLine{
+					Timestamp: time.Unix(0, ts).UTC(),
+					Message:   v[1],
+					Fields:    jsonFields(v[1]),
+				})
+				return nil
+			})
+		default:
+			return d.skip()
+		}
+	})
+
+	for i := first; i < len(d.lines); i++ {
+		d.lines[i].Labels = labels
+		d.lines[i].Level = labels["level"]
+	}
+	return err
+}
+
+// jsonFields returns the top-level fields of a JSON object log line. String
+// values are unquoted; other values keep their JSON encoding. It returns nil
+// when msg is not a JSON object.
+func jsonFields(msg string) map[string]string {
+	msg = strings.TrimSpace(msg)
+	if !strings.HasPrefix(msg, "{") {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if json.Unmarshal([]byte(msg), &raw) != nil || len(raw) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var str string
+		if json.Unmarshal(v, &str) == nil {
+			fields[k] = str
+		} else {
+			fields[k] = string(v)
+		}
+	}
+	return fields
+}
+
+// object iterates the keys of a JSON object, calling fn to consume each value.
+// A null value is treated as an empty object.
+func (d *streamDecoder) object(fn func(key string) error) error {
+	ok, err := d.open('{')
+	if err != nil || !ok {
+		return err
+	}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	_, err = d.dec.Token()
+	return err
+}
+
+// array iterates the elements of a JSON array, calling fn to consume each one.
+// A null value is treated as an empty array.
+func (d *streamDecoder) array(fn func() error) error {
+	ok, err := d.open('[')
+	if err != nil || !ok {
+		return err
+	}
+	for d.dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	_, err = d.dec.Token()
+	return err
+}
+
+// open consumes the opening delimiter of an object or array. It returns false
+// without error when the value is null.
+func (d *streamDecoder) open(want json.Delim) (bool, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return false, fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return true, nil
+}
+
+// skip consumes and discards the next value.
+func (d *streamDecoder) skip() error {
+	var discard json.RawMessage
+	return d.dec.Decode(&discard)
+}
+
+// decodeQueryResult decodes an instant query response holding a vector or a
+// scalar. Any other result type is reported as ErrLokiQueryError.
+func decodeQueryResult(r io.Reader) (QueryResult, error) {
+	var resp lokiInstantResponse
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return QueryResult{}, fmt.Errorf("decoding loki response: %w", err)
+	}
+
+	result := QueryResult{ResultType: resp.Data.ResultType}
+	switch resp.Data.ResultType {
+	case ResultTypeVector:
+		var samples []lokiVectorSample
+		if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+			return QueryResult{}, fmt.Errorf("decoding loki vector: %w", err)
+		}
+		result.Vector = make([]Sample, 0, len(samples))
+		for _, s := range samples {
+			sample, err := parseSample(s.Value)
+			if err != nil {
+				return QueryResult{}, err
+			}
+			sample.Labels = s.Metric
+			result.Vector = append(result.Vector, sample)
+		}
+	case ResultTypeScalar:
+		var value lokiSampleValue
+		if err := json.Unmarshal(resp.Data.Result, &value); err != nil {
+			return QueryResult{}, fmt.Errorf("decoding loki scalar: %w", err)
+		}
+		sample, err := parseSample(value)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		result.Scalar = sample
+	default:
+		return QueryResult{}, fmt.Errorf("%w: unsupported result type %q", ErrLokiQueryError, resp.Data.ResultType)
+	}
+	return result, nil
+}
+
+// parseSample converts a [<unix seconds>, "<value>"] pair. Timestamps carry
+// millisecond precision; values may be NaN or ±Inf.
+func parseSample(v lokiSampleValue) (Sample, error) {
+	var ts float64
+	if err := json.Unmarshal(v[0], &ts); err != nil {
+		return Sample{}, fmt.Errorf("decoding sample timestamp: %w", err)
+	}
+	var raw string
+	if err := json.Unmarshal(v[1], &raw); err != nil {
+		return Sample{}, fmt.Errorf("decoding sample value: %w", err)
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("decoding sample value: %w", err)
+	}
+	return Sample{Timestamp: time.UnixMilli(int64(math.Round(ts * 1000))).UTC(), Value: value}, nil
+}
@@ -3,11 +3,21 @@ package store
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kiranshivaraju/loghunter/internal/config"
 )
 
+// maxConnectBackoff caps the doubling delay between connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
+// Connect creates a connection pool and pings the database until it responds.
+// A failed ping is retried up to cfg.ConnectAttempts times in total, waiting
+// cfg.ConnectBackoff before the first retry and doubling the wait each time, so
+// the server survives a database that comes up slightly after it does.
+// Cancelling ctx aborts the wait.
 func Connect(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
 	poolCfg, err := pgxpool.ParseConfig(cfg.URL)
 	if err != nil {
@@ -22,10 +32,28 @@ func Connect(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, err
 		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("ping database: %w", err)
+	attempts := max(cfg.ConnectAttempts, 1)
+	backoff := cfg.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err = pool.Ping(ctx)
+		if err == nil {
+			return pool, nil
+		}
+		if attempt >= attempts {
+			break
+		}
+
+		slog.Warn("database not ready, retrying",
+			"attempt", attempt, "max_attempts", attempts, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, fmt.Errorf("ping database: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
 	}
 
-	return pool, nil
+	pool.Close()
+	return nil, fmt.Errorf("ping database after %d attempts: %w", attempts, err)
 }
@@ -1,13 +1,18 @@
 package store
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
 )
 
+// RunMigrations applies all pending migrations. It is safe to run on every
+// startup: an up-to-date schema is not an error.
 func RunMigrations(databaseURL, migrationsPath string) error {
 	m, err := migrate.New("file://"+migrationsPath, databaseURL)
 	if err != nil {
@@ -21,3 +26,27 @@ func RunMigrations(databaseURL, migrationsPath string) error {
 
 	return nil
 }
+
+// MigrationStatus is the schema version recorded by golang-migrate. Dirty means
+// a migration failed part-way and the schema needs manual repair.
+type MigrationStatus struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+// MigrationVersion reads the applied schema version from golang-migrate's
+// version table. A database that was never migrated, which has no version
+// table yet, reports version 0.
+func (s *PostgresStore) MigrationVersion(ctx context.Context) (*MigrationStatus, error) {
+	var m MigrationStatus
+	err := s.pool.QueryRow(ctx,
+		`SELECT version, dirty FROM schema_migrations LIMIT 1`,
+	).Scan(&m.Version, &m.Dirty)
+	if errors.Is(err, pgx.ErrNoRows) || isUndefinedTableError(err) {
+		return &MigrationStatus{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read migration version: %w", err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// ErrInvalidCursor is returned when a sync or page cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Pagination policy shared by every list path. Override at startup via
+// ConfigurePagination; the defaults match the documented API contract.
+var (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// ConfigurePagination sets the default and maximum page size used by
+// NormalizePagination. Non-positive values leave the current setting unchanged.
+func ConfigurePagination(defaultLimit, maxLimit int) {
+	if maxLimit > 0 {
+		maxPageLimit = maxLimit
+	}
+	if defaultLimit > 0 {
+		defaultPageLimit = defaultLimit
+	}
+	if defaultPageLimit > maxPageLimit {
+		defaultPageLimit = maxPageLimit
+	}
+}
+
+// NormalizePagination clamps page to >= 1 and limit to [1, max], substituting
+// the default limit for zero or negative values.
+func NormalizePagination(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return page, limit
+}
+
+// EncodeClusterCursor returns an opaque cursor positioned after the cluster
+// with the given updated_at and ID, for use with ClusterFilter.UpdatedSince.
+func EncodeClusterCursor(updatedAt time.Time, id uuid.UUID) string {
+	raw := updatedAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeClusterCursor reverses EncodeClusterCursor.
+func DecodeClusterCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	tsPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	ts, err := time.Parse(time.RFC3339Nano, tsPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return ts, id, nil
+}
+
+// ClusterPageCursor returns the ClusterFilter.Cursor that continues a listing
+// in the given sort order after c.
+func ClusterPageCursor(c *models.ErrorCluster, sort string) string {
+	if sort == ClusterSortFirstSeen {
+		return EncodeClusterCursor(c.FirstSeenAt, c.ID)
+	}
+	return EncodeClusterCursor(c.LastSeenAt, c.ID)
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+func TestNormalizePagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      int
+		limit     int
+		wantPage  int
+		wantLimit int
+	}{
+		{"zero inputs use defaults", 0, 0, 1, 20},
+		{"below min", -3, -5, 1, 20},
+		{"above max", 2, 500, 2, 100},
+		{"within bounds unchanged", 3, 50, 3, 50},
+		{"max is inclusive", 1, 100, 1, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, limit := NormalizePagination(tt.page, tt.limit)
+			if page != tt.wantPage || limit != tt.wantLimit {
+				t.Errorf("NormalizePagination(%d, %d) = (%d, %d), want (%d, %d)",
+					tt.page, tt.limit, page, limit, tt.wantPage, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestConfigurePagination(t *testing.T) {
+	origDefault, origMax := defaultPageLimit, maxPageLimit
+	t.Cleanup(func() { defaultPageLimit, maxPageLimit = origDefault, origMax })
+
+	ConfigurePagination(10, 50)
+
+	if _, limit := NormalizePagination(1, 0); limit != 10 {
+		t.Errorf("expected configured default 10, got %d", limit)
+	}
+	if _, limit := NormalizePagination(1, 51); limit != 50 {
+		t.Errorf("expected configured max 50, got %d", limit)
+	}
+
+	// Non-positive values leave the current setting unchanged.
+	ConfigurePagination(0, -1)
+	if _, limit := NormalizePagination(1, 0); limit != 10 {
+		t.Errorf("expected default to remain 10, got %d", limit)
+	}
+}
+
+func TestClusterCursorRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 2, 17, 10, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	gotTS, gotID, err := DecodeClusterCursor(EncodeClusterCursor(ts, id))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !gotTS.Equal(ts) || gotID != id {
+		t.Errorf("round trip = (%v, %v), want (%v, %v)", gotTS, gotID, ts, id)
+	}
+}
+
+func TestDecodeClusterCursor_Invalid(t *testing.T) {
+	for _, c := range []string{"", "not-base64!", "bm8tc2VwYXJhdG9y", "YmFkfHRpbWU"} {
+		if _, _, err := DecodeClusterCursor(c); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeClusterCursor(%q) error = %v, want ErrInvalidCursor", c, err)
+		}
+	}
+}
+
+func TestClusterPageCursor_FollowsSort(t *testing.T) {
+	c := &models.ErrorCluster{
+		ID:          uuid.New(),
+		FirstSeenAt: time.Date(2024, 2, 17, 9, 0, 0, 0, time.UTC),
+		LastSeenAt:  time.Date(2024, 2, 17, 10, 0, 0, 0, time.UTC),
+	}
+
+	for sort, want := range map[string]time.Time{
+		"":                   c.LastSeenAt,
+		ClusterSortLastSeen:  c.LastSeenAt,
+		ClusterSortFirstSeen: c.FirstSeenAt,
+	} {
+		ts, id, err := DecodeClusterCursor(ClusterPageCursor(c, sort))
+		if err != nil {
+			t.Fatalf("sort %q: unexpected error: %v", sort, err)
+		}
+		if !ts.Equal(want) || id != c.ID {
+			t.Errorf("sort %q: cursor = (%v, %v), want (%v, %v)", sort, ts, id, want, c.ID)
+		}
+	}
+}
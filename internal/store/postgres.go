@@ -2,9 +2,12 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,8 +20,17 @@ import (
 // PostgresStore implements the Store interface using pgx/v5.
 type PostgresStore struct {
 	pool *pgxpool.Pool
+
+	// The default tenant is memoized for defaultTenantTTL; tenants rarely
+	// change and it is looked up on bootstrap and tenant-less paths.
+	tenantMu        sync.Mutex
+	defaultTenant   *models.Tenant
+	defaultTenantAt time.Time
 }
 
+// defaultTenantTTL is how long GetDefaultTenant serves a memoized tenant.
+const defaultTenantTTL = time.Minute
+
 // NewPostgresStore creates a new PostgresStore.
 func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
 	return &PostgresStore{pool: pool}
@@ -31,7 +43,17 @@ func (s *PostgresStore) Ping(ctx context.Context) error {
 
 // --- Tenants ---
 
+// GetDefaultTenant returns the tenant named "default". The result is memoized
+// for defaultTenantTTL; callers get their own copy.
 func (s *PostgresStore) GetDefaultTenant(ctx context.Context) (*models.Tenant, error) {
+	s.tenantMu.Lock()
+	if s.defaultTenant != nil && time.Since(s.defaultTenantAt) < defaultTenantTTL {
+		t := *s.defaultTenant
+		s.tenantMu.Unlock()
+		return &t, nil
+	}
+	s.tenantMu.Unlock()
+
 	var t models.Tenant
 	err := s.pool.QueryRow(ctx,
 		`SELECT id, name, loki_org_id, created_at, updated_at FROM tenants WHERE name = 'default' LIMIT 1`,
@@ -42,6 +64,26 @@ func (s *PostgresStore) GetDefaultTenant(ctx context.Context) (*models.Tenant, e
 	if err != nil {
 		return nil, fmt.Errorf("get default tenant: %w", err)
 	}
+
+	cached := t
+	s.tenantMu.Lock()
+	s.defaultTenant, s.defaultTenantAt = &cached, time.Now()
+	s.tenantMu.Unlock()
+	return &t, nil
+}
+
+// GetTenant returns the tenant with the given ID.
+func (s *PostgresStore) GetTenant(ctx context.Context, id uuid.UUID) (*models.Tenant, error) {
+	var t models.Tenant
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, name, loki_org_id, created_at, updated_at FROM tenants WHERE id = $1`, id,
+	).Scan(&t.ID, &t.Name, &t.LokiOrgID, &t.CreatedAt, &t.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
 	return &t, nil
 }
 
@@ -68,6 +110,27 @@ func (s *PostgresStore) GetAPIKeyByPrefix(ctx context.Context, prefix string) ([
 	return keys, rows.Err()
 }
 
+// GetTenantByAPIKeyPrefix returns the tenant owning the key with the given prefix,
+// without needing the raw key. Revoked keys are included so audit tooling can
+// attribute them; when a prefix matches several keys, active and newer keys win.
+func (s *PostgresStore) GetTenantByAPIKeyPrefix(ctx context.Context, prefix string) (*models.Tenant, error) {
+	var t models.Tenant
+	err := s.pool.QueryRow(ctx,
+		`SELECT t.id, t.name, t.loki_org_id, t.created_at, t.updated_at
+		 FROM api_keys k JOIN tenants t ON t.id = k.tenant_id
+		 WHERE k.key_prefix = $1
+		 ORDER BY (k.deleted_at IS NULL) DESC, k.created_at DESC
+		 LIMIT 1`, prefix,
+	).Scan(&t.ID, &t.Name, &t.LokiOrgID, &t.CreatedAt, &t.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tenant by api key prefix: %w", err)
+	}
+	return &t, nil
+}
+
 func (s *PostgresStore) UpdateAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error {
 	_, err := s.pool.Exec(ctx,
 		`UPDATE api_keys SET last_used_at = NOW(), updated_at = NOW() WHERE id = $1`, id)
@@ -91,10 +154,18 @@ func (s *PostgresStore) CreateAPIKey(ctx context.Context, key *models.APIKey) er
 	return nil
 }
 
-func (s *PostgresStore) ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]*models.APIKey, error) {
+// apiKeyListWhere returns the WHERE clause for a tenant's key listing; $1 is the tenant.
+func apiKeyListWhere(opts []APIKeyListOption) string {
+	if ResolveAPIKeyListOptions(opts...).IncludeRevoked {
+		return "tenant_id = $1"
+	}
+	return "tenant_id = $1 AND deleted_at IS NULL"
+}
+
+func (s *PostgresStore) ListAPIKeys(ctx context.Context, tenantID uuid.UUID, opts ...APIKeyListOption) ([]*models.APIKey, error) {
 	rows, err := s.pool.Query(ctx,
 		`SELECT id, tenant_id, name, key_hash, key_prefix, scopes, last_used_at, deleted_at, created_at, updated_at
-		 FROM api_keys WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`, tenantID)
+		 FROM api_keys WHERE `+apiKeyListWhere(opts)+` ORDER BY created_at DESC`, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("list api keys: %w", err)
 	}
@@ -112,6 +183,41 @@ func (s *PostgresStore) ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]
 	return keys, rows.Err()
 }
 
+// ListAPIKeysPaged returns one page of a tenant's keys, newest first, together
+// with the total number of matching keys. Only active keys are listed unless
+// IncludeRevoked is passed.
+func (s *PostgresStore) ListAPIKeysPaged(ctx context.Context, tenantID uuid.UUID, page, limit int, opts ...APIKeyListOption) ([]*models.APIKey, int, error) {
+	page, limit = NormalizePagination(page, limit)
+	where := apiKeyListWhere(opts)
+
+	var total int
+	if err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM api_keys WHERE `+where, tenantID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count api keys: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, tenant_id, name, key_hash, key_prefix, scopes, last_used_at, deleted_at, created_at, updated_at
+		 FROM api_keys WHERE `+where+`
+		 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, tenantID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.TenantID, &k.Name, &k.KeyHash, &k.KeyPrefix, &k.Scopes,
+			&k.LastUsedAt, &k.DeletedAt, &k.CreatedAt, &k.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, &k)
+	}
+	return keys, total, rows.Err()
+}
+
 func (s *PostgresStore) RevokeAPIKey(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error {
 	tag, err := s.pool.Exec(ctx,
 		`UPDATE api_keys SET deleted_at = NOW(), updated_at = NOW()
@@ -125,8 +231,48 @@ func (s *PostgresStore) RevokeAPIKey(ctx context.Context, id uuid.UUID, tenantID
 	return nil
 }
 
+// RevokeAllAPIKeys soft-deletes every active key of the tenant except those in
+// keep, and returns how many were revoked.
+func (s *PostgresStore) RevokeAllAPIKeys(ctx context.Context, tenantID uuid.UUID, keep ...uuid.UUID) (int, error) {
+	if keep == nil {
+		keep = []uuid.UUID{}
+	}
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE api_keys SET deleted_at = NOW(), updated_at = NOW()
+		 WHERE tenant_id = $1 AND deleted_at IS NULL AND NOT (id = ANY($2))`, tenantID, keep)
+	if err != nil {
+		return 0, fmt.Errorf("revoke all api keys: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
 // --- Error Clusters ---
 
+// errorClusterColumns is the column list scanned by errorClusterDest.
+const errorClusterColumns = `id, tenant_id, service, namespace, fingerprint, level, first_seen_at, last_seen_at,
+	count, sample_message, status, auto_resolved, resolved_at, reopened_at, pinned, created_at, updated_at`
+
+// lightweightClusterColumns is errorClusterColumns with the sample message
+// truncated in SQL, so Lightweight listings do not fetch it in full.
+var lightweightClusterColumns = strings.Replace(errorClusterColumns, "sample_message",
+	fmt.Sprintf("LEFT(sample_message, %d) AS sample_message", SampleMessagePreviewChars), 1)
+
+// errorClusterDest returns scan destinations for errorClusterColumns.
+func errorClusterDest(c *models.ErrorCluster) []any {
+	return []any{&c.ID, &c.TenantID, &c.Service, &c.Namespace, &c.Fingerprint,
+		&c.Level, &c.FirstSeenAt, &c.LastSeenAt, &c.Count, &c.SampleMessage,
+		&c.Status, &c.AutoResolved, &c.ResolvedAt, &c.ReopenedAt, &c.Pinned, &c.CreatedAt, &c.UpdatedAt}
+}
+
+// reopens is true in UpsertErrorCluster's conflict branch when the existing
+// cluster is resolved ($14) and the incoming occurrence is newer than the
+// resolution.
+const reopens = `(error_clusters.status = $14 AND
+		   (error_clusters.resolved_at IS NULL OR EXCLUDED.last_seen_at > error_clusters.resolved_at))`
+
+// UpsertErrorCluster inserts cluster, or merges it into the existing cluster
+// with the same natural key. A resolved cluster that fires again after it was
+// resolved is reopened: its status goes back to open and reopened_at is set.
 func (s *PostgresStore) UpsertErrorCluster(ctx context.Context, cluster *models.ErrorCluster) (*models.ErrorCluster, error) {
 	var result models.ErrorCluster
 	err := s.pool.QueryRow(ctx,
@@ -135,22 +281,24 @@ func (s *PostgresStore) UpsertErrorCluster(ctx context.Context, cluster *models.
 		 ON CONFLICT (tenant_id, service, namespace, fingerprint) DO UPDATE SET
 		   count = error_clusters.count + EXCLUDED.count,
 		   last_seen_at = GREATEST(error_clusters.last_seen_at, EXCLUDED.last_seen_at),
+		   status = CASE WHEN `+reopens+` THEN $13 ELSE error_clusters.status END,
+		   auto_resolved = CASE WHEN `+reopens+` THEN FALSE ELSE error_clusters.auto_resolved END,
+		   resolved_at = CASE WHEN `+reopens+` THEN NULL ELSE error_clusters.resolved_at END,
+		   reopened_at = CASE WHEN `+reopens+` THEN NOW() ELSE error_clusters.reopened_at END,
 		   updated_at = NOW()
-		 RETURNING id, tenant_id, service, namespace, fingerprint, level, first_seen_at, last_seen_at, count, sample_message, created_at, updated_at`,
+		 RETURNING `+errorClusterColumns,
 		cluster.ID, cluster.TenantID, cluster.Service, cluster.Namespace, cluster.Fingerprint,
 		cluster.Level, cluster.FirstSeenAt, cluster.LastSeenAt, cluster.Count, cluster.SampleMessage,
-		cluster.CreatedAt, cluster.UpdatedAt,
-	).Scan(&result.ID, &result.TenantID, &result.Service, &result.Namespace, &result.Fingerprint,
-		&result.Level, &result.FirstSeenAt, &result.LastSeenAt, &result.Count, &result.SampleMessage,
-		&result.CreatedAt, &result.UpdatedAt)
+		cluster.CreatedAt, cluster.UpdatedAt, models.ClusterStatusOpen, models.ClusterStatusResolved,
+	).Scan(errorClusterDest(&result)...)
 	if err != nil {
 		return nil, fmt.Errorf("upsert error cluster: %w", err)
 	}
 	return &result, nil
 }
 
-func (s *PostgresStore) ListErrorClusters(ctx context.Context, filter ClusterFilter) ([]*models.ErrorCluster, int, error) {
-	// Build WHERE clause dynamically
+// clusterWhere builds the WHERE clause and its arguments for filter.
+func clusterWhere(filter ClusterFilter) (string, []any) {
 	conditions := []string{"tenant_id = $1"}
 	args := []any{filter.TenantID}
 	argIdx := 2
@@ -170,41 +318,103 @@ func (s *PostgresStore) ListErrorClusters(ctx context.Context, filter ClusterFil
 		args = append(args, filter.Level)
 		argIdx++
 	}
+	if len(filter.Levels) > 0 {
+		conditions = append(conditions, fmt.Sprintf("level = ANY($%d)", argIdx))
+		args = append(args, filter.Levels)
+		argIdx++
+	}
 	if !filter.Since.IsZero() {
 		conditions = append(conditions, fmt.Sprintf("last_seen_at >= $%d", argIdx))
 		args = append(args, filter.Since)
 		argIdx++
 	}
+	if !filter.SeenBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("last_seen_at < $%d", argIdx))
+		args = append(args, filter.SeenBefore)
+		argIdx++
+	}
+	syncMode := !filter.UpdatedSince.IsZero()
+	if syncMode {
+		if filter.UpdatedAfterID != uuid.Nil {
+			conditions = append(conditions, fmt.Sprintf("(updated_at, id) > ($%d, $%d)", argIdx, argIdx+1))
+			args = append(args, filter.UpdatedSince, filter.UpdatedAfterID)
+			argIdx += 2
+		} else {
+			conditions = append(conditions, fmt.Sprintf("updated_at > $%d", argIdx))
+			args = append(args, filter.UpdatedSince)
+			argIdx++
+		}
+	}
 
-	where := strings.Join(conditions, " AND ")
+	return strings.Join(conditions, " AND "), args
+}
+
+// CountErrorClusters returns the number of clusters matching filter, ignoring
+// its pagination fields.
+func (s *PostgresStore) CountErrorClusters(ctx context.Context, filter ClusterFilter) (int, error) {
+	where, args := clusterWhere(filter)
+	var total int
+	if err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM error_clusters WHERE "+where, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count error clusters: %w", err)
+	}
+	return total, nil
+}
+
+func (s *PostgresStore) ListErrorClusters(ctx context.Context, filter ClusterFilter) ([]*models.ErrorCluster, int, error) {
+	where, args := clusterWhere(filter)
+	argIdx := len(args) + 1
 
-	// Count query
 	var total int
-	countQuery := "SELECT COUNT(*) FROM error_clusters WHERE " + where
-	if err := s.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("count error clusters: %w", err)
+	if !filter.SkipTotal {
+		var err error
+		if total, err = s.CountErrorClusters(ctx, filter); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	// Normalize pagination
-	limit := filter.Limit
-	if limit <= 0 {
-		limit = 20
+	page, limit := NormalizePagination(filter.Page, filter.Limit)
+	offset := (page - 1) * limit
+
+	// Sync mode pages by cursor, so it always starts at the first matching row.
+	sortColumn := "last_seen_at"
+	if filter.Sort == ClusterSortFirstSeen {
+		sortColumn = "first_seen_at"
+	}
+	// id breaks ties so the first keyset page ends where the cursor resumes.
+	orderBy := sortColumn + " DESC, id DESC"
+	if !filter.UpdatedSince.IsZero() {
+		orderBy = "updated_at ASC, id ASC"
+		offset = 0
+	} else if filter.Cursor != "" {
+		// Keyset pagination: rows inserted ahead of the cursor cannot shift
+		// later pages the way they shift an OFFSET.
+		ts, id, err := DecodeClusterCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		where += fmt.Sprintf(" AND (%s, id) < ($%d, $%d)", sortColumn, argIdx, argIdx+1)
+		args = append(args, ts, id)
+		argIdx += 2
+		offset = 0
 	}
-	if limit > 100 {
-		limit = 100
+
+	// Without a count, fetch one extra row to tell whether another page exists.
+	fetch := limit
+	if filter.SkipTotal {
+		fetch++
 	}
-	page := filter.Page
-	if page <= 0 {
-		page = 1
+
+	columns := errorClusterColumns
+	if filter.Lightweight {
+		columns = lightweightClusterColumns
 	}
-	offset := (page - 1) * limit
 
 	// Data query
 	dataQuery := fmt.Sprintf(
-		`SELECT id, tenant_id, service, namespace, fingerprint, level, first_seen_at, last_seen_at, count, sample_message, created_at, updated_at
-		 FROM error_clusters WHERE %s ORDER BY last_seen_at DESC LIMIT $%d OFFSET $%d`,
-		where, argIdx, argIdx+1)
-	args = append(args, limit, offset)
+		`SELECT %s FROM error_clusters WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		columns, where, orderBy, argIdx, argIdx+1)
+	args = append(args, fetch, offset)
 
 	rows, err := s.pool.Query(ctx, dataQuery, args...)
 	if err != nil {
@@ -215,24 +425,28 @@ func (s *PostgresStore) ListErrorClusters(ctx context.Context, filter ClusterFil
 	var clusters []*models.ErrorCluster
 	for rows.Next() {
 		var c models.ErrorCluster
-		if err := rows.Scan(&c.ID, &c.TenantID, &c.Service, &c.Namespace, &c.Fingerprint,
-			&c.Level, &c.FirstSeenAt, &c.LastSeenAt, &c.Count, &c.SampleMessage,
-			&c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(errorClusterDest(&c)...); err != nil {
 			return nil, 0, fmt.Errorf("scan error cluster: %w", err)
 		}
 		clusters = append(clusters, &c)
 	}
-	return clusters, total, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if filter.SkipTotal {
+		total = offset + len(clusters)
+		if len(clusters) > limit {
+			clusters = clusters[:limit]
+		}
+	}
+	return clusters, total, nil
 }
 
 func (s *PostgresStore) GetErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error) {
 	var c models.ErrorCluster
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, tenant_id, service, namespace, fingerprint, level, first_seen_at, last_seen_at, count, sample_message, created_at, updated_at
-		 FROM error_clusters WHERE id = $1 AND tenant_id = $2`, id, tenantID,
-	).Scan(&c.ID, &c.TenantID, &c.Service, &c.Namespace, &c.Fingerprint,
-		&c.Level, &c.FirstSeenAt, &c.LastSeenAt, &c.Count, &c.SampleMessage,
-		&c.CreatedAt, &c.UpdatedAt)
+		`SELECT `+errorClusterColumns+` FROM error_clusters WHERE id = $1 AND tenant_id = $2`, id, tenantID,
+	).Scan(errorClusterDest(&c)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
@@ -242,14 +456,45 @@ func (s *PostgresStore) GetErrorCluster(ctx context.Context, id uuid.UUID, tenan
 	return &c, nil
 }
 
+// ErrorClusterExists reports whether a cluster with the given ID exists for any
+// tenant. It backs the optional 403 response for cross-tenant lookups and must
+// never be used to return another tenant's data.
+func (s *PostgresStore) ErrorClusterExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM error_clusters WHERE id = $1)`, id,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("check error cluster exists: %w", err)
+	}
+	return exists, nil
+}
+
+// GetErrorClusterByFingerprint looks up a cluster by its natural key, so callers
+// can tell a newly-seen fingerprint from a recurring one.
+func (s *PostgresStore) GetErrorClusterByFingerprint(ctx context.Context, tenantID uuid.UUID, service, namespace, fingerprint string) (*models.ErrorCluster, error) {
+	var c models.ErrorCluster
+	err := s.pool.QueryRow(ctx,
+		`SELECT `+errorClusterColumns+` FROM error_clusters
+		 WHERE tenant_id = $1 AND service = $2 AND namespace = $3 AND fingerprint = $4`,
+		tenantID, service, namespace, fingerprint,
+	).Scan(errorClusterDest(&c)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get error cluster by fingerprint: %w", err)
+	}
+	return &c, nil
+}
+
 func (s *PostgresStore) GetClustersByFingerprints(ctx context.Context, tenantID uuid.UUID, fingerprints []string) ([]*models.ErrorCluster, error) {
 	if len(fingerprints) == 0 {
 		return []*models.ErrorCluster{}, nil
 	}
 
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, tenant_id, service, namespace, fingerprint, level, first_seen_at, last_seen_at, count, sample_message, created_at, updated_at
-		 FROM error_clusters WHERE tenant_id = $1 AND fingerprint = ANY($2)`, tenantID, fingerprints)
+		`SELECT `+errorClusterColumns+` FROM error_clusters WHERE tenant_id = $1 AND fingerprint = ANY($2)`, tenantID, fingerprints)
 	if err != nil {
 		return nil, fmt.Errorf("get clusters by fingerprints: %w", err)
 	}
@@ -258,9 +503,7 @@ func (s *PostgresStore) GetClustersByFingerprints(ctx context.Context, tenantID
 	var clusters []*models.ErrorCluster
 	for rows.Next() {
 		var c models.ErrorCluster
-		if err := rows.Scan(&c.ID, &c.TenantID, &c.Service, &c.Namespace, &c.Fingerprint,
-			&c.Level, &c.FirstSeenAt, &c.LastSeenAt, &c.Count, &c.SampleMessage,
-			&c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(errorClusterDest(&c)...); err != nil {
 			return nil, fmt.Errorf("scan error cluster: %w", err)
 		}
 		clusters = append(clusters, &c)
@@ -268,60 +511,311 @@ func (s *PostgresStore) GetClustersByFingerprints(ctx context.Context, tenantID
 	return clusters, rows.Err()
 }
 
+// TouchErrorCluster records that a cluster was seen at seenAt without counting
+// a new occurrence: last_seen_at only moves forward and count is untouched.
+// Returns ErrNotFound if the cluster does not exist for the tenant.
+func (s *PostgresStore) TouchErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, seenAt time.Time) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE error_clusters
+		 SET last_seen_at = GREATEST(last_seen_at, $3), updated_at = NOW()
+		 WHERE id = $1 AND tenant_id = $2`, id, tenantID, seenAt)
+	if err != nil {
+		return fmt.Errorf("touch error cluster: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetClusterPinned pins or unpins a cluster. Returns ErrNotFound if the
+// cluster does not exist for the tenant.
+func (s *PostgresStore) SetClusterPinned(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, pinned bool) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE error_clusters SET pinned = $3, updated_at = NOW()
+		 WHERE id = $1 AND tenant_id = $2`, id, tenantID, pinned)
+	if err != nil {
+		return fmt.Errorf("set cluster pinned: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteErrorCluster removes a tenant's cluster and its analysis results in
+// one transaction. The cluster's jobs are kept as history with cluster_id
+// cleared.
+func (s *PostgresStore) DeleteErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("delete error cluster: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM analysis_results WHERE cluster_id = $1 AND tenant_id = $2`, id, tenantID); err != nil {
+		return fmt.Errorf("delete cluster analysis results: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE jobs SET cluster_id = NULL WHERE cluster_id = $1 AND tenant_id = $2`, id, tenantID); err != nil {
+		return fmt.Errorf("unlink cluster jobs: %w", err)
+	}
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM error_clusters WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete error cluster: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("delete error cluster: %w", err)
+	}
+	return nil
+}
+
+// AutoResolveClusters marks every open cluster last seen before staleBefore as
+// resolved with auto_resolved set, across all tenants. Acknowledged and pinned
+// clusters are left alone. Returns the number of clusters resolved per tenant;
+// tenants with none resolved are absent.
+func (s *PostgresStore) AutoResolveClusters(ctx context.Context, staleBefore time.Time) (map[uuid.UUID]int, error) {
+	rows, err := s.pool.Query(ctx,
+		`WITH resolved AS (
+		   UPDATE error_clusters
+		   SET status = $1, auto_resolved = TRUE, resolved_at = NOW(), updated_at = NOW()
+		   WHERE status = $2 AND last_seen_at < $3 AND NOT pinned
+		   RETURNING tenant_id
+		 )
+		 SELECT tenant_id, COUNT(*) FROM resolved GROUP BY tenant_id`,
+		models.ClusterStatusResolved, models.ClusterStatusOpen, staleBefore)
+	if err != nil {
+		return nil, fmt.Errorf("auto-resolve error clusters: %w", err)
+	}
+	defer rows.Close()
+
+	resolved := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var tenantID uuid.UUID
+		var n int
+		if err := rows.Scan(&tenantID, &n); err != nil {
+			return nil, fmt.Errorf("scan auto-resolved tenant: %w", err)
+		}
+		resolved[tenantID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("auto-resolve error clusters: %w", err)
+	}
+	return resolved, nil
+}
+
+// --- Cluster Suppressions ---
+
+func (s *PostgresStore) CreateClusterSuppression(ctx context.Context, sup *models.ClusterSuppression) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO cluster_suppressions (id, tenant_id, fingerprint, pattern, reason, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		sup.ID, sup.TenantID, sup.Fingerprint, sup.Pattern, sup.Reason, sup.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create cluster suppression: %w", err)
+	}
+	return nil
+}
+
+// ListClusterSuppressions returns all of a tenant's suppressions, oldest first.
+func (s *PostgresStore) ListClusterSuppressions(ctx context.Context, tenantID uuid.UUID) ([]*models.ClusterSuppression, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, tenant_id, fingerprint, pattern, reason, created_at
+		 FROM cluster_suppressions WHERE tenant_id = $1
+		 ORDER BY created_at ASC, id ASC`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list cluster suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	sups := []*models.ClusterSuppression{}
+	for rows.Next() {
+		var sup models.ClusterSuppression
+		if err := rows.Scan(&sup.ID, &sup.TenantID, &sup.Fingerprint, &sup.Pattern, &sup.Reason, &sup.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan cluster suppression: %w", err)
+		}
+		sups = append(sups, &sup)
+	}
+	return sups, rows.Err()
+}
+
+func (s *PostgresStore) DeleteClusterSuppression(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM cluster_suppressions WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete cluster suppression: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // --- Analysis Results ---
 
+// CreateAnalysisResult stores the result for a job. Results are unique per job:
+// re-analysis replaces the existing row's content, keeping its original ID,
+// and result.ID is updated to the stored row's ID.
 func (s *PostgresStore) CreateAnalysisResult(ctx context.Context, result *models.AnalysisResult) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO analysis_results (id, cluster_id, tenant_id, job_id, provider, model, root_cause, confidence, summary, suggested_action, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO analysis_results (id, cluster_id, tenant_id, job_id, provider, model, root_cause, confidence, confidence_clamped, summary, suggested_action, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 ON CONFLICT (job_id) DO UPDATE SET
+		   cluster_id = EXCLUDED.cluster_id,
+		   provider = EXCLUDED.provider,
+		   model = EXCLUDED.model,
+		   root_cause = EXCLUDED.root_cause,
+		   confidence = EXCLUDED.confidence,
+		   confidence_clamped = EXCLUDED.confidence_clamped,
+		   summary = EXCLUDED.summary,
+		   suggested_action = EXCLUDED.suggested_action,
+		   created_at = EXCLUDED.created_at
+		 WHERE analysis_results.tenant_id = EXCLUDED.tenant_id
+		 RETURNING id`,
 		result.ID, result.ClusterID, result.TenantID, result.JobID, result.Provider,
-		result.Model, result.RootCause, result.Confidence, result.Summary,
-		result.SuggestedAction, result.CreatedAt)
+		result.Model, result.RootCause, result.Confidence, result.ConfidenceClamped, result.Summary,
+		result.SuggestedAction, result.CreatedAt,
+	).Scan(&result.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("create analysis result: job belongs to another tenant: %w", ErrDuplicateKey)
+	}
 	if err != nil {
 		return fmt.Errorf("create analysis result: %w", err)
 	}
 	return nil
 }
 
-func (s *PostgresStore) GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID) (*models.AnalysisResult, error) {
-	var r models.AnalysisResult
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, cluster_id, tenant_id, job_id, provider, model, root_cause, confidence, summary, suggested_action, created_at
-		 FROM analysis_results WHERE job_id = $1`, jobID,
-	).Scan(&r.ID, &r.ClusterID, &r.TenantID, &r.JobID, &r.Provider, &r.Model,
-		&r.RootCause, &r.Confidence, &r.Summary, &r.SuggestedAction, &r.CreatedAt)
+// GetAnalysisResultByID returns the result with the given ID, or ErrNotFound if
+// it does not exist or belongs to another tenant.
+func (s *PostgresStore) GetAnalysisResultByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
+	r, err := scanAnalysisResult(s.pool.QueryRow(ctx,
+		`SELECT `+analysisResultColumns+` FROM analysis_results WHERE id = $1 AND tenant_id = $2`, id, tenantID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get analysis result: %w", err)
+	}
+	return r, nil
+}
+
+// GetAnalysisResultByJobID returns the result of the given job, or ErrNotFound
+// if there is none or it belongs to another tenant.
+func (s *PostgresStore) GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
+	r, err := scanAnalysisResult(s.pool.QueryRow(ctx,
+		`SELECT `+analysisResultColumns+` FROM analysis_results WHERE job_id = $1 AND tenant_id = $2`, jobID, tenantID))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get analysis result by job: %w", err)
 	}
-	return &r, nil
+	return r, nil
 }
 
-func (s *PostgresStore) GetAnalysisResultByClusterID(ctx context.Context, clusterID uuid.UUID) (*models.AnalysisResult, error) {
-	var r models.AnalysisResult
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, cluster_id, tenant_id, job_id, provider, model, root_cause, confidence, summary, suggested_action, created_at
-		 FROM analysis_results WHERE cluster_id = $1 ORDER BY created_at DESC LIMIT 1`, clusterID,
-	).Scan(&r.ID, &r.ClusterID, &r.TenantID, &r.JobID, &r.Provider, &r.Model,
-		&r.RootCause, &r.Confidence, &r.Summary, &r.SuggestedAction, &r.CreatedAt)
+// GetAnalysisResultByClusterID returns the cluster's most recent result, or
+// ErrNotFound if there is none or it belongs to another tenant.
+func (s *PostgresStore) GetAnalysisResultByClusterID(ctx context.Context, clusterID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error) {
+	r, err := scanAnalysisResult(s.pool.QueryRow(ctx,
+		`SELECT `+analysisResultColumns+` FROM analysis_results WHERE cluster_id = $1 AND tenant_id = $2 ORDER BY created_at DESC LIMIT 1`, clusterID, tenantID))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get analysis result by cluster: %w", err)
 	}
-	return &r, nil
+	return r, nil
+}
+
+// DeleteOrphanedAnalysisResults deletes results whose cluster no longer exists,
+// across all tenants. The foreign key prevents new orphans; this cleans up rows
+// left behind by clusters deleted before it was enforced. Returns the number
+// of results deleted.
+func (s *PostgresStore) DeleteOrphanedAnalysisResults(ctx context.Context) (int, error) {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM analysis_results r
+		 WHERE NOT EXISTS (SELECT 1 FROM error_clusters c WHERE c.id = r.cluster_id)`)
+	if err != nil {
+		return 0, fmt.Errorf("delete orphaned analysis results: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// SaveAnalysisContext stores the context log sample for a job, replacing any
+// sample saved by an earlier run of the same job.
+func (s *PostgresStore) SaveAnalysisContext(ctx context.Context, ac *models.AnalysisContext) error {
+	lines, err := json.Marshal(ac.Lines)
+	if err != nil {
+		return fmt.Errorf("save analysis context: marshal lines: %w", err)
+	}
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO analysis_context (job_id, tenant_id, lines, total_lines, created_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (job_id) DO UPDATE SET
+		   lines = EXCLUDED.lines,
+		   total_lines = EXCLUDED.total_lines,
+		   created_at = EXCLUDED.created_at
+		 WHERE analysis_context.tenant_id = EXCLUDED.tenant_id`,
+		ac.JobID, ac.TenantID, lines, ac.TotalLines, ac.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save analysis context: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("save analysis context: job belongs to another tenant: %w", ErrDuplicateKey)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetAnalysisContext(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisContext, error) {
+	var ac models.AnalysisContext
+	var lines []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT job_id, tenant_id, lines, total_lines, created_at
+		 FROM analysis_context WHERE job_id = $1 AND tenant_id = $2`, jobID, tenantID,
+	).Scan(&ac.JobID, &ac.TenantID, &lines, &ac.TotalLines, &ac.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get analysis context: %w", err)
+	}
+	if err := json.Unmarshal(lines, &ac.Lines); err != nil {
+		return nil, fmt.Errorf("get analysis context: unmarshal lines: %w", err)
+	}
+	return &ac, nil
 }
 
 // --- Jobs ---
 
+// jobColumns is the column list scanned by jobDest.
+const jobColumns = `id, tenant_id, type, status, cluster_id, error_message, error_code, replayed_from,
+	started_at, completed_at, created_at, updated_at, metadata`
+
+// jobDest returns scan destinations for jobColumns.
+func jobDest(j *models.Job) []any {
+	return []any{&j.ID, &j.TenantID, &j.Type, &j.Status, &j.ClusterID, &j.ErrorMessage, &j.ErrorCode,
+		&j.ReplayedFrom, &j.StartedAt, &j.CompletedAt, &j.CreatedAt, &j.UpdatedAt, &j.Metadata}
+}
+
+// CreateJob stores a new job with its type and metadata. An empty Type is
+// stored as models.JobTypeAnalysis.
 func (s *PostgresStore) CreateJob(ctx context.Context, job *models.Job) error {
+	if job.Type == "" {
+		job.Type = models.JobTypeAnalysis
+	}
+	metadata := job.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
 	_, err := s.pool.Exec(ctx,
-		`INSERT INTO jobs (id, tenant_id, type, status, cluster_id, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		job.ID, job.TenantID, job.Type, job.Status, job.ClusterID, job.CreatedAt, job.UpdatedAt)
+		`INSERT INTO jobs (id, tenant_id, type, status, cluster_id, replayed_from, metadata, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		job.ID, job.TenantID, job.Type, job.Status, job.ClusterID, job.ReplayedFrom, metadata, job.CreatedAt, job.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("create job: %w", err)
 	}
@@ -331,10 +825,8 @@ func (s *PostgresStore) CreateJob(ctx context.Context, job *models.Job) error {
 func (s *PostgresStore) GetJob(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.Job, error) {
 	var j models.Job
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, tenant_id, type, status, cluster_id, error_message, started_at, completed_at, created_at, updated_at
-		 FROM jobs WHERE id = $1 AND tenant_id = $2`, id, tenantID,
-	).Scan(&j.ID, &j.TenantID, &j.Type, &j.Status, &j.ClusterID, &j.ErrorMessage,
-		&j.StartedAt, &j.CompletedAt, &j.CreatedAt, &j.UpdatedAt)
+		`SELECT `+jobColumns+` FROM jobs WHERE id = $1 AND tenant_id = $2`, id, tenantID,
+	).Scan(jobDest(&j)...)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
@@ -344,38 +836,74 @@ func (s *PostgresStore) GetJob(ctx context.Context, id uuid.UUID, tenantID uuid.
 	return &j, nil
 }
 
-var validTransitions = map[string][]string{
-	"pending": {"running"},
-	"running": {"completed", "failed"},
+// JobExists reports whether a job with the given ID exists for any tenant. Like
+// ErrorClusterExists it only backs the optional 403 cross-tenant response.
+func (s *PostgresStore) JobExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)`, id,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("check job exists: %w", err)
+	}
+	return exists, nil
 }
 
-func (s *PostgresStore) UpdateJobStatus(ctx context.Context, id uuid.UUID, status string, opts ...JobUpdateOption) error {
-	params := &jobUpdateParams{}
-	for _, opt := range opts {
-		opt(params)
+// GetJobByID retrieves a job by ID regardless of tenant. It is for background
+// workers that operate across tenants; request handlers must use GetJob.
+func (s *PostgresStore) GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	var j models.Job
+	err := s.pool.QueryRow(ctx,
+		`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id,
+	).Scan(jobDest(&j)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get job by id: %w", err)
 	}
+	return &j, nil
+}
 
-	// Fetch current status
-	var currentStatus string
-	err := s.pool.QueryRow(ctx, `SELECT status FROM jobs WHERE id = $1`, id).Scan(&currentStatus)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return ErrNotFound
+// GetJobsByIDs returns the tenant's jobs among ids in one query. IDs that do not
+// exist or belong to another tenant are omitted; order is unspecified.
+func (s *PostgresStore) GetJobsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*models.Job, error) {
+	if len(ids) == 0 {
+		return []*models.Job{}, nil
 	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+jobColumns+` FROM jobs WHERE tenant_id = $1 AND id = ANY($2)`, tenantID, ids)
 	if err != nil {
-		return fmt.Errorf("get job status: %w", err)
+		return nil, fmt.Errorf("get jobs by ids: %w", err)
 	}
+	defer rows.Close()
 
-	// Validate transition
-	allowed := validTransitions[currentStatus]
-	valid := false
-	for _, a := range allowed {
-		if a == status {
-			valid = true
-			break
+	jobs := []*models.Job{}
+	for rows.Next() {
+		var j models.Job
+		if err := rows.Scan(jobDest(&j)...); err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
 		}
+		jobs = append(jobs, &j)
+	}
+	return jobs, rows.Err()
+}
+
+var validTransitions = map[string][]string{
+	"pending": {"running", "cancelled"},
+	"running": {"completed", "failed", "cancelled"},
+}
+
+func (s *PostgresStore) UpdateJobStatus(ctx context.Context, id uuid.UUID, status string, opts ...JobUpdateOption) error {
+	params := &jobUpdateParams{}
+	for _, opt := range opts {
+		opt(params)
 	}
-	if !valid {
-		return fmt.Errorf("invalid job status transition: %s -> %s", currentStatus, status)
+
+	expected := priorStatuses(status)
+	if len(expected) == 0 {
+		return fmt.Errorf("%w: -> %s", ErrInvalidTransition, status)
 	}
 
 	now := time.Now().UTC()
@@ -383,14 +911,20 @@ func (s *PostgresStore) UpdateJobStatus(ctx context.Context, id uuid.UUID, statu
 	args := []any{id, status, now}
 	argIdx := 4
 
-	if status == "running" {
+	if params.StartedAt == nil && status == "running" {
+		params.StartedAt = &now
+	}
+	if params.CompletedAt == nil && (status == "completed" || status == "failed" || status == "cancelled") {
+		params.CompletedAt = &now
+	}
+	if params.StartedAt != nil {
 		query += fmt.Sprintf(", started_at = $%d", argIdx)
-		args = append(args, now)
+		args = append(args, params.StartedAt.UTC())
 		argIdx++
 	}
-	if status == "completed" || status == "failed" {
+	if params.CompletedAt != nil {
 		query += fmt.Sprintf(", completed_at = $%d", argIdx)
-		args = append(args, now)
+		args = append(args, params.CompletedAt.UTC())
 		argIdx++
 	}
 	if params.ErrorMessage != nil {
@@ -398,19 +932,139 @@ func (s *PostgresStore) UpdateJobStatus(ctx context.Context, id uuid.UUID, statu
 		args = append(args, *params.ErrorMessage)
 		argIdx++
 	}
+	if params.ErrorCode != nil {
+		query += fmt.Sprintf(", error_code = $%d", argIdx)
+		args = append(args, *params.ErrorCode)
+		argIdx++
+	}
 	if params.ClusterID != nil {
 		query += fmt.Sprintf(", cluster_id = $%d", argIdx)
 		args = append(args, *params.ClusterID)
 		argIdx++
 	}
 
-	query += " WHERE id = $1"
+	// The status guard makes the transition atomic: if another writer moved
+	// the job first, no row matches and we diagnose why below.
+	query += fmt.Sprintf(" WHERE id = $1 AND status = ANY($%d)", argIdx)
+	args = append(args, expected)
 
-	_, err = s.pool.Exec(ctx, query, args...)
+	tag, err := s.pool.Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("update job status: %w", err)
 	}
-	return nil
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var currentStatus string
+	err = s.pool.QueryRow(ctx, `SELECT status FROM jobs WHERE id = $1`, id).Scan(&currentStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("get job status: %w", err)
+	}
+	for _, from := range expected {
+		if slices.Contains(validTransitions[from], currentStatus) {
+			return ErrConcurrentUpdate
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, currentStatus, status)
+}
+
+// priorStatuses returns the statuses from which a job may move to status,
+// in a stable order.
+func priorStatuses(status string) []string {
+	var from []string
+	for prior, targets := range validTransitions {
+		if slices.Contains(targets, status) {
+			from = append(from, prior)
+		}
+	}
+	slices.Sort(from)
+	return from
+}
+
+func (s *PostgresStore) JobStats(ctx context.Context, tenantID uuid.UUID, since time.Time) (JobStats, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT status, COUNT(*),
+		        COALESCE(SUM(EXTRACT(EPOCH FROM (completed_at - started_at))), 0),
+		        COUNT(completed_at - started_at)
+		 FROM jobs WHERE tenant_id = $1 AND created_at >= $2
+		 GROUP BY status`, tenantID, since)
+	if err != nil {
+		return JobStats{}, fmt.Errorf("job stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := JobStats{ByStatus: make(map[string]int)}
+	var totalSecs float64
+	var timed int
+	for rows.Next() {
+		var status string
+		var count, finished int
+		var secs float64
+		if err := rows.Scan(&status, &count, &secs, &finished); err != nil {
+			return JobStats{}, fmt.Errorf("scan job stats: %w", err)
+		}
+		stats.ByStatus[status] = count
+		stats.Total += count
+		totalSecs += secs
+		timed += finished
+	}
+	if err := rows.Err(); err != nil {
+		return JobStats{}, fmt.Errorf("job stats: %w", err)
+	}
+
+	if timed > 0 {
+		stats.AvgDuration = time.Duration(totalSecs / float64(timed) * float64(time.Second))
+	}
+	return stats, nil
+}
+
+// ListJobs returns a page of the tenant's jobs matching filter, newest first,
+// and the total number of matches.
+func (s *PostgresStore) ListJobs(ctx context.Context, tenantID uuid.UUID, filter JobFilter) ([]*models.Job, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []any{tenantID}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if filter.ErrorCode != "" {
+		args = append(args, filter.ErrorCode)
+		conditions = append(conditions, fmt.Sprintf("error_code = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM jobs WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count jobs: %w", err)
+	}
+
+	page, limit := NormalizePagination(filter.Page, filter.Limit)
+	args = append(args, limit, (page-1)*limit)
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(
+		`SELECT %s FROM jobs WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`,
+		jobColumns, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.Job{}
+	for rows.Next() {
+		var j models.Job
+		if err := rows.Scan(jobDest(&j)...); err != nil {
+			return nil, 0, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, &j)
+	}
+	return jobs, total, rows.Err()
 }
 
 // isDuplicateKeyError checks if a pgx error is a unique constraint violation.
@@ -421,3 +1075,12 @@ func isDuplicateKeyError(err error) bool {
 	}
 	return false
 }
+
+// isUndefinedTableError checks if a pgx error reports a missing table.
+func isUndefinedTableError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "42P01" // undefined_table
+	}
+	return false
+}
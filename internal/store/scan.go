@@ -0,0 +1,49 @@
+package store
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kiranshivaraju/loghunter/pkg/models"
+)
+
+// analysisResultColumns is the column list read by scanAnalysisResult.
+const analysisResultColumns = `id, cluster_id, tenant_id, job_id, provider, model, root_cause, confidence,
+	confidence_clamped, summary, suggested_action, created_at`
+
+// analysisResultRow holds one analysis_results row as scanned. Nullable
+// columns land in pgtype values first so a NULL never fails the scan, whatever
+// Go type the model uses; new nullable columns belong here too.
+type analysisResultRow struct {
+	models.AnalysisResult
+	suggestedAction pgtype.Text
+}
+
+// dest returns scan destinations for analysisResultColumns.
+func (r *analysisResultRow) dest() []any {
+	return []any{&r.ID, &r.ClusterID, &r.TenantID, &r.JobID, &r.Provider, &r.Model,
+		&r.RootCause, &r.Confidence, &r.ConfidenceClamped, &r.Summary, &r.suggestedAction, &r.CreatedAt}
+}
+
+// result converts the row to the model, mapping NULLs to zero values.
+func (r *analysisResultRow) result() *models.AnalysisResult {
+	res := r.AnalysisResult
+	res.SuggestedAction = textPtr(r.suggestedAction)
+	return &res
+}
+
+// scanAnalysisResult scans a single row selected with analysisResultColumns.
+func scanAnalysisResult(row pgx.Row) (*models.AnalysisResult, error) {
+	var r analysisResultRow
+	if err := row.Scan(r.dest()...); err != nil {
+		return nil, err
+	}
+	return r.result(), nil
+}
+
+// textPtr returns nil for a NULL text value.
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRow scans fixed values the way database/sql drivers do: through
+// sql.Scanner when the destination implements it, otherwise by assignment.
+type fakeRow []any
+
+func (r fakeRow) Scan(dest ...any) error {
+	for i, d := range dest {
+		if s, ok := d.(sql.Scanner); ok {
+			if err := s.Scan(r[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+func analysisRowValues(suggestedAction any) fakeRow {
+	return fakeRow{uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString(), "ollama", "llama3",
+		"OOM", 0.8, false, "out of memory", suggestedAction, time.Now()}
+}
+
+func TestScanAnalysisResult_NullColumns(t *testing.T) {
+	r, err := scanAnalysisResult(analysisRowValues(nil))
+	require.NoError(t, err)
+	assert.Nil(t, r.SuggestedAction)
+	assert.Equal(t, "OOM", r.RootCause)
+}
+
+func TestScanAnalysisResult_SetColumns(t *testing.T) {
+	r, err := scanAnalysisResult(analysisRowValues("restart the pod"))
+	require.NoError(t, err)
+	require.NotNil(t, r.SuggestedAction)
+	assert.Equal(t, "restart the pod", *r.SuggestedAction)
+}
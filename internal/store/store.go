@@ -12,29 +12,63 @@ import (
 var ErrNotFound = errors.New("resource not found")
 var ErrDuplicateKey = errors.New("duplicate key violation")
 
+// ErrConcurrentUpdate is returned when a conditional update loses a race with
+// another writer that changed the row first.
+var ErrConcurrentUpdate = errors.New("concurrent update")
+
+// ErrInvalidTransition is returned when a job status update is not allowed
+// from the job's current status.
+var ErrInvalidTransition = errors.New("invalid job status transition")
+
 // Store is the data access interface. All database operations go through here.
 type Store interface {
 	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (*MigrationStatus, error)
 	GetDefaultTenant(ctx context.Context) (*models.Tenant, error)
+	GetTenant(ctx context.Context, id uuid.UUID) (*models.Tenant, error)
 
 	GetAPIKeyByPrefix(ctx context.Context, prefix string) ([]*models.APIKey, error)
+	GetTenantByAPIKeyPrefix(ctx context.Context, prefix string) (*models.Tenant, error)
 	UpdateAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error
 	CreateAPIKey(ctx context.Context, key *models.APIKey) error
-	ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, tenantID uuid.UUID, opts ...APIKeyListOption) ([]*models.APIKey, error)
+	ListAPIKeysPaged(ctx context.Context, tenantID uuid.UUID, page, limit int, opts ...APIKeyListOption) ([]*models.APIKey, int, error)
 	RevokeAPIKey(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error
+	RevokeAllAPIKeys(ctx context.Context, tenantID uuid.UUID, keep ...uuid.UUID) (int, error)
 
 	UpsertErrorCluster(ctx context.Context, cluster *models.ErrorCluster) (*models.ErrorCluster, error)
 	ListErrorClusters(ctx context.Context, filter ClusterFilter) ([]*models.ErrorCluster, int, error)
+	CountErrorClusters(ctx context.Context, filter ClusterFilter) (int, error)
 	GetErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.ErrorCluster, error)
+	ErrorClusterExists(ctx context.Context, id uuid.UUID) (bool, error)
+	GetErrorClusterByFingerprint(ctx context.Context, tenantID uuid.UUID, service, namespace, fingerprint string) (*models.ErrorCluster, error)
 	GetClustersByFingerprints(ctx context.Context, tenantID uuid.UUID, fingerprints []string) ([]*models.ErrorCluster, error)
+	TouchErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, seenAt time.Time) error
+	SetClusterPinned(ctx context.Context, id uuid.UUID, tenantID uuid.UUID, pinned bool) error
+	DeleteErrorCluster(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error
+	AutoResolveClusters(ctx context.Context, staleBefore time.Time) (map[uuid.UUID]int, error)
+
+	CreateClusterSuppression(ctx context.Context, sup *models.ClusterSuppression) error
+	ListClusterSuppressions(ctx context.Context, tenantID uuid.UUID) ([]*models.ClusterSuppression, error)
+	DeleteClusterSuppression(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) error
 
 	CreateAnalysisResult(ctx context.Context, result *models.AnalysisResult) error
-	GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID) (*models.AnalysisResult, error)
-	GetAnalysisResultByClusterID(ctx context.Context, clusterID uuid.UUID) (*models.AnalysisResult, error)
+	GetAnalysisResultByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+	GetAnalysisResultByJobID(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+	GetAnalysisResultByClusterID(ctx context.Context, clusterID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisResult, error)
+	DeleteOrphanedAnalysisResults(ctx context.Context) (int, error)
+	SaveAnalysisContext(ctx context.Context, ac *models.AnalysisContext) error
+	GetAnalysisContext(ctx context.Context, jobID uuid.UUID, tenantID uuid.UUID) (*models.AnalysisContext, error)
 
 	CreateJob(ctx context.Context, job *models.Job) error
 	GetJob(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*models.Job, error)
+	JobExists(ctx context.Context, id uuid.UUID) (bool, error)
+	// GetJobByID ignores tenant scoping; background workers only.
+	GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error)
+	GetJobsByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*models.Job, error)
 	UpdateJobStatus(ctx context.Context, id uuid.UUID, status string, opts ...JobUpdateOption) error
+	JobStats(ctx context.Context, tenantID uuid.UUID, since time.Time) (JobStats, error)
+	ListJobs(ctx context.Context, tenantID uuid.UUID, filter JobFilter) ([]*models.Job, int, error)
 }
 
 type ClusterFilter struct {
@@ -42,14 +76,99 @@ type ClusterFilter struct {
 	Service   string
 	Namespace string
 	Level     string
-	Since     time.Time
+	// Levels restricts results to clusters whose level is any of these. It is
+	// ANDed with Level when both are set.
+	Levels []string
+	Since  time.Time
+	// SeenBefore restricts results to clusters last seen strictly before this time.
+	SeenBefore time.Time
+	// UpdatedSince switches to incremental sync: only clusters with updated_at
+	// after this time are returned, ordered by updated_at ASC, and Page is
+	// ignored. UpdatedAfterID breaks ties at exactly UpdatedSince when resuming
+	// from a cursor (see EncodeClusterCursor).
+	UpdatedSince   time.Time
+	UpdatedAfterID uuid.UUID
+	Page           int
+	Limit          int
+	// Cursor switches to keyset pagination: only clusters after the one it
+	// names in Sort order are returned, and Page is ignored. Cursor and Page
+	// are mutually exclusive; pass a cursor from ClusterPageCursor. Totals
+	// still count every matching cluster. Incremental sync ignores it.
+	Cursor string
+	// SkipTotal makes ListErrorClusters skip the COUNT query. The returned total
+	// is then only a lower bound: the rows before this page, the rows on it, and
+	// one more if a further row exists, which is enough to derive has_next.
+	SkipTotal bool
+	// Sort is ClusterSortLastSeen (the default when empty) or
+	// ClusterSortFirstSeen. Incremental sync ignores it.
+	Sort string
+	// Lightweight truncates each listed cluster's SampleMessage to
+	// SampleMessagePreviewChars, for list views; GetErrorCluster always
+	// returns the full message.
+	Lightweight bool
+}
+
+// SampleMessagePreviewChars is how much of a sample message a Lightweight
+// listing returns.
+const SampleMessagePreviewChars = 200
+
+// Orderings for ClusterFilter.Sort.
+const (
+	// ClusterSortLastSeen lists the most recently active clusters first.
+	ClusterSortLastSeen = "last_seen_desc"
+	// ClusterSortFirstSeen lists the most recently appeared clusters first, so
+	// a new problem is not buried under an old cluster that keeps firing.
+	ClusterSortFirstSeen = "first_seen_desc"
+)
+
+// JobStats aggregates job counts for a tenant over a time window.
+// AvgDuration covers jobs that have both started_at and completed_at set.
+type JobStats struct {
+	Total       int
+	ByStatus    map[string]int
+	AvgDuration time.Duration
+}
+
+// JobFilter selects the jobs ListJobs returns, newest first. Empty Status,
+// Type and ErrorCode match any job.
+type JobFilter struct {
+	Status    string
+	Type      string
+	ErrorCode string
 	Page      int
 	Limit     int
 }
 
+// APIKeyListOptions selects which keys ListAPIKeys and ListAPIKeysPaged
+// return. By default only active keys are listed.
+type APIKeyListOptions struct {
+	IncludeRevoked bool
+}
+
+type APIKeyListOption func(*APIKeyListOptions)
+
+// IncludeRevoked lists revoked keys alongside active ones.
+func IncludeRevoked() APIKeyListOption {
+	return func(o *APIKeyListOptions) {
+		o.IncludeRevoked = true
+	}
+}
+
+// ResolveAPIKeyListOptions applies opts to the default options.
+func ResolveAPIKeyListOptions(opts ...APIKeyListOption) APIKeyListOptions {
+	var o APIKeyListOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 type jobUpdateParams struct {
 	ErrorMessage *string
+	ErrorCode    *string
 	ClusterID    *uuid.UUID
+	StartedAt    *time.Time
+	CompletedAt  *time.Time
 }
 
 type JobUpdateOption func(*jobUpdateParams)
@@ -60,8 +179,30 @@ func WithErrorMessage(msg string) JobUpdateOption {
 	}
 }
 
+func WithErrorCode(code string) JobUpdateOption {
+	return func(p *jobUpdateParams) {
+		p.ErrorCode = &code
+	}
+}
+
 func WithClusterID(id uuid.UUID) JobUpdateOption {
 	return func(p *jobUpdateParams) {
 		p.ClusterID = &id
 	}
 }
+
+// WithStartedAt sets started_at to t instead of the time of the update, for
+// replays and imported jobs. It applies whatever the new status.
+func WithStartedAt(t time.Time) JobUpdateOption {
+	return func(p *jobUpdateParams) {
+		p.StartedAt = &t
+	}
+}
+
+// WithCompletedAt sets completed_at to t instead of the time of the update.
+// It applies whatever the new status.
+func WithCompletedAt(t time.Time) JobUpdateOption {
+	return func(p *jobUpdateParams) {
+		p.CompletedAt = &t
+	}
+}
@@ -2,13 +2,20 @@ package store_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/golang-migrate/migrate/v4"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kiranshivaraju/loghunter/internal/analysis"
 	"github.com/kiranshivaraju/loghunter/internal/store"
 	"github.com/kiranshivaraju/loghunter/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +33,22 @@ func migrationsDir() string {
 
 // setupTestDB spins up a Postgres container, runs migrations, and returns a pool + cleanup.
 func setupTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	connStr := startPostgres(t)
+
+	// Run migrations
+	err := store.RunMigrations(connStr, migrationsDir())
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(context.Background(), connStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+
+	return pool
+}
+
+// startPostgres spins up an empty Postgres container and returns its connection string.
+func startPostgres(t *testing.T) string {
 	t.Helper()
 	ctx := context.Background()
 
@@ -47,16 +70,7 @@ func setupTestDB(t *testing.T) *pgxpool.Pool {
 
 	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
 	require.NoError(t, err)
-
-	// Run migrations
-	err = store.RunMigrations(connStr, migrationsDir())
-	require.NoError(t, err)
-
-	pool, err := pgxpool.New(ctx, connStr)
-	require.NoError(t, err)
-	t.Cleanup(func() { pool.Close() })
-
-	return pool
+	return connStr
 }
 
 // defaultTenantID returns the UUID of the seeded default tenant.
@@ -83,6 +97,148 @@ func TestGetDefaultTenant(t *testing.T) {
 	assert.NotEqual(t, uuid.Nil, tenant.ID)
 }
 
+func TestGetTenant(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+
+	tenant, err := s.GetTenant(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "default", tenant.Name)
+	assert.Equal(t, "default", tenant.LokiOrgID)
+
+	_, err = s.GetTenant(ctx, uuid.New())
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+// queryCounter is a pgx tracer that counts the queries a pool runs.
+type queryCounter struct{ n atomic.Int64 }
+
+func (c *queryCounter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	c.n.Add(1)
+	return ctx
+}
+
+func (c *queryCounter) TraceQueryEnd(_ context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {}
+
+func TestGetDefaultTenant_Memoized(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	cfg := setupTestDB(t).Config()
+	counter := &queryCounter{}
+	cfg.ConnConfig.Tracer = counter
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	s := store.NewPostgresStore(pool)
+
+	first, err := s.GetDefaultTenant(ctx)
+	require.NoError(t, err)
+	queries := counter.n.Load()
+	require.Positive(t, queries)
+
+	second, err := s.GetDefaultTenant(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, queries, counter.n.Load(), "second call within the TTL must not query")
+	assert.Equal(t, first, second)
+
+	// Callers get copies, so mutating one does not poison the cache.
+	second.Name = "changed"
+	third, err := s.GetDefaultTenant(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "default", third.Name)
+}
+
+// --- Migration Tests ---
+
+func TestMigrationVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir(), "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	var latest int64
+	_, err = fmt.Sscanf(filepath.Base(files[len(files)-1]), "%d_", &latest)
+	require.NoError(t, err)
+
+	status, err := s.MigrationVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, latest, status.Version)
+	assert.False(t, status.Dirty)
+
+	// Re-running is a no-op.
+	require.NoError(t, store.RunMigrations(pool.Config().ConnString(), migrationsDir()))
+	status, err = s.MigrationVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, latest, status.Version)
+}
+
+func TestMigrationVersion_NeverMigrated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool, err := pgxpool.New(context.Background(), startPostgres(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+	s := store.NewPostgresStore(pool)
+
+	status, err := s.MigrationVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.Version)
+	assert.False(t, status.Dirty)
+}
+
+func TestMigration_BackfillsAPIKeyScopes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	connStr := startPostgres(t)
+
+	// Stop just before the backfill, with a key created the old way.
+	m, err := migrate.New("file://"+migrationsDir(), connStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close() })
+	require.NoError(t, m.Migrate(18))
+
+	pool, err := pgxpool.New(ctx, connStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+	s := store.NewPostgresStore(pool)
+	tenantID := defaultTenantID(t, s)
+
+	_, err = pool.Exec(ctx,
+		`INSERT INTO api_keys (tenant_id, name, key_hash, key_prefix) VALUES ($1, 'legacy', 'hash', 'lh_legcy')`,
+		tenantID)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx,
+		`INSERT INTO api_keys (tenant_id, name, key_hash, key_prefix, scopes) VALUES ($1, 'ops', 'hash', 'lh_opsky', '{admin}')`,
+		tenantID)
+	require.NoError(t, err)
+
+	require.NoError(t, store.RunMigrations(connStr, migrationsDir()))
+
+	legacy, err := s.GetAPIKeyByPrefix(ctx, "lh_legcy")
+	require.NoError(t, err)
+	require.Len(t, legacy, 1)
+	assert.ElementsMatch(t, []string{"read", "write"}, legacy[0].Scopes)
+
+	ops, err := s.GetAPIKeyByPrefix(ctx, "lh_opsky")
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.ElementsMatch(t, []string{"admin", "read", "write"}, ops[0].Scopes)
+}
+
 // --- API Key Tests ---
 
 func TestAPIKey_CreateAndGet(t *testing.T) {
@@ -146,6 +302,104 @@ func TestAPIKey_List(t *testing.T) {
 	assert.Len(t, keys, 3)
 }
 
+func TestAPIKey_ListPaged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	base := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Five keys created a second apart; key-4 is the newest.
+	for i := 0; i < 5; i++ {
+		ts := base.Add(time.Duration(i) * time.Second)
+		require.NoError(t, s.CreateAPIKey(ctx, &models.APIKey{
+			ID:        uuid.New(),
+			TenantID:  tenantID,
+			Name:      fmt.Sprintf("key-%d", i),
+			KeyHash:   "hash-" + uuid.NewString()[:4],
+			KeyPrefix: "lh_" + uuid.NewString()[:4],
+			Scopes:    []string{"read"},
+			CreatedAt: ts,
+			UpdatedAt: ts,
+		}))
+	}
+
+	var names []string
+	for page := 1; page <= 3; page++ {
+		keys, total, err := s.ListAPIKeysPaged(ctx, tenantID, page, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		for _, k := range keys {
+			names = append(names, k.Name)
+		}
+	}
+	assert.Equal(t, []string{"key-4", "key-3", "key-2", "key-1", "key-0"}, names)
+
+	keys, total, err := s.ListAPIKeysPaged(ctx, tenantID, 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Empty(t, keys)
+
+	// Another tenant sees nothing.
+	keys, total, err = s.ListAPIKeysPaged(ctx, uuid.New(), 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, keys)
+}
+
+func TestAPIKey_ListIncludeRevoked(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	base := time.Now().UTC().Truncate(time.Microsecond)
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		ts := base.Add(time.Duration(i) * time.Second)
+		key := &models.APIKey{
+			ID: uuid.New(), TenantID: tenantID, Name: fmt.Sprintf("key-%d", i),
+			KeyHash: "hash-" + uuid.NewString()[:4], KeyPrefix: "lh_" + uuid.NewString()[:4],
+			Scopes: []string{"read"}, CreatedAt: ts, UpdatedAt: ts,
+		}
+		require.NoError(t, s.CreateAPIKey(ctx, key))
+		ids = append(ids, key.ID)
+	}
+	require.NoError(t, s.RevokeAPIKey(ctx, ids[1], tenantID))
+
+	// Default: active keys only.
+	keys, err := s.ListAPIKeys(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	for _, k := range keys {
+		assert.Nil(t, k.DeletedAt)
+	}
+	paged, total, err := s.ListAPIKeysPaged(ctx, tenantID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, paged, 2)
+
+	// With revoked keys.
+	keys, err = s.ListAPIKeys(ctx, tenantID, store.IncludeRevoked())
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.Equal(t, ids[1], keys[1].ID)
+	assert.NotNil(t, keys[1].DeletedAt)
+
+	paged, total, err = s.ListAPIKeysPaged(ctx, tenantID, 1, 2, store.IncludeRevoked())
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, paged, 2)
+	assert.Equal(t, ids[2], paged[0].ID)
+	assert.Equal(t, ids[1], paged[1].ID)
+}
+
 func TestAPIKey_Revoke(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -182,6 +436,47 @@ func TestAPIKey_Revoke(t *testing.T) {
 	assert.Empty(t, keys)
 }
 
+func TestAPIKey_RevokeAll(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	var ids []uuid.UUID
+	for i, name := range []string{"caller", "ci", "grafana"} {
+		key := &models.APIKey{
+			ID: uuid.New(), TenantID: tenantID, Name: name, KeyHash: "hash",
+			KeyPrefix: fmt.Sprintf("lh_all%d", i), Scopes: []string{"read"},
+			CreatedAt: now, UpdatedAt: now,
+		}
+		require.NoError(t, s.CreateAPIKey(ctx, key))
+		ids = append(ids, key.ID)
+	}
+	require.NoError(t, s.RevokeAPIKey(ctx, ids[2], tenantID))
+
+	// Already-revoked keys are not counted again; the kept key survives.
+	n, err := s.RevokeAllAPIKeys(ctx, tenantID, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	keys, err := s.ListAPIKeys(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, ids[0], keys[0].ID)
+
+	n, err = s.RevokeAllAPIKeys(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	keys, err = s.ListAPIKeys(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
 func TestAPIKey_RevokeNotFound(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -193,6 +488,43 @@ func TestAPIKey_RevokeNotFound(t *testing.T) {
 	assert.ErrorIs(t, err, store.ErrNotFound)
 }
 
+func TestAPIKey_GetTenantByPrefix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	key := &models.APIKey{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Name:      "owner-lookup",
+		KeyHash:   "hash",
+		KeyPrefix: "lh_ownr",
+		Scopes:    []string{"read"},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateAPIKey(ctx, key))
+
+	tenant, err := s.GetTenantByAPIKeyPrefix(ctx, "lh_ownr")
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, tenant.ID)
+	assert.Equal(t, "default", tenant.Name)
+
+	// Revoked keys are still attributed to their tenant
+	require.NoError(t, s.RevokeAPIKey(ctx, key.ID, tenantID))
+	tenant, err = s.GetTenantByAPIKeyPrefix(ctx, "lh_ownr")
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, tenant.ID)
+
+	_, err = s.GetTenantByAPIKeyPrefix(ctx, "lh_none")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
 func TestAPIKey_UpdateLastUsed(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -372,6 +704,43 @@ func TestErrorCluster_List(t *testing.T) {
 	assert.Len(t, clusters, 3)
 }
 
+func TestErrorCluster_ListLightweightTruncatesSample(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Multi-byte runes check the preview is cut on characters, not bytes.
+	sample := strings.Repeat("é", store.SampleMessagePreviewChars+300)
+	cluster := &models.ErrorCluster{
+		ID: uuid.New(), TenantID: tenantID, Service: "light-svc", Namespace: "default",
+		Fingerprint: "fp-light", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+		Count: 1, SampleMessage: sample, CreatedAt: now, UpdatedAt: now,
+	}
+	_, err := s.UpsertErrorCluster(ctx, cluster)
+	require.NoError(t, err)
+
+	filter := store.ClusterFilter{TenantID: tenantID, Service: "light-svc", Lightweight: true}
+	clusters, _, err := s.ListErrorClusters(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, strings.Repeat("é", store.SampleMessagePreviewChars), clusters[0].SampleMessage)
+
+	filter.Lightweight = false
+	clusters, _, err = s.ListErrorClusters(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, sample, clusters[0].SampleMessage)
+
+	got, err := s.GetErrorCluster(ctx, cluster.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, sample, got.SampleMessage)
+}
+
 func TestErrorCluster_ListWithFilters(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -402,7 +771,7 @@ func TestErrorCluster_ListWithFilters(t *testing.T) {
 	assert.Equal(t, "ERROR", clusters[0].Level)
 }
 
-func TestErrorCluster_GetByFingerprints(t *testing.T) {
+func TestErrorCluster_ListMultipleLevels(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
@@ -412,37 +781,77 @@ func TestErrorCluster_GetByFingerprints(t *testing.T) {
 	tenantID := defaultTenantID(t, s)
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
-	fps := []string{"fp-a", "fp-b", "fp-c"}
-	for _, fp := range fps {
+	for _, level := range []string{"FATAL", "ERROR", "WARN"} {
 		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
-			ID: uuid.New(), TenantID: tenantID, Service: "svc",
-			Namespace: "default", Fingerprint: fp, Level: "ERROR",
+			ID: uuid.New(), TenantID: tenantID, Service: "levels-svc",
+			Namespace: "prod", Fingerprint: "fp-levels-" + level, Level: level,
 			FirstSeenAt: now, LastSeenAt: now, Count: 1,
-			SampleMessage: "msg", CreatedAt: now, UpdatedAt: now,
+			SampleMessage: level + " msg", CreatedAt: now, UpdatedAt: now,
 		})
 		require.NoError(t, err)
 	}
 
-	clusters, err := s.GetClustersByFingerprints(ctx, tenantID, []string{"fp-a", "fp-c"})
+	clusters, total, err := s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "levels-svc", Levels: []string{"ERROR", "FATAL"}, Page: 1, Limit: 20,
+	})
 	require.NoError(t, err)
-	assert.Len(t, clusters, 2)
+	assert.Equal(t, 2, total)
+	require.Len(t, clusters, 2)
+	for _, c := range clusters {
+		assert.Contains(t, []string{"ERROR", "FATAL"}, c.Level)
+	}
+
+	// Level and Levels are ANDed.
+	total, err = s.CountErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "levels-svc", Level: "WARN", Levels: []string{"ERROR", "FATAL"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
 }
 
-func TestErrorCluster_GetByFingerprintsEmpty(t *testing.T) {
+func TestErrorCluster_ListFirstSeenDesc(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
 	pool := setupTestDB(t)
 	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
 
-	clusters, err := s.GetClustersByFingerprints(context.Background(), uuid.New(), []string{})
+	// An old cluster that is still firing, and one that just appeared.
+	oldActive, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: uuid.New(), TenantID: tenantID, Service: "sort-svc",
+		Namespace: "prod", Fingerprint: "fp-old-active", Level: "ERROR",
+		FirstSeenAt: now.Add(-7 * 24 * time.Hour), LastSeenAt: now, Count: 5000,
+		SampleMessage: "old", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+	brandNew, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: uuid.New(), TenantID: tenantID, Service: "sort-svc",
+		Namespace: "prod", Fingerprint: "fp-brand-new", Level: "ERROR",
+		FirstSeenAt: now.Add(-time.Minute), LastSeenAt: now.Add(-time.Minute), Count: 1,
+		SampleMessage: "new", CreatedAt: now, UpdatedAt: now,
+	})
 	require.NoError(t, err)
-	assert.Empty(t, clusters)
-}
 
-// --- Analysis Result Tests ---
+	clusters, _, err := s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "sort-svc", Page: 1, Limit: 20,
+	})
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, oldActive.ID, clusters[0].ID, "default order is last_seen_at DESC")
 
-func TestAnalysisResult_CreateAndGetByJob(t *testing.T) {
+	clusters, _, err = s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "sort-svc", Sort: store.ClusterSortFirstSeen, Page: 1, Limit: 20,
+	})
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, brandNew.ID, clusters[0].ID)
+	assert.Equal(t, oldActive.ID, clusters[1].ID)
+}
+
+func TestErrorCluster_Count(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
@@ -452,9 +861,660 @@ func TestAnalysisResult_CreateAndGetByJob(t *testing.T) {
 	tenantID := defaultTenantID(t, s)
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
-	// Create cluster and job first
-	clusterID := uuid.New()
-	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+	for i, level := range []string{"ERROR", "ERROR", "WARN"} {
+		lastSeen := now.Add(-time.Duration(i) * time.Hour)
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "count-svc",
+			Namespace: "default", Fingerprint: uuid.NewString()[:8], Level: level,
+			FirstSeenAt: lastSeen, LastSeenAt: lastSeen, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	total, err := s.CountErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID, Service: "count-svc"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	total, err = s.CountErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID, Service: "count-svc", Level: "ERROR"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	total, err = s.CountErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "count-svc", Since: now.Add(-90 * time.Minute),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestErrorCluster_ListSkipTotal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	for i := 0; i < 5; i++ {
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "skip-total-svc",
+			Namespace: "default", Fingerprint: uuid.NewString()[:8], Level: "ERROR",
+			FirstSeenAt: now, LastSeenAt: now, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	filter := store.ClusterFilter{TenantID: tenantID, Service: "skip-total-svc", Page: 1, Limit: 3, SkipTotal: true}
+	clusters, total, err := s.ListErrorClusters(ctx, filter)
+	require.NoError(t, err)
+	assert.Len(t, clusters, 3)
+	assert.Greater(t, total, 3, "lower bound should show another page exists")
+
+	filter.Page = 2
+	clusters, total, err = s.ListErrorClusters(ctx, filter)
+	require.NoError(t, err)
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, 5, total)
+}
+
+func TestErrorCluster_ListActiveAndNoisePartition(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	seen := map[string]time.Time{
+		"fp-recent":  now.Add(-10 * time.Minute),
+		"fp-stale-1": now.Add(-3 * time.Hour),
+		"fp-stale-2": now.Add(-48 * time.Hour),
+	}
+	for fp, lastSeen := range seen {
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "partition-svc",
+			Namespace: "default", Fingerprint: fp, Level: "ERROR",
+			FirstSeenAt: lastSeen, LastSeenAt: lastSeen, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	cutoff := now.Add(-1 * time.Hour)
+
+	active, total, err := s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "partition-svc", Since: cutoff,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, active, 1)
+	assert.Equal(t, "fp-recent", active[0].Fingerprint)
+
+	_, suppressed, err := s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "partition-svc", SeenBefore: cutoff, Limit: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, suppressed)
+}
+
+func TestErrorCluster_Touch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	c, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: uuid.New(), TenantID: tenantID, Service: "touch-svc",
+		Namespace: "default", Fingerprint: "fp-touch", Level: "ERROR",
+		FirstSeenAt: now.Add(-time.Hour), LastSeenAt: now.Add(-time.Hour), Count: 4,
+		SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.TouchErrorCluster(ctx, c.ID, tenantID, now))
+	got, err := s.GetErrorCluster(ctx, c.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, got.Count)
+	assert.True(t, got.LastSeenAt.Equal(now), "last_seen_at should advance, got %v", got.LastSeenAt)
+
+	// An older observation never moves last_seen_at backwards.
+	require.NoError(t, s.TouchErrorCluster(ctx, c.ID, tenantID, now.Add(-2*time.Hour)))
+	got, err = s.GetErrorCluster(ctx, c.ID, tenantID)
+	require.NoError(t, err)
+	assert.True(t, got.LastSeenAt.Equal(now))
+	assert.Equal(t, 4, got.Count)
+
+	err = s.TouchErrorCluster(ctx, c.ID, uuid.New(), now)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestErrorCluster_ListUpdatedSince(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	upsert := func(fp string) *models.ErrorCluster {
+		c, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "sync-svc",
+			Namespace: "default", Fingerprint: fp, Level: "ERROR",
+			FirstSeenAt: now, LastSeenAt: now, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+		return c
+	}
+
+	upsert("fp-old")
+	time.Sleep(10 * time.Millisecond)
+	checkpoint := time.Now().UTC()
+	time.Sleep(10 * time.Millisecond)
+	upsert("fp-b")
+	time.Sleep(10 * time.Millisecond)
+	upsert("fp-c")
+	time.Sleep(10 * time.Millisecond)
+	upsert("fp-old") // re-seen: now the most recently updated
+
+	clusters, total, err := s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "sync-svc", UpdatedSince: checkpoint,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, clusters, 3)
+	assert.Equal(t, "fp-b", clusters[0].Fingerprint)
+	assert.Equal(t, "fp-c", clusters[1].Fingerprint)
+	assert.Equal(t, "fp-old", clusters[2].Fingerprint)
+
+	// Resume after the first row using the keyset cursor.
+	page, total, err := s.ListErrorClusters(ctx, store.ClusterFilter{
+		TenantID: tenantID, Service: "sync-svc",
+		UpdatedSince: clusters[0].UpdatedAt, UpdatedAfterID: clusters[0].ID, Limit: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, page, 1)
+	assert.Equal(t, "fp-c", page[0].Fingerprint)
+}
+
+func TestErrorCluster_ListPageCursor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	upsert := func(fp string, lastSeen time.Time) {
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "keyset-svc",
+			Namespace: "default", Fingerprint: fp, Level: "ERROR",
+			FirstSeenAt: lastSeen, LastSeenAt: lastSeen, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+	upsert("fp-3", now.Add(-1*time.Minute))
+	upsert("fp-2", now.Add(-2*time.Minute))
+	upsert("fp-1", now.Add(-3*time.Minute))
+
+	filter := store.ClusterFilter{TenantID: tenantID, Service: "keyset-svc", Limit: 2}
+	first, total, err := s.ListErrorClusters(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, first, 2)
+	assert.Equal(t, "fp-2", first[1].Fingerprint)
+
+	// A cluster arriving ahead of the cursor does not shift the next page.
+	upsert("fp-new", now)
+
+	filter.Cursor = store.ClusterPageCursor(first[1], filter.Sort)
+	next, total, err := s.ListErrorClusters(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total, "totals ignore the cursor")
+	require.Len(t, next, 1)
+	assert.Equal(t, "fp-1", next[0].Fingerprint)
+
+	filter.Cursor = "garbage"
+	_, _, err = s.ListErrorClusters(ctx, filter)
+	assert.ErrorIs(t, err, store.ErrInvalidCursor)
+}
+
+func TestErrorCluster_ListPageCursorTiedTimestamps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Every cluster shares last_seen_at, so only id orders them.
+	for i := range 5 {
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "tied-svc",
+			Namespace: "default", Fingerprint: fmt.Sprintf("fp-%d", i), Level: "ERROR",
+			FirstSeenAt: now, LastSeenAt: now, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	filter := store.ClusterFilter{TenantID: tenantID, Service: "tied-svc", Limit: 2, SkipTotal: true}
+	seen := map[string]bool{}
+	for page := 0; ; page++ {
+		require.Less(t, page, 5, "pagination did not terminate")
+		clusters, total, err := s.ListErrorClusters(ctx, filter)
+		require.NoError(t, err)
+		for _, c := range clusters {
+			assert.False(t, seen[c.Fingerprint], "cluster %s repeated across pages", c.Fingerprint)
+			seen[c.Fingerprint] = true
+		}
+		if total <= len(clusters) {
+			break
+		}
+		filter.Cursor = store.ClusterPageCursor(clusters[len(clusters)-1], filter.Sort)
+	}
+	assert.Len(t, seen, 5, "every cluster appears exactly once")
+}
+
+func TestErrorCluster_AutoResolveStale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	upsert := func(fp string, lastSeen time.Time) *models.ErrorCluster {
+		c, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "resolve-svc",
+			Namespace: "default", Fingerprint: fp, Level: "ERROR",
+			FirstSeenAt: lastSeen, LastSeenAt: lastSeen, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.ClusterStatusOpen, c.Status)
+		return c
+	}
+
+	recent := upsert("fp-recent", now.Add(-1*time.Hour))
+	stale := upsert("fp-stale", now.Add(-96*time.Hour))
+	acked := upsert("fp-acked", now.Add(-96*time.Hour))
+	_, err := pool.Exec(ctx, `UPDATE error_clusters SET status = 'acknowledged' WHERE id = $1`, acked.ID)
+	require.NoError(t, err)
+
+	resolved, err := s.AutoResolveClusters(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{tenantID: 1}, resolved)
+
+	got, err := s.GetErrorCluster(ctx, stale.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ClusterStatusResolved, got.Status)
+	assert.True(t, got.AutoResolved)
+	assert.NotNil(t, got.ResolvedAt)
+
+	got, err = s.GetErrorCluster(ctx, recent.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ClusterStatusOpen, got.Status)
+	assert.False(t, got.AutoResolved)
+	assert.Nil(t, got.ResolvedAt)
+
+	got, err = s.GetErrorCluster(ctx, acked.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ClusterStatusAcknowledged, got.Status)
+	assert.False(t, got.AutoResolved)
+
+	// A second sweep finds nothing new to resolve.
+	resolved, err = s.AutoResolveClusters(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, resolved)
+}
+
+func TestErrorCluster_UpsertReopensResolved(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	occurrence := func(seenAt time.Time) *models.ErrorCluster {
+		return &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "reopen-svc",
+			Namespace: "default", Fingerprint: "fp-reopen", Level: "ERROR",
+			FirstSeenAt: seenAt, LastSeenAt: seenAt, Count: 1,
+			SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+		}
+	}
+
+	first, err := s.UpsertErrorCluster(ctx, occurrence(now.Add(-96*time.Hour)))
+	require.NoError(t, err)
+	assert.Nil(t, first.ReopenedAt)
+
+	resolved, err := s.AutoResolveClusters(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, map[uuid.UUID]int{tenantID: 1}, resolved)
+
+	// A late occurrence from before the resolution leaves it resolved.
+	got, err := s.UpsertErrorCluster(ctx, occurrence(now.Add(-90*time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, models.ClusterStatusResolved, got.Status)
+	assert.Nil(t, got.ReopenedAt)
+
+	got, err = s.UpsertErrorCluster(ctx, occurrence(now.Add(time.Minute)))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, got.ID)
+	assert.Equal(t, models.ClusterStatusOpen, got.Status)
+	assert.False(t, got.AutoResolved)
+	assert.Nil(t, got.ResolvedAt)
+	require.NotNil(t, got.ReopenedAt)
+	assert.Equal(t, 3, got.Count)
+
+	fetched, err := s.GetErrorCluster(ctx, first.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ClusterStatusOpen, fetched.Status)
+	require.NotNil(t, fetched.ReopenedAt)
+	assert.True(t, fetched.ReopenedAt.Equal(*got.ReopenedAt))
+
+	// An open cluster is merged without touching reopened_at.
+	again, err := s.UpsertErrorCluster(ctx, occurrence(now.Add(2*time.Minute)))
+	require.NoError(t, err)
+	require.NotNil(t, again.ReopenedAt)
+	assert.True(t, again.ReopenedAt.Equal(*got.ReopenedAt))
+}
+
+func TestErrorCluster_PinnedSurvivesAutoResolve(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	lastSeen := now.Add(-96 * time.Hour)
+
+	cluster := &models.ErrorCluster{
+		ID: uuid.New(), TenantID: tenantID, Service: "pinned-svc",
+		Namespace: "default", Fingerprint: "fp-pinned", Level: "ERROR",
+		FirstSeenAt: lastSeen, LastSeenAt: lastSeen, Count: 1,
+		SampleMessage: "err", CreatedAt: now, UpdatedAt: now,
+	}
+	c, err := s.UpsertErrorCluster(ctx, cluster)
+	require.NoError(t, err)
+	assert.False(t, c.Pinned)
+
+	require.NoError(t, s.SetClusterPinned(ctx, c.ID, tenantID, true))
+
+	// Re-observing the cluster keeps the pin.
+	c, err = s.UpsertErrorCluster(ctx, cluster)
+	require.NoError(t, err)
+	assert.True(t, c.Pinned)
+
+	resolved, err := s.AutoResolveClusters(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, resolved)
+
+	got, err := s.GetErrorCluster(ctx, c.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ClusterStatusOpen, got.Status)
+	assert.True(t, got.Pinned)
+
+	// Once unpinned it is swept like any other stale cluster.
+	require.NoError(t, s.SetClusterPinned(ctx, c.ID, tenantID, false))
+	resolved, err = s.AutoResolveClusters(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{tenantID: 1}, resolved)
+
+	err = s.SetClusterPinned(ctx, c.ID, uuid.New(), true)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestErrorCluster_DeleteCascades(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	clusterID := uuid.New()
+	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
+		Fingerprint: "fp-delete", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	jobID := uuid.New()
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "completed",
+		ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
+	}))
+	require.NoError(t, s.CreateAnalysisResult(ctx, &models.AnalysisResult{
+		ID: uuid.New(), ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
+		Provider: "ollama", Model: "llama3", RootCause: "disk full",
+		Confidence: 0.9, Summary: "Disk is full", CreatedAt: now,
+	}))
+
+	// Another tenant cannot delete it.
+	err = s.DeleteErrorCluster(ctx, clusterID, uuid.New())
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetAnalysisResultByClusterID(ctx, clusterID, tenantID)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteErrorCluster(ctx, clusterID, tenantID))
+
+	_, err = s.GetErrorCluster(ctx, clusterID, tenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetAnalysisResultByClusterID(ctx, clusterID, tenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// The job is kept as history with its cluster reference cleared.
+	job, err := s.GetJob(ctx, jobID, tenantID)
+	require.NoError(t, err)
+	assert.Nil(t, job.ClusterID)
+
+	err = s.DeleteErrorCluster(ctx, clusterID, tenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestErrorCluster_GetByFingerprints(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	fps := []string{"fp-a", "fp-b", "fp-c"}
+	for _, fp := range fps {
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: uuid.New(), TenantID: tenantID, Service: "svc",
+			Namespace: "default", Fingerprint: fp, Level: "ERROR",
+			FirstSeenAt: now, LastSeenAt: now, Count: 1,
+			SampleMessage: "msg", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	clusters, err := s.GetClustersByFingerprints(ctx, tenantID, []string{"fp-a", "fp-c"})
+	require.NoError(t, err)
+	assert.Len(t, clusters, 2)
+}
+
+func TestErrorCluster_GetByFingerprint(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	created, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: uuid.New(), TenantID: tenantID, Service: "svc",
+		Namespace: "default", Fingerprint: "fp-dedup", Level: "ERROR",
+		FirstSeenAt: now, LastSeenAt: now, Count: 1,
+		SampleMessage: "msg", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	got, err := s.GetErrorClusterByFingerprint(ctx, tenantID, "svc", "default", "fp-dedup")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+
+	// Every part of the natural key must match.
+	_, err = s.GetErrorClusterByFingerprint(ctx, tenantID, "other-svc", "default", "fp-dedup")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetErrorClusterByFingerprint(ctx, tenantID, "svc", "staging", "fp-dedup")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetErrorClusterByFingerprint(ctx, tenantID, "svc", "default", "fp-unknown")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetErrorClusterByFingerprint(ctx, uuid.New(), "svc", "default", "fp-dedup")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestErrorCluster_GetByFingerprintsEmpty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+
+	clusters, err := s.GetClustersByFingerprints(context.Background(), uuid.New(), []string{})
+	require.NoError(t, err)
+	assert.Empty(t, clusters)
+}
+
+// --- Cluster Suppression Tests ---
+
+func TestClusterSuppression_CreateListDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	byFingerprint := &models.ClusterSuppression{
+		ID: uuid.New(), TenantID: tenantID, Fingerprint: "fp-noise", Reason: "health checks", CreatedAt: now,
+	}
+	byPattern := &models.ClusterSuppression{
+		ID: uuid.New(), TenantID: tenantID, Pattern: `broken pipe`, CreatedAt: now.Add(time.Second),
+	}
+	require.NoError(t, s.CreateClusterSuppression(ctx, byFingerprint))
+	require.NoError(t, s.CreateClusterSuppression(ctx, byPattern))
+
+	// Exactly one of fingerprint and pattern must be set.
+	err := s.CreateClusterSuppression(ctx, &models.ClusterSuppression{
+		ID: uuid.New(), TenantID: tenantID, Fingerprint: "fp", Pattern: "x", CreatedAt: now,
+	})
+	assert.Error(t, err)
+
+	sups, err := s.ListClusterSuppressions(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, sups, 2)
+	assert.Equal(t, byFingerprint.ID, sups[0].ID)
+	assert.Equal(t, "health checks", sups[0].Reason)
+	assert.Equal(t, `broken pipe`, sups[1].Pattern)
+
+	others, err := s.ListClusterSuppressions(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, others)
+
+	assert.ErrorIs(t, s.DeleteClusterSuppression(ctx, byFingerprint.ID, uuid.New()), store.ErrNotFound)
+	require.NoError(t, s.DeleteClusterSuppression(ctx, byFingerprint.ID, tenantID))
+	assert.ErrorIs(t, s.DeleteClusterSuppression(ctx, byFingerprint.ID, tenantID), store.ErrNotFound)
+
+	sups, err = s.ListClusterSuppressions(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Len(t, sups, 1)
+}
+
+func TestClusterSuppression_IngestSkipsSuppressed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	require.NoError(t, s.CreateClusterSuppression(ctx, &models.ClusterSuppression{
+		ID: uuid.New(), TenantID: tenantID, Pattern: `GET /healthz .* 404`, CreatedAt: now,
+	}))
+	require.NoError(t, s.CreateClusterSuppression(ctx, &models.ClusterSuppression{
+		ID: uuid.New(), TenantID: tenantID, Fingerprint: "fp-disconnect", CreatedAt: now,
+	}))
+
+	cluster := func(fp, msg string) models.ErrorCluster {
+		return models.ErrorCluster{
+			ID: uuid.New(), Service: "svc", Namespace: "default", Fingerprint: fp, Level: "error",
+			FirstSeenAt: now, LastSeenAt: now, Count: 1, SampleMessage: msg,
+		}
+	}
+	stored, err := analysis.NewIngester(s, nil, "", nil).Ingest(ctx, tenantID, []models.ErrorCluster{
+		cluster("fp-probe", "GET /healthz returned 404"),
+		cluster("fp-disconnect", "client disconnected"),
+		cluster("fp-real", "database timeout"),
+	})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "fp-real", stored[0].Fingerprint)
+
+	clusters, total, err := s.ListErrorClusters(ctx, store.ClusterFilter{TenantID: tenantID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, "fp-real", clusters[0].Fingerprint)
+	_, err = s.GetErrorClusterByFingerprint(ctx, tenantID, "svc", "default", "fp-probe")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+// --- Analysis Result Tests ---
+
+func TestAnalysisResult_CreateAndGetByJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Create cluster and job first
+	clusterID := uuid.New()
+	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
 		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
 		Fingerprint: "fp-analysis", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
 		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
@@ -463,7 +1523,7 @@ func TestAnalysisResult_CreateAndGetByJob(t *testing.T) {
 
 	jobID := uuid.New()
 	require.NoError(t, s.CreateJob(ctx, &models.Job{
-		ID: jobID, TenantID: tenantID, Type: "analysis", Status: "pending",
+		ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "pending",
 		ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
 	}))
 
@@ -474,17 +1534,384 @@ func TestAnalysisResult_CreateAndGetByJob(t *testing.T) {
 		Confidence: 0.85, Summary: "Out of memory error",
 		SuggestedAction: &action, CreatedAt: now,
 	}
-	err = s.CreateAnalysisResult(ctx, result)
+	err = s.CreateAnalysisResult(ctx, result)
+	require.NoError(t, err)
+
+	got, err := s.GetAnalysisResultByJobID(ctx, jobID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, result.ID, got.ID)
+	assert.Equal(t, "OOM", got.RootCause)
+	assert.InDelta(t, 0.85, got.Confidence, 0.001)
+	assert.False(t, got.ConfidenceClamped)
+}
+
+func TestAnalysisResult_NullSuggestedAction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	clusterID := uuid.New()
+	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
+		Fingerprint: "fp-null-action", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	jobID := uuid.New()
+	_, err = pool.Exec(ctx,
+		`INSERT INTO analysis_results (cluster_id, tenant_id, job_id, provider, model, root_cause, confidence, summary, suggested_action)
+		 VALUES ($1, $2, $3, 'ollama', 'llama3', 'OOM', 0.5, 'summary', NULL)`,
+		clusterID, tenantID, jobID)
+	require.NoError(t, err)
+
+	byJob, err := s.GetAnalysisResultByJobID(ctx, jobID, tenantID)
+	require.NoError(t, err)
+	assert.Nil(t, byJob.SuggestedAction)
+
+	byCluster, err := s.GetAnalysisResultByClusterID(ctx, clusterID, tenantID)
+	require.NoError(t, err)
+	assert.Nil(t, byCluster.SuggestedAction)
+	assert.Equal(t, byJob.ID, byCluster.ID)
+}
+
+func TestAnalysisResult_CreateTwiceForJobReplaces(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	clusterID := uuid.New()
+	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
+		Fingerprint: "fp-reanalysis", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	jobID := uuid.New()
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "pending",
+		ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	first := &models.AnalysisResult{
+		ID: uuid.New(), ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
+		Provider: "ollama", Model: "llama3", RootCause: "OOM",
+		Confidence: 0.5, Summary: "first pass", CreatedAt: now,
+	}
+	require.NoError(t, s.CreateAnalysisResult(ctx, first))
+
+	second := &models.AnalysisResult{
+		ID: uuid.New(), ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
+		Provider: "ollama", Model: "llama3", RootCause: "connection pool exhausted",
+		Confidence: 0.9, Summary: "second pass", CreatedAt: now.Add(time.Minute),
+	}
+	require.NoError(t, s.CreateAnalysisResult(ctx, second))
+	assert.Equal(t, first.ID, second.ID, "replacement keeps the original row ID")
+
+	var rows int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM analysis_results WHERE job_id = $1`, jobID).Scan(&rows))
+	assert.Equal(t, 1, rows)
+
+	got, err := s.GetAnalysisResultByJobID(ctx, jobID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "connection pool exhausted", got.RootCause)
+	assert.Equal(t, "second pass", got.Summary)
+	assert.InDelta(t, 0.9, got.Confidence, 0.001)
+}
+
+func TestAnalysisContext_SaveAndGet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	jobID := uuid.New()
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "completed",
+		CreatedAt: now, UpdatedAt: now,
+	}))
+
+	_, err := s.GetAnalysisContext(ctx, jobID, tenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	ac := &models.AnalysisContext{
+		JobID:    jobID,
+		TenantID: tenantID,
+		Lines: []models.LogLine{
+			{Timestamp: now, Message: "connection refused", Level: "error", Labels: map[string]string{"service": "api"}},
+		},
+		TotalLines: 120,
+		CreatedAt:  now,
+	}
+	require.NoError(t, s.SaveAnalysisContext(ctx, ac))
+
+	got, err := s.GetAnalysisContext(ctx, jobID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 120, got.TotalLines)
+	require.Len(t, got.Lines, 1)
+	assert.Equal(t, "connection refused", got.Lines[0].Message)
+	assert.Equal(t, "api", got.Lines[0].Labels["service"])
+	assert.True(t, now.Equal(got.Lines[0].Timestamp))
+
+	// Re-analysis replaces the sample.
+	ac.Lines = nil
+	ac.TotalLines = 0
+	require.NoError(t, s.SaveAnalysisContext(ctx, ac))
+	got, err = s.GetAnalysisContext(ctx, jobID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.TotalLines)
+
+	// Another tenant cannot read it.
+	_, err = s.GetAnalysisContext(ctx, jobID, uuid.New())
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestAnalysisResult_GetByCluster(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	clusterID := uuid.New()
+	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
+		Fingerprint: "fp-cluster-analysis", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	jobID := uuid.New()
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "pending",
+		ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	require.NoError(t, s.CreateAnalysisResult(ctx, &models.AnalysisResult{
+		ID: uuid.New(), ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
+		Provider: "ollama", Model: "llama3", RootCause: "disk full",
+		Confidence: 0.9, Summary: "Disk is full", CreatedAt: now,
+	}))
+
+	got, err := s.GetAnalysisResultByClusterID(ctx, clusterID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "disk full", got.RootCause)
+
+	// Neither lookup finds the result for another tenant.
+	var otherTenantID uuid.UUID
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO tenants (name) VALUES ('other') RETURNING id`).Scan(&otherTenantID))
+	_, err = s.GetAnalysisResultByClusterID(ctx, clusterID, otherTenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetAnalysisResultByJobID(ctx, jobID, otherTenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestAnalysisResult_GetByID(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	clusterID := uuid.New()
+	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
+		Fingerprint: "fp-result-by-id", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
+	})
+	require.NoError(t, err)
+
+	jobID := uuid.New()
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "completed",
+		ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	resultID := uuid.New()
+	require.NoError(t, s.CreateAnalysisResult(ctx, &models.AnalysisResult{
+		ID: resultID, ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
+		Provider: "ollama", Model: "llama3", RootCause: "OOM",
+		Confidence: 1.0, ConfidenceClamped: true, Summary: "Out of memory", CreatedAt: now,
+	}))
+
+	got, err := s.GetAnalysisResultByID(ctx, resultID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, resultID, got.ID)
+	assert.Equal(t, jobID, got.JobID)
+	assert.Equal(t, "OOM", got.RootCause)
+	assert.True(t, got.ConfidenceClamped)
+
+	var otherTenantID uuid.UUID
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO tenants (name) VALUES ('other') RETURNING id`).Scan(&otherTenantID))
+	_, err = s.GetAnalysisResultByID(ctx, resultID, otherTenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	_, err = s.GetAnalysisResultByID(ctx, uuid.New(), tenantID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestAnalysisResult_GetByJobNotFound(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+
+	_, err := s.GetAnalysisResultByJobID(context.Background(), uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestAnalysisResult_DeleteOrphaned(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	seed := func(fingerprint string) (clusterID, resultID uuid.UUID) {
+		clusterID = uuid.New()
+		_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
+			ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
+			Fingerprint: fingerprint, Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
+			Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
+		})
+		require.NoError(t, err)
+
+		jobID := uuid.New()
+		require.NoError(t, s.CreateJob(ctx, &models.Job{
+			ID: jobID, TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "completed",
+			ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
+		}))
+
+		resultID = uuid.New()
+		require.NoError(t, s.CreateAnalysisResult(ctx, &models.AnalysisResult{
+			ID: resultID, ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
+			Provider: "ollama", Model: "llama3", RootCause: "OOM",
+			Confidence: 0.8, Summary: "Out of memory", CreatedAt: now,
+		}))
+		return clusterID, resultID
+	}
+	liveCluster, liveResult := seed("fp-live")
+	orphanCluster, orphanResult := seed("fp-orphan")
+
+	// Simulate a legacy hard delete: replica mode skips the foreign key
+	// triggers, so the cluster goes without its results.
+	conn, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "SET session_replication_role = replica")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "DELETE FROM error_clusters WHERE id = $1", orphanCluster)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "SET session_replication_role = DEFAULT")
+	require.NoError(t, err)
+	conn.Release()
+
+	deleted, err := s.DeleteOrphanedAnalysisResults(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	var remaining []uuid.UUID
+	rows, err := pool.Query(ctx, "SELECT id FROM analysis_results")
+	require.NoError(t, err)
+	for rows.Next() {
+		var id uuid.UUID
+		require.NoError(t, rows.Scan(&id))
+		remaining = append(remaining, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []uuid.UUID{liveResult}, remaining)
+	assert.NotContains(t, remaining, orphanResult)
+
+	got, err := s.GetAnalysisResultByClusterID(ctx, liveCluster, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, liveResult, got.ID)
+
+	deleted, err = s.DeleteOrphanedAnalysisResults(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
+
+// --- Job Tests ---
+
+func TestJob_CreateAndGet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	err := s.CreateJob(ctx, job)
+	require.NoError(t, err)
+
+	got, err := s.GetJob(ctx, job.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", got.Status)
+	assert.Nil(t, got.StartedAt)
+}
+
+func TestJob_MetadataRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Status: "pending", CreatedAt: now, UpdatedAt: now,
+		Metadata: map[string]string{models.JobMetaFingerprint: "fp-1", models.JobMetaModel: "strong-model"},
+	}
+	require.NoError(t, s.CreateJob(ctx, job))
+
+	got, err := s.GetJob(ctx, job.ID, tenantID)
 	require.NoError(t, err)
+	assert.Equal(t, models.JobTypeAnalysis, got.Type)
+	assert.Equal(t, job.Metadata, got.Metadata)
 
-	got, err := s.GetAnalysisResultByJobID(ctx, jobID)
+	bare := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, bare))
+	got, err = s.GetJob(ctx, bare.ID, tenantID)
 	require.NoError(t, err)
-	assert.Equal(t, result.ID, got.ID)
-	assert.Equal(t, "OOM", got.RootCause)
-	assert.InDelta(t, 0.85, got.Confidence, 0.001)
+	assert.Empty(t, got.Metadata)
 }
 
-func TestAnalysisResult_GetByCluster(t *testing.T) {
+func TestJob_ReplayedFrom(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
@@ -494,45 +1921,59 @@ func TestAnalysisResult_GetByCluster(t *testing.T) {
 	tenantID := defaultTenantID(t, s)
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
-	clusterID := uuid.New()
-	_, err := s.UpsertErrorCluster(ctx, &models.ErrorCluster{
-		ID: clusterID, TenantID: tenantID, Service: "svc", Namespace: "default",
-		Fingerprint: "fp-cluster-analysis", Level: "ERROR", FirstSeenAt: now, LastSeenAt: now,
-		Count: 1, SampleMessage: "error", CreatedAt: now, UpdatedAt: now,
-	})
-	require.NoError(t, err)
-
-	jobID := uuid.New()
-	require.NoError(t, s.CreateJob(ctx, &models.Job{
-		ID: jobID, TenantID: tenantID, Type: "analysis", Status: "pending",
-		ClusterID: &clusterID, CreatedAt: now, UpdatedAt: now,
-	}))
+	original := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, original))
+	replay := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "pending",
+		ReplayedFrom: &original.ID, CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, replay))
 
-	require.NoError(t, s.CreateAnalysisResult(ctx, &models.AnalysisResult{
-		ID: uuid.New(), ClusterID: clusterID, TenantID: tenantID, JobID: jobID,
-		Provider: "ollama", Model: "llama3", RootCause: "disk full",
-		Confidence: 0.9, Summary: "Disk is full", CreatedAt: now,
-	}))
+	got, err := s.GetJob(ctx, replay.ID, tenantID)
+	require.NoError(t, err)
+	require.NotNil(t, got.ReplayedFrom)
+	assert.Equal(t, original.ID, *got.ReplayedFrom)
 
-	got, err := s.GetAnalysisResultByClusterID(ctx, clusterID)
+	jobs, err := s.GetJobsByIDs(ctx, tenantID, []uuid.UUID{original.ID})
 	require.NoError(t, err)
-	assert.Equal(t, "disk full", got.RootCause)
+	require.Len(t, jobs, 1)
+	assert.Nil(t, jobs[0].ReplayedFrom)
 }
 
-func TestAnalysisResult_GetByJobNotFound(t *testing.T) {
+func TestJob_GetJobByIDAnyTenant(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
 	pool := setupTestDB(t)
 	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	var otherTenantID uuid.UUID
+	err := pool.QueryRow(ctx, `INSERT INTO tenants (name) VALUES ('other') RETURNING id`).Scan(&otherTenantID)
+	require.NoError(t, err)
+
+	for _, tenantID := range []uuid.UUID{defaultTenantID(t, s), otherTenantID} {
+		job := &models.Job{
+			ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+			Status: "pending", CreatedAt: now, UpdatedAt: now,
+		}
+		require.NoError(t, s.CreateJob(ctx, job))
 
-	_, err := s.GetAnalysisResultByJobID(context.Background(), uuid.New())
+		got, err := s.GetJobByID(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tenantID, got.TenantID)
+		assert.Equal(t, "pending", got.Status)
+	}
+
+	_, err = s.GetJobByID(ctx, uuid.New())
 	assert.ErrorIs(t, err, store.ErrNotFound)
 }
 
-// --- Job Tests ---
-
-func TestJob_CreateAndGet(t *testing.T) {
+func TestJob_GetByIDs(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
 	}
@@ -542,17 +1983,34 @@ func TestJob_CreateAndGet(t *testing.T) {
 	tenantID := defaultTenantID(t, s)
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
-	job := &models.Job{
-		ID: uuid.New(), TenantID: tenantID, Type: "analysis",
-		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	var ids []uuid.UUID
+	for _, status := range []string{"pending", "running"} {
+		job := &models.Job{
+			ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+			Status: status, CreatedAt: now, UpdatedAt: now,
+		}
+		require.NoError(t, s.CreateJob(ctx, job))
+		ids = append(ids, job.ID)
 	}
-	err := s.CreateJob(ctx, job)
+
+	jobs, err := s.GetJobsByIDs(ctx, tenantID, append(ids, uuid.New()))
 	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	got := map[uuid.UUID]string{}
+	for _, j := range jobs {
+		got[j.ID] = j.Status
+	}
+	assert.Equal(t, "pending", got[ids[0]])
+	assert.Equal(t, "running", got[ids[1]])
 
-	got, err := s.GetJob(ctx, job.ID, tenantID)
+	// Scoped to the tenant.
+	jobs, err = s.GetJobsByIDs(ctx, uuid.New(), ids)
 	require.NoError(t, err)
-	assert.Equal(t, "pending", got.Status)
-	assert.Nil(t, got.StartedAt)
+	assert.Empty(t, jobs)
+
+	jobs, err = s.GetJobsByIDs(ctx, tenantID, nil)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
 }
 
 func TestJob_GetNotFound(t *testing.T) {
@@ -577,7 +2035,7 @@ func TestJob_UpdateStatusPendingToRunning(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
 	job := &models.Job{
-		ID: uuid.New(), TenantID: tenantID, Type: "analysis",
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
 		Status: "pending", CreatedAt: now, UpdatedAt: now,
 	}
 	require.NoError(t, s.CreateJob(ctx, job))
@@ -602,7 +2060,7 @@ func TestJob_UpdateStatusRunningToCompleted(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
 	job := &models.Job{
-		ID: uuid.New(), TenantID: tenantID, Type: "analysis",
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
 		Status: "pending", CreatedAt: now, UpdatedAt: now,
 	}
 	require.NoError(t, s.CreateJob(ctx, job))
@@ -617,6 +2075,34 @@ func TestJob_UpdateStatusRunningToCompleted(t *testing.T) {
 	assert.NotNil(t, got.CompletedAt)
 }
 
+func TestJob_UpdateStatusExplicitTimestamps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	startedAt := now.Add(-2 * time.Hour)
+	completedAt := now.Add(-time.Hour)
+
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, job))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, "running", store.WithStartedAt(startedAt)))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, "completed", store.WithCompletedAt(completedAt)))
+
+	got, err := s.GetJob(ctx, job.ID, tenantID)
+	require.NoError(t, err)
+	require.NotNil(t, got.StartedAt)
+	require.NotNil(t, got.CompletedAt)
+	assert.True(t, startedAt.Equal(*got.StartedAt), "started_at = %s, want %s", got.StartedAt, startedAt)
+	assert.True(t, completedAt.Equal(*got.CompletedAt), "completed_at = %s, want %s", got.CompletedAt, completedAt)
+}
+
 func TestJob_UpdateStatusRunningToFailed(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -628,7 +2114,7 @@ func TestJob_UpdateStatusRunningToFailed(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
 	job := &models.Job{
-		ID: uuid.New(), TenantID: tenantID, Type: "analysis",
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
 		Status: "pending", CreatedAt: now, UpdatedAt: now,
 	}
 	require.NoError(t, s.CreateJob(ctx, job))
@@ -645,6 +2131,164 @@ func TestJob_UpdateStatusRunningToFailed(t *testing.T) {
 	assert.Equal(t, "timeout", *got.ErrorMessage)
 }
 
+func TestJob_UpdateStatusFailedWithErrorCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, job))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, "running"))
+
+	err := s.UpdateJobStatus(ctx, job.ID, "failed",
+		store.WithErrorMessage("loki query error: status 400"),
+		store.WithErrorCode(models.JobErrorLokiQuery))
+	require.NoError(t, err)
+
+	got, err := s.GetJob(ctx, job.ID, tenantID)
+	require.NoError(t, err)
+	require.NotNil(t, got.ErrorCode)
+	assert.Equal(t, models.JobErrorLokiQuery, *got.ErrorCode)
+	require.NotNil(t, got.ErrorMessage)
+	assert.Equal(t, "loki query error: status 400", *got.ErrorMessage)
+}
+
+// createFailedJob stores a job and fails it with code.
+func createFailedJob(t *testing.T, s *store.PostgresStore, tenantID uuid.UUID, code string) *models.Job {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, job))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, "running"))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, "failed",
+		store.WithErrorMessage("failed with "+code), store.WithErrorCode(code)))
+	return job
+}
+
+func TestJob_ListFiltersByStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	failed := createFailedJob(t, s, tenantID, models.JobErrorAITimeout)
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}))
+
+	jobs, total, err := s.ListJobs(ctx, tenantID, store.JobFilter{Status: models.JobStatusFailed})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, failed.ID, jobs[0].ID)
+	require.NotNil(t, jobs[0].ErrorMessage)
+	assert.Equal(t, "failed with "+models.JobErrorAITimeout, *jobs[0].ErrorMessage)
+
+	_, total, err = s.ListJobs(ctx, tenantID, store.JobFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	jobs, total, err = s.ListJobs(ctx, uuid.New(), store.JobFilter{Status: models.JobStatusFailed})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, jobs)
+}
+
+func TestJob_ListFiltersByTypeAndPaginates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	var analyses []uuid.UUID
+	for i := range 3 {
+		job := &models.Job{
+			ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis, Status: "pending",
+			CreatedAt: now.Add(time.Duration(i) * time.Second), UpdatedAt: now,
+		}
+		require.NoError(t, s.CreateJob(ctx, job))
+		analyses = append(analyses, job.ID)
+	}
+	require.NoError(t, s.CreateJob(ctx, &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: "export", Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}))
+
+	jobs, total, err := s.ListJobs(ctx, tenantID, store.JobFilter{Type: models.JobTypeAnalysis, Page: 1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, jobs, 2)
+	// Newest first.
+	assert.Equal(t, analyses[2], jobs[0].ID)
+	assert.Equal(t, analyses[1], jobs[1].ID)
+
+	jobs, _, err = s.ListJobs(ctx, tenantID, store.JobFilter{Type: models.JobTypeAnalysis, Page: 2, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, analyses[0], jobs[0].ID)
+
+	jobs, total, err = s.ListJobs(ctx, tenantID, store.JobFilter{Type: "export", Status: models.JobStatusPending})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "export", jobs[0].Type)
+}
+
+func TestJob_ListFiltersByErrorCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+
+	first := createFailedJob(t, s, tenantID, models.JobErrorAITimeout)
+	createFailedJob(t, s, tenantID, models.JobErrorLokiQuery)
+	second := createFailedJob(t, s, tenantID, models.JobErrorAITimeout)
+
+	jobs, total, err := s.ListJobs(ctx, tenantID, store.JobFilter{
+		Status: models.JobStatusFailed, ErrorCode: models.JobErrorAITimeout,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, jobs, 2)
+	ids := []uuid.UUID{jobs[0].ID, jobs[1].ID}
+	assert.ElementsMatch(t, []uuid.UUID{first.ID, second.ID}, ids)
+	for _, j := range jobs {
+		require.NotNil(t, j.ErrorCode)
+		assert.Equal(t, models.JobErrorAITimeout, *j.ErrorCode)
+	}
+
+	// Pagination applies after filtering.
+	jobs, total, err = s.ListJobs(ctx, tenantID, store.JobFilter{
+		ErrorCode: models.JobErrorAITimeout, Page: 2, Limit: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, jobs, 1)
+}
+
 func TestJob_UpdateStatusInvalidTransition(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -656,7 +2300,7 @@ func TestJob_UpdateStatusInvalidTransition(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Microsecond)
 
 	job := &models.Job{
-		ID: uuid.New(), TenantID: tenantID, Type: "analysis",
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
 		Status: "pending", CreatedAt: now, UpdatedAt: now,
 	}
 	require.NoError(t, s.CreateJob(ctx, job))
@@ -664,6 +2308,76 @@ func TestJob_UpdateStatusInvalidTransition(t *testing.T) {
 	err := s.UpdateJobStatus(ctx, job.ID, "completed") // pending -> completed is invalid
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid job status transition")
+	assert.ErrorIs(t, err, store.ErrInvalidTransition)
+}
+
+func TestJob_UpdateStatusCancel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	newJob := func() *models.Job {
+		job := &models.Job{
+			ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+			Status: "pending", CreatedAt: now, UpdatedAt: now,
+		}
+		require.NoError(t, s.CreateJob(ctx, job))
+		return job
+	}
+
+	pending := newJob()
+	require.NoError(t, s.UpdateJobStatus(ctx, pending.ID, models.JobStatusCancelled))
+	got, err := s.GetJob(ctx, pending.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusCancelled, got.Status)
+	assert.NotNil(t, got.CompletedAt)
+	assert.Nil(t, got.StartedAt)
+
+	running := newJob()
+	require.NoError(t, s.UpdateJobStatus(ctx, running.ID, models.JobStatusRunning))
+	require.NoError(t, s.UpdateJobStatus(ctx, running.ID, models.JobStatusCancelled))
+	got, err = s.GetJob(ctx, running.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusCancelled, got.Status)
+	assert.NotNil(t, got.StartedAt)
+	assert.NotNil(t, got.CompletedAt)
+
+	// A cancelled job is terminal.
+	err = s.UpdateJobStatus(ctx, running.ID, models.JobStatusRunning)
+	assert.Error(t, err)
+	err = s.UpdateJobStatus(ctx, running.ID, models.JobStatusCompleted)
+	assert.Error(t, err)
+}
+
+func TestJob_UpdateStatusCancelFinishedJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, job))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, models.JobStatusCompleted))
+
+	err := s.UpdateJobStatus(ctx, job.ID, models.JobStatusCancelled)
+	assert.Error(t, err)
+
+	got, err := s.GetJob(ctx, job.ID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusCompleted, got.Status)
 }
 
 func TestJob_UpdateStatusNotFound(t *testing.T) {
@@ -677,6 +2391,54 @@ func TestJob_UpdateStatusNotFound(t *testing.T) {
 	assert.ErrorIs(t, err, store.ErrNotFound)
 }
 
+func TestJob_UpdateStatusConcurrentTransitions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	job := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "pending", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, job))
+	require.NoError(t, s.UpdateJobStatus(ctx, job.ID, "running"))
+
+	// Two workers race to finish the same running job.
+	start := make(chan struct{})
+	results := make(chan error, 2)
+	for _, status := range []string{"completed", "failed"} {
+		go func(status string) {
+			<-start
+			results <- s.UpdateJobStatus(ctx, job.ID, status)
+		}(status)
+	}
+	close(start)
+
+	var succeeded, lost int
+	for range 2 {
+		err := <-results
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, store.ErrConcurrentUpdate):
+			lost++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 1, lost)
+
+	got, err := s.GetJob(ctx, job.ID, tenantID)
+	require.NoError(t, err)
+	assert.Contains(t, []string{"completed", "failed"}, got.Status)
+}
+
 func TestJob_UpdateStatusWithClusterID(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -697,7 +2459,7 @@ func TestJob_UpdateStatusWithClusterID(t *testing.T) {
 	require.NoError(t, err)
 
 	job := &models.Job{
-		ID: uuid.New(), TenantID: tenantID, Type: "analysis",
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
 		Status: "pending", CreatedAt: now, UpdatedAt: now,
 	}
 	require.NoError(t, s.CreateJob(ctx, job))
@@ -714,6 +2476,70 @@ func TestJob_UpdateStatusWithClusterID(t *testing.T) {
 
 // --- Ping Test ---
 
+func TestJob_Stats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+	ctx := context.Background()
+	tenantID := defaultTenantID(t, s)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	seed := []struct {
+		status   string
+		duration time.Duration
+	}{
+		{"pending", 0},
+		{"running", 0},
+		{"completed", 2 * time.Second},
+		{"completed", 4 * time.Second},
+		{"failed", 6 * time.Second},
+	}
+	for _, sd := range seed {
+		job := &models.Job{
+			ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+			Status: sd.status, CreatedAt: now, UpdatedAt: now,
+		}
+		require.NoError(t, s.CreateJob(ctx, job))
+		if sd.duration > 0 {
+			_, err := pool.Exec(ctx,
+				`UPDATE jobs SET started_at = $2, completed_at = $3 WHERE id = $1`,
+				job.ID, now, now.Add(sd.duration))
+			require.NoError(t, err)
+		}
+	}
+
+	// A job outside the window must not be counted
+	old := &models.Job{
+		ID: uuid.New(), TenantID: tenantID, Type: models.JobTypeAnalysis,
+		Status: "failed", CreatedAt: now.Add(-48 * time.Hour), UpdatedAt: now,
+	}
+	require.NoError(t, s.CreateJob(ctx, old))
+
+	stats, err := s.JobStats(ctx, tenantID, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, 1, stats.ByStatus["pending"])
+	assert.Equal(t, 1, stats.ByStatus["running"])
+	assert.Equal(t, 2, stats.ByStatus["completed"])
+	assert.Equal(t, 1, stats.ByStatus["failed"])
+	assert.Equal(t, 4*time.Second, stats.AvgDuration)
+}
+
+func TestJob_StatsEmpty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pool := setupTestDB(t)
+	s := store.NewPostgresStore(pool)
+
+	stats, err := s.JobStats(context.Background(), uuid.New(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Total)
+	assert.Equal(t, time.Duration(0), stats.AvgDuration)
+}
+
 func TestPing(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
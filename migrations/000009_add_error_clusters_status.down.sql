@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_error_clusters_open_last_seen;
+
+ALTER TABLE error_clusters
+    DROP COLUMN IF EXISTS resolved_at,
+    DROP COLUMN IF EXISTS auto_resolved,
+    DROP COLUMN IF EXISTS status;
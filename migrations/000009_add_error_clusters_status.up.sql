@@ -0,0 +1,7 @@
+ALTER TABLE error_clusters
+    ADD COLUMN status        VARCHAR(16) NOT NULL DEFAULT 'open'
+                             CHECK (status IN ('open','acknowledged','resolved')),
+    ADD COLUMN auto_resolved BOOLEAN     NOT NULL DEFAULT FALSE,
+    ADD COLUMN resolved_at   TIMESTAMPTZ;
+
+CREATE INDEX idx_error_clusters_open_last_seen ON error_clusters(last_seen_at) WHERE status = 'open';
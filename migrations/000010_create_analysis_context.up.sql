@@ -0,0 +1,9 @@
+CREATE TABLE analysis_context (
+    job_id      UUID        PRIMARY KEY,
+    tenant_id   UUID        NOT NULL REFERENCES tenants(id),
+    lines       JSONB       NOT NULL DEFAULT '[]',
+    total_lines INTEGER     NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_analysis_context_tenant_id ON analysis_context(tenant_id);
@@ -0,0 +1,2 @@
+ALTER TABLE error_clusters
+    ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE jobs
+    ADD COLUMN replayed_from UUID REFERENCES jobs(id) ON DELETE SET NULL;
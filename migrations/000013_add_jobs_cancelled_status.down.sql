@@ -0,0 +1,5 @@
+UPDATE jobs SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs
+    ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('pending','running','completed','failed'));
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_error_clusters_tenant_first_seen;
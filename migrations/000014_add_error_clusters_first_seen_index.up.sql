@@ -0,0 +1 @@
+CREATE INDEX idx_error_clusters_tenant_first_seen ON error_clusters(tenant_id, first_seen_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE analysis_results
+    DROP COLUMN IF EXISTS confidence_clamped;
@@ -0,0 +1,2 @@
+ALTER TABLE analysis_results
+    ADD COLUMN confidence_clamped BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE error_clusters
+    DROP COLUMN IF EXISTS reopened_at;
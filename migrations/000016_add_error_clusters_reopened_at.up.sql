@@ -0,0 +1,2 @@
+ALTER TABLE error_clusters
+    ADD COLUMN reopened_at TIMESTAMPTZ;
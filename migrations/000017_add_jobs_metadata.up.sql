@@ -0,0 +1,2 @@
+ALTER TABLE jobs
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
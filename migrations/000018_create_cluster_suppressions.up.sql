@@ -0,0 +1,11 @@
+CREATE TABLE cluster_suppressions (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id   UUID        NOT NULL REFERENCES tenants(id),
+    fingerprint VARCHAR(64) NOT NULL DEFAULT '',
+    pattern     TEXT        NOT NULL DEFAULT '',
+    reason      TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((fingerprint = '') <> (pattern = ''))
+);
+
+CREATE INDEX idx_cluster_suppressions_tenant_id ON cluster_suppressions(tenant_id);
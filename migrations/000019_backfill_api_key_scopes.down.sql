@@ -0,0 +1,3 @@
+-- The backfill cannot be told apart from scopes granted afterwards, so it is
+-- left in place.
+SELECT 1;
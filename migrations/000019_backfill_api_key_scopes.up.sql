@@ -0,0 +1,4 @@
+-- Keys created before route scopes existed have no scopes; keep them working.
+UPDATE api_keys
+SET scopes = ARRAY(SELECT DISTINCT unnest(scopes || ARRAY['read', 'write']::TEXT[]))
+WHERE NOT scopes @> ARRAY['read', 'write']::TEXT[];
@@ -1,15 +1,62 @@
 package logql
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// ErrInvalidLabel is returned when a query would reference a label name
+// outside the builder's allowlist.
+var ErrInvalidLabel = errors.New("label not allowed")
+
+// ErrInvalidMatcher is returned when a label matcher has an unknown operator
+// or a malformed label name.
+var ErrInvalidMatcher = errors.New("invalid label matcher")
+
+// DefaultAllowedLabels are the label names a zero-value QueryBuilder permits.
+var DefaultAllowedLabels = []string{"service", "namespace", "level"}
+
 // QueryBuilder constructs safe LogQL query strings.
 // All methods are pure functions with no side effects.
 // Zero value is ready to use.
-type QueryBuilder struct{}
+type QueryBuilder struct {
+	// AllowedLabels restricts which label names queries may reference.
+	// Nil means DefaultAllowedLabels.
+	AllowedLabels []string
+}
+
+// Label matcher operators.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// LabelMatcher is one matcher of a stream selector, such as pod=~"api-.*".
+type LabelMatcher struct {
+	Label string
+	// Op is one of the Match* operators.
+	Op    string
+	Value string
+}
+
+// labelNamePattern is the syntax Loki accepts for label names.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// valid reports whether m can be written into a selector as is.
+func (m LabelMatcher) valid() bool {
+	switch m.Op {
+	case MatchEqual, MatchNotEqual, MatchRegexp, MatchNotRegexp:
+		return labelNamePattern.MatchString(m.Label)
+	}
+	return false
+}
 
 // DetectionParams defines inputs for error/warning detection queries.
 type DetectionParams struct {
@@ -18,6 +65,8 @@ type DetectionParams struct {
 	Start     time.Time
 	End       time.Time
 	Levels    []string
+	// Matchers are added to the stream selector after service and namespace.
+	Matchers []LabelMatcher
 }
 
 // SearchParams defines inputs for log search queries.
@@ -28,11 +77,70 @@ type SearchParams struct {
 	End       time.Time
 	Levels    []string
 	Keyword   string
+	// Matchers are added to the stream selector after service and namespace.
+	Matchers []LabelMatcher
+}
+
+// Labels returns the label names a detection query built from p references.
+func (p DetectionParams) Labels() []string {
+	return queryLabels(p.Namespace, p.Levels, p.Matchers)
+}
+
+// Labels returns the label names a search query built from p references.
+func (p SearchParams) Labels() []string {
+	return queryLabels(p.Namespace, p.Levels, p.Matchers)
+}
+
+func queryLabels(namespace string, levels []string, matchers []LabelMatcher) []string {
+	labels := []string{"service"}
+	if namespace != "" {
+		labels = append(labels, "namespace")
+	}
+	if len(levels) > 0 {
+		labels = append(labels, "level")
+	}
+	for _, m := range matchers {
+		if !slices.Contains(labels, m.Label) {
+			labels = append(labels, m.Label)
+		}
+	}
+	return labels
+}
+
+// CheckLabels returns an error wrapping ErrInvalidLabel for the first name
+// not in the builder's allowlist.
+func (b QueryBuilder) CheckLabels(names ...string) error {
+	allowed := b.AllowedLabels
+	if allowed == nil {
+		allowed = DefaultAllowedLabels
+	}
+	for _, name := range names {
+		if !slices.Contains(allowed, name) {
+			return fmt.Errorf("%w: %q", ErrInvalidLabel, name)
+		}
+	}
+	return nil
+}
+
+// CheckMatchers returns an error wrapping ErrInvalidMatcher for the first
+// matcher with an unknown operator or malformed label name, or one wrapping
+// ErrInvalidLabel for a label outside the allowlist. Matchers that fail it are
+// left out of built queries.
+func (b QueryBuilder) CheckMatchers(matchers []LabelMatcher) error {
+	for _, m := range matchers {
+		if !m.valid() {
+			return fmt.Errorf("%w: %s%s", ErrInvalidMatcher, m.Label, m.Op)
+		}
+		if err := b.CheckLabels(m.Label); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // BuildDetectionQuery returns a LogQL query for error/warning detection.
 func (b QueryBuilder) BuildDetectionQuery(p DetectionParams) string {
-	parts := []string{b.buildSelector(p.Service, p.Namespace)}
+	parts := []string{b.buildSelector(p.Service, p.Namespace, p.Matchers)}
 
 	if lf := b.buildLevelFilter(p.Levels); lf != "" {
 		parts = append(parts, lf)
@@ -43,7 +151,7 @@ func (b QueryBuilder) BuildDetectionQuery(p DetectionParams) string {
 
 // BuildSearchQuery returns a LogQL query for smart search.
 func (b QueryBuilder) BuildSearchQuery(p SearchParams) string {
-	parts := []string{b.buildSelector(p.Service, p.Namespace)}
+	parts := []string{b.buildSelector(p.Service, p.Namespace, p.Matchers)}
 
 	if kf := b.buildKeywordFilter(p.Keyword); kf != "" {
 		parts = append(parts, kf)
@@ -55,27 +163,109 @@ func (b QueryBuilder) BuildSearchQuery(p SearchParams) string {
 	return strings.Join(parts, " ")
 }
 
-func (b QueryBuilder) buildSelector(service, namespace string) string {
+// quote returns v as a double-quoted LogQL string. Quotes and backslashes
+// are escaped and newlines dropped, so raw input cannot end the string early
+// or spill onto another line of the query.
+func quote(v string) string {
+	return strconv.Quote(stripNewlines(v))
+}
+
+var newlineStripper = strings.NewReplacer("\r", "", "\n", "")
+
+func stripNewlines(v string) string {
+	return newlineStripper.Replace(v)
+}
+
+// buildSelector returns the stream selector for service and namespace plus
+// matchers, with every value passed through quote.
+func (b QueryBuilder) buildSelector(service, namespace string, matchers []LabelMatcher) string {
+	parts := []string{"service=" + quote(service)}
 	if namespace != "" {
-		return fmt.Sprintf(`{service="%s", namespace="%s"}`, service, namespace)
+		parts = append(parts, "namespace="+quote(namespace))
 	}
-	return fmt.Sprintf(`{service="%s"}`, service)
+	for _, m := range matchers {
+		// The label and operator are written verbatim, so skip any that
+		// CheckMatchers would reject.
+		if !m.valid() {
+			continue
+		}
+		parts = append(parts, m.Label+m.Op+quote(m.Value))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
 }
 
 func (b QueryBuilder) buildLevelFilter(levels []string) string {
 	if len(levels) == 0 {
 		return ""
 	}
+	// Levels are names, not patterns, so regexp syntax in one matches literally.
 	lower := make([]string, len(levels))
 	for i, l := range levels {
-		lower[i] = strings.ToLower(l)
+		lower[i] = regexp.QuoteMeta(strings.ToLower(l))
 	}
-	return fmt.Sprintf(`| level =~ "(?i)(%s)"`, strings.Join(lower, "|"))
+	return "| level =~ " + quote("(?i)("+strings.Join(lower, "|")+")")
 }
 
+// buildKeywordFilter returns a line filter for keyword. A raw string keeps
+// the common case readable; a keyword containing a backtick, which would end
+// one, is quoted instead.
 func (b QueryBuilder) buildKeywordFilter(keyword string) string {
+	keyword = stripNewlines(keyword)
 	if keyword == "" {
 		return ""
 	}
+	if strings.Contains(keyword, "`") {
+		return "|= " + quote(keyword)
+	}
 	return fmt.Sprintf("|= `%s`", keyword)
 }
+
+// CheckQuery applies CheckLabels to the label names used in the stream
+// selectors of a raw LogQL expression. It does not otherwise parse the query:
+// syntax errors are left for Loki to report.
+func (b QueryBuilder) CheckQuery(query string) error {
+	return b.CheckLabels(selectorLabels(query)...)
+}
+
+// selectorLabels returns the label names between braces in query, skipping
+// quoted and backtick strings so braces inside filters and templates are not
+// mistaken for selectors. Selector values are always strings, so every bare
+// identifier inside braces is a label name.
+func selectorLabels(query string) []string {
+	var labels []string
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '"':
+			for i++; i < len(query) && query[i] != '"'; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+		case c == '`':
+			for i++; i < len(query) && query[i] != '`'; i++ {
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0 && isLabelStart(c):
+			start := i
+			for i+1 < len(query) && isLabelChar(query[i+1]) {
+				i++
+			}
+			labels = append(labels, query[start:i+1])
+		}
+	}
+	return labels
+}
+
+func isLabelStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isLabelChar(c byte) bool {
+	return isLabelStart(c) || ('0' <= c && c <= '9')
+}
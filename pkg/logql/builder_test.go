@@ -1,6 +1,9 @@
 package logql
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestBuildDetectionQuery(t *testing.T) {
 	b := QueryBuilder{}
@@ -151,6 +154,7 @@ func TestBuildSelector(t *testing.T) {
 		name      string
 		service   string
 		namespace string
+		matchers  []LabelMatcher
 		expected  string
 	}{
 		{
@@ -164,18 +168,110 @@ func TestBuildSelector(t *testing.T) {
 			service:  "api",
 			expected: `{service="api"}`,
 		},
+		{
+			name:      "matchers follow the shorthand",
+			service:   "api",
+			namespace: "prod",
+			matchers: []LabelMatcher{
+				{Label: "pod", Op: MatchRegexp, Value: "api-.*"},
+				{Label: "container", Op: MatchNotEqual, Value: "sidecar"},
+				{Label: "app", Op: MatchEqual, Value: "payments"},
+				{Label: "env", Op: MatchNotRegexp, Value: "dev|test"},
+			},
+			expected: `{service="api", namespace="prod", pod=~"api-.*", container!="sidecar", app="payments", env!~"dev|test"}`,
+		},
+		{
+			name:     "values are escaped",
+			service:  `api", evil="1`,
+			matchers: []LabelMatcher{{Label: "pod", Op: MatchRegexp, Value: `api\d+"}`}},
+			expected: `{service="api\", evil=\"1", pod=~"api\\d+\"}"}`,
+		},
+		{
+			name:    "invalid matchers are skipped",
+			service: "api",
+			matchers: []LabelMatcher{
+				{Label: "pod", Op: "==", Value: "x"},
+				{Label: `pod"}`, Op: MatchEqual, Value: "x"},
+			},
+			expected: `{service="api"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.buildSelector(tt.service, tt.namespace, tt.matchers)
+			if got != tt.expected {
+				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildSelector_Injection(t *testing.T) {
+	b := QueryBuilder{}
+
+	tests := []struct {
+		name      string
+		service   string
+		namespace string
+		expected  string
+	}{
+		{
+			name:     "quote and brace in service",
+			service:  `api"}|logfmt|line_format"`,
+			expected: `{service="api\"}|logfmt|line_format\""}`,
+		},
+		{
+			name:      "trailing backslash in namespace",
+			service:   "api",
+			namespace: `prod\`,
+			expected:  `{service="api", namespace="prod\\"}`,
+		},
+		{
+			name:     "escaped quote in service",
+			service:  `api\"} or {x="`,
+			expected: `{service="api\\\"} or {x=\""}`,
+		},
+		{
+			name:      "newlines are dropped",
+			service:   "api\n} |= `x`",
+			namespace: "prod\r\n",
+			expected:  "{service=\"api} |= `x`\", namespace=\"prod\"}",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := b.buildSelector(tt.service, tt.namespace)
+			got := b.buildSelector(tt.service, tt.namespace, nil)
 			if got != tt.expected {
 				t.Errorf("\nexpected: %s\ngot:      %s", tt.expected, got)
 			}
+			// Every quote the value contributed is escaped, so the selector
+			// closes exactly once, at the end.
+			if i := closingBrace(got); i != len(got)-1 {
+				t.Errorf("selector closes at %d, not at its end: %s", i, got)
+			}
 		})
 	}
 }
 
+// closingBrace returns the index of the first } outside a double-quoted
+// string in a selector, or -1.
+func closingBrace(sel string) int {
+	inString := false
+	for i := 0; i < len(sel); i++ {
+		switch c := sel[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
 func TestBuildLevelFilter(t *testing.T) {
 	b := QueryBuilder{}
 
@@ -199,6 +295,11 @@ func TestBuildLevelFilter(t *testing.T) {
 			levels:   nil,
 			expected: "",
 		},
+		{
+			name:     "level with quote and regexp syntax",
+			levels:   []string{`ERROR)|.*"} |= "x`},
+			expected: `| level =~ "(?i)(error\\)\\|\\.\\*\"\\} \\|= \"x)"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -239,6 +340,21 @@ func TestBuildKeywordFilter(t *testing.T) {
 			keyword:  `error "fatal"`,
 			expected: "|= `error \"fatal\"`",
 		},
+		{
+			name:     "keyword with backtick is quoted",
+			keyword:  "x` | line_format `pwned",
+			expected: `|= "x` + "`" + ` | line_format ` + "`" + `pwned"`,
+		},
+		{
+			name:     "newlines are dropped",
+			keyword:  "line one\nline two\r",
+			expected: "|= `line oneline two`",
+		},
+		{
+			name:     "only newlines",
+			keyword:  "\n",
+			expected: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -263,3 +379,96 @@ func TestQueryBuilder_ZeroValue(t *testing.T) {
 		t.Errorf("zero-value builder failed:\nexpected: %s\ngot:      %s", expected, got)
 	}
 }
+
+func TestCheckLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		labels  []string
+		wantErr bool
+	}{
+		{name: "default allows service and namespace", labels: []string{"service", "namespace", "level"}},
+		{name: "default rejects pod", labels: []string{"service", "pod"}, wantErr: true},
+		{name: "custom allowlist", allowed: []string{"service", "pod"}, labels: []string{"pod"}},
+		{name: "custom allowlist rejects namespace", allowed: []string{"service"}, labels: []string{"service", "namespace"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := QueryBuilder{AllowedLabels: tt.allowed}.CheckLabels(tt.labels...)
+			if tt.wantErr && !errors.Is(err, ErrInvalidLabel) {
+				t.Errorf("expected ErrInvalidLabel, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckMatchers(t *testing.T) {
+	b := QueryBuilder{AllowedLabels: []string{"service", "pod"}}
+
+	if err := b.CheckMatchers([]LabelMatcher{{Label: "pod", Op: MatchRegexp, Value: "api-.*"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := b.CheckMatchers([]LabelMatcher{{Label: "pod", Op: "~", Value: "x"}}); !errors.Is(err, ErrInvalidMatcher) {
+		t.Errorf("expected ErrInvalidMatcher for an unknown operator, got %v", err)
+	}
+	if err := b.CheckMatchers([]LabelMatcher{{Label: "1pod", Op: MatchEqual, Value: "x"}}); !errors.Is(err, ErrInvalidMatcher) {
+		t.Errorf("expected ErrInvalidMatcher for a malformed label, got %v", err)
+	}
+	if err := b.CheckMatchers([]LabelMatcher{{Label: "app", Op: MatchEqual, Value: "x"}}); !errors.Is(err, ErrInvalidLabel) {
+		t.Errorf("expected ErrInvalidLabel for a label outside the allowlist, got %v", err)
+	}
+}
+
+func TestSearchParams_Labels(t *testing.T) {
+	got := SearchParams{Service: "api"}.Labels()
+	if len(got) != 1 || got[0] != "service" {
+		t.Errorf("expected [service], got %v", got)
+	}
+
+	got = SearchParams{Service: "api", Namespace: "prod", Levels: []string{"ERROR"}}.Labels()
+	if len(got) != 3 || got[1] != "namespace" || got[2] != "level" {
+		t.Errorf("expected [service namespace level], got %v", got)
+	}
+
+	got = SearchParams{Service: "api", Matchers: []LabelMatcher{
+		{Label: "pod", Op: MatchEqual, Value: "a"},
+		{Label: "service", Op: MatchNotEqual, Value: "b"},
+	}}.Labels()
+	if len(got) != 2 || got[1] != "pod" {
+		t.Errorf("expected [service pod], got %v", got)
+	}
+}
+
+func TestCheckQuery(t *testing.T) {
+	b := QueryBuilder{AllowedLabels: []string{"service", "namespace"}}
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "allowed selector", query: `{service="api", namespace=~"prod|staging"}`},
+		{name: "metric query", query: `sum by (pod) (rate({service="api"} |= "error" [5m]))`},
+		{name: "braces inside strings ignored", query: `{service="a{pod=\"x\"}"} | line_format "{{.pod}}" |~ ` + "`{pod=1}`"},
+		{name: "no selector", query: `oops`},
+		{name: "disallowed label", query: `{service="api", pod!="x"}`, wantErr: true},
+		{name: "disallowed label without spaces", query: `{pod=~".+"}`, wantErr: true},
+		{name: "second selector checked", query: `{service="api"} or {tenant="other"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.CheckQuery(tt.query)
+			if tt.wantErr && !errors.Is(err, ErrInvalidLabel) {
+				t.Errorf("expected ErrInvalidLabel, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -12,7 +12,7 @@ type AIProvider interface {
 	// Analyze performs root cause analysis on an error cluster.
 	Analyze(ctx context.Context, req AnalysisRequest) (AnalysisResult, error)
 	// Summarize condenses a stream of log lines into a plain-language summary.
-	Summarize(ctx context.Context, logs []LogLine) (string, error)
+	Summarize(ctx context.Context, req SummarizeRequest) (Summary, error)
 	// Name returns the provider identifier (e.g., "ollama", "openai").
 	Name() string
 }
@@ -21,6 +21,22 @@ type AIProvider interface {
 type AnalysisRequest struct {
 	Cluster     ErrorCluster
 	ContextLogs []LogLine // Surrounding log lines for context, sorted chronologically
+	// Model overrides the provider's configured model; empty uses the default.
+	Model string
+}
+
+// SummarizeRequest is the input to an AI summarization operation.
+type SummarizeRequest struct {
+	Logs []LogLine
+	// Model overrides the provider's configured model; empty uses the default.
+	Model string
+}
+
+// Summary is the output of an AI summarization operation.
+type Summary struct {
+	Text string
+	// Model is the model that wrote the summary.
+	Model string
 }
 
 // LogLine represents a single log entry from Loki.
@@ -29,4 +45,6 @@ type LogLine struct {
 	Message   string            `json:"message"`
 	Labels    map[string]string `json:"labels"`
 	Level     string            `json:"level"`
+	// Fields holds the top-level fields of a JSON log line; nil for plaintext.
+	Fields map[string]string `json:"fields,omitempty"`
 }
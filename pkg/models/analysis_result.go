@@ -7,16 +7,31 @@ import (
 )
 
 // AnalysisResult holds AI-generated analysis output for a specific error cluster.
+// ConfidenceClamped is set when the provider's confidence fell outside [0, 1]
+// and Confidence holds the clamped value.
 type AnalysisResult struct {
-	ID              uuid.UUID `db:"id"               json:"id"`
-	ClusterID       uuid.UUID `db:"cluster_id"       json:"cluster_id"`
-	TenantID        uuid.UUID `db:"tenant_id"        json:"tenant_id"`
-	JobID           uuid.UUID `db:"job_id"           json:"job_id"`
-	Provider        string    `db:"provider"         json:"provider"`
-	Model           string    `db:"model"            json:"model"`
-	RootCause       string    `db:"root_cause"       json:"root_cause"`
-	Confidence      float64   `db:"confidence"       json:"confidence"`
-	Summary         string    `db:"summary"          json:"summary"`
-	SuggestedAction *string   `db:"suggested_action" json:"suggested_action,omitempty"`
-	CreatedAt       time.Time `db:"created_at"       json:"created_at"`
+	ID                uuid.UUID `db:"id"                 json:"id"`
+	ClusterID         uuid.UUID `db:"cluster_id"         json:"cluster_id"`
+	TenantID          uuid.UUID `db:"tenant_id"          json:"tenant_id"`
+	JobID             uuid.UUID `db:"job_id"             json:"job_id"`
+	Provider          string    `db:"provider"           json:"provider"`
+	Model             string    `db:"model"              json:"model"`
+	RootCause         string    `db:"root_cause"         json:"root_cause"`
+	Confidence        float64   `db:"confidence"         json:"confidence"`
+	ConfidenceClamped bool      `db:"confidence_clamped" json:"confidence_clamped"`
+	Summary           string    `db:"summary"            json:"summary"`
+	SuggestedAction   *string   `db:"suggested_action"   json:"suggested_action,omitempty"`
+	CreatedAt         time.Time `db:"created_at"         json:"created_at"`
+}
+
+// AnalysisContext is the sample of context logs that was sent to the AI provider
+// for a job. Lines holds at most the first and last few lines; TotalLines is the
+// number sent to the provider, after the fetched logs were cut to
+// AI_CONTEXT_LIMIT.
+type AnalysisContext struct {
+	JobID      uuid.UUID `db:"job_id"      json:"job_id"`
+	TenantID   uuid.UUID `db:"tenant_id"   json:"tenant_id"`
+	Lines      []LogLine `db:"lines"       json:"lines"`
+	TotalLines int       `db:"total_lines" json:"total_lines"`
+	CreatedAt  time.Time `db:"created_at"  json:"created_at"`
 }
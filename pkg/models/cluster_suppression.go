@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClusterSuppression marks a known-benign error pattern. Ingestion skips
+// clusters that match it: exactly one of Fingerprint (an exact cluster
+// fingerprint) or Pattern (a regular expression matched against the sample
+// message) is set.
+type ClusterSuppression struct {
+	ID          uuid.UUID `db:"id"          json:"id"`
+	TenantID    uuid.UUID `db:"tenant_id"   json:"tenant_id"`
+	Fingerprint string    `db:"fingerprint" json:"fingerprint,omitempty"`
+	Pattern     string    `db:"pattern"     json:"pattern,omitempty"`
+	Reason      string    `db:"reason"      json:"reason,omitempty"`
+	CreatedAt   time.Time `db:"created_at"  json:"created_at"`
+}
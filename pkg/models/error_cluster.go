@@ -6,19 +6,34 @@ import (
 	"github.com/google/uuid"
 )
 
+// Cluster lifecycle statuses. New clusters start open; resolved clusters may be
+// resolved by an operator or automatically once they stop firing.
+const (
+	ClusterStatusOpen         = "open"
+	ClusterStatusAcknowledged = "acknowledged"
+	ClusterStatusResolved     = "resolved"
+)
+
 // ErrorCluster represents a deduplicated group of related error log lines
 // that share the same normalized fingerprint within a service.
 type ErrorCluster struct {
-	ID            uuid.UUID `db:"id"             json:"id"`
-	TenantID      uuid.UUID `db:"tenant_id"      json:"tenant_id"`
-	Service       string    `db:"service"        json:"service"`
-	Namespace     string    `db:"namespace"      json:"namespace"`
-	Fingerprint   string    `db:"fingerprint"    json:"fingerprint"`
-	Level         string    `db:"level"          json:"level"`
-	FirstSeenAt   time.Time `db:"first_seen_at"  json:"first_seen_at"`
-	LastSeenAt    time.Time `db:"last_seen_at"   json:"last_seen_at"`
-	Count         int       `db:"count"          json:"count"`
-	SampleMessage string    `db:"sample_message" json:"sample_message"`
-	CreatedAt     time.Time `db:"created_at"     json:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at"     json:"updated_at"`
+	ID            uuid.UUID  `db:"id"             json:"id"`
+	TenantID      uuid.UUID  `db:"tenant_id"      json:"tenant_id"`
+	Service       string     `db:"service"        json:"service"`
+	Namespace     string     `db:"namespace"      json:"namespace"`
+	Fingerprint   string     `db:"fingerprint"    json:"fingerprint"`
+	Level         string     `db:"level"          json:"level"`
+	FirstSeenAt   time.Time  `db:"first_seen_at"  json:"first_seen_at"`
+	LastSeenAt    time.Time  `db:"last_seen_at"   json:"last_seen_at"`
+	Count         int        `db:"count"          json:"count"`
+	SampleMessage string     `db:"sample_message" json:"sample_message"`
+	Status        string     `db:"status"         json:"status"`
+	AutoResolved  bool       `db:"auto_resolved"  json:"auto_resolved"`
+	ResolvedAt    *time.Time `db:"resolved_at"    json:"resolved_at,omitempty"`
+	// ReopenedAt is when a resolved cluster last fired again and went back to open.
+	ReopenedAt *time.Time `db:"reopened_at"    json:"reopened_at,omitempty"`
+	// Pinned clusters are never auto-resolved.
+	Pinned    bool      `db:"pinned"         json:"pinned"`
+	CreatedAt time.Time `db:"created_at"     json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"     json:"updated_at"`
 }
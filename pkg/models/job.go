@@ -11,10 +11,39 @@ const (
 	JobStatusRunning   = "running"
 	JobStatusCompleted = "completed"
 	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// JobTypeAnalysis is the type of jobs that run root cause analysis on a cluster.
+const JobTypeAnalysis = "analysis"
+
+// Keys recorded in Job.Metadata.
+const (
+	// JobMetaFingerprint is the fingerprint of the analyzed cluster.
+	JobMetaFingerprint = "fingerprint"
+	// JobMetaModel is the model the request asked for, when it overrode the default.
+	JobMetaModel = "model"
+)
+
+// Machine-readable error codes recorded on failed jobs so clients can react
+// programmatically without parsing ErrorMessage.
+const (
+	JobErrorLokiUnavailable   = "LOKI_UNAVAILABLE"
+	JobErrorLokiQuery         = "LOKI_QUERY_ERROR"
+	JobErrorAITimeout         = "AI_TIMEOUT"
+	JobErrorAIUnavailable     = "AI_UNAVAILABLE"
+	JobErrorAIInvalidResponse = "AI_INVALID_RESPONSE"
+	JobErrorStore             = "STORE_ERROR"
+	JobErrorNoLogs            = "NO_LOGS"
+	JobErrorInternal          = "INTERNAL_ERROR"
+	// JobErrorCancelled marks a job stopped through the cancel endpoint.
+	JobErrorCancelled = "CANCELLED"
+	// JobErrorQueueTimeout marks a job that waited too long for an analysis slot.
+	JobErrorQueueTimeout = "QUEUE_TIMEOUT"
 )
 
 // Job tracks async AI inference jobs. The API returns a job_id on POST /api/v1/analyze;
-// the client polls GET /api/v1/analyze/{job_id} until status is completed or failed.
+// the client polls GET /api/v1/analyze/{job_id} until status is completed, failed or cancelled.
 type Job struct {
 	ID           uuid.UUID  `db:"id"            json:"id"`
 	TenantID     uuid.UUID  `db:"tenant_id"     json:"tenant_id"`
@@ -22,8 +51,13 @@ type Job struct {
 	Status       string     `db:"status"        json:"status"`
 	ClusterID    *uuid.UUID `db:"cluster_id"    json:"cluster_id,omitempty"`
 	ErrorMessage *string    `db:"error_message" json:"error_message,omitempty"`
+	ErrorCode    *string    `db:"error_code"    json:"error_code,omitempty"`
+	ReplayedFrom *uuid.UUID `db:"replayed_from" json:"replayed_from,omitempty"`
 	StartedAt    *time.Time `db:"started_at"    json:"started_at,omitempty"`
 	CompletedAt  *time.Time `db:"completed_at"  json:"completed_at,omitempty"`
 	CreatedAt    time.Time  `db:"created_at"    json:"created_at"`
 	UpdatedAt    time.Time  `db:"updated_at"    json:"updated_at"`
+	// Metadata records the request context the job was created with, keyed
+	// by the JobMeta constants.
+	Metadata map[string]string `db:"metadata" json:"metadata,omitempty"`
 }